@@ -0,0 +1,39 @@
+-- lua插件示例 在application.toml的plugin_enable_list中添加"example"即可启用
+
+function OnEnable()
+    Plugin.Log("example plugin enable")
+end
+
+function OnDisable()
+    Plugin.Log("example plugin disable")
+end
+
+-- 进入场景后欢迎玩家 并在10秒后提醒
+Plugin.ListenEvent(EventId.POST_ENTER_SCENE, EventPriority.NORMAL, function(event)
+    Plugin.SendMessage(event.uid, "欢迎来到服务器")
+    Plugin.CreateUserTimer(event.uid, 10, function(uid)
+        Plugin.SendMessage(uid, "输入 help 查看可用命令")
+    end)
+    return false
+end)
+
+-- 每分钟打印一次日志
+Plugin.AddGlobalTick(GlobalTick.MINUTE_CHANGE, function()
+    Plugin.Log("minute change")
+end)
+
+-- 注册命令
+Plugin.RegCommand({
+    name = "示例",
+    alias = { "example" },
+    description = "<color=#FFFFCC>{alias}</color> <color=#FFCC99>lua插件示例命令</color>",
+    usage = { "{alias} <文本> 复读文本" },
+    perm = CommandPerm.NORMAL,
+    func = function(ctx)
+        if #ctx.param_list < 1 then
+            return false
+        end
+        Plugin.SendMessage(ctx.executor_uid, ctx.param_list[1])
+        return true
+    end,
+})
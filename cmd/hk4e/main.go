@@ -30,6 +30,9 @@ func main() {
 		GMCmd(),
 		RobotCmd(),
 		NatsCmd(),
+		ReplayCmd(),
+		ScenarioCmd(),
+		NavMeshCmd(),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"hk4e/pkg/alg"
+)
+
+func NavMeshCmd() *cobra.Command {
+	var objFile string
+	var outFile string
+	c := &cobra.Command{
+		Use:   "navmesh",
+		Short: "convert obj model to navmesh file, output file name should be {sceneId}.navmesh",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			navMesh, err := alg.ConvertNavMeshObjFile(objFile, outFile)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("convert navmesh ok, vert num: %v, poly num: %v\n", navMesh.GetVertNum(), navMesh.GetPolyNum())
+			return nil
+		},
+	}
+	c.Flags().StringVar(&objFile, "obj", "", "obj model file")
+	c.Flags().StringVar(&outFile, "out", "", "output navmesh file")
+	_ = c.MarkFlagRequired("obj")
+	_ = c.MarkFlagRequired("out")
+	return c
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"context"
+
+	"hk4e/robot/app"
+)
+
+func ReplayCmd() *cobra.Command {
+	var configFile string
+	var captureFile string
+	var account string
+	var speed float64
+	c := &cobra.Command{
+		Use:   "replay",
+		Short: "replay client session from packet capture file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.Replay(context.Background(), configFile, captureFile, account, speed)
+		},
+	}
+	c.Flags().StringVar(&configFile, "config", "application.toml", "config file")
+	c.Flags().StringVar(&captureFile, "file", "", "packet capture file")
+	c.Flags().StringVar(&account, "account", "", "robot account, default use config account")
+	c.Flags().Float64Var(&speed, "speed", 1.0, "replay speed")
+	_ = c.MarkFlagRequired("file")
+	return c
+}
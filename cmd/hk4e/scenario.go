@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"context"
+
+	"hk4e/robot/app"
+)
+
+func ScenarioCmd() *cobra.Command {
+	var configFile string
+	var scenarioFile string
+	var num int
+	c := &cobra.Command{
+		Use:   "scenario",
+		Short: "run robot scenario file and report step result",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.RunScenario(context.Background(), configFile, scenarioFile, num)
+		},
+	}
+	c.Flags().StringVar(&configFile, "config", "application.toml", "config file")
+	c.Flags().StringVar(&scenarioFile, "file", "scenario.toml", "scenario file")
+	c.Flags().IntVar(&num, "num", 1, "robot num")
+	return c
+}
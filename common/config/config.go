@@ -21,23 +21,40 @@ type Config struct {
 
 // Hk4e 原神服务器
 type Hk4e struct {
-	KcpAddr                 string `toml:"kcp_addr"`                   // kcp地址 该地址只用来注册到节点服务器 填网关的外网地址 网关本地监听为0.0.0.0
-	KcpPort                 int32  `toml:"kcp_port"`                   // kcp端口号
-	TcpModeEnable           bool   `toml:"tcp_mode_enable"`            // 是否开启tcp模式 需要hook客户端网络库才能支持 共用kcp端口号
-	GameDataConfigPath      string `toml:"game_data_config_path"`      // 配置表路径
-	ClientProtoProxyEnable  bool   `toml:"client_proto_proxy_enable"`  // 是否开启客户端协议代理功能
-	ForwardModeEnable       bool   `toml:"forward_mode_enable"`        // 是否开启网关到机器人的转发功能
-	Version                 string `toml:"version"`                    // 支持的客户端协议版本号 三位数字 多个以逗号分隔 如300,310,315,320
-	GateTcpMqAddr           string `toml:"gate_tcp_mq_addr"`           // 访问网关tcp直连消息队列的地址 填网关的内网地址
-	GateTcpMqPort           int32  `toml:"gate_tcp_mq_port"`           // tcp消息队列端口号
-	LoginSdkUrl             string `toml:"login_sdk_url"`              // 网关登录验证token的sdk服务器地址 目前填dispatch的内网地址
-	LoginSdkAccountKey      string `toml:"login_sdk_account_key"`      // sdk服务器账号验证的签名密钥
-	LoadSceneLuaConfig      bool   `toml:"load_scene_lua_config"`      // 是否加载场景详情LUA配置数据
-	DispatchUrl             string `toml:"dispatch_url"`               // 二级dispatch地址 将域名改为dispatch的外网地址
-	ForwardRegionUrl        string `toml:"forward_region_url"`         // 转发的一级dispatch地址
-	ForwardDispatchUrl      string `toml:"forward_dispatch_url"`       // 转发的二级dispatch地址
-	GmAuthKey               string `toml:"gm_auth_key"`                // gm认证密钥
-	RegisterAllProtoMessage bool   `toml:"register_all_proto_message"` // 注册全部pb消息
+	KcpAddr                 string   `toml:"kcp_addr"`                   // kcp地址 该地址只用来注册到节点服务器 填网关的外网地址 网关本地监听为0.0.0.0
+	KcpPort                 int32    `toml:"kcp_port"`                   // kcp端口号
+	TcpModeEnable           bool     `toml:"tcp_mode_enable"`            // 是否开启tcp模式 需要hook客户端网络库才能支持 共用kcp端口号
+	GameDataConfigPath      string   `toml:"game_data_config_path"`      // 配置表路径
+	ClientProtoProxyEnable  bool     `toml:"client_proto_proxy_enable"`  // 是否开启客户端协议代理功能
+	ForwardModeEnable       bool     `toml:"forward_mode_enable"`        // 是否开启网关到机器人的转发功能
+	Version                 string   `toml:"version"`                    // 支持的客户端协议版本号 三位数字 多个以逗号分隔 如300,310,315,320
+	GateTcpMqAddr           string   `toml:"gate_tcp_mq_addr"`           // 访问网关tcp直连消息队列的地址 填网关的内网地址
+	GateTcpMqPort           int32    `toml:"gate_tcp_mq_port"`           // tcp消息队列端口号
+	LoginSdkUrl             string   `toml:"login_sdk_url"`              // 网关登录验证token的sdk服务器地址 目前填dispatch的内网地址
+	LoginSdkAccountKey      string   `toml:"login_sdk_account_key"`      // sdk服务器账号验证的签名密钥
+	LoadSceneLuaConfig      bool     `toml:"load_scene_lua_config"`      // 是否加载场景详情LUA配置数据
+	DispatchUrl             string   `toml:"dispatch_url"`               // 二级dispatch地址 将域名改为dispatch的外网地址
+	ForwardRegionUrl        string   `toml:"forward_region_url"`         // 转发的一级dispatch地址
+	ForwardDispatchUrl      string   `toml:"forward_dispatch_url"`       // 转发的二级dispatch地址
+	GmAuthKey               string   `toml:"gm_auth_key"`                // gm认证密钥
+	RegisterAllProtoMessage bool     `toml:"register_all_proto_message"` // 注册全部pb消息
+	GachaHistoryServer      string   `toml:"gacha_history_server"`       // 抽卡记录页面服务器地址 填dispatch的外网地址
+	GachaHistoryJwtKey      string   `toml:"gacha_history_jwt_key"`      // 抽卡记录页面jwt签名密钥 gs与dispatch需保持一致 为空则抽卡记录页面不可用
+	PluginEnableList        []string `toml:"plugin_enable_list"`         // 启用的gs插件列表 内置插件填插件名 lua插件填脚本文件名(不含扩展名)
+	LuaPluginPath           string   `toml:"lua_plugin_path"`            // gs的lua插件脚本目录
+	ServerWeight            int32    `toml:"server_weight"`              // 服务器权重 按机器承载能力填写 注册到节点服务器用于加权负载均衡 默认100
+	ServerZone              string   `toml:"server_zone"`                // 服务器所在区域 网关按区域就近分配 dispatch按自身区域获取网关
+	GateBalancePolicy       string   `toml:"gate_balance_policy"`        // 节点服务器分配网关的负载均衡策略 min_load/weighted/random 默认weighted
+	GsBalancePolicy         string   `toml:"gs_balance_policy"`          // 节点服务器分配gs的负载均衡策略 同上
+	GsStickyEnable          bool     `toml:"gs_sticky_enable"`           // 玩家重新登录时优先分配到上次所在的gs
+	PacketCaptureEnable     bool     `toml:"packet_capture_enable"`      // 网关是否开启客户端会话抓包落盘 每个会话一个文件
+	PacketCapturePath       string   `toml:"packet_capture_path"`        // 抓包文件目录 默认./capture
+	AutoRegisterDisable     bool     `toml:"auto_register_disable"`      // dispatch关闭登录时自动注册账号 账号只能通过管理接口创建
+	ChatFilterFile          string   `toml:"chat_filter_file"`           // gs的聊天过滤配置文件 为空则不过滤
+	AnticheatFile           string   `toml:"anticheat_file"`             // multi的反作弊规则配置文件 为空则使用默认阈值且只记录日志
+	NavMeshPath             string   `toml:"nav_mesh_path"`              // multi和gs的导航网格文件目录 文件名为场景id.navmesh 默认./navmesh
+	MonsterAiEnable         bool     `toml:"monster_ai_enable"`          // gs的ai世界是否由服务器驱动怪物ai
+	PhysicsFile             string   `toml:"physics_file"`               // gs的物理引擎配置文件 抛射物和碰撞体定义 为空则只模拟蓄力箭
 }
 
 // Hk4eRobot 原神机器人
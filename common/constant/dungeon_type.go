@@ -0,0 +1,18 @@
+package constant
+
+const (
+	DUNGEON_PASS_COND_NONE               = 0
+	DUNGEON_PASS_COND_KILL_MONSTER       = 3  // 击杀指定怪物 参数1:怪物id
+	DUNGEON_PASS_COND_KILL_GROUP_MONSTER = 5  // 击杀group内全部怪物 参数1:groupId
+	DUNGEON_PASS_COND_FINISH_QUEST       = 9  // 完成任务 参数1:任务id
+	DUNGEON_PASS_COND_KILL_MONSTER_COUNT = 11 // 击杀怪物数量 参数1:数量
+	DUNGEON_PASS_COND_IN_TIME            = 13 // 限时 参数1:秒数 超时失败
+	DUNGEON_PASS_COND_FINISH_CHALLENGE   = 14 // 完成挑战 参数1:挑战索引
+)
+
+const (
+	CHALLENGE_TYPE_NONE               = 0
+	CHALLENGE_TYPE_KILL_COUNT         = 1 // 击杀数量 参数:groupId 目标数量
+	CHALLENGE_TYPE_KILL_COUNT_IN_TIME = 2 // 限时击杀数量 参数:限时秒数 groupId 目标数量
+	CHALLENGE_TYPE_SURVIVE            = 3 // 存活 参数:存活秒数
+)
@@ -15,6 +15,8 @@ const (
 	ITEM_ID_WEAPON_UPGRADE_MOTLEY = 104011 // 精锻用杂矿
 
 	ITEM_ID_AVATAR_EXP = 101 // 角色经验
+
+	ITEM_ID_FRAGILE_RESIN = 220007 // 脆弱树脂
 )
 
 // 虚拟物品对应玩家的属性
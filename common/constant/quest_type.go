@@ -247,3 +247,18 @@ const (
 	QUEST_EXEC_TYPE_FAIL_MAINCOOP                    = 67
 	QUEST_EXEC_TYPE_MODIFY_WEATHER_AREA              = 68
 )
+
+// 由客户端上报驱动 可被客户端伪造的任务完成条件 不能用于有奖励的每日委托进度
+var QUEST_FINISH_COND_CLIENT_FORGEABLE map[int32]bool
+
+func init() {
+	QUEST_FINISH_COND_CLIENT_FORGEABLE = map[int32]bool{
+		QUEST_FINISH_COND_TYPE_COMPLETE_TALK:      true,
+		QUEST_FINISH_COND_TYPE_FINISH_PLOT:        true,
+		QUEST_FINISH_COND_TYPE_TRIGGER_FIRE:       true,
+		QUEST_FINISH_COND_TYPE_LUA_NOTIFY:         true,
+		QUEST_FINISH_COND_TYPE_UNLOCK_TRANS_POINT: true,
+		QUEST_FINISH_COND_TYPE_SKILL:              true,
+		QUEST_FINISH_COND_TYPE_UNLOCK_AREA:        true,
+	}
+}
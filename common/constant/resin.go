@@ -0,0 +1,12 @@
+package constant
+
+const (
+	RESIN_MAX                = 160 // 树脂自然恢复上限
+	RESIN_RECOVER_INTERVAL   = 480 // 树脂恢复间隔 单位秒
+	RESIN_BUY_ADD            = 60  // 每次购买获得的树脂
+	RESIN_FRAGILE_ADD        = 60  // 使用脆弱树脂获得的树脂
+	RESIN_COST_BLOSSOM_CHEST = 40  // 领取征讨之花奖励消耗的树脂
+)
+
+// RESIN_BUY_COST_LIST 每日第N次购买树脂消耗的原石
+var RESIN_BUY_COST_LIST = []uint32{50, 100, 100, 150, 200, 200}
@@ -61,6 +61,13 @@ const (
 	ServerStopNotify                          // 停服通知
 	ServerDispatchCancelNotify                // 服务器取消调度通知
 	ServerGmCmdNotify                         // 服务器GM指令执行通知
+	ServerMatchReq                            // 匹配相关请求
+	ServerMatchNotify                         // 匹配相关通知
+	ServerDrainNotify                         // 服务器排空通知
+	ServerKickPlayerNotify                    // 剔除在线玩家通知
+	ServerPlayerMuteNotify                    // 玩家禁言通知
+	ServerSendMailNotify                      // 发送邮件通知
+	ServerAnticheatRollbackNotify             // 反作弊回退玩家位置通知
 )
 
 type ServerMsg struct {
@@ -76,6 +83,11 @@ type ServerMsg struct {
 	AppVersion          string
 	GmCmdFuncName       string
 	GmCmdParamList      []string
+	MatchInfo           *MatchInfo
+	KickReason          uint32
+	MuteInfo            *MuteInfo
+	MailInfo            *MailInfo
+	AnticheatInfo       *AnticheatInfo
 }
 
 type OriginInfo struct {
@@ -128,3 +140,71 @@ type ForwardDispatchInfo struct {
 	GatePort    uint32
 	DispatchKey []byte
 }
+
+type MatchPlayerInfo struct {
+	PlayerInfo *PlayerBaseInfo
+	IsAgreed   bool
+}
+
+type MatchCmd uint8
+
+const (
+	MatchCmdStartReq      MatchCmd = iota // 开始匹配请求
+	MatchCmdCancelReq                     // 取消匹配请求
+	MatchCmdConfirmReq                    // 确认匹配请求
+	MatchCmdInfoNotify                    // 匹配信息通知
+	MatchCmdSuccNotify                    // 匹配成功通知
+	MatchCmdConfirmNotify                 // 玩家确认匹配通知
+	MatchCmdDismissNotify                 // 匹配解散通知
+	MatchCmdStopNotify                    // 匹配停止通知
+	MatchCmdFinishNotify                  // 匹配完成通知
+)
+
+type MatchInfo struct {
+	MatchCmd       MatchCmd
+	UserId         uint32
+	MatchType      uint32
+	DungeonId      uint32
+	MpPlayId       uint32
+	WorldLevel     uint32
+	MatchId        uint32
+	MatchBeginTime uint32
+	ConfirmEndTime uint32
+	IsAgreed       bool
+	Reason         uint32
+	HostUserId     uint32
+	PlayerInfo     *PlayerBaseInfo
+	PlayerList     []*MatchPlayerInfo
+}
+
+type MuteInfo struct {
+	UserId  uint32
+	IsMute  bool
+	EndTime uint32
+	Reason  string
+}
+
+type MailInfo struct {
+	UserIdList   []uint32 // 收件人uid列表
+	GlobalMailId uint32   // 全服邮件id 非0时发给所有在线玩家
+	Title        string
+	Content      string
+	Sender       string
+	SendTime     uint32
+	ExpireTime   uint32
+	ItemList     []*MailItemInfo
+}
+
+type MailItemInfo struct {
+	ItemId    uint32
+	ItemCount uint32
+}
+
+type AnticheatInfo struct {
+	UserId  uint32
+	SceneId uint32
+	Rule    string
+	PosX    float64 // 回退的目标坐标
+	PosY    float64
+	PosZ    float64
+}
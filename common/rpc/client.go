@@ -73,3 +73,26 @@ func newGmClient(conn *nats.Conn, gsId uint32) (*GMClient, error) {
 		GMNATSRPCClient: cli,
 	}, nil
 }
+
+// GachaClient gs的抽卡记录服务
+type GachaClient struct {
+	gsapi.GachaNATSRPCClient
+}
+
+func NewGachaClient() (*GachaClient, error) {
+	conn, err := nats.Connect(config.GetConfig().MQ.NatsUrl)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := nats.NewEncodedConn(conn, protobuf.PROTOBUF_ENCODER)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := gsapi.NewGachaNATSRPCClient(enc)
+	if err != nil {
+		return nil, err
+	}
+	return &GachaClient{
+		GachaNATSRPCClient: cli,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	nodeapi "hk4e/node/api"
+	"hk4e/pkg/logger"
+
+	"github.com/byebyebruce/natsrpc"
+	"github.com/nats-io/nats.go"
+)
+
+// node主备切换期间的请求重试
+// 主节点宕机后备用节点需要一个选举超时周期才能接管 期间rpc请求会无响应或超时
+
+const (
+	DiscoveryFailoverRetryCount    = 10               // 最大重试次数
+	DiscoveryFailoverRetryInterval = time.Second      // 重试间隔
+	DiscoveryFailoverTimeout       = time.Second * 30 // 调用方未设置超时时 包含全部重试的总超时
+)
+
+// isNodeUnavailable 是否为node不可用导致的错误 业务错误不重试
+func isNodeUnavailable(err error) bool {
+	return errors.Is(err, nats.ErrNoResponders) ||
+		errors.Is(err, nats.ErrTimeout) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func failoverCall[T any](ctx context.Context, method string, call func(ctx context.Context) (T, error)) (T, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DiscoveryFailoverTimeout)
+		defer cancel()
+	}
+	var rsp T
+	var err error
+	for i := 0; i < DiscoveryFailoverRetryCount; i++ {
+		rsp, err = call(ctx)
+		if err == nil || !isNodeUnavailable(err) {
+			return rsp, err
+		}
+		logger.Warn("node discovery unavailable, wait failover, method: %v, retry: %v, err: %v", method, i+1, err)
+		select {
+		case <-ctx.Done():
+			return rsp, err
+		case <-time.After(DiscoveryFailoverRetryInterval):
+		}
+	}
+	return rsp, err
+}
+
+func (d *DiscoveryClient) RegisterServer(ctx context.Context, req *nodeapi.RegisterServerReq, opt ...natsrpc.CallOption) (*nodeapi.RegisterServerRsp, error) {
+	return failoverCall(ctx, "RegisterServer", func(ctx context.Context) (*nodeapi.RegisterServerRsp, error) {
+		return d.DiscoveryNATSRPCClient.RegisterServer(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) CancelServer(ctx context.Context, req *nodeapi.CancelServerReq, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "CancelServer", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.CancelServer(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) KeepaliveServer(ctx context.Context, req *nodeapi.KeepaliveServerReq, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "KeepaliveServer", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.KeepaliveServer(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetServerAppId(ctx context.Context, req *nodeapi.GetServerAppIdReq, opt ...natsrpc.CallOption) (*nodeapi.GetServerAppIdRsp, error) {
+	return failoverCall(ctx, "GetServerAppId", func(ctx context.Context) (*nodeapi.GetServerAppIdRsp, error) {
+		return d.DiscoveryNATSRPCClient.GetServerAppId(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetRegionEc2B(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.RegionEc2B, error) {
+	return failoverCall(ctx, "GetRegionEc2B", func(ctx context.Context) (*nodeapi.RegionEc2B, error) {
+		return d.DiscoveryNATSRPCClient.GetRegionEc2B(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetGateServerAddr(ctx context.Context, req *nodeapi.GetGateServerAddrReq, opt ...natsrpc.CallOption) (*nodeapi.GateServerAddr, error) {
+	return failoverCall(ctx, "GetGateServerAddr", func(ctx context.Context) (*nodeapi.GateServerAddr, error) {
+		return d.DiscoveryNATSRPCClient.GetGateServerAddr(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetAllGateServerInfoList(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GateServerInfoList, error) {
+	return failoverCall(ctx, "GetAllGateServerInfoList", func(ctx context.Context) (*nodeapi.GateServerInfoList, error) {
+		return d.DiscoveryNATSRPCClient.GetAllGateServerInfoList(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetMainGameServerAppId(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GetMainGameServerAppIdRsp, error) {
+	return failoverCall(ctx, "GetMainGameServerAppId", func(ctx context.Context) (*nodeapi.GetMainGameServerAppIdRsp, error) {
+		return d.DiscoveryNATSRPCClient.GetMainGameServerAppId(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetMainMultiServerAppId(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GetMainMultiServerAppIdRsp, error) {
+	return failoverCall(ctx, "GetMainMultiServerAppId", func(ctx context.Context) (*nodeapi.GetMainMultiServerAppIdRsp, error) {
+		return d.DiscoveryNATSRPCClient.GetMainMultiServerAppId(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetGlobalGsOnlineMap(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GlobalGsOnlineMap, error) {
+	return failoverCall(ctx, "GetGlobalGsOnlineMap", func(ctx context.Context) (*nodeapi.GlobalGsOnlineMap, error) {
+		return d.DiscoveryNATSRPCClient.GetGlobalGsOnlineMap(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetStopServerInfo(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.StopServerInfo, error) {
+	return failoverCall(ctx, "GetStopServerInfo", func(ctx context.Context) (*nodeapi.StopServerInfo, error) {
+		return d.DiscoveryNATSRPCClient.GetStopServerInfo(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) SetStopServerInfo(ctx context.Context, req *nodeapi.StopServerInfo, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "SetStopServerInfo", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.SetStopServerInfo(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetWhiteList(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GetWhiteListRsp, error) {
+	return failoverCall(ctx, "GetWhiteList", func(ctx context.Context) (*nodeapi.GetWhiteListRsp, error) {
+		return d.DiscoveryNATSRPCClient.GetWhiteList(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) SetWhiteList(ctx context.Context, req *nodeapi.SetWhiteListReq, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "SetWhiteList", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.SetWhiteList(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetNextUid(ctx context.Context, req *nodeapi.NullMsg, opt ...natsrpc.CallOption) (*nodeapi.GetNextUidRsp, error) {
+	return failoverCall(ctx, "GetNextUid", func(ctx context.Context) (*nodeapi.GetNextUidRsp, error) {
+		return d.DiscoveryNATSRPCClient.GetNextUid(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) ServerDispatchCancel(ctx context.Context, req *nodeapi.ServerDispatchCancelReq, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "ServerDispatchCancel", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.ServerDispatchCancel(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) ServerDrain(ctx context.Context, req *nodeapi.ServerDrainReq, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "ServerDrain", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.ServerDrain(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) ReportServerDrain(ctx context.Context, req *nodeapi.ServerDrainState, opt ...natsrpc.CallOption) (*nodeapi.NullMsg, error) {
+	return failoverCall(ctx, "ReportServerDrain", func(ctx context.Context) (*nodeapi.NullMsg, error) {
+		return d.DiscoveryNATSRPCClient.ReportServerDrain(ctx, req, opt...)
+	})
+}
+
+func (d *DiscoveryClient) GetServerDrainState(ctx context.Context, req *nodeapi.ServerDrainReq, opt ...natsrpc.CallOption) (*nodeapi.ServerDrainState, error) {
+	return failoverCall(ctx, "GetServerDrainState", func(ctx context.Context) (*nodeapi.ServerDrainState, error) {
+		return d.DiscoveryNATSRPCClient.GetServerDrainState(ctx, req, opt...)
+	})
+}
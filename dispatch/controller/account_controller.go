@@ -0,0 +1,282 @@
+package controller
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"hk4e/common/config"
+	"hk4e/dispatch/model"
+	"hk4e/pkg/endec"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 账号管理接口
+// 请求头GmAuthKey需与配置的gm认证密钥一致 未配置密钥时接口不可用
+
+const (
+	AccountPageSizeMax = 100
+)
+
+type AccountRsp struct {
+	Code int32  `json:"code"`
+	Msg  string `json:"msg"`
+	Data any    `json:"data"`
+}
+
+// AccountInfo 返回给管理接口的账号信息 不包含密码和token
+type AccountInfo struct {
+	AccountId     uint32 `json:"account_id"`
+	Username      string `json:"username"`
+	CreateTime    int64  `json:"create_time"`
+	Forbid        bool   `json:"forbid"`
+	ForbidEndTime int64  `json:"forbid_end_time"`
+	ForbidReason  string `json:"forbid_reason"`
+}
+
+func newAccountInfo(account *model.Account) *AccountInfo {
+	return &AccountInfo{
+		AccountId:     account.AccountId,
+		Username:      account.Username,
+		CreateTime:    account.CreateTime,
+		Forbid:        account.IsForbid(time.Now().Unix()),
+		ForbidEndTime: account.ForbidEndTime,
+		ForbidReason:  account.ForbidReason,
+	}
+}
+
+func (c *Controller) accountAuthorize() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authKey := config.GetConfig().Hk4e.GmAuthKey
+		if authKey != "" && ctx.GetHeader("GmAuthKey") == authKey {
+			ctx.Next()
+			return
+		}
+		ctx.Abort()
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: 10001, Msg: "没有访问权限", Data: nil})
+	}
+}
+
+// checkAccountFormat 检查用户名和密码格式 返回错误提示 格式正确时返回空字符串
+func checkAccountFormat(username string, password string) string {
+	if len(username) < 6 || len(username) > 20 {
+		return "用户名为6-20位字符"
+	}
+	if len(password) < 8 || len(password) > 20 {
+		return "密码为8-20位字符"
+	}
+	ok, err := regexp.MatchString("^[a-zA-Z0-9]{6,20}$", username)
+	if err != nil || !ok {
+		return "用户名只能包含大小写字母和数字"
+	}
+	return ""
+}
+
+// createAccount 创建账号 调用前需检查用户名是否已存在
+func (c *Controller) createAccount(username string, password string) (*model.Account, error) {
+	accountId, err := c.db.GetNextAccountId()
+	if err != nil {
+		logger.Error("get next account id error: %v", err)
+		return nil, err
+	}
+	passwordHash, err := endec.PasswordHash(password)
+	if err != nil {
+		logger.Error("hash password error: %v", err)
+		return nil, err
+	}
+	account := &model.Account{
+		AccountId:  accountId,
+		Username:   username,
+		Password:   passwordHash,
+		Token:      "",
+		ComboToken: "",
+		CreateTime: time.Now().Unix(),
+	}
+	_, err = c.db.InsertAccount(account)
+	if err != nil {
+		logger.Error("insert account error: %v", err)
+		return nil, err
+	}
+	return account, nil
+}
+
+type AccountCreateReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (c *Controller) accountCreate(ctx *gin.Context) {
+	req := new(AccountCreateReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "参数解析错误", Data: nil})
+		return
+	}
+	msg := checkAccountFormat(req.Username, req.Password)
+	if msg != "" {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: msg, Data: nil})
+		return
+	}
+	account, err := c.db.QueryAccountByField("username", req.Username)
+	if err != nil {
+		logger.Error("query account from db error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	if account != nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "用户名已存在", Data: nil})
+		return
+	}
+	account, err = c.createAccount(req.Username, req.Password)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	logger.Info("admin create account, accountId: %v, username: %v", account.AccountId, account.Username)
+	ctx.JSON(http.StatusOK, &AccountRsp{Code: 0, Msg: "", Data: newAccountInfo(account)})
+}
+
+type AccountForbidReq struct {
+	AccountId uint32 `json:"account_id"`
+	Forbid    bool   `json:"forbid"`
+	EndTime   int64  `json:"end_time"` // 封禁结束时间 秒时间戳 0为永久封禁
+	Reason    string `json:"reason"`
+}
+
+// accountForbid 封禁或解封账号 封禁时清空token使已登录的客户端无法继续使用
+func (c *Controller) accountForbid(ctx *gin.Context) {
+	req := new(AccountForbidReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "参数解析错误", Data: nil})
+		return
+	}
+	fieldMap := bson.M{
+		"forbid":          req.Forbid,
+		"forbid_end_time": req.EndTime,
+		"forbid_reason":   req.Reason,
+	}
+	if !req.Forbid {
+		fieldMap["forbid_end_time"] = int64(0)
+		fieldMap["forbid_reason"] = ""
+	} else {
+		fieldMap["token"] = ""
+		fieldMap["combo_token"] = ""
+	}
+	matchCount, err := c.db.UpdateAccountFieldMapByAccountId(req.AccountId, fieldMap)
+	if err != nil {
+		logger.Error("update account forbid error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	if matchCount == 0 {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "账号不存在", Data: nil})
+		return
+	}
+	logger.Info("admin forbid account, accountId: %v, forbid: %v, endTime: %v, reason: %v", req.AccountId, req.Forbid, req.EndTime, req.Reason)
+	ctx.JSON(http.StatusOK, &AccountRsp{Code: 0, Msg: "", Data: nil})
+}
+
+type AccountPasswordResetReq struct {
+	AccountId uint32 `json:"account_id"`
+	Password  string `json:"password"`
+}
+
+// accountPasswordReset 重置密码 同时清空token要求重新登录
+func (c *Controller) accountPasswordReset(ctx *gin.Context) {
+	req := new(AccountPasswordResetReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "参数解析错误", Data: nil})
+		return
+	}
+	if len(req.Password) < 8 || len(req.Password) > 20 {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "密码为8-20位字符", Data: nil})
+		return
+	}
+	passwordHash, err := endec.PasswordHash(req.Password)
+	if err != nil {
+		logger.Error("hash password error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	matchCount, err := c.db.UpdateAccountFieldMapByAccountId(req.AccountId, bson.M{
+		"password":    passwordHash,
+		"token":       "",
+		"combo_token": "",
+	})
+	if err != nil {
+		logger.Error("update account password error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	if matchCount == 0 {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "账号不存在", Data: nil})
+		return
+	}
+	logger.Info("admin reset account password, accountId: %v", req.AccountId)
+	ctx.JSON(http.StatusOK, &AccountRsp{Code: 0, Msg: "", Data: nil})
+}
+
+type AccountListRsp struct {
+	Total int64          `json:"total"`
+	List  []*AccountInfo `json:"list"`
+}
+
+// accountList 分页查询账号 参数keyword page size
+func (c *Controller) accountList(ctx *gin.Context) {
+	keyword := ctx.Query("keyword")
+	page, err := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.ParseInt(ctx.DefaultQuery("size", "20"), 10, 64)
+	if err != nil || size < 1 || size > AccountPageSizeMax {
+		size = 20
+	}
+	accountList, total, err := c.db.QueryAccountPage(keyword, page, size)
+	if err != nil {
+		logger.Error("query account page error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	rsp := &AccountListRsp{
+		Total: total,
+		List:  make([]*AccountInfo, 0, len(accountList)),
+	}
+	for _, account := range accountList {
+		rsp.List = append(rsp.List, newAccountInfo(account))
+	}
+	ctx.JSON(http.StatusOK, &AccountRsp{Code: 0, Msg: "", Data: rsp})
+}
+
+// accountInfo 查询单个账号 参数account_id或username
+func (c *Controller) accountInfo(ctx *gin.Context) {
+	var account *model.Account = nil
+	var err error = nil
+	accountIdStr := ctx.Query("account_id")
+	if accountIdStr != "" {
+		accountId, parseErr := strconv.ParseUint(accountIdStr, 10, 32)
+		if parseErr != nil {
+			ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "参数解析错误", Data: nil})
+			return
+		}
+		account, err = c.db.QueryAccountByField("account_id", uint32(accountId))
+	} else {
+		account, err = c.db.QueryAccountByField("username", ctx.Query("username"))
+	}
+	if err != nil {
+		logger.Error("query account from db error: %v", err)
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "服务器内部错误", Data: nil})
+		return
+	}
+	if account == nil {
+		ctx.JSON(http.StatusOK, &AccountRsp{Code: -1, Msg: "账号不存在", Data: nil})
+		return
+	}
+	ctx.JSON(http.StatusOK, &AccountRsp{Code: 0, Msg: "", Data: newAccountInfo(account)})
+}
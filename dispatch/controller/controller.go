@@ -22,6 +22,7 @@ import (
 type Controller struct {
 	db              *dao.Dao
 	discoveryClient *rpc.DiscoveryClient
+	gachaClient     *rpc.GachaClient
 	signRsaKey      []byte
 	encRsaKeyMap    map[string][]byte
 	pwdRsaKey       []byte
@@ -36,6 +37,12 @@ func NewController(db *dao.Dao, discovery *rpc.DiscoveryClient, messageQueue *mq
 	r = new(Controller)
 	r.db = db
 	r.discoveryClient = discovery
+	gachaClient, err := rpc.NewGachaClient()
+	if err != nil {
+		logger.Error("create gacha client error: %v", err)
+		return nil
+	}
+	r.gachaClient = gachaClient
 	r.signRsaKey, r.encRsaKeyMap, r.pwdRsaKey = region.LoadRegionRsaKey()
 	rsp, err := r.discoveryClient.GetRegionEc2B(context.TODO(), &api.NullMsg{})
 	if err != nil {
@@ -110,6 +117,10 @@ func (c *Controller) registerRouter() {
 		engine.POST("/log", c.log8888)
 		engine.POST("/crash/dataUpload", c.crashDataUpload)
 	}
+	{
+		// 抽卡记录
+		engine.GET("/gacha", c.gachaRecord)
+	}
 	{
 		// 收集数据
 		engine.GET("/device-fp/api/getExtList", c.deviceExtList)
@@ -162,6 +173,15 @@ func (c *Controller) registerRouter() {
 		engine.StaticFS("/pictures", http.Dir("./static/geetest/pictures"))
 	}
 	engine.POST("/gate/token/verify", c.gateTokenVerify)
+	{
+		// 账号管理
+		accountGroup := engine.Group("/account", c.accountAuthorize())
+		accountGroup.POST("/create", c.accountCreate)
+		accountGroup.POST("/forbid", c.accountForbid)
+		accountGroup.POST("/password/reset", c.accountPasswordReset)
+		accountGroup.GET("/list", c.accountList)
+		accountGroup.GET("/info", c.accountInfo)
+	}
 	port := config.GetConfig().HttpPort
 	addr := ":" + strconv.Itoa(int(port))
 	err := engine.Run(addr)
@@ -204,6 +204,7 @@ func (c *Controller) queryCurRegion(ctx *gin.Context) {
 	if !exist || (exist && now-inst.(*GateServerInfo).timestamp > 60) {
 		gateServerAddr, err = c.discoveryClient.GetGateServerAddr(ctx.Request.Context(), &api.GetGateServerAddrReq{
 			GameVersion: versionStr,
+			Zone:        config.GetConfig().Hk4e.ServerZone,
 		})
 		if err != nil {
 			logger.Error("get gate server addr error: %v", err)
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"hk4e/common/config"
+	"hk4e/dispatch/model"
+	gsapi "hk4e/gs/api"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	GachaRecordDefaultPageSize = 20  // 抽卡记录默认每页条数
+	GachaRecordMaxPageSize     = 100 // 抽卡记录最大每页条数
+)
+
+// GachaUserInfo gs签发的抽卡记录页面jwt
+type GachaUserInfo struct {
+	UserId uint32 `json:"userId"`
+	jwt.RegisteredClaims
+}
+
+type GachaRecordPage struct {
+	Page  int64                `json:"page"`
+	Size  int64                `json:"size"`
+	Total int64                `json:"total"`
+	List  []*model.GachaRecord `json:"list"`
+}
+
+// 抽卡记录请求错误响应
+func (c *Controller) gachaReqErrorRsp(ctx *gin.Context, retCode int32, message string) {
+	ctx.JSON(http.StatusOK, gin.H{"retcode": retCode, "message": message, "data": nil})
+}
+
+// GET /gacha?gachaType=300&jwt=xxx&page=1&size=20
+func (c *Controller) gachaRecord(ctx *gin.Context) {
+	gachaType, err := strconv.Atoi(ctx.Query("gachaType"))
+	if err != nil {
+		c.gachaReqErrorRsp(ctx, -1, "参数错误")
+		return
+	}
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", strconv.Itoa(GachaRecordDefaultPageSize)))
+	if err != nil || size < 1 {
+		size = GachaRecordDefaultPageSize
+	}
+	if size > GachaRecordMaxPageSize {
+		size = GachaRecordMaxPageSize
+	}
+	jwtKey := config.GetConfig().Hk4e.GachaHistoryJwtKey
+	if jwtKey == "" {
+		logger.Error("gacha history jwt key not config")
+		c.gachaReqErrorRsp(ctx, -1, "抽卡记录未开放")
+		return
+	}
+	userInfo := new(GachaUserInfo)
+	token, err := jwt.ParseWithClaims(ctx.Query("jwt"), userInfo, func(token *jwt.Token) (any, error) {
+		return []byte(jwtKey), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}))
+	if err != nil || !token.Valid {
+		logger.Error("gacha record jwt verify error: %v", err)
+		c.gachaReqErrorRsp(ctx, -100, "身份验证失败")
+		return
+	}
+	rsp, err := c.gachaClient.QueryGachaRecord(context.TODO(), &gsapi.QueryGachaRecordReq{
+		Uid:       userInfo.UserId,
+		GachaType: uint32(gachaType),
+		Page:      int64(page),
+		Size:      int64(size),
+	})
+	if err != nil || rsp.Code != 0 {
+		logger.Error("query gacha record error: %v, uid: %v", err, userInfo.UserId)
+		c.gachaReqErrorRsp(ctx, -1, "系统错误")
+		return
+	}
+	gachaRecordList := make([]*model.GachaRecord, 0, len(rsp.RecordList))
+	for _, gachaRecord := range rsp.RecordList {
+		gachaRecordList = append(gachaRecordList, &model.GachaRecord{
+			Uid:        userInfo.UserId,
+			GachaType:  gachaRecord.GachaType,
+			ScheduleId: gachaRecord.ScheduleId,
+			ItemId:     gachaRecord.ItemId,
+			Rarity:     gachaRecord.Rarity,
+			Time:       gachaRecord.Time,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"retcode": 0, "message": "OK", "data": &GachaRecordPage{
+		Page:  int64(page),
+		Size:  int64(size),
+		Total: rsp.Total,
+		List:  gachaRecordList,
+	}})
+}
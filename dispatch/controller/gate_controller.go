@@ -73,6 +73,10 @@ func (c *Controller) gateTokenVerify(ctx *gin.Context) {
 		c.gateReqErrorRsp(ctx)
 		return
 	}
+	if account.IsForbid(time.Now().Unix()) {
+		c.gateReqErrorRsp(ctx)
+		return
+	}
 	if time.Now().UnixMilli()-int64(account.ComboTokenCreateTime) > time.Hour.Milliseconds()*24 {
 		c.gateReqErrorRsp(ctx)
 		return
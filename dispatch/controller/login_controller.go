@@ -4,11 +4,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"hk4e/common/config"
 	"hk4e/dispatch/api"
 	"hk4e/dispatch/model"
 	"hk4e/pkg/endec"
@@ -73,22 +73,10 @@ func (c *Controller) apiLogin(ctx *gin.Context) {
 		password = string(pwdDecData)
 	}
 
-	if len(username) < 6 || len(username) > 20 {
+	msg := checkAccountFormat(username, password)
+	if msg != "" {
 		responseData.Retcode = -201
-		responseData.Message = "用户名为6-20位字符"
-		ctx.JSON(http.StatusOK, responseData)
-		return
-	}
-	if len(password) < 8 || len(password) > 20 {
-		responseData.Retcode = -201
-		responseData.Message = "密码为8-20位字符"
-		ctx.JSON(http.StatusOK, responseData)
-		return
-	}
-	ok, err := regexp.MatchString("^[a-zA-Z0-9]{6,20}$", username)
-	if err != nil || !ok {
-		responseData.Retcode = -201
-		responseData.Message = "用户名只能包含大小写字母和数字"
+		responseData.Message = msg
 		ctx.JSON(http.StatusOK, responseData)
 		return
 	}
@@ -98,38 +86,43 @@ func (c *Controller) apiLogin(ctx *gin.Context) {
 		return
 	}
 	if account == nil {
-		// 自动注册
-		accountId, err := c.db.GetNextAccountId()
-		if err != nil {
-			logger.Error("get next account id error: %v", err)
+		if config.GetConfig().Hk4e.AutoRegisterDisable {
 			responseData.Retcode = -201
-			responseData.Message = "服务器内部错误:-1"
+			responseData.Message = "用户名或密码错误"
 			ctx.JSON(http.StatusOK, responseData)
 			return
 		}
-		regAccount := &model.Account{
-			AccountId:  accountId,
-			Username:   username,
-			Password:   endec.Md5Str(password),
-			Token:      "",
-			ComboToken: "",
-		}
-		_, err = c.db.InsertAccount(regAccount)
+		// 自动注册
+		account, err = c.createAccount(username, password)
 		if err != nil {
-			logger.Error("insert account error: %v", err)
 			responseData.Retcode = -201
-			responseData.Message = "服务器内部错误:-2"
+			responseData.Message = "服务器内部错误:-1"
 			ctx.JSON(http.StatusOK, responseData)
 			return
 		}
-		account = regAccount
 	}
-	if endec.Md5Str(password) != account.Password {
+	if !endec.PasswordVerify(account.Password, password) {
 		responseData.Retcode = -201
 		responseData.Message = "用户名或密码错误"
 		ctx.JSON(http.StatusOK, responseData)
 		return
 	}
+	if account.IsForbid(time.Now().Unix()) {
+		responseData.Retcode = -201
+		responseData.Message = accountForbidMessage(account)
+		ctx.JSON(http.StatusOK, responseData)
+		return
+	}
+	if endec.PasswordIsLegacyHash(account.Password) {
+		// 旧版md5密码哈希升级为bcrypt
+		passwordHash, err := endec.PasswordHash(password)
+		if err == nil {
+			_, err = c.db.UpdateAccountFieldByFieldName("account_id", account.AccountId, "password", passwordHash)
+		}
+		if err != nil {
+			logger.Error("upgrade account password hash error: %v", err)
+		}
+	}
 	// 生成新的token
 	account.Token = base64.StdEncoding.EncodeToString(random.GetRandomByte(24))
 	_, err = c.db.UpdateAccountFieldByFieldName("account_id", account.AccountId, "token", account.Token)
@@ -155,6 +148,18 @@ func (c *Controller) apiLogin(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, responseData)
 }
 
+// accountForbidMessage 封禁提示 包含原因和结束时间
+func accountForbidMessage(account *model.Account) string {
+	msg := "账号已被封禁"
+	if account.ForbidReason != "" {
+		msg += " 原因:" + account.ForbidReason
+	}
+	if account.ForbidEndTime != 0 {
+		msg += " 解封时间:" + time.Unix(account.ForbidEndTime, 0).Format("2006-01-02 15:04:05")
+	}
+	return msg
+}
+
 func (c *Controller) apiVerify(ctx *gin.Context) {
 	requestData := new(api.LoginTokenRequest)
 	err := ctx.ShouldBindJSON(requestData)
@@ -179,6 +184,12 @@ func (c *Controller) apiVerify(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, responseData)
 		return
 	}
+	if account.IsForbid(time.Now().Unix()) {
+		responseData.Retcode = -111
+		responseData.Message = accountForbidMessage(account)
+		ctx.JSON(http.StatusOK, responseData)
+		return
+	}
 	if uint64(time.Now().UnixMilli())-account.TokenCreateTime > uint64(time.Hour.Milliseconds()*24*7) {
 		responseData.Retcode = -111
 		responseData.Message = "登录已失效"
@@ -223,6 +234,12 @@ func (c *Controller) v2Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, responseData)
 		return
 	}
+	if account.IsForbid(time.Now().Unix()) {
+		responseData.Retcode = -201
+		responseData.Message = accountForbidMessage(account)
+		ctx.JSON(http.StatusOK, responseData)
+		return
+	}
 	// 生成新的comboToken
 	account.ComboToken = random.GetRandomByteHexStr(20)
 	_, err = c.db.UpdateAccountFieldByFieldName("account_id", account.AccountId, "combo_token", account.ComboToken)
@@ -2,12 +2,14 @@ package dao
 
 import (
 	"context"
+	"regexp"
 
 	"hk4e/dispatch/model"
 	"hk4e/pkg/logger"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func (d *Dao) InsertAccount(account *model.Account) (primitive.ObjectID, error) {
@@ -71,3 +73,48 @@ func (d *Dao) QueryAccountByField(fieldName string, fieldValue any) (*model.Acco
 		return result[0], nil
 	}
 }
+
+// UpdateAccountFieldMapByAccountId 按账号id同时更新多个字段
+func (d *Dao) UpdateAccountFieldMapByAccountId(accountId uint32, fieldMap bson.M) (int64, error) {
+	db := d.db.Collection("account")
+	updateCount, err := db.UpdateOne(
+		context.TODO(),
+		bson.D{{"account_id", accountId}},
+		bson.D{{"$set", fieldMap}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return updateCount.MatchedCount, nil
+}
+
+// QueryAccountPage 分页查询账号 按账号id排序 keyword不为空时按用户名模糊搜索 返回当前页账号和总条数
+func (d *Dao) QueryAccountPage(keyword string, page int64, size int64) ([]*model.Account, int64, error) {
+	db := d.db.Collection("account")
+	filter := bson.D{}
+	if keyword != "" {
+		filter = bson.D{{"username", primitive.Regex{Pattern: regexp.QuoteMeta(keyword), Options: "i"}}}
+	}
+	total, err := db.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	find, err := db.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{"account_id", 1}}).SetSkip((page-1)*size).SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*model.Account, 0)
+	for find.Next(context.TODO()) {
+		item := new(model.Account)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
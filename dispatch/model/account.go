@@ -11,4 +11,16 @@ type Account struct {
 	TokenCreateTime      uint64             `bson:"token_create_time"`       // 毫秒时间戳
 	ComboToken           string             `bson:"combo_token"`             // 游戏服务器token
 	ComboTokenCreateTime uint64             `bson:"combo_token_create_time"` // 毫秒时间戳
+	CreateTime           int64              `bson:"create_time"`             // 注册时间 秒时间戳
+	Forbid               bool               `bson:"forbid"`                  // 是否封禁
+	ForbidEndTime        int64              `bson:"forbid_end_time"`         // 封禁结束时间 秒时间戳 0为永久封禁
+	ForbidReason         string             `bson:"forbid_reason"`           // 封禁原因
+}
+
+// IsForbid 账号当前是否处于封禁状态
+func (a *Account) IsForbid(now int64) bool {
+	if !a.Forbid {
+		return false
+	}
+	return a.ForbidEndTime == 0 || now < a.ForbidEndTime
 }
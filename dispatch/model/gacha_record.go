@@ -0,0 +1,11 @@
+package model
+
+// GachaRecord 抽卡记录 由gs查询后返回
+type GachaRecord struct {
+	Uid        uint32 `json:"uid"`
+	GachaType  uint32 `json:"gachaType"`
+	ScheduleId uint32 `json:"scheduleId"`
+	ItemId     uint32 `json:"itemId"`
+	Rarity     uint32 `json:"rarity"`
+	Time       uint32 `json:"time"`
+}
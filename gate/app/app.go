@@ -42,6 +42,8 @@ func Run(ctx context.Context, configFile string) error {
 			MqPort:  uint32(config.GetConfig().Hk4e.GateTcpMqPort),
 		},
 		GameVersionList: strings.Split(config.GetConfig().Hk4e.Version, ","),
+		Weight:          uint32(config.GetConfig().Hk4e.ServerWeight),
+		Zone:            config.GetConfig().Hk4e.ServerZone,
 	})
 	if err != nil {
 		return err
@@ -16,6 +16,15 @@ type Account struct {
 	Uid           uint32             `bson:"uid"`
 	IsForbid      bool               `bson:"is_forbid"`
 	ForbidEndTime uint32             `bson:"forbid_end_time"`
+	ForbidReason  string             `bson:"forbid_reason"`
+}
+
+// IsForbidNow 当前是否处于封号状态 结束时间为0表示永久封号
+func (a *Account) IsForbidNow(now uint32) bool {
+	if !a.IsForbid {
+		return false
+	}
+	return a.ForbidEndTime == 0 || now < a.ForbidEndTime
 }
 
 func (d *Dao) InsertAccount(account *Account) (primitive.ObjectID, error) {
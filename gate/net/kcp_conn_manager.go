@@ -190,6 +190,7 @@ func (k *KcpConnManager) kcpNetInfo() {
 		logger.Info("udp send: %v pps, udp recv: %v pps", snmp.OutPkts/60, snmp.InPkts/60)
 		clientConnNum := atomic.LoadInt32(&CLIENT_CONN_NUM)
 		logger.Info("conn num: %v, new conn num: %v, kcp error num: %v", clientConnNum, snmp.CurrEstab, kcpErrorCount)
+		metricsKcpSnmp(snmp)
 		kcp.DefaultSnmp.Reset()
 	}
 }
@@ -274,6 +275,15 @@ func (k *KcpConnManager) acceptHandle(tcpMode bool, kcpListener *kcp.Listener, t
 			clientRandKey:      "",
 			tcpRtt:             0,
 			tcpRttLastSendTime: 0,
+			packetCapture:      nil,
+		}
+		if config.GetConfig().Hk4e.PacketCaptureEnable {
+			packetCapture, err := NewPacketCapture(config.GetConfig().Hk4e.PacketCapturePath, sessionId)
+			if err != nil {
+				logger.Error("create packet capture error: %v, sessionId: %v", err, sessionId)
+			} else {
+				session.packetCapture = packetCapture
+			}
 		}
 		if config.GetConfig().Hk4e.ForwardModeEnable {
 			robotServerAppId, err := k.discoveryClient.GetServerAppId(context.TODO(), &api.GetServerAppIdReq{
@@ -366,6 +376,7 @@ type Session struct {
 	clientRandKey      string
 	tcpRtt             uint32
 	tcpRttLastSendTime int64
+	packetCapture      *PacketCapture // 会话抓包 未开启时为空
 }
 
 // 接收协程
@@ -455,6 +466,9 @@ func (k *KcpConnManager) recvHandle(session *Session) {
 		for _, v := range kcpMsgList {
 			protoMsgList := ProtoDecode(v, k.serverCmdProtoMap, k.clientCmdProtoMap)
 			for _, vv := range protoMsgList {
+				if session.packetCapture != nil {
+					session.packetCapture.Capture(PacketCaptureDirC2S, session.userId, vv)
+				}
 				if config.GetConfig().Hk4e.ForwardModeEnable {
 					k.forwardClientMsgToRobotHandle(vv, session)
 				} else {
@@ -478,6 +492,9 @@ func (k *KcpConnManager) sendHandle(session *Session) {
 			k.closeKcpConn(session, kcp.EnetServerKick)
 			return
 		}
+		if session.packetCapture != nil {
+			session.packetCapture.Capture(PacketCaptureDirS2C, session.userId, protoMsg)
+		}
 		kcpMsg := ProtoEncode(protoMsg, k.serverCmdProtoMap, k.clientCmdProtoMap)
 		if kcpMsg == nil {
 			logger.Error("encode kcp msg is nil, sessionId: %v", session.sessionId)
@@ -593,6 +610,9 @@ func (k *KcpConnManager) closeKcpConn(session *Session, enetType uint32) {
 		})
 	}
 	session.conn.Close()
+	if session.packetCapture != nil {
+		session.packetCapture.Close()
+	}
 	// 连接关闭通知
 	k.kcpEventChan <- &KcpEvent{
 		SessionId:    session.sessionId,
@@ -713,6 +733,21 @@ func (k *KcpConnManager) syncMinLoadServerAppid() {
 	}
 }
 
+// getGsServerAppId 为登录玩家分配gs 由节点服务器按玩家上次所在的gs粘滞分配 失败则使用定时同步的最小负载gs
+func (k *KcpConnManager) getGsServerAppId(uid uint32) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	rsp, err := k.discoveryClient.GetServerAppId(ctx, &api.GetServerAppIdReq{
+		ServerType: api.GS,
+		Uid:        uid,
+	})
+	if err != nil {
+		logger.Error("get gs server appid error: %v, uid: %v", err, uid)
+		return k.minLoadGsServerAppId
+	}
+	return rsp.AppId
+}
+
 func (k *KcpConnManager) autoSyncStopServerInfo() {
 	ticker := time.NewTicker(time.Minute * 1)
 	for {
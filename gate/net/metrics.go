@@ -0,0 +1,49 @@
+package net
+
+import (
+	"sync/atomic"
+
+	"hk4e/gate/kcp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 网关服务器监控指标
+
+var kcpSnmpCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "hk4e_gate_kcp_snmp_total",
+	Help: "kcp协议栈累计统计",
+}, []string{"field"})
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "hk4e_gate_client_conn_num",
+		Help: "当前客户端连接数",
+	}, func() float64 {
+		return float64(atomic.LoadInt32(&CLIENT_CONN_NUM))
+	})
+}
+
+// metricsKcpSnmp 累加一个统计周期内的kcp计数 需要在重置snmp之前调用
+func metricsKcpSnmp(snmp *kcp.Snmp) {
+	for field, value := range map[string]uint64{
+		"bytes_sent":         snmp.BytesSent,
+		"bytes_received":     snmp.BytesReceived,
+		"passive_opens":      snmp.PassiveOpens,
+		"kcp_in_errors":      snmp.KCPInErrors,
+		"in_pkts":            snmp.InPkts,
+		"out_pkts":           snmp.OutPkts,
+		"in_segs":            snmp.InSegs,
+		"out_segs":           snmp.OutSegs,
+		"in_bytes":           snmp.InBytes,
+		"out_bytes":          snmp.OutBytes,
+		"retrans_segs":       snmp.RetransSegs,
+		"fast_retrans_segs":  snmp.FastRetransSegs,
+		"early_retrans_segs": snmp.EarlyRetransSegs,
+		"lost_segs":          snmp.LostSegs,
+		"repeat_segs":        snmp.RepeatSegs,
+	} {
+		kcpSnmpCounter.WithLabelValues(field).Add(float64(value))
+	}
+}
@@ -0,0 +1,182 @@
+package net
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"hk4e/pkg/logger"
+	"hk4e/protocol/proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// 客户端会话抓包
+// 每个会话写入一个jsonl文件 每行一个数据包 可通过hk4e replay命令离线回放
+
+const (
+	PacketCaptureDirC2S = "C2S" // 客户端发往服务器
+	PacketCaptureDirS2C = "S2C" // 服务器发往客户端
+)
+
+const (
+	DefaultPacketCapturePath = "./capture"
+	PacketCaptureRedacted    = "<redacted>" // 抓包中脱敏字段的替换值
+)
+
+// CapturePacket 抓包文件中的一个数据包
+type CapturePacket struct {
+	Time        int64           `json:"time"`         // 毫秒时间戳
+	Dir         string          `json:"dir"`          // 方向
+	Uid         uint32          `json:"uid"`          // 玩家uid 网关登录前为0
+	CmdId       uint16          `json:"cmd_id"`       // 协议号
+	CmdName     string          `json:"cmd_name"`     // 协议名
+	HeadData    []byte          `json:"head_data"`    // 原始包头pb数据
+	PayloadData []byte          `json:"payload_data"` // 原始负载pb数据 服务器协议
+	PayloadMsg  json.RawMessage `json:"payload_msg"`  // 解码后的负载 仅供阅读
+}
+
+type PacketCapture struct {
+	lock     sync.Mutex
+	file     *os.File
+	isClose  bool
+	fileName string
+}
+
+func NewPacketCapture(path string, sessionId uint32) (*PacketCapture, error) {
+	if path == "" {
+		path = DefaultPacketCapturePath
+	}
+	err := os.MkdirAll(path, 0755)
+	if err != nil {
+		return nil, err
+	}
+	fileName := filepath.Join(path, time.Now().Format("20060102150405")+"_"+strconv.Itoa(int(sessionId))+".jsonl")
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	p := &PacketCapture{
+		file:     file,
+		isClose:  false,
+		fileName: fileName,
+	}
+	return p, nil
+}
+
+// Capture 记录一个数据包 需要在消息对象被回收进缓存池之前调用
+func (p *PacketCapture) Capture(dir string, uid uint32, protoMsg *ProtoMsg) {
+	if protoMsg.PayloadMessage == nil {
+		return
+	}
+	packet := &CapturePacket{
+		Time:    time.Now().UnixMilli(),
+		Dir:     dir,
+		Uid:     uid,
+		CmdId:   protoMsg.CmdId,
+		CmdName: string(protoMsg.PayloadMessage.ProtoReflect().Descriptor().FullName()),
+	}
+	payloadMessage := redactCapturePayload(protoMsg.PayloadMessage)
+	var err error = nil
+	if protoMsg.HeadMessage != nil {
+		packet.HeadData, err = pb.Marshal(protoMsg.HeadMessage)
+		if err != nil {
+			logger.Error("marshal capture head msg error: %v", err)
+			return
+		}
+	}
+	packet.PayloadData, err = pb.Marshal(payloadMessage)
+	if err != nil {
+		logger.Error("marshal capture payload msg error: %v", err)
+		return
+	}
+	packet.PayloadMsg, err = protojson.Marshal(payloadMessage)
+	if err != nil {
+		logger.Error("marshal capture payload msg to json error: %v", err)
+		return
+	}
+	data, err := json.Marshal(packet)
+	if err != nil {
+		logger.Error("marshal capture packet error: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.isClose {
+		return
+	}
+	_, err = p.file.Write(data)
+	if err != nil {
+		logger.Error("write capture file error: %v, file: %v", err, p.fileName)
+	}
+}
+
+// redactCapturePayload 登录相关协议中的令牌和密钥不写入抓包文件 复制后替换 不修改原消息
+// 回放时登录流程由回放自身完成 不依赖这些字段
+func redactCapturePayload(payloadMessage pb.Message) pb.Message {
+	switch payloadMessage.(type) {
+	case *proto.GetPlayerTokenReq, *proto.GetPlayerTokenRsp, *proto.PlayerLoginReq:
+	default:
+		return payloadMessage
+	}
+	redactMessage := pb.Clone(payloadMessage)
+	switch msg := redactMessage.(type) {
+	case *proto.GetPlayerTokenReq:
+		msg.AccountToken = PacketCaptureRedacted
+		msg.ClientRandKey = PacketCaptureRedacted
+	case *proto.GetPlayerTokenRsp:
+		msg.Token = PacketCaptureRedacted
+		msg.SecretKey = PacketCaptureRedacted
+		msg.SecretKeySeed = 0
+	case *proto.PlayerLoginReq:
+		msg.Token = PacketCaptureRedacted
+	}
+	return redactMessage
+}
+
+func (p *PacketCapture) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.isClose {
+		return
+	}
+	p.isClose = true
+	_ = p.file.Close()
+}
+
+// ReadPacketCaptureFile 按顺序读取抓包文件中的全部数据包
+func ReadPacketCaptureFile(fileName string) ([]*CapturePacket, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	packetList := make([]*CapturePacket, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, PacketMaxLen), PacketMaxLen*4)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		packet := new(CapturePacket)
+		err = json.Unmarshal(line, packet)
+		if err != nil {
+			return nil, err
+		}
+		packetList = append(packetList, packet)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	return packetList, nil
+}
@@ -504,8 +504,9 @@ func (k *KcpConnManager) doGateLogin(req *proto.GetPlayerTokenReq, session *Sess
 		}
 	}
 	uid := account.Uid
-	if account.IsForbid {
+	if account.IsForbidNow(uint32(time.Now().Unix())) {
 		// 封号
+		logger.Warn("forbid account login, uid: %v, endTime: %v, reason: %v", uid, account.ForbidEndTime, account.ForbidReason)
 		return k.loginFailRsp(uid, proto.Retcode_RET_BLACK_UID, true, account.ForbidEndTime)
 	}
 	addr := session.conn.RemoteAddr()
@@ -583,10 +584,11 @@ func (k *KcpConnManager) doGateLogin(req *proto.GetPlayerTokenReq, session *Sess
 	k.SetSession(session, session.sessionId, session.userId)
 	k.createSessionChan <- session
 	// 绑定各个服务器appid
-	if k.minLoadGsServerAppId == "" {
+	gsServerAppId := k.getGsServerAppId(uid)
+	if gsServerAppId == "" {
 		return k.loginFailRsp(0, proto.Retcode_RET_SVR_ERROR, false, 0)
 	}
-	session.gsServerAppId = k.minLoadGsServerAppId
+	session.gsServerAppId = gsServerAppId
 	session.multiServerAppId = k.minLoadMultiServerAppId
 	logger.Debug("session gs appid: %v, uid: %v", session.gsServerAppId, uid)
 	logger.Debug("session multi appid: %v, uid: %v", session.multiServerAppId, uid)
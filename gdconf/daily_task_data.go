@@ -0,0 +1,76 @@
+package gdconf
+
+import (
+	"fmt"
+
+	"hk4e/common/constant"
+	"hk4e/pkg/logger"
+)
+
+// DailyTaskData 每日委托配置表
+type DailyTaskData struct {
+	DailyTaskId            int32    `csv:"DailyTaskId"`
+	MinPlayerLevel         int32    `csv:"MinPlayerLevel"`
+	MaxPlayerLevel         int32    `csv:"MaxPlayerLevel"`
+	Weight                 int32    `csv:"Weight"`
+	FinishCondType         int32    `csv:"FinishCondType"`
+	FinishCondParam        IntArray `csv:"FinishCondParam"`
+	FinishCondComplexParam string   `csv:"FinishCondComplexParam"`
+	FinishProgress         int32    `csv:"FinishProgress"`
+	RewardId               int32    `csv:"RewardId"`
+}
+
+func (g *GameDataConfig) loadDailyTaskData() {
+	g.DailyTaskDataMap = make(map[int32]*DailyTaskData)
+	dailyTaskDataList := make([]*DailyTaskData, 0)
+	readExtCsv[DailyTaskData](g.extPrefix+"DailyTaskData.csv", &dailyTaskDataList)
+	for _, dailyTaskData := range dailyTaskDataList {
+		if dailyTaskData.Weight <= 0 || dailyTaskData.FinishProgress <= 0 {
+			info := fmt.Sprintf("daily task weight or finish progress error: %v", dailyTaskData)
+			panic(info)
+		}
+		// 可被客户端伪造的任务条件不计入委托进度
+		if constant.QUEST_FINISH_COND_CLIENT_FORGEABLE[dailyTaskData.FinishCondType] {
+			info := fmt.Sprintf("daily task finish cond type not support: %v", dailyTaskData)
+			panic(info)
+		}
+		g.DailyTaskDataMap[dailyTaskData.DailyTaskId] = dailyTaskData
+	}
+	logger.Info("DailyTaskData count: %v", len(g.DailyTaskDataMap))
+}
+
+func GetDailyTaskDataById(dailyTaskId int32) *DailyTaskData {
+	return CONF.DailyTaskDataMap[dailyTaskId]
+}
+
+func GetDailyTaskDataMap() map[int32]*DailyTaskData {
+	return CONF.DailyTaskDataMap
+}
+
+// DailyTaskScoreRewardData 每日委托积分奖励配置表
+type DailyTaskScoreRewardData struct {
+	ScoreRewardId  int32 `csv:"ScoreRewardId"`
+	MinPlayerLevel int32 `csv:"MinPlayerLevel"`
+	MaxPlayerLevel int32 `csv:"MaxPlayerLevel"`
+	RewardId       int32 `csv:"RewardId"`
+}
+
+func (g *GameDataConfig) loadDailyTaskScoreRewardData() {
+	g.DailyTaskScoreRewardMap = make(map[int32]*DailyTaskScoreRewardData)
+	dailyTaskScoreRewardDataList := make([]*DailyTaskScoreRewardData, 0)
+	readExtCsv[DailyTaskScoreRewardData](g.extPrefix+"DailyTaskScoreRewardData.csv", &dailyTaskScoreRewardDataList)
+	for _, dailyTaskScoreRewardData := range dailyTaskScoreRewardDataList {
+		g.DailyTaskScoreRewardMap[dailyTaskScoreRewardData.ScoreRewardId] = dailyTaskScoreRewardData
+	}
+	logger.Info("DailyTaskScoreRewardData count: %v", len(g.DailyTaskScoreRewardMap))
+}
+
+// GetDailyTaskScoreRewardDataByPlayerLevel 通过冒险等级获取每日委托积分奖励
+func GetDailyTaskScoreRewardDataByPlayerLevel(playerLevel int32) *DailyTaskScoreRewardData {
+	for _, dailyTaskScoreRewardData := range CONF.DailyTaskScoreRewardMap {
+		if playerLevel >= dailyTaskScoreRewardData.MinPlayerLevel && playerLevel <= dailyTaskScoreRewardData.MaxPlayerLevel {
+			return dailyTaskScoreRewardData
+		}
+	}
+	return nil
+}
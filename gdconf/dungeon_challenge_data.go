@@ -0,0 +1,25 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+// DungeonChallengeData 地牢挑战配置表
+type DungeonChallengeData struct {
+	ChallengeId   int32 `csv:"ID"`
+	ChallengeType int32 `csv:"ChallengeType,omitempty"`
+}
+
+func (g *GameDataConfig) loadDungeonChallengeData() {
+	g.DungeonChallengeDataMap = make(map[int32]*DungeonChallengeData)
+	dungeonChallengeDataList := make([]*DungeonChallengeData, 0)
+	readTable[DungeonChallengeData](g.txtPrefix+"DungeonChallengeData.txt", &dungeonChallengeDataList)
+	for _, dungeonChallengeData := range dungeonChallengeDataList {
+		g.DungeonChallengeDataMap[dungeonChallengeData.ChallengeId] = dungeonChallengeData
+	}
+	logger.Info("DungeonChallengeData count: %v", len(g.DungeonChallengeDataMap))
+}
+
+func GetDungeonChallengeDataById(challengeId int32) *DungeonChallengeData {
+	return CONF.DungeonChallengeDataMap[challengeId]
+}
@@ -6,8 +6,18 @@ import (
 
 // DungeonData 地牢配置表
 type DungeonData struct {
-	DungeonId int32 `csv:"ID"`
-	SceneId   int32 `csv:"场景ID,omitempty"`
+	DungeonId               int32 `csv:"ID"`
+	Type                    int32 `csv:"类型,omitempty"`
+	SceneId                 int32 `csv:"场景ID,omitempty"`
+	PassCond                int32 `csv:"通关条件,omitempty"`
+	ReviveMaxCount          int32 `csv:"复活次数上限,omitempty"`
+	FirstPassRewardId       int32 `csv:"首通奖励RewardID,omitempty"`
+	SettleCountdownTime     int32 `csv:"结算倒计时,omitempty"`
+	FailSettleCountdownTime int32 `csv:"失败后退出等待时间,omitempty"`
+	QuitCountdownTime       int32 `csv:"主动退出倒计时,omitempty"`
+	StatueCostItemId        int32 `csv:"开启神像消耗道具,omitempty"`
+	StatueCostCount         int32 `csv:"消耗数量,omitempty"`
+	StatueDropId            int32 `csv:"神像奖励,omitempty"`
 }
 
 func (g *GameDataConfig) loadDungeonData() {
@@ -0,0 +1,82 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+const (
+	DungeonPassCondComposeAnd = 1 // 全部条件满足
+	DungeonPassCondComposeOr  = 2 // 任一条件满足
+)
+
+type DungeonPassCond struct {
+	Type  int32
+	Param []int32
+}
+
+// DungeonPassData 地牢通关条件配置表
+type DungeonPassData struct {
+	PassId          int32 `csv:"ID"`
+	CondCompose     int32 `csv:"[条件]组合,omitempty"`
+	CondType1       int32 `csv:"[条件]1类型,omitempty"`
+	CondType1Param1 int32 `csv:"[条件]1参数1,omitempty"`
+	CondType1Param2 int32 `csv:"[条件]1参数2,omitempty"`
+	CondType1Param3 int32 `csv:"[条件]1参数3,omitempty"`
+	CondType2       int32 `csv:"[条件]2类型,omitempty"`
+	CondType2Param1 int32 `csv:"[条件]2参数1,omitempty"`
+	CondType2Param2 int32 `csv:"[条件]2参数2,omitempty"`
+	CondType2Param3 int32 `csv:"[条件]2参数3,omitempty"`
+	CondType3       int32 `csv:"[条件]3类型,omitempty"`
+	CondType3Param1 int32 `csv:"[条件]3参数1,omitempty"`
+	CondType3Param2 int32 `csv:"[条件]3参数2,omitempty"`
+	CondType3Param3 int32 `csv:"[条件]3参数3,omitempty"`
+	CondType4       int32 `csv:"[条件]4类型,omitempty"`
+	CondType4Param1 int32 `csv:"[条件]4参数1,omitempty"`
+	CondType4Param2 int32 `csv:"[条件]4参数2,omitempty"`
+	CondType4Param3 int32 `csv:"[条件]4参数3,omitempty"`
+
+	CondList []*DungeonPassCond // 通关条件
+}
+
+func (g *GameDataConfig) loadDungeonPassData() {
+	g.DungeonPassDataMap = make(map[int32]*DungeonPassData)
+	dungeonPassDataList := make([]*DungeonPassData, 0)
+	readTable[DungeonPassData](g.txtPrefix+"DungeonPassData.txt", &dungeonPassDataList)
+	for _, dungeonPassData := range dungeonPassDataList {
+		condTypeList := []int32{
+			dungeonPassData.CondType1,
+			dungeonPassData.CondType2,
+			dungeonPassData.CondType3,
+			dungeonPassData.CondType4,
+		}
+		condParamList := [][]int32{
+			{dungeonPassData.CondType1Param1, dungeonPassData.CondType1Param2, dungeonPassData.CondType1Param3},
+			{dungeonPassData.CondType2Param1, dungeonPassData.CondType2Param2, dungeonPassData.CondType2Param3},
+			{dungeonPassData.CondType3Param1, dungeonPassData.CondType3Param2, dungeonPassData.CondType3Param3},
+			{dungeonPassData.CondType4Param1, dungeonPassData.CondType4Param2, dungeonPassData.CondType4Param3},
+		}
+		dungeonPassData.CondList = make([]*DungeonPassCond, 0)
+		for index, condType := range condTypeList {
+			if condType == 0 {
+				continue
+			}
+			paramList := make([]int32, 0)
+			for _, param := range condParamList[index] {
+				if param == 0 {
+					continue
+				}
+				paramList = append(paramList, param)
+			}
+			dungeonPassData.CondList = append(dungeonPassData.CondList, &DungeonPassCond{
+				Type:  condType,
+				Param: paramList,
+			})
+		}
+		g.DungeonPassDataMap[dungeonPassData.PassId] = dungeonPassData
+	}
+	logger.Info("DungeonPassData count: %v", len(g.DungeonPassDataMap))
+}
+
+func GetDungeonPassDataById(passId int32) *DungeonPassData {
+	return CONF.DungeonPassDataMap[passId]
+}
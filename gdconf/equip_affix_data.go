@@ -0,0 +1,61 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+// EquipAffixData 装备词缀配置表
+type EquipAffixData struct {
+	AffixId       int32   `csv:"AffixID"`
+	OpenConfig    string  `csv:"开启天赋配置,omitempty"`
+	AddPropType1  int32   `csv:"[增加属性]1类型,omitempty"`
+	AddPropValue1 float32 `csv:"[增加属性]1值,omitempty"`
+	AddPropType2  int32   `csv:"[增加属性]2类型,omitempty"`
+	AddPropValue2 float32 `csv:"[增加属性]2值,omitempty"`
+	AddPropType3  int32   `csv:"[增加属性]3类型,omitempty"`
+	AddPropValue3 float32 `csv:"[增加属性]3值,omitempty"`
+	Id            int32   `csv:"ID,omitempty"`
+	Level         int32   `csv:"词缀等级,omitempty"`
+
+	AddPropMap map[uint32]float32 // 增加属性
+}
+
+func (g *GameDataConfig) loadEquipAffixData() {
+	g.EquipAffixDataMap = make(map[int32]*EquipAffixData)
+	g.EquipAffixDataIdLevelMap = make(map[int32]map[int32]*EquipAffixData)
+	equipAffixDataList := make([]*EquipAffixData, 0)
+	readTable[EquipAffixData](g.txtPrefix+"EquipAffixData.txt", &equipAffixDataList)
+	for _, equipAffixData := range equipAffixDataList {
+		equipAffixData.AddPropMap = map[uint32]float32{
+			uint32(equipAffixData.AddPropType1): equipAffixData.AddPropValue1,
+			uint32(equipAffixData.AddPropType2): equipAffixData.AddPropValue2,
+			uint32(equipAffixData.AddPropType3): equipAffixData.AddPropValue3,
+		}
+		for propType, propValue := range equipAffixData.AddPropMap {
+			// 两个值都不能为0
+			if propType == 0 || propValue == 0 {
+				delete(equipAffixData.AddPropMap, propType)
+			}
+		}
+		g.EquipAffixDataMap[equipAffixData.AffixId] = equipAffixData
+		// 通过词缀ID和词缀等级找到 武器精炼等阶和圣遗物套装激活档位都对应词缀等级
+		_, ok := g.EquipAffixDataIdLevelMap[equipAffixData.Id]
+		if !ok {
+			g.EquipAffixDataIdLevelMap[equipAffixData.Id] = make(map[int32]*EquipAffixData)
+		}
+		g.EquipAffixDataIdLevelMap[equipAffixData.Id][equipAffixData.Level] = equipAffixData
+	}
+	logger.Info("EquipAffixData count: %v", len(g.EquipAffixDataMap))
+}
+
+func GetEquipAffixDataById(affixId int32) *EquipAffixData {
+	return CONF.EquipAffixDataMap[affixId]
+}
+
+func GetEquipAffixDataByIdAndLevel(id int32, level int32) *EquipAffixData {
+	value, exist := CONF.EquipAffixDataIdLevelMap[id]
+	if !exist {
+		return nil
+	}
+	return value[level]
+}
@@ -0,0 +1,81 @@
+package gdconf
+
+import (
+	"fmt"
+	"time"
+
+	"hk4e/pkg/logger"
+)
+
+const (
+	GachaPoolTimeLayout = "2006-01-02 15:04:05"
+)
+
+const (
+	GachaModeNormal    = 0 // 普通卡池 按掉落组抽取
+	GachaModeRandomAll = 1 // 全随机卡池 从全部角色和5星武器中随机
+)
+
+// GachaPoolData 卡池配置表
+type GachaPoolData struct {
+	ScheduleId         int32    `csv:"ScheduleId"`
+	GachaType          int32    `csv:"GachaType"`
+	GachaMode          int32    `csv:"GachaMode"`
+	BeginTimeStr       string   `csv:"BeginTime"`
+	EndTimeStr         string   `csv:"EndTime"`
+	SortId             int32    `csv:"SortId"`
+	PrefabPath         string   `csv:"PrefabPath"`
+	PreviewPrefabPath  string   `csv:"PreviewPrefabPath"`
+	TitleTextmap       string   `csv:"TitleTextmap"`
+	CostItemId         int32    `csv:"CostItemId"`
+	UpOrangeList       IntArray `csv:"UpOrangeList"`
+	UpPurpleList       IntArray `csv:"UpPurpleList"`
+	DisplayUp5List     IntArray `csv:"DisplayUp5List"`
+	DisplayUp4List     IntArray `csv:"DisplayUp4List"`
+	MustGetUpEnable    bool     `csv:"MustGetUpEnable"`
+	OrangeFixThreshold int32    `csv:"OrangeFixThreshold"`
+	OrangeFixValue     int32    `csv:"OrangeFixValue"`
+	PurpleFixThreshold int32    `csv:"PurpleFixThreshold"`
+	PurpleFixValue     int32    `csv:"PurpleFixValue"`
+
+	BeginTime uint32 // 开启时间戳 0为不限制
+	EndTime   uint32 // 结束时间戳 0为不限制
+}
+
+func (g *GameDataConfig) loadGachaPoolData() {
+	g.GachaPoolDataMap = make(map[int32]*GachaPoolData)
+	gachaPoolDataList := make([]*GachaPoolData, 0)
+	readExtCsv[GachaPoolData](g.extPrefix+"GachaPoolData.csv", &gachaPoolDataList)
+	for _, gachaPoolData := range gachaPoolDataList {
+		if gachaPoolData.GachaMode != GachaModeNormal && gachaPoolData.GachaMode != GachaModeRandomAll {
+			info := fmt.Sprintf("gacha mode not support: %v", gachaPoolData)
+			panic(info)
+		}
+		if gachaPoolData.BeginTimeStr != "" {
+			beginTime, err := time.ParseInLocation(GachaPoolTimeLayout, gachaPoolData.BeginTimeStr, time.Local)
+			if err != nil {
+				info := fmt.Sprintf("gacha pool begin time format error: %v", gachaPoolData)
+				panic(info)
+			}
+			gachaPoolData.BeginTime = uint32(beginTime.Unix())
+		}
+		if gachaPoolData.EndTimeStr != "" {
+			endTime, err := time.ParseInLocation(GachaPoolTimeLayout, gachaPoolData.EndTimeStr, time.Local)
+			if err != nil {
+				info := fmt.Sprintf("gacha pool end time format error: %v", gachaPoolData)
+				panic(info)
+			}
+			gachaPoolData.EndTime = uint32(endTime.Unix())
+		}
+		g.GachaPoolDataMap[gachaPoolData.ScheduleId] = gachaPoolData
+	}
+	logger.Info("GachaPoolData count: %v", len(g.GachaPoolDataMap))
+}
+
+func GetGachaPoolDataByScheduleId(scheduleId int32) *GachaPoolData {
+	return CONF.GachaPoolDataMap[scheduleId]
+}
+
+func GetGachaPoolDataMap() map[int32]*GachaPoolData {
+	return CONF.GachaPoolDataMap
+}
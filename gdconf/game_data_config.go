@@ -58,17 +58,24 @@ type GameDataConfig struct {
 	AvatarFlycloakDataMap      map[int32]*AvatarFlycloakData           // 角色风之翼
 	ReliquaryMainDataMap       map[int32]map[int32]*ReliquaryMainData  // 圣遗物主属性
 	ReliquaryAffixDataMap      map[int32]map[int32]*ReliquaryAffixData // 圣遗物追加属性
+	ReliquaryLevelDataMap      map[int32]map[int32]*ReliquaryLevelData // 圣遗物等级
+	ReliquarySetDataMap        map[int32]*ReliquarySetData             // 圣遗物套装
+	EquipAffixDataMap          map[int32]*EquipAffixData               // 装备词缀
+	EquipAffixDataIdLevelMap   map[int32]map[int32]*EquipAffixData     // 装备词缀id和等级索引
 	QuestDataMap               map[int32]*QuestData                    // 任务
 	ParentQuestMap             map[int32]map[int32]*QuestData          // 父任务索引
 	DropDataMap                map[int32]*DropData                     // 掉落
 	MonsterDropDataMap         map[string]map[int32]*MonsterDropData   // 怪物掉落
 	ChestDropDataMap           map[string]map[int32]*ChestDropData     // 宝箱掉落
 	DungeonDataMap             map[int32]*DungeonData                  // 地牢
+	DungeonPassDataMap         map[int32]*DungeonPassData              // 地牢通关条件
+	DungeonChallengeDataMap    map[int32]*DungeonChallengeData         // 地牢挑战
 	GadgetDataMap              map[int32]*GadgetData                   // 物件
 	RefreshPolicyDataMap       map[int32]*RefreshPolicyData            // 刷新策略
 	GCGCharDataMap             map[int32]*GCGCharData                  // 七圣召唤角色卡牌
 	GCGSkillDataMap            map[int32]*GCGSkillData                 // 七圣召唤卡牌技能
 	GachaDropGroupDataMap      map[int32]*GachaDropGroupData           // 卡池掉落组 临时的
+	GachaPoolDataMap           map[int32]*GachaPoolData                // 卡池
 	SkillStaminaDataMap        map[int32]*SkillStaminaData             // 角色技能消耗体力 临时的
 	VehicleDataMap             map[int32]*VehicleData                  // 载具
 	OpenStateDataMap           map[int32]*OpenStateData                // 开放状态
@@ -79,6 +86,11 @@ type GameDataConfig struct {
 	MonsterRelationshipDataMap map[int32]*MonsterRelationshipData      // 怪物关联
 	MonsterDataMap             map[int32]*MonsterData                  // 怪物
 	ProudSkillDataMap          map[int32]map[int32]*ProudSkillData     // 天赋
+	ShopDataMap                map[int32]*ShopData                     // 商店
+	ShopGoodsDataMap           map[int32]*ShopGoodsData                // 商店商品
+	ShopGoodsDataShopTypeMap   map[int32]map[int32]*ShopGoodsData      // 商店商品商店类型索引
+	DailyTaskDataMap           map[int32]*DailyTaskData                // 每日委托
+	DailyTaskScoreRewardMap    map[int32]*DailyTaskScoreRewardData     // 每日委托积分奖励
 }
 
 func InitGameDataConfig() {
@@ -170,16 +182,22 @@ func (g *GameDataConfig) load(loadSceneLua bool) {
 	g.loadAvatarFlycloakData()         // 角色风之翼
 	g.loadReliquaryMainData()          // 圣遗物主属性
 	g.loadReliquaryAffixData()         // 圣遗物追加属性
+	g.loadReliquaryLevelData()         // 圣遗物等级
+	g.loadReliquarySetData()           // 圣遗物套装
+	g.loadEquipAffixData()             // 装备词缀
 	g.loadQuestData()                  // 任务
 	g.loadDropData()                   // 掉落
 	g.loadMonsterDropData()            // 怪物掉落
 	g.loadChestDropData()              // 宝箱掉落
 	g.loadDungeonData()                // 地牢
+	g.loadDungeonPassData()            // 地牢通关条件
+	g.loadDungeonChallengeData()       // 地牢挑战
 	g.loadGadgetData()                 // 物件
 	g.loadRefreshPolicyData()          // 刷新策略
 	g.loadGCGCharData()                // 七圣召唤角色卡牌
 	g.loadGCGSkillData()               // 七圣召唤卡牌技能
 	g.loadGachaDropGroupData()         // 卡池掉落组 临时的
+	g.loadGachaPoolData()              // 卡池
 	g.loadSkillStaminaData()           // 角色技能消耗体力 临时的
 	g.loadVehicleData()                // 载具
 	g.loadOpenStateData()              // 开放状态
@@ -190,6 +208,10 @@ func (g *GameDataConfig) load(loadSceneLua bool) {
 	g.loadMonsterRelationshipData()    // 怪物关联
 	g.loadMonsterData()                // 怪物
 	g.loadProudSkillData()             // 天赋
+	g.loadShopData()                   // 商店
+	g.loadShopGoodsData()              // 商店商品
+	g.loadDailyTaskData()              // 每日委托
+	g.loadDailyTaskScoreRewardData()   // 每日委托积分奖励
 }
 
 // CSV相关
@@ -50,6 +50,8 @@ type ItemData struct {
 	MainPropDepotId   int32 `csv:"主属性库ID,omitempty"`
 	AppendPropDepotId int32 `csv:"追加属性库ID,omitempty"`
 	AppendPropCount   int32 `csv:"追加属性初始条数,omitempty"`
+	Rank              int32 `csv:"阶数,omitempty"`
+	SetId             int32 `csv:"套装ID,omitempty"`
 }
 
 func (g *GameDataConfig) loadItemData() {
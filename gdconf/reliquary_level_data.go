@@ -0,0 +1,105 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+// ReliquaryLevelData 圣遗物等级配置表
+type ReliquaryLevelData struct {
+	Rank           int32   `csv:"阶数"`
+	Level          int32   `csv:"等级,omitempty"`
+	Exp            int32   `csv:"成长到下一级所需经验,omitempty"`
+	AddPropType1   int32   `csv:"[增加属性]1类型,omitempty"`
+	AddPropValue1  float32 `csv:"[增加属性]1值,omitempty"`
+	AddPropType2   int32   `csv:"[增加属性]2类型,omitempty"`
+	AddPropValue2  float32 `csv:"[增加属性]2值,omitempty"`
+	AddPropType3   int32   `csv:"[增加属性]3类型,omitempty"`
+	AddPropValue3  float32 `csv:"[增加属性]3值,omitempty"`
+	AddPropType4   int32   `csv:"[增加属性]4类型,omitempty"`
+	AddPropValue4  float32 `csv:"[增加属性]4值,omitempty"`
+	AddPropType5   int32   `csv:"[增加属性]5类型,omitempty"`
+	AddPropValue5  float32 `csv:"[增加属性]5值,omitempty"`
+	AddPropType6   int32   `csv:"[增加属性]6类型,omitempty"`
+	AddPropValue6  float32 `csv:"[增加属性]6值,omitempty"`
+	AddPropType7   int32   `csv:"[增加属性]7类型,omitempty"`
+	AddPropValue7  float32 `csv:"[增加属性]7值,omitempty"`
+	AddPropType8   int32   `csv:"[增加属性]8类型,omitempty"`
+	AddPropValue8  float32 `csv:"[增加属性]8值,omitempty"`
+	AddPropType9   int32   `csv:"[增加属性]9类型,omitempty"`
+	AddPropValue9  float32 `csv:"[增加属性]9值,omitempty"`
+	AddPropType10  int32   `csv:"[增加属性]10类型,omitempty"`
+	AddPropValue10 float32 `csv:"[增加属性]10值,omitempty"`
+	AddPropType11  int32   `csv:"[增加属性]11类型,omitempty"`
+	AddPropValue11 float32 `csv:"[增加属性]11值,omitempty"`
+	AddPropType12  int32   `csv:"[增加属性]12类型,omitempty"`
+	AddPropValue12 float32 `csv:"[增加属性]12值,omitempty"`
+	AddPropType13  int32   `csv:"[增加属性]13类型,omitempty"`
+	AddPropValue13 float32 `csv:"[增加属性]13值,omitempty"`
+	AddPropType14  int32   `csv:"[增加属性]14类型,omitempty"`
+	AddPropValue14 float32 `csv:"[增加属性]14值,omitempty"`
+	AddPropType15  int32   `csv:"[增加属性]15类型,omitempty"`
+	AddPropValue15 float32 `csv:"[增加属性]15值,omitempty"`
+	AddPropType16  int32   `csv:"[增加属性]16类型,omitempty"`
+	AddPropValue16 float32 `csv:"[增加属性]16值,omitempty"`
+	AddPropType17  int32   `csv:"[增加属性]17类型,omitempty"`
+	AddPropValue17 float32 `csv:"[增加属性]17值,omitempty"`
+	AddPropType18  int32   `csv:"[增加属性]18类型,omitempty"`
+	AddPropValue18 float32 `csv:"[增加属性]18值,omitempty"`
+	AddPropType19  int32   `csv:"[增加属性]19类型,omitempty"`
+	AddPropValue19 float32 `csv:"[增加属性]19值,omitempty"`
+	AddPropType20  int32   `csv:"[增加属性]20类型,omitempty"`
+	AddPropValue20 float32 `csv:"[增加属性]20值,omitempty"`
+
+	AddPropMap map[uint32]float32 // 主属性类型对应的属性值
+}
+
+func (g *GameDataConfig) loadReliquaryLevelData() {
+	g.ReliquaryLevelDataMap = make(map[int32]map[int32]*ReliquaryLevelData)
+	reliquaryLevelDataList := make([]*ReliquaryLevelData, 0)
+	readTable[ReliquaryLevelData](g.txtPrefix+"ReliquaryLevelData.txt", &reliquaryLevelDataList)
+	for _, reliquaryLevelData := range reliquaryLevelDataList {
+		_, ok := g.ReliquaryLevelDataMap[reliquaryLevelData.Rank]
+		if !ok {
+			g.ReliquaryLevelDataMap[reliquaryLevelData.Rank] = make(map[int32]*ReliquaryLevelData)
+		}
+		reliquaryLevelData.AddPropMap = map[uint32]float32{
+			uint32(reliquaryLevelData.AddPropType1):  reliquaryLevelData.AddPropValue1,
+			uint32(reliquaryLevelData.AddPropType2):  reliquaryLevelData.AddPropValue2,
+			uint32(reliquaryLevelData.AddPropType3):  reliquaryLevelData.AddPropValue3,
+			uint32(reliquaryLevelData.AddPropType4):  reliquaryLevelData.AddPropValue4,
+			uint32(reliquaryLevelData.AddPropType5):  reliquaryLevelData.AddPropValue5,
+			uint32(reliquaryLevelData.AddPropType6):  reliquaryLevelData.AddPropValue6,
+			uint32(reliquaryLevelData.AddPropType7):  reliquaryLevelData.AddPropValue7,
+			uint32(reliquaryLevelData.AddPropType8):  reliquaryLevelData.AddPropValue8,
+			uint32(reliquaryLevelData.AddPropType9):  reliquaryLevelData.AddPropValue9,
+			uint32(reliquaryLevelData.AddPropType10): reliquaryLevelData.AddPropValue10,
+			uint32(reliquaryLevelData.AddPropType11): reliquaryLevelData.AddPropValue11,
+			uint32(reliquaryLevelData.AddPropType12): reliquaryLevelData.AddPropValue12,
+			uint32(reliquaryLevelData.AddPropType13): reliquaryLevelData.AddPropValue13,
+			uint32(reliquaryLevelData.AddPropType14): reliquaryLevelData.AddPropValue14,
+			uint32(reliquaryLevelData.AddPropType15): reliquaryLevelData.AddPropValue15,
+			uint32(reliquaryLevelData.AddPropType16): reliquaryLevelData.AddPropValue16,
+			uint32(reliquaryLevelData.AddPropType17): reliquaryLevelData.AddPropValue17,
+			uint32(reliquaryLevelData.AddPropType18): reliquaryLevelData.AddPropValue18,
+			uint32(reliquaryLevelData.AddPropType19): reliquaryLevelData.AddPropValue19,
+			uint32(reliquaryLevelData.AddPropType20): reliquaryLevelData.AddPropValue20,
+		}
+		for propType, propValue := range reliquaryLevelData.AddPropMap {
+			// 两个值都不能为0
+			if propType == 0 || propValue == 0 {
+				delete(reliquaryLevelData.AddPropMap, propType)
+			}
+		}
+		// 通过阶数和等级找到等级数据
+		g.ReliquaryLevelDataMap[reliquaryLevelData.Rank][reliquaryLevelData.Level] = reliquaryLevelData
+	}
+	logger.Info("ReliquaryLevelData count: %v", len(g.ReliquaryLevelDataMap))
+}
+
+func GetReliquaryLevelDataByRankAndLevel(rank int32, level int32) *ReliquaryLevelData {
+	value, exist := CONF.ReliquaryLevelDataMap[rank]
+	if !exist {
+		return nil
+	}
+	return value[level]
+}
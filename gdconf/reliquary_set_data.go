@@ -0,0 +1,26 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+// ReliquarySetData 圣遗物套装配置表
+type ReliquarySetData struct {
+	SetId        int32    `csv:"套装ID"`
+	SetNeedNum   IntArray `csv:"套装激活件数,omitempty"`
+	EquipAffixId int32    `csv:"装备词缀ID,omitempty"`
+}
+
+func (g *GameDataConfig) loadReliquarySetData() {
+	g.ReliquarySetDataMap = make(map[int32]*ReliquarySetData)
+	reliquarySetDataList := make([]*ReliquarySetData, 0)
+	readTable[ReliquarySetData](g.txtPrefix+"ReliquarySetData.txt", &reliquarySetDataList)
+	for _, reliquarySetData := range reliquarySetDataList {
+		g.ReliquarySetDataMap[reliquarySetData.SetId] = reliquarySetData
+	}
+	logger.Info("ReliquarySetData count: %v", len(g.ReliquarySetDataMap))
+}
+
+func GetReliquarySetDataById(setId int32) *ReliquarySetData {
+	return CONF.ReliquarySetDataMap[setId]
+}
@@ -118,6 +118,8 @@ type Gadget struct {
 	DropTag     string  `json:"drop_tag"`
 	IsOneOff    bool    `json:"isOneoff"`
 	ChestDropId int32   `json:"chest_drop_id"`
+	// 征讨之花等需要消耗树脂领取的宝箱
+	IsBlossomChest bool `json:"is_blossom_chest"`
 }
 
 type Region struct {
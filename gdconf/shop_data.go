@@ -0,0 +1,39 @@
+package gdconf
+
+import (
+	"hk4e/pkg/logger"
+)
+
+const (
+	ShopRefreshTypeNone    = 0 // 不刷新
+	ShopRefreshTypeDaily   = 1 // 每日刷新
+	ShopRefreshTypeWeekly  = 2 // 每周刷新 参数为星期几
+	ShopRefreshTypeMonthly = 3 // 每月刷新 参数为几号
+)
+
+// ShopData 商店配置表
+type ShopData struct {
+	ShopType      int32 `csv:"商店类型"`
+	ShopId        int32 `csv:"商店ID,omitempty"`
+	RefreshType   int32 `csv:"刷新类型,omitempty"`
+	RefreshParam  int32 `csv:"刷新参数,omitempty"`
+	OpenStateType int32 `csv:"OpenStateType,omitempty"`
+}
+
+func (g *GameDataConfig) loadShopData() {
+	g.ShopDataMap = make(map[int32]*ShopData)
+	shopDataList := make([]*ShopData, 0)
+	readTable[ShopData](g.txtPrefix+"ShopData.txt", &shopDataList)
+	for _, shopData := range shopDataList {
+		g.ShopDataMap[shopData.ShopType] = shopData
+	}
+	logger.Info("ShopData count: %v", len(g.ShopDataMap))
+}
+
+func GetShopDataByShopType(shopType int32) *ShopData {
+	return CONF.ShopDataMap[shopType]
+}
+
+func GetShopDataMap() map[int32]*ShopData {
+	return CONF.ShopDataMap
+}
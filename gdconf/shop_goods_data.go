@@ -0,0 +1,108 @@
+package gdconf
+
+import (
+	"fmt"
+	"time"
+
+	"hk4e/pkg/logger"
+)
+
+const (
+	ShopGoodsTimeLayout = "2006-01-02 15:04:05"
+)
+
+// ShopGoodsData 商店商品配置表
+type ShopGoodsData struct {
+	GoodsId        int32  `csv:"商品ID"`
+	ShopType       int32  `csv:"商店类型,omitempty"`
+	ItemId         int32  `csv:"对应物品ID,omitempty"`
+	ItemCount      int32  `csv:"对应物品数量,omitempty"`
+	CostScoin      int32  `csv:"消耗金币,omitempty"`
+	CostHcoin      int32  `csv:"消耗水晶,omitempty"`
+	CostMcoin      int32  `csv:"消耗创世结晶,omitempty"`
+	CostItemId1    int32  `csv:"[消耗物品]1ID,omitempty"`
+	CostItemCount1 int32  `csv:"[消耗物品]1数量,omitempty"`
+	CostItemId2    int32  `csv:"[消耗物品]2ID,omitempty"`
+	CostItemCount2 int32  `csv:"[消耗物品]2数量,omitempty"`
+	CostItemId3    int32  `csv:"[消耗物品]3ID,omitempty"`
+	CostItemCount3 int32  `csv:"[消耗物品]3数量,omitempty"`
+	CostItemId4    int32  `csv:"[消耗物品]4ID,omitempty"`
+	CostItemCount4 int32  `csv:"[消耗物品]4数量,omitempty"`
+	BuyLimit       int32  `csv:"限购数量,omitempty"`
+	RefreshType    int32  `csv:"刷新类型,omitempty"`
+	RefreshParam   int32  `csv:"刷新参数,omitempty"`
+	BeginTimeStr   string `csv:"上架时间,omitempty"`
+	EndTimeStr     string `csv:"下架时间,omitempty"`
+	MinPlayerLevel int32  `csv:"最小队伍等级,omitempty"`
+	MaxPlayerLevel int32  `csv:"最大队伍等级,omitempty"`
+	SortLevel      int32  `csv:"排序等级,omitempty"`
+
+	CostItemMap map[uint32]uint32 // 消耗物品列表
+	BeginTime   uint32            // 上架时间戳 0为不限制
+	EndTime     uint32            // 下架时间戳 0为不限制
+}
+
+func (g *GameDataConfig) loadShopGoodsData() {
+	g.ShopGoodsDataMap = make(map[int32]*ShopGoodsData)
+	g.ShopGoodsDataShopTypeMap = make(map[int32]map[int32]*ShopGoodsData)
+	shopGoodsDataList := make([]*ShopGoodsData, 0)
+	readTable[ShopGoodsData](g.txtPrefix+"ShopGoodsData.txt", &shopGoodsDataList)
+	for _, shopGoodsData := range shopGoodsDataList {
+		if shopGoodsData.RefreshType < ShopRefreshTypeNone || shopGoodsData.RefreshType > ShopRefreshTypeMonthly {
+			info := fmt.Sprintf("invalid shop goods refresh type: %v", shopGoodsData)
+			panic(info)
+		}
+		if shopGoodsData.ItemCount == 0 {
+			shopGoodsData.ItemCount = 1
+		}
+		shopGoodsData.CostItemMap = map[uint32]uint32{
+			uint32(shopGoodsData.CostItemId1): uint32(shopGoodsData.CostItemCount1),
+			uint32(shopGoodsData.CostItemId2): uint32(shopGoodsData.CostItemCount2),
+			uint32(shopGoodsData.CostItemId3): uint32(shopGoodsData.CostItemCount3),
+			uint32(shopGoodsData.CostItemId4): uint32(shopGoodsData.CostItemCount4),
+		}
+		for itemId, count := range shopGoodsData.CostItemMap {
+			// 两个值都不能为0
+			if itemId == 0 || count == 0 {
+				delete(shopGoodsData.CostItemMap, itemId)
+			}
+		}
+		// 上下架时间
+		if shopGoodsData.BeginTimeStr != "" {
+			beginTime, err := time.ParseInLocation(ShopGoodsTimeLayout, shopGoodsData.BeginTimeStr, time.Local)
+			if err != nil {
+				info := fmt.Sprintf("shop goods begin time format error: %v", shopGoodsData)
+				panic(info)
+			}
+			shopGoodsData.BeginTime = uint32(beginTime.Unix())
+		}
+		if shopGoodsData.EndTimeStr != "" {
+			endTime, err := time.ParseInLocation(ShopGoodsTimeLayout, shopGoodsData.EndTimeStr, time.Local)
+			if err != nil {
+				info := fmt.Sprintf("shop goods end time format error: %v", shopGoodsData)
+				panic(info)
+			}
+			shopGoodsData.EndTime = uint32(endTime.Unix())
+		}
+		g.ShopGoodsDataMap[shopGoodsData.GoodsId] = shopGoodsData
+		// 通过商店类型找到商品列表
+		_, ok := g.ShopGoodsDataShopTypeMap[shopGoodsData.ShopType]
+		if !ok {
+			g.ShopGoodsDataShopTypeMap[shopGoodsData.ShopType] = make(map[int32]*ShopGoodsData)
+		}
+		g.ShopGoodsDataShopTypeMap[shopGoodsData.ShopType][shopGoodsData.GoodsId] = shopGoodsData
+	}
+	logger.Info("ShopGoodsData count: %v", len(g.ShopGoodsDataMap))
+}
+
+func GetShopGoodsDataById(goodsId int32) *ShopGoodsData {
+	return CONF.ShopGoodsDataMap[goodsId]
+}
+
+func GetShopGoodsDataMapByShopType(shopType int32) map[int32]*ShopGoodsData {
+	return CONF.ShopGoodsDataShopTypeMap[shopType]
+}
+
+func GetShopGoodsDataMap() map[int32]*ShopGoodsData {
+	return CONF.ShopGoodsDataMap
+}
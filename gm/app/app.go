@@ -10,6 +10,7 @@ import (
 	"hk4e/common/mq"
 	"hk4e/common/rpc"
 	"hk4e/gm/controller"
+	"hk4e/gm/dao"
 	"hk4e/node/api"
 	"hk4e/pkg/logger"
 )
@@ -32,7 +33,13 @@ func Run(ctx context.Context, configFile string) error {
 	messageQueue := mq.NewMessageQueue(api.GM, "gm", nil)
 	defer messageQueue.Close()
 
-	_ = controller.NewController(discoveryClient, messageQueue)
+	db, err := dao.NewDao()
+	if err != nil {
+		return err
+	}
+	defer db.CloseDao()
+
+	_ = controller.NewController(db, discoveryClient, messageQueue)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT)
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"hk4e/gm/dao"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AnticheatViolationPageSizeMax = 100
+)
+
+type AnticheatViolationListRsp struct {
+	Total int64                     `json:"total"`
+	List  []*dao.AnticheatViolation `json:"list"`
+}
+
+// anticheatViolationList 分页查询反作弊违规记录 参数uid rule action begin_time end_time page size 均可选
+func (c *Controller) anticheatViolationList(ctx *gin.Context) {
+	uid, _ := strconv.ParseUint(ctx.Query("uid"), 10, 32)
+	beginTime, _ := strconv.ParseUint(ctx.Query("begin_time"), 10, 32)
+	endTime, _ := strconv.ParseUint(ctx.Query("end_time"), 10, 32)
+	page, err := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.ParseInt(ctx.DefaultQuery("size", "20"), 10, 64)
+	if err != nil || size < 1 || size > AnticheatViolationPageSizeMax {
+		size = 20
+	}
+	violationList, total, err := c.db.QueryAnticheatViolationPage(uint32(uid), ctx.Query("rule"), ctx.Query("action"), uint32(beginTime), uint32(endTime), page, size)
+	if err != nil {
+		logger.Error("query anticheat violation page error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: &AnticheatViolationListRsp{Total: total, List: violationList}})
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"hk4e/gm/dao"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ChatAuditPageSizeMax = 100
+)
+
+type ChatAuditListRsp struct {
+	Total int64            `json:"total"`
+	List  []*dao.ChatAudit `json:"list"`
+}
+
+// chatAuditList 分页查询聊天审计记录 参数uid action begin_time end_time page size 均可选
+func (c *Controller) chatAuditList(ctx *gin.Context) {
+	uid, _ := strconv.ParseUint(ctx.Query("uid"), 10, 32)
+	beginTime, _ := strconv.ParseUint(ctx.Query("begin_time"), 10, 32)
+	endTime, _ := strconv.ParseUint(ctx.Query("end_time"), 10, 32)
+	page, err := strconv.ParseInt(ctx.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.ParseInt(ctx.DefaultQuery("size", "20"), 10, 64)
+	if err != nil || size < 1 || size > ChatAuditPageSizeMax {
+		size = 20
+	}
+	chatAuditList, total, err := c.db.QueryChatAuditPage(uint32(uid), ctx.Query("action"), uint32(beginTime), uint32(endTime), page, size)
+	if err != nil {
+		logger.Error("query chat audit page error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: &ChatAuditListRsp{Total: total, List: chatAuditList}})
+}
@@ -10,12 +10,14 @@ import (
 	"hk4e/common/config"
 	"hk4e/common/mq"
 	"hk4e/common/rpc"
+	"hk4e/gm/dao"
 	"hk4e/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Controller struct {
+	db                    *dao.Dao
 	gmClientMap           map[uint32]*rpc.GMClient
 	gmClientMapLock       sync.RWMutex
 	discoveryClient       *rpc.DiscoveryClient
@@ -24,8 +26,9 @@ type Controller struct {
 	globalGsOnlineMapLock sync.RWMutex
 }
 
-func NewController(discoveryClient *rpc.DiscoveryClient, messageQueue *mq.MessageQueue) (r *Controller) {
+func NewController(db *dao.Dao, discoveryClient *rpc.DiscoveryClient, messageQueue *mq.MessageQueue) (r *Controller) {
 	r = new(Controller)
+	r.db = db
 	r.gmClientMap = make(map[uint32]*rpc.GMClient)
 	r.discoveryClient = discoveryClient
 	r.messageQueue = messageQueue
@@ -107,6 +110,14 @@ func (c *Controller) registerRouter() {
 	engine.POST("/server/white/add", c.serverWhiteAdd)
 	engine.POST("/server/white/del", c.serverWhiteDel)
 	engine.POST("/server/dispatch/cancel", c.serverDispatchCancel)
+	engine.POST("/server/drain", c.serverDrain)
+	engine.GET("/server/drain/state", c.serverDrainState)
+	engine.GET("/player/forbid/info", c.playerForbidInfo)
+	engine.POST("/player/forbid", c.playerForbid)
+	engine.POST("/player/mute", c.playerMute)
+	engine.GET("/chat/audit/list", c.chatAuditList)
+	engine.POST("/mail/send", c.mailSend)
+	engine.GET("/anticheat/violation/list", c.anticheatViolationList)
 	port := config.GetConfig().HttpPort
 	addr := ":" + strconv.Itoa(int(port))
 	err := engine.Run(addr)
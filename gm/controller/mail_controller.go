@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"hk4e/common/mq"
+	"hk4e/gm/dao"
+	"hk4e/node/api"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MailUidListMax              = 1000
+	GlobalMailDefaultExpireTime = 30 * 24 * 3600 // 全服邮件默认有效期 单位秒
+)
+
+type MailSendReq struct {
+	UidList    []uint32        `json:"uid_list"`    // 收件人uid列表
+	All        bool            `json:"all"`         // 是否发给全服玩家 为true时忽略uid_list
+	Title      string          `json:"title"`       // 标题
+	Content    string          `json:"content"`     // 正文
+	Sender     string          `json:"sender"`      // 发件人 为空则使用默认发件人
+	ExpireTime uint32          `json:"expire_time"` // 过期时间 秒时间戳 0为默认有效期
+	ItemList   []*dao.MailItem `json:"item_list"`   // 附件
+}
+
+// mailSend 发送邮件 指定uid列表时按玩家所在的gs分组发送 离线玩家由主gs写入db
+// 全服邮件先写入db 再通知全部gs发给在线玩家 离线玩家登录时领取
+func (c *Controller) mailSend(ctx *gin.Context) {
+	req := new(MailSendReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "参数解析错误", Data: err})
+		return
+	}
+	if req.Title == "" {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "邮件标题不能为空", Data: nil})
+		return
+	}
+	if !req.All && (len(req.UidList) == 0 || len(req.UidList) > MailUidListMax) {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "收件人数量错误", Data: nil})
+		return
+	}
+	now := uint32(time.Now().Unix())
+	if req.ExpireTime != 0 && req.ExpireTime <= now {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "过期时间错误", Data: nil})
+		return
+	}
+	itemList := make([]*mq.MailItemInfo, 0, len(req.ItemList))
+	for _, item := range req.ItemList {
+		if item.ItemId == 0 || item.ItemCount == 0 {
+			ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "附件参数错误", Data: nil})
+			return
+		}
+		itemList = append(itemList, &mq.MailItemInfo{ItemId: item.ItemId, ItemCount: item.ItemCount})
+	}
+	mailInfo := &mq.MailInfo{
+		Title:      req.Title,
+		Content:    req.Content,
+		Sender:     req.Sender,
+		SendTime:   now,
+		ExpireTime: req.ExpireTime,
+		ItemList:   itemList,
+	}
+	if req.All {
+		globalMail, err := c.insertGlobalMail(req, now)
+		if err != nil {
+			logger.Error("insert global mail error: %v", err)
+			ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+			return
+		}
+		mailInfo.GlobalMailId = globalMail.GlobalMailId
+		mailInfo.ExpireTime = globalMail.ExpireTime
+		c.messageQueue.SendToAll(&mq.NetMsg{
+			MsgType: mq.MsgTypeServer,
+			EventId: mq.ServerSendMailNotify,
+			ServerMsg: &mq.ServerMsg{
+				MailInfo: mailInfo,
+			},
+		})
+		logger.Info("send global mail, globalMailId: %v, title: %v", globalMail.GlobalMailId, req.Title)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: globalMail})
+		return
+	}
+	gsUidMap := make(map[string][]uint32)
+	offlineUidList := make([]uint32, 0)
+	c.globalGsOnlineMapLock.RLock()
+	for _, uid := range req.UidList {
+		gsAppId, exist := c.globalGsOnlineMap[uid]
+		if !exist {
+			offlineUidList = append(offlineUidList, uid)
+			continue
+		}
+		gsUidMap[gsAppId] = append(gsUidMap[gsAppId], uid)
+	}
+	c.globalGsOnlineMapLock.RUnlock()
+	if len(offlineUidList) > 0 {
+		rsp, err := c.discoveryClient.GetMainGameServerAppId(ctx.Request.Context(), &api.NullMsg{})
+		if err != nil {
+			logger.Error("get main gs appid error: %v", err)
+			ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+			return
+		}
+		gsUidMap[rsp.AppId] = append(gsUidMap[rsp.AppId], offlineUidList...)
+	}
+	for gsAppId, uidList := range gsUidMap {
+		gsMailInfo := *mailInfo
+		gsMailInfo.UserIdList = uidList
+		c.messageQueue.SendToGs(gsAppId, &mq.NetMsg{
+			MsgType: mq.MsgTypeServer,
+			EventId: mq.ServerSendMailNotify,
+			ServerMsg: &mq.ServerMsg{
+				MailInfo: &gsMailInfo,
+			},
+		})
+	}
+	logger.Info("send player mail, title: %v, uid num: %v, gs num: %v", req.Title, len(req.UidList), len(gsUidMap))
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: nil})
+}
+
+// insertGlobalMail 写入全服邮件 全服邮件id由db计数器原子分配 多个gm实例同时发送也不会重复
+func (c *Controller) insertGlobalMail(req *MailSendReq, now uint32) (*dao.GlobalMail, error) {
+	globalMailId, err := c.db.NextGlobalMailId()
+	if err != nil {
+		return nil, err
+	}
+	globalMail := &dao.GlobalMail{
+		GlobalMailId: globalMailId,
+		Title:        req.Title,
+		Content:      req.Content,
+		Sender:       req.Sender,
+		SendTime:     now,
+		ExpireTime:   req.ExpireTime,
+		ItemList:     req.ItemList,
+	}
+	if globalMail.ExpireTime == 0 {
+		globalMail.ExpireTime = now + GlobalMailDefaultExpireTime
+	}
+	if globalMail.ItemList == nil {
+		globalMail.ItemList = make([]*dao.MailItem, 0)
+	}
+	err = c.db.InsertGlobalMail(globalMail)
+	if err != nil {
+		return nil, err
+	}
+	return globalMail, nil
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"hk4e/common/mq"
+	"hk4e/gate/kcp"
+	"hk4e/node/api"
+	"hk4e/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Controller) playerForbidInfo(ctx *gin.Context) {
+	uid, err := strconv.ParseUint(ctx.Query("uid"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "参数解析错误", Data: err})
+		return
+	}
+	account, err := c.db.QueryGateAccountByUid(uint32(uid))
+	if err != nil {
+		logger.Error("query gate account error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	if account == nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "玩家不存在", Data: nil})
+		return
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: account})
+}
+
+type PlayerForbidReq struct {
+	Uid     uint32 `json:"uid"`
+	Forbid  bool   `json:"forbid"`
+	EndTime uint32 `json:"end_time"` // 封号结束时间 秒时间戳 0为永久封号
+	Reason  string `json:"reason"`
+}
+
+// playerForbid 封号或解封 封号后立即踢出在线的玩家
+func (c *Controller) playerForbid(ctx *gin.Context) {
+	req := new(PlayerForbidReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "参数解析错误", Data: err})
+		return
+	}
+	if !req.Forbid {
+		req.EndTime = 0
+		req.Reason = ""
+	}
+	matchCount, err := c.db.UpdateGateAccountForbid(req.Uid, req.Forbid, req.EndTime, req.Reason)
+	if err != nil {
+		logger.Error("update gate account forbid error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	if matchCount == 0 {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "玩家不存在", Data: nil})
+		return
+	}
+	logger.Info("player forbid, uid: %v, forbid: %v, endTime: %v, reason: %v", req.Uid, req.Forbid, req.EndTime, req.Reason)
+	if req.Forbid {
+		// 玩家所在的gs不一定准确 直接通知全部gs
+		c.messageQueue.SendToAll(&mq.NetMsg{
+			MsgType: mq.MsgTypeServer,
+			EventId: mq.ServerKickPlayerNotify,
+			ServerMsg: &mq.ServerMsg{
+				UserId:     req.Uid,
+				KickReason: kcp.EnetServerKick,
+			},
+		})
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: nil})
+}
+
+type PlayerMuteReq struct {
+	Uid     uint32 `json:"uid"`
+	Mute    bool   `json:"mute"`
+	EndTime uint32 `json:"end_time"` // 禁言结束时间 秒时间戳 0为永久禁言
+	Reason  string `json:"reason"`
+}
+
+// playerMute 禁言或解除禁言 发往玩家所在的gs 离线玩家由主gs修改离线存档
+func (c *Controller) playerMute(ctx *gin.Context) {
+	req := new(PlayerMuteReq)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "参数解析错误", Data: err})
+		return
+	}
+	c.globalGsOnlineMapLock.RLock()
+	gsAppId, exist := c.globalGsOnlineMap[req.Uid]
+	c.globalGsOnlineMapLock.RUnlock()
+	if !exist {
+		rsp, err := c.discoveryClient.GetMainGameServerAppId(ctx.Request.Context(), &api.NullMsg{})
+		if err != nil {
+			logger.Error("get main gs appid error: %v", err)
+			ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+			return
+		}
+		gsAppId = rsp.AppId
+	}
+	c.messageQueue.SendToGs(gsAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerPlayerMuteNotify,
+		ServerMsg: &mq.ServerMsg{
+			MuteInfo: &mq.MuteInfo{
+				UserId:  req.Uid,
+				IsMute:  req.Mute,
+				EndTime: req.EndTime,
+				Reason:  req.Reason,
+			},
+		},
+	})
+	logger.Info("player mute, uid: %v, mute: %v, endTime: %v, reason: %v, gsAppId: %v", req.Uid, req.Mute, req.EndTime, req.Reason, gsAppId)
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: nil})
+}
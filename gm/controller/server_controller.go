@@ -123,6 +123,53 @@ func (c *Controller) serverDispatchCancel(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: nil})
 }
 
+type ServerDrain struct {
+	AppId string `json:"app_id"`
+}
+
+func (c *Controller) serverDrain(ctx *gin.Context) {
+	req := new(ServerDrain)
+	err := ctx.ShouldBindJSON(req)
+	if err != nil {
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "参数解析错误", Data: err})
+		return
+	}
+	_, err = c.discoveryClient.ServerDrain(ctx.Request.Context(), &api.ServerDrainReq{
+		AppId: req.AppId,
+	})
+	if err != nil {
+		logger.Error("server drain error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: nil})
+}
+
+type ServerDrainState struct {
+	AppId     string `json:"app_id"`
+	State     uint32 `json:"state"` // 0:未排空 1:排空中 2:排空完成 3:无迁移目标已停止
+	OnlineNum uint32 `json:"online_num"`
+	Msg       string `json:"msg"`
+}
+
+func (c *Controller) serverDrainState(ctx *gin.Context) {
+	appId := ctx.Query("app_id")
+	rsp, err := c.discoveryClient.GetServerDrainState(ctx.Request.Context(), &api.ServerDrainReq{
+		AppId: appId,
+	})
+	if err != nil {
+		logger.Error("get server drain state error: %v", err)
+		ctx.JSON(http.StatusOK, &CommonRsp{Code: -1, Msg: "服务器内部错误", Data: err})
+		return
+	}
+	ctx.JSON(http.StatusOK, &CommonRsp{Code: 0, Msg: "", Data: &ServerDrainState{
+		AppId:     rsp.AppId,
+		State:     rsp.State,
+		OnlineNum: rsp.OnlineNum,
+		Msg:       rsp.Msg,
+	}})
+}
+
 type ServerOnlineStats struct {
 	TotalOnlinePlayerNum uint32 `json:"total_online_player_num"`
 }
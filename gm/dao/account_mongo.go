@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GateAccount 网关账号 与gate中的账号结构保持一致 只包含gm需要的字段
+type GateAccount struct {
+	OpenId        string `bson:"open_id" json:"open_id"`
+	Uid           uint32 `bson:"uid" json:"uid"`
+	IsForbid      bool   `bson:"is_forbid" json:"is_forbid"`
+	ForbidEndTime uint32 `bson:"forbid_end_time" json:"forbid_end_time"`
+	ForbidReason  string `bson:"forbid_reason" json:"forbid_reason"`
+}
+
+func (d *Dao) QueryGateAccountByUid(uid uint32) (*GateAccount, error) {
+	db := d.gateDb.Collection("account")
+	result := db.FindOne(
+		context.TODO(),
+		bson.D{{"uid", uid}},
+	)
+	account := new(GateAccount)
+	err := result.Decode(account)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+// UpdateGateAccountForbid 更新网关账号的封号状态 返回匹配的账号数量
+func (d *Dao) UpdateGateAccountForbid(uid uint32, isForbid bool, forbidEndTime uint32, forbidReason string) (int64, error) {
+	db := d.gateDb.Collection("account")
+	updateResult, err := db.UpdateOne(
+		context.TODO(),
+		bson.D{{"uid", uid}},
+		bson.D{{"$set", bson.D{
+			{"is_forbid", isForbid},
+			{"forbid_end_time", forbidEndTime},
+			{"forbid_reason", forbidReason},
+		}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return updateResult.MatchedCount, nil
+}
@@ -0,0 +1,72 @@
+package dao
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AnticheatViolation multi写入的反作弊违规记录
+type AnticheatViolation struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Uid     uint32             `bson:"uid" json:"uid"`
+	SceneId uint32             `bson:"scene_id" json:"scene_id"`
+	Rule    string             `bson:"rule" json:"rule"`
+	Action  string             `bson:"action" json:"action"`
+	Value   float64            `bson:"value" json:"value"`
+	Limit   float64            `bson:"limit" json:"limit"`
+	Score   float64            `bson:"score" json:"score"`
+	PosX    float64            `bson:"pos_x" json:"pos_x"`
+	PosY    float64            `bson:"pos_y" json:"pos_y"`
+	PosZ    float64            `bson:"pos_z" json:"pos_z"`
+	Time    uint32             `bson:"time" json:"time"`
+}
+
+// QueryAnticheatViolationPage 分页查询反作弊违规记录 按时间倒序 条件为零值时不过滤 返回当前页记录和总条数
+func (d *Dao) QueryAnticheatViolationPage(uid uint32, rule string, action string, beginTime uint32, endTime uint32, page int64, size int64) ([]*AnticheatViolation, int64, error) {
+	db := d.multiDb.Collection("anticheat_violation")
+	filter := bson.D{}
+	if uid != 0 {
+		filter = append(filter, bson.E{Key: "uid", Value: uid})
+	}
+	if rule != "" {
+		filter = append(filter, bson.E{Key: "rule", Value: rule})
+	}
+	if action != "" {
+		filter = append(filter, bson.E{Key: "action", Value: action})
+	}
+	if beginTime != 0 || endTime != 0 {
+		timeFilter := bson.D{}
+		if beginTime != 0 {
+			timeFilter = append(timeFilter, bson.E{Key: "$gte", Value: beginTime})
+		}
+		if endTime != 0 {
+			timeFilter = append(timeFilter, bson.E{Key: "$lt", Value: endTime})
+		}
+		filter = append(filter, bson.E{Key: "time", Value: timeFilter})
+	}
+	total, err := db.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	find, err := db.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{"time", -1}, {"_id", -1}}).SetSkip((page-1)*size).SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*AnticheatViolation, 0)
+	for find.Next(context.TODO()) {
+		item := new(AnticheatViolation)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChatAudit gs写入的聊天审计记录
+type ChatAudit struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Uid        uint32             `bson:"uid" json:"uid"`
+	TargetUid  uint32             `bson:"target_uid" json:"target_uid"`
+	Text       string             `bson:"text" json:"text"`
+	FilterText string             `bson:"filter_text" json:"filter_text"`
+	Action     string             `bson:"action" json:"action"`
+	HitList    []string           `bson:"hit_list" json:"hit_list"`
+	Time       uint32             `bson:"time" json:"time"`
+}
+
+// QueryChatAuditPage 分页查询聊天审计记录 按时间倒序 条件为零值时不过滤 返回当前页记录和总条数
+func (d *Dao) QueryChatAuditPage(uid uint32, action string, beginTime uint32, endTime uint32, page int64, size int64) ([]*ChatAudit, int64, error) {
+	db := d.gsDb.Collection("chat_audit")
+	filter := bson.D{}
+	if uid != 0 {
+		filter = append(filter, bson.E{Key: "uid", Value: uid})
+	}
+	if action != "" {
+		filter = append(filter, bson.E{Key: "action", Value: action})
+	}
+	if beginTime != 0 || endTime != 0 {
+		timeFilter := bson.D{}
+		if beginTime != 0 {
+			timeFilter = append(timeFilter, bson.E{Key: "$gte", Value: beginTime})
+		}
+		if endTime != 0 {
+			timeFilter = append(timeFilter, bson.E{Key: "$lt", Value: endTime})
+		}
+		filter = append(filter, bson.E{Key: "time", Value: timeFilter})
+	}
+	total, err := db.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	find, err := db.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{"time", -1}, {"_id", -1}}).SetSkip((page-1)*size).SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*ChatAudit, 0)
+	for find.Next(context.TODO()) {
+		item := new(ChatAudit)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
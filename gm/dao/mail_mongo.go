@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GlobalMail 全服邮件 gs在玩家在线或登录时领取
+type GlobalMail struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GlobalMailId uint32             `bson:"global_mail_id" json:"global_mail_id"`
+	Title        string             `bson:"title" json:"title"`
+	Content      string             `bson:"content" json:"content"`
+	Sender       string             `bson:"sender" json:"sender"`
+	SendTime     uint32             `bson:"send_time" json:"send_time"`
+	ExpireTime   uint32             `bson:"expire_time" json:"expire_time"`
+	ItemList     []*MailItem        `bson:"item_list" json:"item_list"`
+}
+
+type MailItem struct {
+	ItemId    uint32 `bson:"item_id" json:"item_id"`
+	ItemCount uint32 `bson:"item_count" json:"item_count"`
+}
+
+// globalMailIdCounter 全服邮件id计数器
+type globalMailIdCounter struct {
+	ID  string `bson:"_id"`
+	Seq uint32 `bson:"seq"`
+}
+
+const (
+	GlobalMailIdCounterId = "global_mail_id"
+)
+
+// InitGlobalMailId 为全服邮件id建立唯一索引 并用已有的最大id初始化计数器
+func (d *Dao) InitGlobalMailId() error {
+	db := d.gsDb.Collection("global_mail")
+	_, err := db.Indexes().CreateOne(
+		context.TODO(),
+		mongo.IndexModel{
+			Keys:    bson.D{{"global_mail_id", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	maxGlobalMailId, err := d.queryMaxGlobalMailId()
+	if err != nil {
+		return err
+	}
+	_, err = d.gsDb.Collection("global_mail_counter").UpdateOne(
+		context.TODO(),
+		bson.D{{"_id", GlobalMailIdCounterId}},
+		bson.D{{"$max", bson.D{{"seq", maxGlobalMailId}}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NextGlobalMailId 原子分配下一个全服邮件id
+func (d *Dao) NextGlobalMailId() (uint32, error) {
+	db := d.gsDb.Collection("global_mail_counter")
+	result := db.FindOneAndUpdate(
+		context.TODO(),
+		bson.D{{"_id", GlobalMailIdCounterId}},
+		bson.D{{"$inc", bson.D{{"seq", 1}}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	counter := new(globalMailIdCounter)
+	err := result.Decode(counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// queryMaxGlobalMailId 查询当前最大的全服邮件id 没有全服邮件时返回0
+func (d *Dao) queryMaxGlobalMailId() (uint32, error) {
+	db := d.gsDb.Collection("global_mail")
+	result := db.FindOne(
+		context.TODO(),
+		bson.D{},
+		options.FindOne().SetSort(bson.D{{"global_mail_id", -1}}),
+	)
+	globalMail := new(GlobalMail)
+	err := result.Decode(globalMail)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		} else {
+			return 0, err
+		}
+	}
+	return globalMail.GlobalMailId, nil
+}
+
+func (d *Dao) InsertGlobalMail(globalMail *GlobalMail) error {
+	db := d.gsDb.Collection("global_mail")
+	_, err := db.InsertOne(context.TODO(), globalMail)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -40,6 +40,8 @@ func Run(ctx context.Context, configFile string) error {
 	rsp, err := discoveryClient.RegisterServer(context.TODO(), &api.RegisterServerReq{
 		ServerType: api.GS,
 		AppVersion: APPVERSION,
+		Weight:     uint32(config.GetConfig().Hk4e.ServerWeight),
+		Zone:       config.GetConfig().Hk4e.ServerZone,
 	})
 	if err != nil {
 		return err
@@ -94,7 +96,7 @@ func Run(ctx context.Context, configFile string) error {
 		return err
 	}
 	defer conn.Close()
-	s, err := service.NewService(conn, GSID)
+	s, err := service.NewService(conn, GSID, db)
 	if err != nil {
 		return err
 	}
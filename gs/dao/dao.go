@@ -23,7 +23,7 @@ type Dao struct {
 func NewDao() (*Dao, error) {
 	r := new(Dao)
 
-	clientOptions := options.Client().ApplyURI(config.GetConfig().Database.Url).SetMinPoolSize(1).SetMaxPoolSize(10)
+	clientOptions := options.Client().ApplyURI(config.GetConfig().Database.Url).SetMinPoolSize(1).SetMaxPoolSize(10).SetMonitor(newMongoMonitor())
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
 		logger.Error("mongo connect error: %v", err)
@@ -57,6 +57,11 @@ func NewDao() (*Dao, error) {
 			MinIdleConns: 1,
 		})
 	}
+	if r.redisCluster != nil {
+		r.redisCluster.AddHook(new(redisMetricsHook))
+	} else {
+		r.redis.AddHook(new(redisMetricsHook))
+	}
 	if r.redisCluster != nil {
 		err = r.redisCluster.Ping(context.TODO()).Err()
 	} else {
@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+
+	"hk4e/gs/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (d *Dao) InsertGachaRecordList(gachaRecordList []*model.GachaRecord) error {
+	if len(gachaRecordList) == 0 {
+		return nil
+	}
+	db := d.db.Collection("gacha_record")
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, gachaRecord := range gachaRecordList {
+		modelOperate := mongo.NewInsertOneModel().SetDocument(gachaRecord)
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	_, err := db.BulkWrite(context.TODO(), modelOperateList)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) DeleteAllGachaRecordByUid(uid uint32) error {
+	db := d.db.Collection("gacha_record")
+	_, err := db.DeleteMany(context.TODO(), bson.D{{"uid", uid}})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// QueryGachaRecordPage 分页查询玩家的抽卡记录 按时间倒序 返回当前页记录和总条数
+func (d *Dao) QueryGachaRecordPage(uid uint32, gachaType uint32, page int64, size int64) ([]*model.GachaRecord, int64, error) {
+	db := d.db.Collection("gacha_record")
+	filter := bson.D{{"uid", uid}, {"gacha_type", gachaType}}
+	total, err := db.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	find, err := db.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{"time", -1}, {"_id", -1}}).SetSkip((page-1)*size).SetLimit(size),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]*model.GachaRecord, 0)
+	for find.Next(context.TODO()) {
+		item := new(model.GachaRecord)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, item)
+	}
+	return result, total, nil
+}
@@ -0,0 +1,108 @@
+package dao
+
+import (
+	"context"
+
+	"hk4e/gs/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (d *Dao) InsertMailList(mailList []*model.Mail) error {
+	if len(mailList) == 0 {
+		return nil
+	}
+	db := d.db.Collection("mail")
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, mail := range mailList {
+		modelOperate := mongo.NewInsertOneModel().SetDocument(mail)
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	_, err := db.BulkWrite(context.TODO(), modelOperateList)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) DeleteMailList(idList []primitive.ObjectID) error {
+	if len(idList) == 0 {
+		return nil
+	}
+	db := d.db.Collection("mail")
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, id := range idList {
+		modelOperate := mongo.NewDeleteManyModel().SetFilter(bson.D{{"_id", id}})
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	_, err := db.BulkWrite(context.TODO(), modelOperateList)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) UpdateMailList(mailList []*model.Mail) error {
+	if len(mailList) == 0 {
+		return nil
+	}
+	db := d.db.Collection("mail")
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, mail := range mailList {
+		modelOperate := mongo.NewUpdateManyModel().SetFilter(bson.D{{"_id", mail.ID}}).SetUpdate(bson.D{{"$set", mail}})
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	_, err := db.BulkWrite(context.TODO(), modelOperateList)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) QueryMailListByUid(uid uint32) ([]*model.Mail, error) {
+	db := d.db.Collection("mail")
+	result := make([]*model.Mail, 0)
+	find, err := db.Find(
+		context.TODO(),
+		bson.D{{"uid", uid}},
+		options.Find().SetSort(bson.D{{"send_time", 1}, {"_id", 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for find.Next(context.TODO()) {
+		item := new(model.Mail)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// QueryGlobalMailList 查询id大于globalMailId且未过期的全服邮件
+func (d *Dao) QueryGlobalMailList(globalMailId uint32, now uint32) ([]*model.GlobalMail, error) {
+	db := d.db.Collection("global_mail")
+	result := make([]*model.GlobalMail, 0)
+	find, err := db.Find(
+		context.TODO(),
+		bson.D{{"global_mail_id", bson.D{{"$gt", globalMailId}}}, {"expire_time", bson.D{{"$gt", now}}}},
+		options.Find().SetSort(bson.D{{"global_mail_id", 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for find.Next(context.TODO()) {
+		item := new(model.GlobalMail)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
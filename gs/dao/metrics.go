@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// 数据库访问耗时监控
+
+var daoLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "hk4e_gs_dao_latency_seconds",
+	Help:    "数据库命令耗时",
+	Buckets: prometheus.DefBuckets,
+}, []string{"db", "cmd"})
+
+func newMongoMonitor() *event.CommandMonitor {
+	observe := func(commandName string, durationNanos int64) {
+		daoLatency.WithLabelValues("mongo", commandName).Observe(float64(durationNanos) / 1e9)
+	}
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			observe(evt.CommandName, evt.DurationNanos)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			observe(evt.CommandName, evt.DurationNanos)
+		},
+	}
+}
+
+type redisStartTimeKey struct{}
+
+// redisMetricsHook 统计redis命令耗时 管道按整体计为pipeline
+type redisMetricsHook struct {
+}
+
+func (h *redisMetricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartTimeKey{}, time.Now()), nil
+}
+
+func (h *redisMetricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd.Name())
+	return nil
+}
+
+func (h *redisMetricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartTimeKey{}, time.Now()), nil
+}
+
+func (h *redisMetricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	h.observe(ctx, "pipeline")
+	return nil
+}
+
+func (h *redisMetricsHook) observe(ctx context.Context, cmdName string) {
+	startTime, ok := ctx.Value(redisStartTimeKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	daoLatency.WithLabelValues("redis", cmdName).Observe(time.Since(startTime).Seconds())
+}
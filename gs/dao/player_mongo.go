@@ -466,3 +466,12 @@ func (d *Dao) SaveSceneBlock(sceneBlock *model.SceneBlock) error {
 		return d.UpdateSceneBlock(sceneBlock)
 	}
 }
+
+func (d *Dao) InsertChatAudit(chatAudit *model.ChatAudit) error {
+	db := d.db.Collection("chat_audit")
+	_, err := db.InsertOne(context.TODO(), chatAudit)
+	if err != nil {
+		return err
+	}
+	return nil
+}
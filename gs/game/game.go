@@ -1,9 +1,11 @@
 package game
 
 import (
+	"context"
 	"os"
 	"runtime"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"hk4e/common/constant"
@@ -12,6 +14,7 @@ import (
 	"hk4e/gate/kcp"
 	"hk4e/gs/dao"
 	"hk4e/gs/model"
+	"hk4e/node/api"
 	"hk4e/pkg/alg"
 	"hk4e/pkg/logger"
 	"hk4e/pkg/reflection"
@@ -38,6 +41,7 @@ var TICK_MANAGER *TickManager = nil
 var COMMAND_MANAGER *CommandManager = nil
 var GCG_MANAGER *GCGManager = nil
 var PLUGIN_MANAGER *PluginManager = nil
+var CHAT_FILTER_MANAGER *ChatFilterManager = nil
 
 var ONLINE_PLAYER_NUM int32 = 0 // 当前在线玩家数
 
@@ -53,6 +57,10 @@ type Game struct {
 	snowflake          *alg.SnowflakeWorker // 雪花唯一id生成器
 	isStop             bool                 // 停服标志
 	dispatchCancel     bool                 // 取消调度标志
+	isDrain            bool                 // 排空标志
+	drainWait          bool                 // 排空时等待迁移目标gs查询结果
+	drainFailCount     int                  // 排空时连续查询不到迁移目标gs的次数
+	mainMultiAppid     string               // 主多功能服务器appid 全服匹配队列所在的服务器
 	endlessLoopCounter map[int]uint64       // 死循环保护计数器
 	transactionSeq     uint32               // 事务序列号
 	ai                 *model.Player        // 本服的Ai玩家对象
@@ -69,6 +77,10 @@ func NewGameCore(discoveryClient *rpc.DiscoveryClient, db *dao.Dao, messageQueue
 	r.snowflake = alg.NewSnowflakeWorker(int64(gsId))
 	r.isStop = false
 	r.dispatchCancel = false
+	r.isDrain = false
+	r.drainWait = false
+	r.drainFailCount = 0
+	r.mainMultiAppid = ""
 	r.endlessLoopCounter = make(map[int]uint64)
 	r.transactionSeq = 0
 	GAME = r
@@ -80,6 +92,7 @@ func NewGameCore(discoveryClient *rpc.DiscoveryClient, db *dao.Dao, messageQueue
 	COMMAND_MANAGER = NewCommandManager()
 	GCG_MANAGER = NewGCGManager()
 	PLUGIN_MANAGER = NewPluginManager()
+	CHAT_FILTER_MANAGER = NewChatFilterManager()
 	RegLuaScriptLibFunc()
 	// 创建本服的Ai世界
 	uid := AiBaseUid + gsId
@@ -90,6 +103,7 @@ func NewGameCore(discoveryClient *rpc.DiscoveryClient, db *dao.Dao, messageQueue
 	COMMAND_MANAGER.SetSystem(r.ai)
 	// 初始化插件 最后再调用以免插件需要访问其他模块导致出错
 	PLUGIN_MANAGER.InitPlugin()
+	r.SyncMainMultiServerAppId()
 	go r.gameMainLoopD()
 	return r
 }
@@ -191,18 +205,21 @@ func (g *Game) gameMainLoop() {
 			}
 			routeCost += end - start
 			routeCount++
+			METRICS_ROUTE_COST.Observe(float64(end-start) / 1e9)
 		case <-TICK_MANAGER.GetGlobalTick().C:
 			// 游戏服务器定时帧
 			start := time.Now().UnixNano()
 			TICK_MANAGER.OnGameServerTick()
 			end := time.Now().UnixNano()
 			tickCost += end - start
+			METRICS_TICK_COST.Observe(float64(end-start) / 1e9)
 		case localEvent := <-LOCAL_EVENT_MANAGER.GetLocalEventChan():
 			// 处理本地事件
 			start := time.Now().UnixNano()
 			LOCAL_EVENT_MANAGER.LocalEventHandle(localEvent)
 			end := time.Now().UnixNano()
 			localEventCost += end - start
+			METRICS_LOCAL_EVENT.Observe(float64(end-start) / 1e9)
 		case command := <-COMMAND_MANAGER.GetCommandMessageInput():
 			// 处理GM命令
 			start := time.Now().UnixNano()
@@ -363,6 +380,131 @@ func (g *Game) ServerDispatchCancelNotify(appVersion string) {
 	g.dispatchCancel = true
 }
 
+func (g *Game) ServerDrainNotify() {
+	if g.isDrain {
+		return
+	}
+	logger.Warn("game server drain begin, online player num: %v", atomic.LoadInt32(&ONLINE_PLAYER_NUM))
+	g.dispatchCancel = true
+	g.isDrain = true
+	g.drainFailCount = 0
+}
+
+const (
+	DrainMigrateMaxFail = 5 // 排空时连续查询不到迁移目标gs的最大次数 超过后停止排空
+)
+
+type DrainMigrateInfo struct {
+	UserId  uint32
+	GsAppId string
+}
+
+// DrainTick 排空期间每秒将一个在线玩家迁移到其它游戏服务器 客户端连接保持不断开
+func (g *Game) DrainTick() {
+	if !g.isDrain || g.drainWait || g.isStop {
+		return
+	}
+	var userId uint32 = 0
+	for _, player := range USER_MANAGER.GetAllOnlineUserList() {
+		if player.PlayerId < PlayerBaseUid {
+			continue
+		}
+		userId = player.PlayerId
+		break
+	}
+	if userId == 0 {
+		logger.Warn("game server drain finish")
+		g.isDrain = false
+		g.ReportServerDrain(api.ServerDrainFinish, "")
+		return
+	}
+	g.drainWait = true
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		gsAppId := ""
+		rsp, err := g.discoveryClient.GetServerAppId(ctx, &api.GetServerAppIdReq{
+			ServerType: api.GS,
+			Uid:        userId,
+		})
+		if err != nil {
+			logger.Error("get drain target gs appid error: %v, uid: %v", err, userId)
+		} else {
+			gsAppId = rsp.AppId
+		}
+		LOCAL_EVENT_MANAGER.GetLocalEventChan() <- &LocalEvent{
+			EventId: DrainMigrateUser,
+			Msg: &DrainMigrateInfo{
+				UserId:  userId,
+				GsAppId: gsAppId,
+			},
+		}
+	}()
+}
+
+// ReportServerDrain 异步上报排空状态到节点服务器 供gm查询排空结果
+func (g *Game) ReportServerDrain(state uint32, msg string) {
+	onlineNum := uint32(atomic.LoadInt32(&ONLINE_PLAYER_NUM))
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		_, err := g.discoveryClient.ReportServerDrain(ctx, &api.ServerDrainState{
+			AppId:     g.gsAppid,
+			State:     state,
+			OnlineNum: onlineNum,
+			Msg:       msg,
+		})
+		if err != nil {
+			logger.Error("report server drain error: %v", err)
+		}
+	}()
+}
+
+// SyncMainMultiServerAppId 异步查询主多功能服务器appid 所有游戏服务器的匹配请求统一发往该服务器
+func (g *Game) SyncMainMultiServerAppId() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+		defer cancel()
+		appid := ""
+		rsp, err := g.discoveryClient.GetMainMultiServerAppId(ctx, &api.NullMsg{})
+		if err != nil {
+			logger.Error("get main multi server appid error: %v", err)
+		} else {
+			appid = rsp.AppId
+		}
+		LOCAL_EVENT_MANAGER.GetLocalEventChan() <- &LocalEvent{
+			EventId: SyncMainMultiAppidFinish,
+			Msg:     appid,
+		}
+	}()
+}
+
+// DrainMigrateUser 排空迁移玩家 走跨服迁移流程由网关转发登录到目标gs
+func (g *Game) DrainMigrateUser(drainMigrateInfo *DrainMigrateInfo) {
+	g.drainWait = false
+	if drainMigrateInfo.GsAppId == "" || drainMigrateInfo.GsAppId == g.gsAppid {
+		g.drainFailCount++
+		logger.Error("no drain migrate target gs, fail count: %v, uid: %v", g.drainFailCount, drainMigrateInfo.UserId)
+		if g.drainFailCount >= DrainMigrateMaxFail {
+			// 没有其它可用的gs 停止排空 保持取消调度 等待重新发起排空
+			logger.Error("game server drain stop, no migration target, online player num: %v", atomic.LoadInt32(&ONLINE_PLAYER_NUM))
+			g.isDrain = false
+			g.ReportServerDrain(api.ServerDrainNoTarget, "no migration target")
+		}
+		return
+	}
+	g.drainFailCount = 0
+	player := USER_MANAGER.GetOnlineUser(drainMigrateInfo.UserId)
+	if player == nil || !player.Online {
+		return
+	}
+	logger.Warn("drain migrate user, uid: %v, target gs appid: %v", drainMigrateInfo.UserId, drainMigrateInfo.GsAppId)
+	g.OnOffline(drainMigrateInfo.UserId, &ChangeGsInfo{
+		IsChangeGs:    true,
+		TargetGsAppId: drainMigrateInfo.GsAppId,
+	})
+}
+
 // SendMsgToGate 发送消息给客户端 指定网关
 func (g *Game) SendMsgToGate(cmdId uint16, userId uint32, clientSeq uint32, gateAppId string, payloadMsg pb.Message) {
 	if userId < PlayerBaseUid {
@@ -543,6 +685,25 @@ func (g *Game) SendToSceneACV(scene *Scene, cmdId uint16, seq uint32, msg pb.Mes
 	}
 }
 
+// SendToScenePos 给场景内某位置附近的玩家发消息 用于服务器主动驱动的实体
+func (g *Game) SendToScenePos(scene *Scene, pos *model.Vector, cmdId uint16, seq uint32, msg pb.Message) {
+	world := scene.GetWorld()
+	if WORLD_MANAGER.IsAiWorld(world) {
+		aiWorldAoi := world.GetAiWorldAoi()
+		otherWorldAvatarMap := aiWorldAoi.GetObjectListByPos(float32(pos.X), float32(pos.Y), float32(pos.Z), 1)
+		for uid := range otherWorldAvatarMap {
+			g.SendMsg(cmdId, uint32(uid), seq, msg)
+		}
+	} else {
+		for _, v := range scene.GetAllPlayer() {
+			if !g.IsInVision(pos, g.GetPlayerPos(v), constant.VISION_LEVEL_NORMAL) {
+				continue
+			}
+			g.SendMsg(cmdId, v.PlayerId, seq, msg)
+		}
+	}
+}
+
 func (g *Game) ReLoginPlayer(userId uint32, isQuitMp bool) {
 	reason := proto.ClientReconnectReason_CLIENT_RECONNNECT_NONE
 	if isQuitMp {
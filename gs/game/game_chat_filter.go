@@ -0,0 +1,167 @@
+package game
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+
+	"hk4e/common/config"
+	"hk4e/gs/model"
+	"hk4e/pkg/logger"
+	"hk4e/pkg/wordfilter"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 聊天过滤
+// 发言频率限制 屏蔽词和正则规则在广播之前处理 被过滤或标记的消息写入审计记录供gm查询
+
+type ChatFilterConfig struct {
+	RateLimitCount  int32             `toml:"rate_limit_count"`  // 时间窗口内最多发言次数 0为不限制
+	RateLimitWindow int32             `toml:"rate_limit_window"` // 频率限制时间窗口 单位秒
+	WordList        []string          `toml:"word_list"`         // 屏蔽词列表
+	WordFile        string            `toml:"word_file"`         // 屏蔽词文件 每行一个屏蔽词
+	RuleList        []*ChatFilterRule `toml:"rule"`              // 正则规则列表
+}
+
+type ChatFilterRule struct {
+	Name    string `toml:"name"`    // 规则名 记录在审计中
+	Pattern string `toml:"pattern"` // 正则表达式
+	Action  string `toml:"action"`  // 命中后的处理方式 mask/block/flag 默认mask
+}
+
+type ChatFilterManager struct {
+	filter          *wordfilter.Filter // 为空则不过滤文本
+	rateLimitCount  int32
+	rateLimitWindow uint32
+}
+
+func NewChatFilterManager() (r *ChatFilterManager) {
+	r = new(ChatFilterManager)
+	err := r.Reload()
+	if err != nil {
+		logger.Error("load chat filter config error: %v", err)
+	}
+	return r
+}
+
+// Reload 重新加载聊天过滤配置 失败时保留原有配置
+func (c *ChatFilterManager) Reload() error {
+	fileName := config.GetConfig().Hk4e.ChatFilterFile
+	if fileName == "" {
+		return nil
+	}
+	filterConfig := new(ChatFilterConfig)
+	_, err := toml.DecodeFile(fileName, filterConfig)
+	if err != nil {
+		return err
+	}
+	filter := wordfilter.NewFilter()
+	for _, word := range filterConfig.WordList {
+		filter.AddWord(word)
+	}
+	if filterConfig.WordFile != "" {
+		err = c.loadWordFile(filter, filterConfig.WordFile)
+		if err != nil {
+			return err
+		}
+	}
+	for _, rule := range filterConfig.RuleList {
+		action := wordfilter.RuleActionMask
+		switch rule.Action {
+		case "", model.ChatAuditActionMask:
+		case model.ChatAuditActionBlock:
+			action = wordfilter.RuleActionBlock
+		case model.ChatAuditActionFlag:
+			action = wordfilter.RuleActionFlag
+		default:
+			return errors.New("unknown chat filter rule action: " + rule.Action)
+		}
+		err = filter.AddRule(rule.Name, rule.Pattern, action)
+		if err != nil {
+			return err
+		}
+	}
+	c.filter = filter
+	c.rateLimitCount = filterConfig.RateLimitCount
+	c.rateLimitWindow = uint32(filterConfig.RateLimitWindow)
+	logger.Info("load chat filter config finish, word num: %v, rule num: %v", len(filterConfig.WordList), len(filterConfig.RuleList))
+	return nil
+}
+
+func (c *ChatFilterManager) loadWordFile(filter *wordfilter.Filter, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		filter.AddWord(word)
+	}
+	return scanner.Err()
+}
+
+// CheckRate 检查玩家发言频率 未超限时记录本次发言 超限时写入审计记录 同一时间窗口内每个玩家最多写入一条
+func (c *ChatFilterManager) CheckRate(player *model.Player, targetUid uint32, now uint32) bool {
+	if c.rateLimitCount <= 0 || c.rateLimitWindow == 0 {
+		return true
+	}
+	timeList := player.ChatTimeList[:0]
+	for _, chatTime := range player.ChatTimeList {
+		if chatTime+c.rateLimitWindow > now {
+			timeList = append(timeList, chatTime)
+		}
+	}
+	player.ChatTimeList = timeList
+	if int32(len(player.ChatTimeList)) >= c.rateLimitCount {
+		if player.ChatLimitAuditTime+c.rateLimitWindow <= now {
+			player.ChatLimitAuditTime = now
+			c.Audit(player, targetUid, "", "", model.ChatAuditActionRateLimit, nil, now)
+		}
+		return false
+	}
+	player.ChatTimeList = append(player.ChatTimeList, now)
+	return true
+}
+
+// FilterText 过滤聊天文本 返回处理后的文本和是否允许发送 被过滤或标记的消息写入审计记录
+func (c *ChatFilterManager) FilterText(player *model.Player, targetUid uint32, text string, now uint32) (string, bool) {
+	if c.filter == nil {
+		return text, true
+	}
+	result := c.filter.Filter(text)
+	if !result.Hit() {
+		return text, true
+	}
+	action := model.ChatAuditActionFlag
+	if result.Block {
+		action = model.ChatAuditActionBlock
+	} else if result.Text != text {
+		action = model.ChatAuditActionMask
+	}
+	c.Audit(player, targetUid, text, result.Text, action, result.HitList, now)
+	return result.Text, !result.Block
+}
+
+// Audit 异步写入聊天审计记录
+func (c *ChatFilterManager) Audit(player *model.Player, targetUid uint32, text string, filterText string, action string, hitList []string, now uint32) {
+	logger.Info("chat audit, uid: %v, targetUid: %v, action: %v, hitList: %v", player.PlayerId, targetUid, action, hitList)
+	chatAudit := &model.ChatAudit{
+		Uid:        player.PlayerId,
+		TargetUid:  targetUid,
+		Text:       text,
+		FilterText: filterText,
+		Action:     action,
+		HitList:    hitList,
+		Time:       now,
+	}
+	go USER_MANAGER.SaveChatAuditToDbSync(chatAudit)
+}
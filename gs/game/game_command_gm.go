@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 
 	"hk4e/common/constant"
+	"hk4e/common/mq"
 	"hk4e/gdconf"
 	"hk4e/gs/model"
 	"hk4e/pkg/logger"
@@ -796,18 +797,64 @@ func (g *GMCmd) StopPubg(v bool) {
 	pluginPubg.StopPubg()
 }
 
+// ReloadPlugin 重新加载插件 lua插件会重新读取脚本文件
+func (g *GMCmd) ReloadPlugin(name string) {
+	iPlugin := PLUGIN_MANAGER.GetPluginByName(name)
+	if iPlugin != nil {
+		PLUGIN_MANAGER.DelPlugin(iPlugin)
+	}
+	iPlugin = PLUGIN_MANAGER.NewPluginByName(name)
+	if iPlugin == nil {
+		return
+	}
+	PLUGIN_MANAGER.RegPlugin(name, iPlugin)
+}
+
+// UnloadPlugin 卸载插件
+func (g *GMCmd) UnloadPlugin(name string) {
+	iPlugin := PLUGIN_MANAGER.GetPluginByName(name)
+	if iPlugin == nil {
+		logger.Error("plugin not exist, name: %v", name)
+		return
+	}
+	PLUGIN_MANAGER.DelPlugin(iPlugin)
+}
+
 func (g *GMCmd) SetPhysicsEngineParam(pathTracing bool) {
 	world := WORLD_MANAGER.GetAiWorld()
-	engine := world.GetBulletPhysicsEngine()
+	engine := world.GetPhysicsEngine()
 	engine.SetPhysicsEngineParam(pathTracing)
 }
 
+// ReloadPhysicsConfig 重新加载物理引擎配置
+func (g *GMCmd) ReloadPhysicsConfig() {
+	err := WORLD_MANAGER.ReloadPhysicsConfig()
+	if err != nil {
+		logger.Error("reload physics config error: %v", err)
+	}
+}
+
 func (g *GMCmd) ShowAvatarCollider(v bool) {
 	world := WORLD_MANAGER.GetAiWorld()
-	engine := world.GetBulletPhysicsEngine()
+	engine := world.GetPhysicsEngine()
 	engine.ShowAvatarCollider()
 }
 
+// SetMonsterAi 开关ai世界的服务器怪物ai
+func (g *GMCmd) SetMonsterAi(enable bool) {
+	aiWorld := WORLD_MANAGER.GetAiWorld()
+	if aiWorld == nil {
+		return
+	}
+	if !enable {
+		aiWorld.DestroyMonsterAi()
+		return
+	}
+	if aiWorld.GetMonsterAi() == nil {
+		aiWorld.NewMonsterAi()
+	}
+}
+
 func (g *GMCmd) AiWorldAoiDebug(v bool) {
 	aiWorld := WORLD_MANAGER.GetAiWorld()
 	if aiWorld == nil {
@@ -853,11 +900,19 @@ func (g *GMCmd) GetPlayerPos(userId uint32) (*model.Vector, *model.Vector) {
 	return GAME.GetPlayerPos(player), player.GetPos()
 }
 
+// SendMail 发送邮件 玩家离线时登录后收到
 func (g *GMCmd) SendMail(userId uint32, title string, content string) {
-	player := USER_MANAGER.GetOnlineUser(userId)
-	if player == nil {
-		logger.Error("player is nil, uid: %v", userId)
-		return
+	GAME.SendPlayerMail(&mq.MailInfo{
+		UserIdList: []uint32{userId},
+		Title:      title,
+		Content:    content,
+	})
+}
+
+// ReloadChatFilter 重新加载聊天过滤配置
+func (g *GMCmd) ReloadChatFilter() {
+	err := CHAT_FILTER_MANAGER.Reload()
+	if err != nil {
+		logger.Error("reload chat filter config error: %v", err)
 	}
-	GAME.AddPlayerMail(userId, title, content)
 }
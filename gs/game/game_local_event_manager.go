@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"hk4e/gdconf"
+	"hk4e/gs/model"
 	"hk4e/pkg/logger"
 )
 
@@ -17,6 +18,9 @@ const (
 	ReloadGameDataConfig              // 执行热更表
 	ReloadGameDataConfigFinish        // 热更表完成
 	AsyncLoadSceneBlockFinish         // 异步加载场景区块存档完成
+	DrainMigrateUser                  // 排空迁移玩家
+	SyncMainMultiAppidFinish          // 查询主多功能服务器appid完成
+	LoadGlobalMailFinish              // 从数据库补齐全服邮件完成
 )
 
 type LocalEvent struct {
@@ -76,5 +80,13 @@ func (l *LocalEventManager) LocalEventHandle(localEvent *LocalEvent) {
 	case AsyncLoadSceneBlockFinish:
 		sceneBlockLoadInfo := localEvent.Msg.(*SceneBlockLoadInfo)
 		GAME.OnSceneBlockLoad(sceneBlockLoadInfo)
+	case DrainMigrateUser:
+		drainMigrateInfo := localEvent.Msg.(*DrainMigrateInfo)
+		GAME.DrainMigrateUser(drainMigrateInfo)
+	case SyncMainMultiAppidFinish:
+		GAME.mainMultiAppid = localEvent.Msg.(string)
+	case LoadGlobalMailFinish:
+		globalMailList := localEvent.Msg.([]*model.GlobalMail)
+		GAME.OnGlobalMailLoad(globalMailList)
 	}
 }
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 游戏服务器监控指标
+
+// 主协程耗时通常在毫秒以下 使用更细的分桶
+var mainLoopBuckets = prometheus.ExponentialBuckets(0.0005, 2, 14)
+
+var (
+	METRICS_ROUTE_MSG_COUNT = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hk4e_gs_route_msg_total",
+		Help: "按协议号统计的路由客户端消息数",
+	}, []string{"cmd_id"})
+	METRICS_ROUTE_COST = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hk4e_gs_main_loop_route_seconds",
+		Help:    "主协程单条消息路由处理耗时",
+		Buckets: mainLoopBuckets,
+	})
+	METRICS_TICK_COST = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hk4e_gs_main_loop_tick_seconds",
+		Help:    "主协程单次定时帧耗时",
+		Buckets: mainLoopBuckets,
+	})
+	METRICS_LOCAL_EVENT = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hk4e_gs_main_loop_local_event_seconds",
+		Help:    "主协程单次本地事件处理耗时",
+		Buckets: mainLoopBuckets,
+	})
+	METRICS_SAVE_COST = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hk4e_gs_save_user_seconds",
+		Help:    "一批玩家数据落地保存耗时",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "hk4e_gs_online_player_num",
+		Help: "当前在线玩家数",
+	}, func() float64 {
+		return float64(atomic.LoadInt32(&ONLINE_PLAYER_NUM))
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "hk4e_gs_save_user_pending",
+		Help: "已提交给保存协程 等待落地的玩家数据批次数",
+	}, func() float64 {
+		if USER_MANAGER == nil {
+			return 0
+		}
+		return float64(len(USER_MANAGER.saveUserChan))
+	})
+}
+
+func metricsRouteMsg(cmdId uint16) {
+	METRICS_ROUTE_MSG_COUNT.WithLabelValues(strconv.Itoa(int(cmdId))).Inc()
+}
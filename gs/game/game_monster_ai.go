@@ -0,0 +1,483 @@
+package game
+
+import (
+	"math"
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gs/model"
+	"hk4e/pkg/alg"
+	"hk4e/pkg/logger"
+	"hk4e/pkg/random"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+)
+
+// 服务器怪物ai
+// 没有客户端房主驱动怪物的世界(如ai世界)由服务器在tick中驱动怪物的行为
+// 状态 待机 -> 巡逻 -> 待机 发现玩家或受击后 追击 -> 攻击 离出生点太远或目标丢失后 返回出生点 -> 待机
+
+const (
+	MonsterAiStateIdle   = iota // 待机
+	MonsterAiStatePatrol        // 巡逻
+	MonsterAiStateChase         // 追击
+	MonsterAiStateAttack        // 攻击
+	MonsterAiStateReturn        // 返回出生点
+)
+
+const (
+	MonsterAiAlertRange      = 10.0                 // 主动发现玩家的距离
+	MonsterAiAttackRange     = 2.5                  // 攻击距离
+	MonsterAiLeashRange      = 30.0                 // 离开出生点超过该距离后放弃追击
+	MonsterAiPatrolRadius    = 8.0                  // 巡逻点离出生点的最大距离
+	MonsterAiWalkSpeed       = 2.0                  // 巡逻移动速度
+	MonsterAiRunSpeed        = 5.0                  // 追击和返回移动速度
+	MonsterAiIdleTimeMin     = 3000                 // 待机最短时间 毫秒
+	MonsterAiIdleTimeMax     = 6000                 // 待机最长时间 毫秒
+	MonsterAiAttackInterval  = 2000                 // 攻击间隔 毫秒
+	MonsterAiRepathInterval  = 1000                 // 追击时重新寻路的最小间隔 毫秒
+	MonsterAiRepathDistance  = 2.0                  // 追击目标离开路径终点超过该距离时重新寻路
+	MonsterAiArriveDistance  = 0.1                  // 到达路径点的判定距离
+	MonsterAiPathTimeBudget  = time.Millisecond * 2 // 单次寻路的时间预算
+	MonsterAiPathExtent      = 3.0                  // 寻路起点终点吸附到网格的搜索范围
+	MonsterAiAttackDamageMul = 0.5                  // 攻击伤害为怪物攻击力的倍数
+	MonsterAiHitThreat       = 1.0                  // 每点伤害增加的仇恨值
+)
+
+// MonsterBrain 单个怪物的ai状态
+type MonsterBrain struct {
+	entityId       uint32
+	state          int
+	bornPos        *alg.Vector3
+	targetUid      uint32             // 当前攻击目标玩家
+	threatMap      map[uint32]float32 // 仇恨值 key:uid value:仇恨值
+	path           []*alg.Vector3     // 当前移动路径 不含起点
+	pathIndex      int
+	lastRepathTime int64
+	nextIdleTime   int64 // 待机结束时间
+	lastAttackTime int64
+}
+
+// MonsterAi 世界的服务器怪物ai管理器
+type MonsterAi struct {
+	world          *World
+	brainMap       map[uint32]*MonsterBrain // key:怪物实体id
+	lastUpdateTime int64
+}
+
+func (w *World) NewMonsterAi() {
+	w.monsterAi = &MonsterAi{
+		world:          w,
+		brainMap:       make(map[uint32]*MonsterBrain),
+		lastUpdateTime: 0,
+	}
+}
+
+func (w *World) GetMonsterAi() *MonsterAi {
+	return w.monsterAi
+}
+
+// DestroyMonsterAi 关闭服务器怪物ai 怪物停留在当前位置
+func (w *World) DestroyMonsterAi() {
+	w.monsterAi = nil
+}
+
+// IsServerAiMonster 是否为服务器ai驱动的怪物
+func (m *MonsterAi) IsServerAiMonster(entityId uint32) bool {
+	_, exist := m.brainMap[entityId]
+	return exist
+}
+
+func (m *MonsterAi) GetBrainNum() int {
+	return len(m.brainMap)
+}
+
+func (m *MonsterAi) RemoveBrain(entityId uint32) {
+	delete(m.brainMap, entityId)
+}
+
+func (m *MonsterAi) newBrain(entity *Entity, now int64) *MonsterBrain {
+	pos := entity.GetPos()
+	brain := &MonsterBrain{
+		entityId:     entity.GetId(),
+		state:        MonsterAiStateIdle,
+		bornPos:      &alg.Vector3{X: float32(pos.X), Y: float32(pos.Y), Z: float32(pos.Z)},
+		threatMap:    make(map[uint32]float32),
+		path:         nil,
+		pathIndex:    0,
+		nextIdleTime: now + int64(random.GetRandomInt32(MonsterAiIdleTimeMin, MonsterAiIdleTimeMax)),
+	}
+	m.brainMap[entity.GetId()] = brain
+	return brain
+}
+
+// fightState 追击和攻击状态下根据目标位置决定下一个状态 targetPos为空表示没有可攻击的目标
+// 追击时离开出生点超过拴绳距离也会返回出生点
+func (b *MonsterBrain) fightState(monsterPos *alg.Vector3, targetPos *alg.Vector3) int {
+	if targetPos == nil {
+		return MonsterAiStateReturn
+	}
+	dist := alg.Vector3Magnitude(alg.Vector3Sub(monsterPos, targetPos))
+	switch b.state {
+	case MonsterAiStateChase:
+		if alg.Vector3Magnitude(alg.Vector3Sub(monsterPos, b.bornPos)) > MonsterAiLeashRange {
+			return MonsterAiStateReturn
+		}
+		if dist <= MonsterAiAttackRange {
+			return MonsterAiStateAttack
+		}
+	case MonsterAiStateAttack:
+		if dist > MonsterAiAttackRange*1.2 {
+			return MonsterAiStateChase
+		}
+	}
+	return b.state
+}
+
+// selectTarget 清除失效玩家的仇恨 选择仇恨值最高的玩家作为攻击目标 没有目标时返回0
+func (b *MonsterBrain) selectTarget(isValid func(uid uint32) bool) uint32 {
+	maxThreat := float32(-1.0)
+	for uid, threat := range b.threatMap {
+		if !isValid(uid) {
+			delete(b.threatMap, uid)
+			continue
+		}
+		if threat > maxThreat {
+			maxThreat = threat
+			b.targetUid = uid
+		}
+	}
+	if len(b.threatMap) == 0 {
+		b.targetUid = 0
+	}
+	return b.targetUid
+}
+
+// MonsterAiTick 驱动世界内全部怪物
+func (g *Game) MonsterAiTick(world *World, now int64) {
+	monsterAi := world.GetMonsterAi()
+	if monsterAi == nil {
+		return
+	}
+	dt := float32(0.0)
+	if monsterAi.lastUpdateTime != 0 {
+		dt = float32(now-monsterAi.lastUpdateTime) / 1000.0
+	}
+	monsterAi.lastUpdateTime = now
+	aliveMap := make(map[uint32]bool)
+	for _, scene := range world.GetAllScene() {
+		if len(scene.GetAllPlayer()) == 0 {
+			continue
+		}
+		for _, entity := range scene.GetAllEntity() {
+			if entity.GetEntityType() != constant.ENTITY_TYPE_MONSTER || entity.GetLifeState() != constant.LIFE_STATE_ALIVE {
+				continue
+			}
+			aliveMap[entity.GetId()] = true
+			brain, exist := monsterAi.brainMap[entity.GetId()]
+			if !exist {
+				brain = monsterAi.newBrain(entity, now)
+			}
+			g.monsterAiUpdate(world, scene, entity, brain, now, dt)
+		}
+	}
+	for entityId := range monsterAi.brainMap {
+		if !aliveMap[entityId] {
+			delete(monsterAi.brainMap, entityId)
+		}
+	}
+}
+
+// MonsterAiBeingHit 怪物受击 增加攻击者的仇恨并开始追击
+func (g *Game) MonsterAiBeingHit(player *model.Player, scene *Scene, entity *Entity, damage float32) {
+	monsterAi := scene.GetWorld().GetMonsterAi()
+	if monsterAi == nil {
+		return
+	}
+	brain, exist := monsterAi.brainMap[entity.GetId()]
+	if !exist {
+		return
+	}
+	if brain.state == MonsterAiStateReturn {
+		return
+	}
+	brain.threatMap[player.PlayerId] += MonsterAiHitThreat * (damage + 1.0)
+	if brain.state != MonsterAiStateChase && brain.state != MonsterAiStateAttack {
+		brain.targetUid = player.PlayerId
+		g.monsterAiChangeState(brain, MonsterAiStateChase)
+	}
+}
+
+func (g *Game) monsterAiChangeState(brain *MonsterBrain, state int) {
+	brain.state = state
+	brain.path = nil
+	brain.pathIndex = 0
+	brain.lastRepathTime = 0
+}
+
+func (g *Game) monsterAiUpdate(world *World, scene *Scene, entity *Entity, brain *MonsterBrain, now int64, dt float32) {
+	pos := entity.GetPos()
+	monsterPos := &alg.Vector3{X: float32(pos.X), Y: float32(pos.Y), Z: float32(pos.Z)}
+	switch brain.state {
+	case MonsterAiStateIdle:
+		if g.monsterAiAlert(world, scene, brain, monsterPos) {
+			return
+		}
+		if now < brain.nextIdleTime {
+			return
+		}
+		// 在出生点附近随机选择巡逻点
+		angle := random.GetRandomFloat64(0.0, 2.0*math.Pi)
+		radius := random.GetRandomFloat64(0.0, MonsterAiPatrolRadius)
+		patrolPos := &alg.Vector3{
+			X: brain.bornPos.X + float32(math.Cos(angle)*radius),
+			Y: brain.bornPos.Y,
+			Z: brain.bornPos.Z + float32(math.Sin(angle)*radius),
+		}
+		g.monsterAiChangeState(brain, MonsterAiStatePatrol)
+		brain.path = g.monsterAiFindPath(scene, monsterPos, patrolPos)
+	case MonsterAiStatePatrol:
+		if g.monsterAiAlert(world, scene, brain, monsterPos) {
+			return
+		}
+		if g.monsterAiMove(scene, entity, brain, monsterPos, MonsterAiWalkSpeed*dt, proto.MotionState_MOTION_WALK) {
+			g.monsterAiIdle(scene, entity, brain, now)
+		}
+	case MonsterAiStateChase:
+		targetEntity := g.monsterAiGetTarget(world, scene, brain)
+		var targetPos *alg.Vector3 = nil
+		if targetEntity != nil {
+			targetPos = g.monsterAiEntityPos(targetEntity)
+		}
+		switch brain.fightState(monsterPos, targetPos) {
+		case MonsterAiStateReturn:
+			g.monsterAiReturn(scene, brain, monsterPos)
+			return
+		case MonsterAiStateAttack:
+			g.monsterAiChangeState(brain, MonsterAiStateAttack)
+			g.monsterAiSyncMove(scene, entity, monsterPos, targetPos, proto.MotionState_MOTION_STANDBY)
+			return
+		}
+		// 目标离开路径终点太远时重新寻路
+		repath := brain.path == nil || brain.pathIndex >= len(brain.path)
+		if !repath && now-brain.lastRepathTime >= MonsterAiRepathInterval {
+			pathEnd := brain.path[len(brain.path)-1]
+			repath = alg.Vector3Magnitude(alg.Vector3Sub(pathEnd, targetPos)) > MonsterAiRepathDistance
+		}
+		if repath {
+			brain.path = g.monsterAiFindPath(scene, monsterPos, targetPos)
+			brain.pathIndex = 0
+			brain.lastRepathTime = now
+		}
+		g.monsterAiMove(scene, entity, brain, monsterPos, MonsterAiRunSpeed*dt, proto.MotionState_MOTION_RUN)
+	case MonsterAiStateAttack:
+		targetEntity := g.monsterAiGetTarget(world, scene, brain)
+		var targetPos *alg.Vector3 = nil
+		if targetEntity != nil {
+			targetPos = g.monsterAiEntityPos(targetEntity)
+		}
+		switch brain.fightState(monsterPos, targetPos) {
+		case MonsterAiStateReturn:
+			g.monsterAiReturn(scene, brain, monsterPos)
+			return
+		case MonsterAiStateChase:
+			g.monsterAiChangeState(brain, MonsterAiStateChase)
+			return
+		}
+		if now-brain.lastAttackTime < MonsterAiAttackInterval {
+			return
+		}
+		brain.lastAttackTime = now
+		damage := entity.GetFightProp()[constant.FIGHT_PROP_CUR_ATTACK] * MonsterAiAttackDamageMul
+		g.SubPlayerAvatarHp(brain.targetUid, targetEntity.GetAvatarEntity().GetAvatarId(), damage, false, proto.ChangHpReason_CHANGE_HP_SUB_MONSTER)
+	case MonsterAiStateReturn:
+		if g.monsterAiMove(scene, entity, brain, monsterPos, MonsterAiRunSpeed*dt, proto.MotionState_MOTION_RUN) {
+			// 回到出生点后回满血
+			fightProp := entity.GetFightProp()
+			if fightProp[constant.FIGHT_PROP_CUR_HP] < fightProp[constant.FIGHT_PROP_MAX_HP] {
+				fightProp[constant.FIGHT_PROP_CUR_HP] = fightProp[constant.FIGHT_PROP_MAX_HP]
+				g.EntityFightPropUpdateNotifyBroadcast(scene, entity)
+			}
+			g.monsterAiIdle(scene, entity, brain, now)
+		}
+	}
+}
+
+func (g *Game) monsterAiIdle(scene *Scene, entity *Entity, brain *MonsterBrain, now int64) {
+	g.monsterAiChangeState(brain, MonsterAiStateIdle)
+	brain.nextIdleTime = now + int64(random.GetRandomInt32(MonsterAiIdleTimeMin, MonsterAiIdleTimeMax))
+	pos := entity.GetPos()
+	monsterPos := &alg.Vector3{X: float32(pos.X), Y: float32(pos.Y), Z: float32(pos.Z)}
+	g.monsterAiSyncMove(scene, entity, monsterPos, nil, proto.MotionState_MOTION_STANDBY)
+}
+
+func (g *Game) monsterAiReturn(scene *Scene, brain *MonsterBrain, monsterPos *alg.Vector3) {
+	g.monsterAiChangeState(brain, MonsterAiStateReturn)
+	brain.targetUid = 0
+	brain.threatMap = make(map[uint32]float32)
+	brain.path = g.monsterAiFindPath(scene, monsterPos, brain.bornPos)
+}
+
+// monsterAiAlert 主动发现附近的玩家 通过aoi查询周围格子的玩家
+func (g *Game) monsterAiAlert(world *World, scene *Scene, brain *MonsterBrain, monsterPos *alg.Vector3) bool {
+	minDist := float32(MonsterAiAlertRange)
+	targetUid := uint32(0)
+	for _, player := range g.monsterAiNearbyPlayerList(world, scene, monsterPos) {
+		entity := g.monsterAiPlayerEntity(world, scene, player)
+		if entity == nil {
+			continue
+		}
+		dist := alg.Vector3Magnitude(alg.Vector3Sub(monsterPos, g.monsterAiEntityPos(entity)))
+		if dist <= minDist {
+			minDist = dist
+			targetUid = player.PlayerId
+		}
+	}
+	if targetUid == 0 {
+		return false
+	}
+	brain.targetUid = targetUid
+	brain.threatMap[targetUid] += MonsterAiHitThreat
+	g.monsterAiChangeState(brain, MonsterAiStateChase)
+	return true
+}
+
+func (g *Game) monsterAiNearbyPlayerList(world *World, scene *Scene, pos *alg.Vector3) []*model.Player {
+	playerList := make([]*model.Player, 0)
+	aiWorldAoi := world.GetAiWorldAoi()
+	if aiWorldAoi == nil {
+		for _, player := range scene.GetAllPlayer() {
+			playerList = append(playerList, player)
+		}
+		return playerList
+	}
+	for uid := range aiWorldAoi.GetObjectListByPos(pos.X, pos.Y, pos.Z, 1) {
+		player := scene.GetAllPlayer()[uint32(uid)]
+		if player == nil {
+			continue
+		}
+		playerList = append(playerList, player)
+	}
+	return playerList
+}
+
+// monsterAiPlayerEntity 获取可作为攻击目标的玩家角色实体
+func (g *Game) monsterAiPlayerEntity(world *World, scene *Scene, player *model.Player) *Entity {
+	if player.SceneLoadState != model.SceneEnterDone || player.WuDi {
+		return nil
+	}
+	if WORLD_MANAGER.IsAiWorld(world) && player.PlayerId == world.GetOwner().PlayerId {
+		// ai世界的房主不是真实玩家
+		return nil
+	}
+	if player.GetSceneId() != scene.GetId() {
+		return nil
+	}
+	entity := world.GetPlayerActiveAvatarEntity(player)
+	if entity == nil || entity.GetLifeState() != constant.LIFE_STATE_ALIVE {
+		return nil
+	}
+	return entity
+}
+
+// monsterAiGetTarget 获取攻击目标 当前目标失效时切换到仇恨值最高的玩家
+func (g *Game) monsterAiGetTarget(world *World, scene *Scene, brain *MonsterBrain) *Entity {
+	targetUid := brain.selectTarget(func(uid uint32) bool {
+		player := scene.GetAllPlayer()[uid]
+		return player != nil && g.monsterAiPlayerEntity(world, scene, player) != nil
+	})
+	if targetUid == 0 {
+		return nil
+	}
+	return g.monsterAiPlayerEntity(world, scene, scene.GetAllPlayer()[targetUid])
+}
+
+func (g *Game) monsterAiEntityPos(entity *Entity) *alg.Vector3 {
+	pos := entity.GetPos()
+	return &alg.Vector3{X: float32(pos.X), Y: float32(pos.Y), Z: float32(pos.Z)}
+}
+
+// monsterAiFindPath 在场景导航网格上寻路 没有导航网格或寻路失败时直线移动
+func (g *Game) monsterAiFindPath(scene *Scene, startPos *alg.Vector3, endPos *alg.Vector3) []*alg.Vector3 {
+	navMesh := WORLD_MANAGER.GetSceneNavMesh(scene.GetId())
+	if navMesh == nil {
+		return []*alg.Vector3{endPos}
+	}
+	status, path := navMesh.FindPath(startPos, endPos, MonsterAiPathExtent, nil, MonsterAiPathTimeBudget)
+	if status == alg.NavPathFail || len(path) < 2 {
+		logger.Debug("monster ai find path fail, sceneId: %v, start: %v, end: %v", scene.GetId(), startPos, endPos)
+		return []*alg.Vector3{endPos}
+	}
+	// 去掉起点
+	return path[1:]
+}
+
+// monsterAiMove 沿路径移动指定距离 到达路径终点时返回true
+func (g *Game) monsterAiMove(scene *Scene, entity *Entity, brain *MonsterBrain, monsterPos *alg.Vector3, distance float32, state proto.MotionState) bool {
+	if brain.pathIndex >= len(brain.path) {
+		return true
+	}
+	pos := &alg.Vector3{X: monsterPos.X, Y: monsterPos.Y, Z: monsterPos.Z}
+	for distance > 0.0 && brain.pathIndex < len(brain.path) {
+		next := brain.path[brain.pathIndex]
+		dir := alg.Vector3Sub(next, pos)
+		dist := alg.Vector3Magnitude(dir)
+		if dist <= distance || dist <= MonsterAiArriveDistance {
+			pos.X, pos.Y, pos.Z = next.X, next.Y, next.Z
+			distance -= dist
+			brain.pathIndex++
+			continue
+		}
+		rate := distance / dist
+		pos.X += dir.X * rate
+		pos.Y += dir.Y * rate
+		pos.Z += dir.Z * rate
+		distance = 0.0
+	}
+	var facePos *alg.Vector3 = nil
+	if brain.pathIndex < len(brain.path) {
+		facePos = brain.path[brain.pathIndex]
+	} else {
+		state = proto.MotionState_MOTION_STANDBY
+	}
+	g.monsterAiSyncMove(scene, entity, pos, facePos, state)
+	return brain.pathIndex >= len(brain.path)
+}
+
+// monsterAiSyncMove 更新怪物位置朝向并广播给附近的玩家
+func (g *Game) monsterAiSyncMove(scene *Scene, entity *Entity, pos *alg.Vector3, facePos *alg.Vector3, state proto.MotionState) {
+	rot := entity.GetRot()
+	if facePos != nil {
+		dx := float64(facePos.X - pos.X)
+		dz := float64(facePos.Z - pos.Z)
+		if dx != 0.0 || dz != 0.0 {
+			rot.Y = math.Atan2(dx, dz) / math.Pi * 180.0
+			if rot.Y < 0.0 {
+				rot.Y += 360.0
+			}
+		}
+	}
+	oldPos := entity.GetPos()
+	if oldPos.X == float64(pos.X) && oldPos.Y == float64(pos.Y) && oldPos.Z == float64(pos.Z) &&
+		entity.GetMoveState() == uint16(state) && entity.GetRot().Y == rot.Y {
+		return
+	}
+	entity.SetPos(&model.Vector{X: float64(pos.X), Y: float64(pos.Y), Z: float64(pos.Z)})
+	entity.SetRot(rot)
+	entity.SetMoveState(uint16(state))
+	entity.SetLastMoveReliableSeq(entity.GetLastMoveReliableSeq() + 1)
+	sceneTime := uint32(scene.GetSceneTime())
+	entity.SetLastMoveSceneTimeMs(sceneTime)
+	ntf := &proto.SceneEntityMoveNotify{
+		EntityId: entity.GetId(),
+		MotionInfo: &proto.MotionInfo{
+			Pos:   &proto.Vector{X: pos.X, Y: pos.Y, Z: pos.Z},
+			Rot:   &proto.Vector{X: float32(rot.X), Y: float32(rot.Y), Z: float32(rot.Z)},
+			Speed: new(proto.Vector),
+			State: state,
+		},
+		SceneTime:   sceneTime,
+		ReliableSeq: entity.GetLastMoveReliableSeq(),
+	}
+	g.SendToScenePos(scene, entity.GetPos(), cmd.SceneEntityMoveNotify, 0, ntf)
+}
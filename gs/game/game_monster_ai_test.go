@@ -0,0 +1,114 @@
+package game
+
+import (
+	"testing"
+
+	"hk4e/gs/model"
+	"hk4e/pkg/alg"
+)
+
+func newTestMonsterBrain(state int) *MonsterBrain {
+	return &MonsterBrain{
+		entityId:  1,
+		state:     state,
+		bornPos:   &alg.Vector3{X: 0, Y: 0, Z: 0},
+		threatMap: make(map[uint32]float32),
+	}
+}
+
+func TestMonsterAiFightState(t *testing.T) {
+	brain := newTestMonsterBrain(MonsterAiStateChase)
+	monsterPos := &alg.Vector3{X: 10, Y: 0, Z: 0}
+	if state := brain.fightState(monsterPos, &alg.Vector3{X: 20, Y: 0, Z: 0}); state != MonsterAiStateChase {
+		t.Fatalf("target out of attack range should keep chase, state: %v", state)
+	}
+	if state := brain.fightState(monsterPos, &alg.Vector3{X: 12, Y: 0, Z: 0}); state != MonsterAiStateAttack {
+		t.Fatalf("target in attack range should attack, state: %v", state)
+	}
+	if state := brain.fightState(monsterPos, nil); state != MonsterAiStateReturn {
+		t.Fatalf("lost target should return, state: %v", state)
+	}
+	// 超出拴绳距离 即使目标就在身边也返回出生点
+	leashPos := &alg.Vector3{X: MonsterAiLeashRange + 1, Y: 0, Z: 0}
+	if state := brain.fightState(leashPos, leashPos); state != MonsterAiStateReturn {
+		t.Fatalf("out of leash range should return, state: %v", state)
+	}
+
+	brain = newTestMonsterBrain(MonsterAiStateAttack)
+	// 攻击状态有一定的距离缓冲 避免在追击和攻击之间反复切换
+	if state := brain.fightState(monsterPos, &alg.Vector3{X: 10 + MonsterAiAttackRange*1.1, Y: 0, Z: 0}); state != MonsterAiStateAttack {
+		t.Fatalf("target in attack buffer should keep attack, state: %v", state)
+	}
+	if state := brain.fightState(monsterPos, &alg.Vector3{X: 20, Y: 0, Z: 0}); state != MonsterAiStateChase {
+		t.Fatalf("target out of attack range should chase, state: %v", state)
+	}
+	if state := brain.fightState(monsterPos, nil); state != MonsterAiStateReturn {
+		t.Fatalf("lost target should return, state: %v", state)
+	}
+}
+
+func TestMonsterAiSelectTarget(t *testing.T) {
+	brain := newTestMonsterBrain(MonsterAiStateChase)
+	brain.threatMap[100] = 5.0
+	brain.threatMap[200] = 10.0
+	brain.threatMap[300] = 20.0
+	validMap := map[uint32]bool{100: true, 200: true}
+	isValid := func(uid uint32) bool {
+		return validMap[uid]
+	}
+	if uid := brain.selectTarget(isValid); uid != 200 {
+		t.Fatalf("should select max threat valid target, uid: %v", uid)
+	}
+	if _, exist := brain.threatMap[300]; exist {
+		t.Fatalf("invalid target threat should be removed")
+	}
+	delete(validMap, 200)
+	if uid := brain.selectTarget(isValid); uid != 100 {
+		t.Fatalf("should switch target after current target invalid, uid: %v", uid)
+	}
+	delete(validMap, 100)
+	if uid := brain.selectTarget(isValid); uid != 0 || brain.targetUid != 0 {
+		t.Fatalf("no valid target should return 0, uid: %v", uid)
+	}
+}
+
+func TestMonsterAiBeingHit(t *testing.T) {
+	world := new(World)
+	world.NewMonsterAi()
+	scene := &Scene{id: 3, world: world}
+	entity := &Entity{id: 1}
+	brain := newTestMonsterBrain(MonsterAiStateIdle)
+	world.GetMonsterAi().brainMap[entity.GetId()] = brain
+	g := new(Game)
+
+	g.MonsterAiBeingHit(&model.Player{PlayerId: 100}, scene, entity, 10.0)
+	if brain.state != MonsterAiStateChase || brain.targetUid != 100 {
+		t.Fatalf("idle monster being hit should chase attacker, state: %v, target: %v", brain.state, brain.targetUid)
+	}
+	// 追击中被其他玩家攻击只增加仇恨 不立即切换目标
+	g.MonsterAiBeingHit(&model.Player{PlayerId: 200}, scene, entity, 100.0)
+	if brain.targetUid != 100 || brain.threatMap[200] <= brain.threatMap[100] {
+		t.Fatalf("threat error, target: %v, threat: %v", brain.targetUid, brain.threatMap)
+	}
+	if uid := brain.selectTarget(func(uint32) bool { return true }); uid != 200 {
+		t.Fatalf("should select max threat target, uid: %v", uid)
+	}
+
+	// 返回出生点时清空仇恨 且不响应受击
+	worldManager := WORLD_MANAGER
+	WORLD_MANAGER = &WorldManager{sceneNavMeshMap: make(map[uint32]*alg.NavMesh)}
+	defer func() {
+		WORLD_MANAGER = worldManager
+	}()
+	g.monsterAiReturn(scene, brain, &alg.Vector3{X: 10, Y: 0, Z: 0})
+	if brain.state != MonsterAiStateReturn || brain.targetUid != 0 || len(brain.threatMap) != 0 {
+		t.Fatalf("return state error, state: %v, target: %v, threat: %v", brain.state, brain.targetUid, brain.threatMap)
+	}
+	if len(brain.path) != 1 || *brain.path[0] != *brain.bornPos {
+		t.Fatalf("return path should end at born pos, path: %v", brain.path)
+	}
+	g.MonsterAiBeingHit(&model.Player{PlayerId: 100}, scene, entity, 10.0)
+	if brain.state != MonsterAiStateReturn || len(brain.threatMap) != 0 {
+		t.Fatalf("returning monster should ignore hit, state: %v", brain.state)
+	}
+}
@@ -1,13 +1,22 @@
 package game
 
 import (
+	"errors"
 	"math"
 
+	"hk4e/common/config"
+	"hk4e/gdconf"
 	"hk4e/gs/model"
 	"hk4e/pkg/alg"
 	"hk4e/pkg/logger"
+
+	"github.com/BurntSushi/toml"
 )
 
+// 物理引擎
+// 按子弹物件id配置抛射物参数 以固定步长模拟运动 与角色 物件 场景静态碰撞体做扫掠检测
+// 命中后销毁刚体并触发插件事件 同时提供射线检测和球体扫掠检测接口
+
 const (
 	AVATAR_RADIUS      = 0.5
 	AVATAR_HEIGHT      = 2.0
@@ -18,40 +27,232 @@ const (
 	INIT_SPEED         = 50.0
 )
 
+const (
+	PhysicsDefaultFixedStep   = 20    // 默认固定步长 单位毫秒
+	PhysicsDefaultMaxStep     = 10    // 默认单次更新最多模拟的步数
+	PhysicsDefaultMaxLifeTime = 10000 // 默认子弹最大存活时间 单位毫秒
+)
+
+// 命中类型
+const (
+	PhysicsHitNone = iota
+	PhysicsHitAvatar
+	PhysicsHitGadget
+	PhysicsHitStatic
+)
+
+// 碰撞掩码
+const (
+	PhysicsMaskAvatar = 1 << PhysicsHitAvatar
+	PhysicsMaskGadget = 1 << PhysicsHitGadget
+	PhysicsMaskStatic = 1 << PhysicsHitStatic
+	PhysicsMaskAll    = PhysicsMaskAvatar | PhysicsMaskGadget | PhysicsMaskStatic
+)
+
+var physicsHitMaskMap = map[string]int{
+	"avatar": PhysicsMaskAvatar,
+	"gadget": PhysicsMaskGadget,
+	"static": PhysicsMaskStatic,
+}
+
+var physicsShapeMap = map[string]int{
+	"sphere":  alg.ColliderSphere,
+	"box":     alg.ColliderBox,
+	"capsule": alg.ColliderCapsule,
+}
+
+type PhysicsConfig struct {
+	FixedStep          int32                   `toml:"fixed_step"`          // 固定步长 单位毫秒
+	MaxStepPerUpdate   int32                   `toml:"max_step_per_update"` // 单次更新最多模拟的步数 落后过多时丢弃剩余时间
+	ProjectileList     []*ProjectileConfig     `toml:"projectile"`          // 抛射物列表
+	StaticColliderList []*StaticColliderConfig `toml:"static_collider"`     // 场景静态碰撞体列表
+	GadgetColliderList []*GadgetColliderConfig `toml:"gadget_collider"`     // 物件碰撞体列表
+	projectileMap      map[uint32]*ProjectileConfig
+	prefabMap          map[string]*ProjectileConfig
+	staticColliderMap  map[uint32][]*alg.Collider
+	gadgetColliderMap  map[uint32]*GadgetColliderConfig
+}
+
+type ProjectileConfig struct {
+	GadgetId         uint32   `toml:"gadget_id"`          // 子弹物件id
+	PrefabPath       string   `toml:"prefab_path"`        // 子弹物件预制体路径 物件id为0时按预制体匹配
+	InitSpeed        float32  `toml:"init_speed"`         // 初始速度
+	Gravity          float32  `toml:"gravity"`            // 重力加速度 向下为负
+	Drag             float32  `toml:"drag"`               // 阻力参数
+	PitchAngleOffset float32  `toml:"pitch_angle_offset"` // 俯仰角偏移 单位角度
+	Radius           float32  `toml:"radius"`             // 子弹半径 为0时按射线检测
+	MaxLifeTime      int32    `toml:"max_life_time"`      // 最大存活时间 单位毫秒 为0则使用默认值
+	HitMask          []string `toml:"hit_mask"`           // 可命中的碰撞类型 avatar/gadget/static 为空则全部可命中
+	hitMask          int
+}
+
+type StaticColliderConfig struct {
+	SceneId     uint32     `toml:"scene_id"`     // 场景id
+	Shape       string     `toml:"shape"`        // 形状 box/sphere/capsule
+	Center      [3]float32 `toml:"center"`       // 中心点
+	HalfExtents [3]float32 `toml:"half_extents"` // 半尺寸 球体和胶囊体的半径为第一个分量 胶囊体的半高为第二个分量
+	Yaw         float32    `toml:"yaw"`          // 绕Y轴的旋转角度 单位角度
+}
+
+type GadgetColliderConfig struct {
+	GadgetId    uint32     `toml:"gadget_id"`    // 物件id
+	Shape       string     `toml:"shape"`        // 形状 box/sphere/capsule
+	HalfExtents [3]float32 `toml:"half_extents"` // 半尺寸
+	YOffset     float32    `toml:"y_offset"`     // 碰撞体中心相对物件坐标的高度偏移
+	shape       int
+}
+
+// NewDefaultPhysicsConfig 未配置时的默认参数 只模拟蓄力箭
+func NewDefaultPhysicsConfig() *PhysicsConfig {
+	physicsConfig := new(PhysicsConfig)
+	for _, prefabPath := range []string{"ART/Others/Bullet/Bullet_ArrowAiming", "ART/Others/Bullet/Bullet_Venti_ArrowAiming"} {
+		physicsConfig.ProjectileList = append(physicsConfig.ProjectileList, &ProjectileConfig{
+			PrefabPath:       prefabPath,
+			InitSpeed:        INIT_SPEED,
+			Gravity:          ACC,
+			Drag:             DRAG,
+			PitchAngleOffset: PITCH_ANGLE_OFFSET,
+		})
+	}
+	_ = physicsConfig.init()
+	return physicsConfig
+}
+
+// LoadPhysicsConfig 加载物理引擎配置 未配置文件时使用默认参数
+func LoadPhysicsConfig() (*PhysicsConfig, error) {
+	fileName := config.GetConfig().Hk4e.PhysicsFile
+	if fileName == "" {
+		return NewDefaultPhysicsConfig(), nil
+	}
+	physicsConfig := new(PhysicsConfig)
+	_, err := toml.DecodeFile(fileName, physicsConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = physicsConfig.init()
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("load physics config finish, projectile num: %v, static collider num: %v, gadget collider num: %v",
+		len(physicsConfig.ProjectileList), len(physicsConfig.StaticColliderList), len(physicsConfig.GadgetColliderList))
+	return physicsConfig, nil
+}
+
+func (c *PhysicsConfig) init() error {
+	if c.FixedStep <= 0 {
+		c.FixedStep = PhysicsDefaultFixedStep
+	}
+	if c.MaxStepPerUpdate <= 0 {
+		c.MaxStepPerUpdate = PhysicsDefaultMaxStep
+	}
+	c.projectileMap = make(map[uint32]*ProjectileConfig)
+	c.prefabMap = make(map[string]*ProjectileConfig)
+	for _, projectile := range c.ProjectileList {
+		if projectile.MaxLifeTime <= 0 {
+			projectile.MaxLifeTime = PhysicsDefaultMaxLifeTime
+		}
+		projectile.hitMask = 0
+		for _, name := range projectile.HitMask {
+			mask, exist := physicsHitMaskMap[name]
+			if !exist {
+				return errors.New("unknown projectile hit mask: " + name)
+			}
+			projectile.hitMask |= mask
+		}
+		if projectile.hitMask == 0 {
+			projectile.hitMask = PhysicsMaskAll
+		}
+		if projectile.GadgetId != 0 {
+			c.projectileMap[projectile.GadgetId] = projectile
+		} else if projectile.PrefabPath != "" {
+			c.prefabMap[projectile.PrefabPath] = projectile
+		} else {
+			return errors.New("projectile gadget id and prefab path are both empty")
+		}
+	}
+	c.staticColliderMap = make(map[uint32][]*alg.Collider)
+	for _, staticCollider := range c.StaticColliderList {
+		shape, exist := physicsShapeMap[staticCollider.Shape]
+		if !exist {
+			return errors.New("unknown static collider shape: " + staticCollider.Shape)
+		}
+		c.staticColliderMap[staticCollider.SceneId] = append(c.staticColliderMap[staticCollider.SceneId], &alg.Collider{
+			Shape:       shape,
+			Center:      alg.Vector3{X: staticCollider.Center[0], Y: staticCollider.Center[1], Z: staticCollider.Center[2]},
+			HalfExtents: alg.Vector3{X: staticCollider.HalfExtents[0], Y: staticCollider.HalfExtents[1], Z: staticCollider.HalfExtents[2]},
+			Yaw:         staticCollider.Yaw / 180.0 * math.Pi,
+		})
+	}
+	c.gadgetColliderMap = make(map[uint32]*GadgetColliderConfig)
+	for _, gadgetCollider := range c.GadgetColliderList {
+		shape, exist := physicsShapeMap[gadgetCollider.Shape]
+		if !exist {
+			return errors.New("unknown gadget collider shape: " + gadgetCollider.Shape)
+		}
+		gadgetCollider.shape = shape
+		c.gadgetColliderMap[gadgetCollider.GadgetId] = gadgetCollider
+	}
+	return nil
+}
+
+// GetProjectileConfig 获取子弹物件的抛射物配置 优先按物件id匹配 其次按预制体路径匹配
+func (c *PhysicsConfig) GetProjectileConfig(gadgetId uint32) *ProjectileConfig {
+	projectile, exist := c.projectileMap[gadgetId]
+	if exist {
+		return projectile
+	}
+	if len(c.prefabMap) == 0 {
+		return nil
+	}
+	gadgetDataConfig := gdconf.GetGadgetDataById(int32(gadgetId))
+	if gadgetDataConfig == nil {
+		return nil
+	}
+	return c.prefabMap[gadgetDataConfig.PrefabPath]
+}
+
+// PhysicsHit 碰撞检测结果
+type PhysicsHit struct {
+	HitType  int          // 命中类型
+	EntityId uint32       // 命中的实体id 静态碰撞体为0
+	Pos      *alg.Vector3 // 命中点
+	Distance float32      // 起点到命中点的距离
+}
+
 // RigidBody 刚体
 type RigidBody struct {
-	entityId          uint32       // 子弹实体id
-	avatarEntityId    uint32       // 子弹发射者角色实体id
-	hitAvatarEntityId uint32       // 子弹命中的角色实体id
-	sceneId           uint32       // 子弹所在场景id
-	position          *alg.Vector3 // 坐标
-	velocity          *alg.Vector3 // 速度
+	entityId       uint32            // 子弹实体id
+	gadgetId       uint32            // 子弹物件id
+	avatarEntityId uint32            // 子弹发射者角色实体id
+	ownerUid       uint32            // 子弹发射者uid
+	sceneId        uint32            // 子弹所在场景id
+	position       *alg.Vector3      // 坐标
+	velocity       *alg.Vector3      // 速度
+	lifeTime       int32             // 已存活时间 单位毫秒
+	projectile     *ProjectileConfig // 抛射物配置
+	hit            *PhysicsHit       // 命中结果
 }
 
 // PhysicsEngine 物理引擎
 type PhysicsEngine struct {
-	rigidBodyMap     map[uint32]*RigidBody // 刚体集合
-	pathTracing      bool                  // 子弹路径追踪调试
-	acc              float32               // 重力加速度
-	drag             float32               // 阻力参数
-	pitchAngleOffset float32               // 子弹俯仰角偏移
-	initSpeed        float32               // 子弹初始速度
-	avatarYOffset    float32               // 角色中心点位置高度偏移
-	lastUpdateTime   int64                 // 上一次更新时间
-	world            *World                // 世界对象
-}
-
-func (w *World) NewPhysicsEngine() {
-	w.bulletPhysicsEngine = &PhysicsEngine{
-		rigidBodyMap:     make(map[uint32]*RigidBody),
-		pathTracing:      false,
-		acc:              ACC,
-		drag:             DRAG,
-		pitchAngleOffset: PITCH_ANGLE_OFFSET,
-		initSpeed:        INIT_SPEED,
-		avatarYOffset:    AVATAR_Y_OFFSET,
-		lastUpdateTime:   0,
-		world:            w,
+	rigidBodyMap   map[uint32]*RigidBody // 刚体集合
+	pathTracing    bool                  // 子弹路径追踪调试
+	avatarYOffset  float32               // 角色中心点位置高度偏移
+	lastUpdateTime int64                 // 上一次更新时间
+	accumulateTime int64                 // 尚未模拟的累计时间 单位毫秒
+	physicsConfig  *PhysicsConfig        // 物理引擎配置
+	world          *World                // 世界对象
+}
+
+func (w *World) NewPhysicsEngine(physicsConfig *PhysicsConfig) {
+	w.physicsEngine = &PhysicsEngine{
+		rigidBodyMap:   make(map[uint32]*RigidBody),
+		pathTracing:    false,
+		avatarYOffset:  AVATAR_Y_OFFSET,
+		lastUpdateTime: 0,
+		accumulateTime: 0,
+		physicsConfig:  physicsConfig,
+		world:          w,
 	}
 }
 
@@ -59,6 +260,14 @@ func (p *PhysicsEngine) SetPhysicsEngineParam(pathTracing bool) {
 	p.pathTracing = pathTracing
 }
 
+func (p *PhysicsEngine) SetPhysicsConfig(physicsConfig *PhysicsConfig) {
+	p.physicsConfig = physicsConfig
+}
+
+func (p *PhysicsEngine) GetPhysicsConfig() *PhysicsConfig {
+	return p.physicsConfig
+}
+
 func (p *PhysicsEngine) ShowAvatarCollider() {
 	for _, scene := range p.world.GetAllScene() {
 		for _, player := range scene.GetAllPlayer() {
@@ -76,119 +285,203 @@ func (p *PhysicsEngine) ShowAvatarCollider() {
 	}
 }
 
+// Update 按固定步长推进模拟 与调用间隔无关 返回本次更新中命中的刚体
 func (p *PhysicsEngine) Update(now int64) []*RigidBody {
 	hitList := make([]*RigidBody, 0)
-	dt := float32(now-p.lastUpdateTime) / 1000.0
+	if p.lastUpdateTime == 0 || len(p.rigidBodyMap) == 0 {
+		p.lastUpdateTime = now
+		p.accumulateTime = 0
+		return hitList
+	}
+	fixedStep := int64(p.physicsConfig.FixedStep)
+	p.accumulateTime += now - p.lastUpdateTime
+	p.lastUpdateTime = now
+	stepNum := p.accumulateTime / fixedStep
+	if stepNum > int64(p.physicsConfig.MaxStepPerUpdate) {
+		logger.Debug("physics engine step num exceed limit, step num: %v, worldId: %v", stepNum, p.world.GetId())
+		stepNum = int64(p.physicsConfig.MaxStepPerUpdate)
+		p.accumulateTime = 0
+	} else {
+		p.accumulateTime -= stepNum * fixedStep
+	}
+	if stepNum == 0 {
+		return hitList
+	}
+	// 物件在一次更新内视为静止 每个场景只收集一次
+	gadgetColliderMap := make(map[uint32]map[uint32]*alg.Collider)
 	for _, rigidBody := range p.rigidBodyMap {
-		if !p.world.IsValidAiWorldPos(rigidBody.sceneId, rigidBody.position.X, rigidBody.position.Y, rigidBody.position.Z) {
-			p.DestroyRigidBody(rigidBody.entityId)
-			continue
+		_, exist := gadgetColliderMap[rigidBody.sceneId]
+		if !exist {
+			gadgetColliderMap[rigidBody.sceneId] = p.collectGadgetCollider(rigidBody.sceneId)
 		}
-		// 阻力作用于速度
-		dvx := p.drag * rigidBody.velocity.X * dt
-		if math.Abs(float64(dvx)) >= math.Abs(float64(rigidBody.velocity.X)) {
-			rigidBody.velocity.X = 0.0
-		} else {
-			rigidBody.velocity.X -= dvx
-		}
-		dvy := p.drag * rigidBody.velocity.Y * dt
-		if math.Abs(float64(dvy)) >= math.Abs(float64(rigidBody.velocity.Y)) {
-			rigidBody.velocity.Y = 0.0
-		} else {
-			rigidBody.velocity.Y -= dvy
+	}
+	for i := int64(0); i < stepNum; i++ {
+		for _, rigidBody := range p.rigidBodyMap {
+			if p.step(rigidBody, int32(fixedStep), gadgetColliderMap[rigidBody.sceneId]) {
+				hitList = append(hitList, rigidBody)
+			}
 		}
-		dvz := p.drag * rigidBody.velocity.Z * dt
-		if math.Abs(float64(dvz)) >= math.Abs(float64(rigidBody.velocity.Z)) {
-			rigidBody.velocity.Z = 0.0
-		} else {
-			rigidBody.velocity.Z -= dvz
-		}
-		// 重力作用于速度
-		rigidBody.velocity.Y += p.acc * dt
-		// 速度作用于位移
-		oldPos := &alg.Vector3{X: rigidBody.position.X, Y: rigidBody.position.Y, Z: rigidBody.position.Z}
-		rigidBody.position.X += rigidBody.velocity.X * dt
-		rigidBody.position.Y += rigidBody.velocity.Y * dt
-		rigidBody.position.Z += rigidBody.velocity.Z * dt
-		newPos := &alg.Vector3{X: rigidBody.position.X, Y: rigidBody.position.Y, Z: rigidBody.position.Z}
-		// 碰撞检测
-		hitAvatarEntityId := p.Collision(rigidBody.sceneId, rigidBody.avatarEntityId, oldPos, newPos)
-		if hitAvatarEntityId != 0 {
-			rigidBody.hitAvatarEntityId = hitAvatarEntityId
-			hitList = append(hitList, rigidBody)
+	}
+	return hitList
+}
+
+// step 单步模拟 刚体命中或失效时销毁 返回是否命中
+func (p *PhysicsEngine) step(rigidBody *RigidBody, stepTime int32, gadgetColliderMap map[uint32]*alg.Collider) bool {
+	projectile := rigidBody.projectile
+	rigidBody.lifeTime += stepTime
+	if rigidBody.lifeTime > projectile.MaxLifeTime {
+		p.DestroyRigidBody(rigidBody.entityId)
+		return false
+	}
+	if p.world.aiWorldAoi != nil && !p.world.IsValidAiWorldPos(rigidBody.sceneId, rigidBody.position.X, rigidBody.position.Y, rigidBody.position.Z) {
+		p.DestroyRigidBody(rigidBody.entityId)
+		return false
+	}
+	dt := float32(stepTime) / 1000.0
+	// 阻力作用于速度
+	dvx := projectile.Drag * rigidBody.velocity.X * dt
+	if math.Abs(float64(dvx)) >= math.Abs(float64(rigidBody.velocity.X)) {
+		rigidBody.velocity.X = 0.0
+	} else {
+		rigidBody.velocity.X -= dvx
+	}
+	dvy := projectile.Drag * rigidBody.velocity.Y * dt
+	if math.Abs(float64(dvy)) >= math.Abs(float64(rigidBody.velocity.Y)) {
+		rigidBody.velocity.Y = 0.0
+	} else {
+		rigidBody.velocity.Y -= dvy
+	}
+	dvz := projectile.Drag * rigidBody.velocity.Z * dt
+	if math.Abs(float64(dvz)) >= math.Abs(float64(rigidBody.velocity.Z)) {
+		rigidBody.velocity.Z = 0.0
+	} else {
+		rigidBody.velocity.Z -= dvz
+	}
+	// 重力作用于速度
+	rigidBody.velocity.Y += projectile.Gravity * dt
+	// 速度作用于位移 对本步的位移做扫掠检测 避免高速子弹穿过薄墙
+	move := &alg.Vector3{X: rigidBody.velocity.X * dt, Y: rigidBody.velocity.Y * dt, Z: rigidBody.velocity.Z * dt}
+	dist := alg.Vector3Magnitude(move)
+	if dist > 0.0 {
+		dir := &alg.Vector3{X: move.X / dist, Y: move.Y / dist, Z: move.Z / dist}
+		hit := p.sweep(rigidBody.sceneId, rigidBody.position, dir, dist, projectile.Radius, projectile.hitMask,
+			[]uint32{rigidBody.entityId, rigidBody.avatarEntityId}, gadgetColliderMap)
+		if hit != nil {
+			rigidBody.position = hit.Pos
+			rigidBody.hit = hit
 			p.DestroyRigidBody(rigidBody.entityId)
-		}
-		if p.pathTracing {
-			logger.Debug("[PhysicsEngineUpdate] e: %v, s: %v, p: %v, v: %v", rigidBody.entityId, rigidBody.sceneId, rigidBody.position, rigidBody.velocity)
-			GAME.CreateGadget(p.world.GetOwner(), &model.Vector{
-				X: float64(rigidBody.position.X),
-				Y: float64(rigidBody.position.Y),
-				Z: float64(rigidBody.position.Z),
-			}, GADGET_RED, nil)
+			return true
 		}
 	}
-	p.lastUpdateTime = now
-	return hitList
+	rigidBody.position = alg.Vector3Add(rigidBody.position, move)
+	if p.pathTracing {
+		logger.Debug("[PhysicsEngineUpdate] e: %v, s: %v, p: %v, v: %v", rigidBody.entityId, rigidBody.sceneId, rigidBody.position, rigidBody.velocity)
+		GAME.CreateGadget(p.world.GetOwner(), &model.Vector{
+			X: float64(rigidBody.position.X),
+			Y: float64(rigidBody.position.Y),
+			Z: float64(rigidBody.position.Z),
+		}, GADGET_RED, nil)
+	}
+	return false
 }
 
-func (p *PhysicsEngine) Collision(sceneId uint32, avatarEntityId uint32, oldPos *alg.Vector3, newPos *alg.Vector3) uint32 {
+// collectGadgetCollider 收集场景内配置了碰撞体的物件
+func (p *PhysicsEngine) collectGadgetCollider(sceneId uint32) map[uint32]*alg.Collider {
+	colliderMap := make(map[uint32]*alg.Collider)
+	if len(p.physicsConfig.gadgetColliderMap) == 0 {
+		return colliderMap
+	}
 	scene := p.world.GetSceneById(sceneId)
-	world := scene.GetWorld()
-	for _, player := range scene.GetAllPlayer() {
-		entity := world.GetPlayerActiveAvatarEntity(player)
-		if entity.GetId() == avatarEntityId {
+	if scene == nil {
+		return colliderMap
+	}
+	for _, entity := range scene.GetAllEntity() {
+		gadgetEntity := entity.GetGadgetEntity()
+		if gadgetEntity == nil {
 			continue
 		}
-		avatarPos := entity.GetPos()
-		avatarPos.Y += float64(p.avatarYOffset)
-		// x轴
-		lineMinX := float32(0)
-		lineMaxX := float32(0)
-		if oldPos.X < newPos.X {
-			lineMinX = oldPos.X
-			lineMaxX = newPos.X
-		} else {
-			lineMinX = newPos.X
-			lineMaxX = oldPos.X
-		}
-		shapeMinX := float32(avatarPos.X) - AVATAR_RADIUS
-		shapeMaxX := float32(avatarPos.X) + AVATAR_RADIUS
-		if lineMaxX < shapeMinX || lineMinX > shapeMaxX {
+		gadgetCollider, exist := p.physicsConfig.gadgetColliderMap[gadgetEntity.GetGadgetId()]
+		if !exist {
 			continue
 		}
-		// z轴
-		lineMinZ := float32(0)
-		lineMaxZ := float32(0)
-		if oldPos.Z < newPos.Z {
-			lineMinZ = oldPos.Z
-			lineMaxZ = newPos.Z
-		} else {
-			lineMinZ = newPos.Z
-			lineMaxZ = oldPos.Z
+		pos := entity.GetPos()
+		rot := entity.GetRot()
+		colliderMap[entity.GetId()] = &alg.Collider{
+			Shape:       gadgetCollider.shape,
+			Center:      alg.Vector3{X: float32(pos.X), Y: float32(pos.Y) + gadgetCollider.YOffset, Z: float32(pos.Z)},
+			HalfExtents: alg.Vector3{X: gadgetCollider.HalfExtents[0], Y: gadgetCollider.HalfExtents[1], Z: gadgetCollider.HalfExtents[2]},
+			Yaw:         float32(rot.Y / 180.0 * math.Pi),
 		}
-		shapeMinZ := float32(avatarPos.Z) - AVATAR_RADIUS
-		shapeMaxZ := float32(avatarPos.Z) + AVATAR_RADIUS
-		if lineMaxZ < shapeMinZ || lineMinZ > shapeMaxZ {
-			continue
+	}
+	return colliderMap
+}
+
+// sweep 球体扫掠检测 返回距离最近的命中 半径为0时等价于射线检测
+func (p *PhysicsEngine) sweep(sceneId uint32, origin *alg.Vector3, dir *alg.Vector3, maxDist float32, radius float32,
+	mask int, ignoreEntityIdList []uint32, gadgetColliderMap map[uint32]*alg.Collider) *PhysicsHit {
+	var hit *PhysicsHit = nil
+	check := func(hitType int, entityId uint32, collider *alg.Collider) {
+		for _, ignoreEntityId := range ignoreEntityIdList {
+			if entityId != 0 && entityId == ignoreEntityId {
+				return
+			}
 		}
-		// y轴
-		lineMinY := float32(0)
-		lineMaxY := float32(0)
-		if oldPos.Y < newPos.Y {
-			lineMinY = oldPos.Y
-			lineMaxY = newPos.Y
-		} else {
-			lineMinY = newPos.Y
-			lineMaxY = oldPos.Y
+		dist, ok := collider.SphereCast(origin, dir, maxDist, radius)
+		if !ok || (hit != nil && dist >= hit.Distance) {
+			return
 		}
-		shapeMinY := float32(avatarPos.Y) - AVATAR_HEIGHT/2.0
-		shapeMaxY := float32(avatarPos.Y) + AVATAR_HEIGHT/2.0
-		if lineMaxY < shapeMinY || lineMinY > shapeMaxY {
-			continue
+		hit = &PhysicsHit{HitType: hitType, EntityId: entityId, Distance: dist}
+	}
+	if mask&PhysicsMaskStatic != 0 {
+		for _, collider := range p.physicsConfig.staticColliderMap[sceneId] {
+			check(PhysicsHitStatic, 0, collider)
 		}
-		return entity.GetId()
 	}
-	return 0
+	if mask&PhysicsMaskGadget != 0 {
+		for entityId, collider := range gadgetColliderMap {
+			check(PhysicsHitGadget, entityId, collider)
+		}
+	}
+	if mask&PhysicsMaskAvatar != 0 {
+		scene := p.world.GetSceneById(sceneId)
+		if scene != nil {
+			for _, player := range scene.GetAllPlayer() {
+				entity := p.world.GetPlayerActiveAvatarEntity(player)
+				if entity == nil {
+					continue
+				}
+				avatarPos := entity.GetPos()
+				check(PhysicsHitAvatar, entity.GetId(), &alg.Collider{
+					Shape:       alg.ColliderCapsule,
+					Center:      alg.Vector3{X: float32(avatarPos.X), Y: float32(avatarPos.Y) + p.avatarYOffset, Z: float32(avatarPos.Z)},
+					HalfExtents: alg.Vector3{X: AVATAR_RADIUS, Y: AVATAR_HEIGHT / 2.0, Z: AVATAR_RADIUS},
+				})
+			}
+		}
+	}
+	if hit != nil {
+		hit.Pos = &alg.Vector3{X: origin.X + dir.X*hit.Distance, Y: origin.Y + dir.Y*hit.Distance, Z: origin.Z + dir.Z*hit.Distance}
+	}
+	return hit
+}
+
+// RayCast 射线检测 未命中返回空
+func (p *PhysicsEngine) RayCast(sceneId uint32, origin *alg.Vector3, dir *alg.Vector3, maxDist float32, mask int, ignoreEntityIdList ...uint32) *PhysicsHit {
+	return p.SphereCast(sceneId, origin, dir, maxDist, 0.0, mask, ignoreEntityIdList...)
+}
+
+// SphereCast 球体扫掠检测 未命中返回空
+func (p *PhysicsEngine) SphereCast(sceneId uint32, origin *alg.Vector3, dir *alg.Vector3, maxDist float32, radius float32, mask int, ignoreEntityIdList ...uint32) *PhysicsHit {
+	length := alg.Vector3Magnitude(dir)
+	if length == 0.0 || maxDist <= 0.0 {
+		return nil
+	}
+	dir = &alg.Vector3{X: dir.X / length, Y: dir.Y / length, Z: dir.Z / length}
+	var gadgetColliderMap map[uint32]*alg.Collider = nil
+	if mask&PhysicsMaskGadget != 0 {
+		gadgetColliderMap = p.collectGadgetCollider(sceneId)
+	}
+	return p.sweep(sceneId, origin, dir, maxDist, radius, mask, ignoreEntityIdList, gadgetColliderMap)
 }
 
 func (p *PhysicsEngine) IsRigidBody(entityId uint32) bool {
@@ -196,21 +489,43 @@ func (p *PhysicsEngine) IsRigidBody(entityId uint32) bool {
 	return exist
 }
 
-func (p *PhysicsEngine) CreateRigidBody(entityId, avatarEntityId, sceneId uint32, x, y, z float32, pitchAngle, yawAngle float32) {
-	pitchAngle += p.pitchAngleOffset
-	vy := math.Sin(float64(pitchAngle)/360.0*2*math.Pi) * float64(p.initSpeed)
-	vxz := math.Cos(float64(pitchAngle)/360.0*2*math.Pi) * float64(p.initSpeed)
+// CreateRigidBody 按子弹物件的抛射物配置创建刚体 未配置的物件不创建 欧拉角为客户端上报的原始角度
+func (p *PhysicsEngine) CreateRigidBody(entityId, gadgetId, avatarEntityId, ownerUid, sceneId uint32, pos *alg.Vector3, eulerAngles *alg.Vector3) bool {
+	projectile := p.physicsConfig.GetProjectileConfig(gadgetId)
+	if projectile == nil {
+		return false
+	}
+	// 客户端俯仰角向下为正
+	pitchAngle := float32(0.0)
+	if eulerAngles.X < 90.0 {
+		pitchAngle = -eulerAngles.X
+	} else if eulerAngles.X > 270.0 {
+		pitchAngle = 360.0 - eulerAngles.X
+	} else {
+		logger.Error("invalid raw pitch angle: %v, uid: %v", eulerAngles.X, ownerUid)
+		return false
+	}
+	pitchAngle += projectile.PitchAngleOffset
+	yawAngle := eulerAngles.Y
+	vy := math.Sin(float64(pitchAngle)/360.0*2*math.Pi) * float64(projectile.InitSpeed)
+	vxz := math.Cos(float64(pitchAngle)/360.0*2*math.Pi) * float64(projectile.InitSpeed)
 	vx := math.Sin(float64(yawAngle)/360.0*2*math.Pi) * vxz
 	vz := math.Cos(float64(yawAngle)/360.0*2*math.Pi) * vxz
 	rigidBody := &RigidBody{
 		entityId:       entityId,
+		gadgetId:       gadgetId,
 		avatarEntityId: avatarEntityId,
+		ownerUid:       ownerUid,
 		sceneId:        sceneId,
-		position:       &alg.Vector3{X: x, Y: y, Z: z},
+		position:       &alg.Vector3{X: pos.X, Y: pos.Y, Z: pos.Z},
 		velocity:       &alg.Vector3{X: float32(vx), Y: float32(vy), Z: float32(vz)},
+		lifeTime:       0,
+		projectile:     projectile,
+		hit:            nil,
 	}
 	logger.Debug("[CreateRigidBody] e: %v, s: %v, p: %v, v: %v", rigidBody.entityId, rigidBody.sceneId, rigidBody.position, rigidBody.velocity)
 	p.rigidBodyMap[entityId] = rigidBody
+	return true
 }
 
 func (p *PhysicsEngine) DestroyRigidBody(entityId uint32) {
@@ -0,0 +1,430 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"reflect"
+
+	"hk4e/gs/model"
+	"hk4e/pkg/alg"
+	"hk4e/pkg/logger"
+
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/encoding/protojson"
+	pb "google.golang.org/protobuf/proto"
+)
+
+// lua脚本插件
+// 脚本中通过全局的Plugin表访问插件接口
+// Plugin.ListenEvent(eventId, priority, func(event) return isCancel end) 监听事件 返回true则取消事件
+// Plugin.AddGlobalTick(tick, func() end) 添加全局tick
+// Plugin.CreateUserTimer(uid, delay, func(uid) end) 创建用户timer 延迟单位秒
+// Plugin.RegCommand({name, alias, description, usage, perm, func(ctx) return isSucc end}) 注册命令
+// Plugin.SendMessage(uid, text) 以系统身份向玩家发送私聊消息
+// Plugin.Log(text) 打印日志
+// Plugin.RayCast(uid, ox, oy, oz, dx, dy, dz, maxDist) 在玩家所在场景做射线检测 忽略玩家自身角色 未命中返回nil
+// Plugin.SphereCast(uid, ox, oy, oz, dx, dy, dz, maxDist, radius) 在玩家所在场景做球体扫掠检测 返回值同RayCast
+// 脚本可定义全局的OnEnable和OnDisable函数作为插件的生命周期
+
+// PluginLuaEventIdMap lua中可使用的事件编号
+var PluginLuaEventIdMap = map[string]PluginEventId{
+	"MARK_MAP":                 PluginEventIdMarkMap,
+	"AVATAR_DIE_ANIMATION_END": PluginEventIdAvatarDieAnimationEnd,
+	"GADGET_INTERACT":          PluginEventIdGadgetInteract,
+	"POST_ENTER_SCENE":         PluginEventIdPostEnterScene,
+	"EVT_DO_SKILL_SUCC":        PluginEventIdEvtDoSkillSucc,
+	"EVT_BEING_HIT":            PluginEventIdEvtBeingHit,
+	"EVT_CREATE_GADGET":        PluginEventIdEvtCreateGadget,
+	"EVT_BULLET_HIT":           PluginEventIdEvtBulletHit,
+	"LOGIN":                    PluginEventIdLogin,
+	"OFFLINE":                  PluginEventIdOffline,
+	"PLAYER_CHAT":              PluginEventIdPlayerChat,
+	"PRIVATE_CHAT":             PluginEventIdPrivateChat,
+	"ADD_ITEM":                 PluginEventIdAddItem,
+	"COST_ITEM":                PluginEventIdCostItem,
+	"QUEST_START":              PluginEventIdQuestStart,
+	"QUEST_FINISH":             PluginEventIdQuestFinish,
+	"DO_GACHA":                 PluginEventIdDoGacha,
+	"MONSTER_DIE":              PluginEventIdMonsterDie,
+	"PHYSICS_HIT":              PluginEventIdPhysicsHit,
+}
+
+// PluginLuaEventPriorityMap lua中可使用的事件优先级
+var PluginLuaEventPriorityMap = map[string]PluginEventPriority{
+	"LOWEST":  PluginEventPriorityLowest,
+	"LOW":     PluginEventPriorityLow,
+	"NORMAL":  PluginEventPriorityNormal,
+	"HIGH":    PluginEventPriorityHigh,
+	"HIGHEST": PluginEventPriorityHighest,
+}
+
+// PluginLuaGlobalTickMap lua中可使用的全局tick
+var PluginLuaGlobalTickMap = map[string]PluginGlobalTick{
+	"SECOND":        PluginGlobalTickSecond,
+	"MINUTE_CHANGE": PluginGlobalTickMinuteChange,
+}
+
+// PluginLuaCommandPermMap lua中可使用的命令权限
+var PluginLuaCommandPermMap = map[string]CommandPerm{
+	"NORMAL": CommandPermNormal,
+	"GM":     CommandPermGM,
+}
+
+// PluginLuaPhysicsHitMap lua中可使用的物理命中类型
+var PluginLuaPhysicsHitMap = map[string]int{
+	"AVATAR": PhysicsHitAvatar,
+	"GADGET": PhysicsHitGadget,
+	"STATIC": PhysicsHitStatic,
+}
+
+// PluginLua lua脚本插件
+type PluginLua struct {
+	*Plugin
+	luaStr   string      // 脚本内容
+	luaState *lua.LState // lua虚拟机实例
+}
+
+func NewPluginLua(name string, luaPluginPath string) *PluginLua {
+	if luaPluginPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path.Join(luaPluginPath, name+".lua"))
+	if err != nil {
+		logger.Error("read lua plugin file error: %v, name: %v", err, name)
+		return nil
+	}
+	p := &PluginLua{
+		Plugin:   NewPlugin(),
+		luaStr:   string(data),
+		luaState: nil,
+	}
+	return p
+}
+
+// OnEnable 插件启用生命周期
+func (p *PluginLua) OnEnable() error {
+	p.luaState = lua.NewState(lua.Options{
+		IncludeGoStackTrace: true,
+	})
+	p.regLuaPluginLib()
+	err := p.luaState.DoString(p.luaStr)
+	if err != nil {
+		// 脚本错误则关闭虚拟机 由插件管理器放弃注册
+		p.luaState.Close()
+		p.luaState = nil
+		return err
+	}
+	p.callLuaGlobalFunc("OnEnable")
+	return nil
+}
+
+// OnDisable 插件禁用生命周期
+func (p *PluginLua) OnDisable() {
+	if p.luaState == nil {
+		return
+	}
+	if p.isEnable {
+		p.callLuaGlobalFunc("OnDisable")
+	}
+	p.luaState.Close()
+	p.luaState = nil
+}
+
+// callLuaGlobalFunc 调用脚本中定义的全局函数 未定义则忽略
+func (p *PluginLua) callLuaGlobalFunc(funcName string) {
+	fn, ok := p.luaState.GetGlobal(funcName).(*lua.LFunction)
+	if !ok {
+		return
+	}
+	p.callLuaFunc(fn, 0)
+}
+
+// callLuaFunc 调用lua函数 返回第一个返回值
+func (p *PluginLua) callLuaFunc(fn *lua.LFunction, nRet int, args ...lua.LValue) lua.LValue {
+	GAME.EndlessLoopCheck(EndlessLoopCheckTypeCallLuaFunc)
+	err := p.luaState.CallByParam(lua.P{
+		Fn:      fn,
+		NRet:    nRet,
+		Protect: true,
+	}, args...)
+	if err != nil {
+		logger.Error("call lua plugin func error: %v, name: %v", err, p.name)
+		return lua.LNil
+	}
+	if nRet == 0 {
+		return lua.LNil
+	}
+	ret := p.luaState.Get(-1)
+	p.luaState.Pop(nRet)
+	return ret
+}
+
+// regLuaPluginLib 注册脚本可访问的插件接口
+func (p *PluginLua) regLuaPluginLib() {
+	L := p.luaState
+	pluginLib := L.NewTable()
+	L.SetGlobal("Plugin", pluginLib)
+	L.SetField(pluginLib, "ListenEvent", L.NewFunction(p.luaListenEvent))
+	L.SetField(pluginLib, "AddGlobalTick", L.NewFunction(p.luaAddGlobalTick))
+	L.SetField(pluginLib, "CreateUserTimer", L.NewFunction(p.luaCreateUserTimer))
+	L.SetField(pluginLib, "RegCommand", L.NewFunction(p.luaRegCommand))
+	L.SetField(pluginLib, "SendMessage", L.NewFunction(p.luaSendMessage))
+	L.SetField(pluginLib, "Log", L.NewFunction(p.luaLog))
+	L.SetField(pluginLib, "RayCast", L.NewFunction(p.luaRayCast))
+	L.SetField(pluginLib, "SphereCast", L.NewFunction(p.luaSphereCast))
+	// 常量表
+	eventIdTable := L.NewTable()
+	for name, eventId := range PluginLuaEventIdMap {
+		L.SetField(eventIdTable, name, lua.LNumber(eventId))
+	}
+	L.SetGlobal("EventId", eventIdTable)
+	priorityTable := L.NewTable()
+	for name, priority := range PluginLuaEventPriorityMap {
+		L.SetField(priorityTable, name, lua.LNumber(priority))
+	}
+	L.SetGlobal("EventPriority", priorityTable)
+	globalTickTable := L.NewTable()
+	for name, tick := range PluginLuaGlobalTickMap {
+		L.SetField(globalTickTable, name, lua.LNumber(tick))
+	}
+	L.SetGlobal("GlobalTick", globalTickTable)
+	commandPermTable := L.NewTable()
+	for name, perm := range PluginLuaCommandPermMap {
+		L.SetField(commandPermTable, name, lua.LNumber(perm))
+	}
+	L.SetGlobal("CommandPerm", commandPermTable)
+	physicsHitTable := L.NewTable()
+	for name, hitType := range PluginLuaPhysicsHitMap {
+		L.SetField(physicsHitTable, name, lua.LNumber(hitType))
+	}
+	L.SetGlobal("PhysicsHit", physicsHitTable)
+}
+
+func (p *PluginLua) luaListenEvent(L *lua.LState) int {
+	eventId := PluginEventId(L.CheckInt(1))
+	priority := PluginEventPriority(L.CheckInt(2))
+	fn := L.CheckFunction(3)
+	p.ListenEvent(eventId, priority, func(event IPluginEvent) {
+		ret := p.callLuaFunc(fn, 1, p.packLuaEvent(eventId, event))
+		if ret == lua.LTrue {
+			event.Cancel()
+		}
+	})
+	return 0
+}
+
+func (p *PluginLua) luaAddGlobalTick(L *lua.LState) int {
+	tick := PluginGlobalTick(L.CheckInt(1))
+	fn := L.CheckFunction(2)
+	p.AddGlobalTick(tick, func() {
+		p.callLuaFunc(fn, 0)
+	})
+	return 0
+}
+
+func (p *PluginLua) luaCreateUserTimer(L *lua.LState) int {
+	userId := uint32(L.CheckInt(1))
+	delay := uint32(L.CheckInt(2))
+	fn := L.CheckFunction(3)
+	p.CreateUserTimer(userId, delay, func(player *model.Player, data []any) {
+		p.callLuaFunc(fn, 0, lua.LNumber(player.PlayerId))
+	})
+	return 0
+}
+
+func (p *PluginLua) luaRegCommand(L *lua.LState) int {
+	table := L.CheckTable(1)
+	fn, ok := L.GetField(table, "func").(*lua.LFunction)
+	if !ok {
+		L.ArgError(1, "command func not found")
+		return 0
+	}
+	controller := &CommandController{
+		Name:        lua.LVAsString(L.GetField(table, "name")),
+		AliasList:   luaTableToStringList(L.GetField(table, "alias")),
+		Description: lua.LVAsString(L.GetField(table, "description")),
+		UsageList:   luaTableToStringList(L.GetField(table, "usage")),
+		Perm:        CommandPerm(lua.LVAsNumber(L.GetField(table, "perm"))),
+		Func: func(content *CommandContent) bool {
+			ctx := L.NewTable()
+			L.SetField(ctx, "executor_uid", lua.LNumber(content.Executor.PlayerId))
+			if content.AssignPlayer != nil {
+				L.SetField(ctx, "assign_uid", lua.LNumber(content.AssignPlayer.PlayerId))
+			}
+			paramTable := L.NewTable()
+			for _, param := range content.ParamList {
+				paramTable.Append(lua.LString(param))
+			}
+			L.SetField(ctx, "param_list", paramTable)
+			return p.callLuaFunc(fn, 1, ctx) == lua.LTrue
+		},
+	}
+	p.RegCommandController(controller)
+	return 0
+}
+
+func (p *PluginLua) luaSendMessage(L *lua.LState) int {
+	userId := uint32(L.CheckInt(1))
+	text := L.CheckString(2)
+	GAME.SendPrivateChat(COMMAND_MANAGER.system, userId, text)
+	return 0
+}
+
+func (p *PluginLua) luaLog(L *lua.LState) int {
+	logger.Info("[lua plugin %v] %v", p.name, L.CheckString(1))
+	return 0
+}
+
+func (p *PluginLua) luaRayCast(L *lua.LState) int {
+	return p.luaPhysicsCast(L, 0.0)
+}
+
+func (p *PluginLua) luaSphereCast(L *lua.LState) int {
+	return p.luaPhysicsCast(L, float32(L.CheckNumber(9)))
+}
+
+// luaPhysicsCast 射线检测和球体扫掠检测的公共实现 命中时返回包含HitType EntityId Pos Distance的表
+func (p *PluginLua) luaPhysicsCast(L *lua.LState, radius float32) int {
+	userId := uint32(L.CheckInt(1))
+	origin := &alg.Vector3{X: float32(L.CheckNumber(2)), Y: float32(L.CheckNumber(3)), Z: float32(L.CheckNumber(4))}
+	dir := &alg.Vector3{X: float32(L.CheckNumber(5)), Y: float32(L.CheckNumber(6)), Z: float32(L.CheckNumber(7))}
+	maxDist := float32(L.CheckNumber(8))
+	player := USER_MANAGER.GetOnlineUser(userId)
+	if player == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	world := WORLD_MANAGER.GetWorldById(player.WorldId)
+	if world == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	ignoreEntityId := uint32(0)
+	entity := world.GetPlayerActiveAvatarEntity(player)
+	if entity != nil {
+		ignoreEntityId = entity.GetId()
+	}
+	hit := world.GetPhysicsEngine().SphereCast(player.GetSceneId(), origin, dir, maxDist, radius, PhysicsMaskAll, ignoreEntityId)
+	if hit == nil {
+		L.Push(lua.LNil)
+		return 1
+	}
+	L.Push(goValueToLuaValue(L, hit))
+	return 1
+}
+
+// packLuaEvent 将事件结构转换为lua表
+// 玩家字段转为uid 协议消息字段转为表 其余导出字段按原值转换
+func (p *PluginLua) packLuaEvent(eventId PluginEventId, event IPluginEvent) *lua.LTable {
+	L := p.luaState
+	table := L.NewTable()
+	L.SetField(table, "event_id", lua.LNumber(eventId))
+	refValue := reflect.ValueOf(event)
+	if refValue.Kind() == reflect.Pointer {
+		refValue = refValue.Elem()
+	}
+	if refValue.Kind() != reflect.Struct {
+		return table
+	}
+	refType := refValue.Type()
+	for i := 0; i < refValue.NumField(); i++ {
+		field := refType.Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		value := refValue.Field(i).Interface()
+		switch v := value.(type) {
+		case *model.Player:
+			if v != nil {
+				L.SetField(table, "uid", lua.LNumber(v.PlayerId))
+			}
+		case pb.Message:
+			L.SetField(table, field.Name, protoMsgToLuaValue(L, v))
+		default:
+			L.SetField(table, field.Name, goValueToLuaValue(L, value))
+		}
+	}
+	return table
+}
+
+// protoMsgToLuaValue 协议消息转换为lua表 字段名使用proto中的原始名
+func protoMsgToLuaValue(L *lua.LState, msg pb.Message) lua.LValue {
+	if msg == nil || reflect.ValueOf(msg).IsNil() {
+		return lua.LNil
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	if err != nil {
+		logger.Error("marshal proto msg error: %v", err)
+		return lua.LNil
+	}
+	var obj any
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		logger.Error("unmarshal json error: %v", err)
+		return lua.LNil
+	}
+	return goValueToLuaValue(L, obj)
+}
+
+// goValueToLuaValue go基础类型转换为lua值
+func goValueToLuaValue(L *lua.LState, value any) lua.LValue {
+	if value == nil {
+		return lua.LNil
+	}
+	refValue := reflect.ValueOf(value)
+	switch refValue.Kind() {
+	case reflect.Pointer:
+		if refValue.IsNil() {
+			return lua.LNil
+		}
+		return goValueToLuaValue(L, refValue.Elem().Interface())
+	case reflect.Struct:
+		table := L.NewTable()
+		refType := refValue.Type()
+		for i := 0; i < refValue.NumField(); i++ {
+			if !refType.Field(i).IsExported() {
+				continue
+			}
+			L.SetField(table, refType.Field(i).Name, goValueToLuaValue(L, refValue.Field(i).Interface()))
+		}
+		return table
+	case reflect.Bool:
+		return lua.LBool(refValue.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(refValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(refValue.Uint())
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(refValue.Float())
+	case reflect.String:
+		return lua.LString(refValue.String())
+	case reflect.Slice, reflect.Array:
+		table := L.NewTable()
+		for i := 0; i < refValue.Len(); i++ {
+			table.Append(goValueToLuaValue(L, refValue.Index(i).Interface()))
+		}
+		return table
+	case reflect.Map:
+		table := L.NewTable()
+		iter := refValue.MapRange()
+		for iter.Next() {
+			L.SetTable(table, goValueToLuaValue(L, iter.Key().Interface()), goValueToLuaValue(L, iter.Value().Interface()))
+		}
+		return table
+	default:
+		return lua.LNil
+	}
+}
+
+func luaTableToStringList(value lua.LValue) []string {
+	list := make([]string, 0)
+	table, ok := value.(*lua.LTable)
+	if !ok {
+		return list
+	}
+	table.ForEach(func(_ lua.LValue, v lua.LValue) {
+		list = append(list, v.String())
+	})
+	return list
+}
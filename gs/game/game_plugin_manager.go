@@ -6,19 +6,44 @@ import (
 	"reflect"
 	"sort"
 
+	"hk4e/common/config"
 	"hk4e/gs/model"
+	"hk4e/pkg/alg"
 	"hk4e/pkg/logger"
 	"hk4e/protocol/proto"
 )
 
 // 游戏服务器插件管理器
 
+// BuiltinPluginMap 内置插件集合 插件名 -> 插件构造函数
+var BuiltinPluginMap = map[string]func() IPlugin{
+	"pubg": func() IPlugin { return NewPluginPubg() },
+}
+
 // InitPlugin 初始化插件
+// 只启用配置中指定的插件 内置插件填插件名 lua插件填脚本文件名(不含扩展名)
 func (p *PluginManager) InitPlugin() {
-	iPluginList := []IPlugin{
-		NewPluginPubg(),
+	for _, name := range config.GetConfig().Hk4e.PluginEnableList {
+		iPlugin := p.NewPluginByName(name)
+		if iPlugin == nil {
+			continue
+		}
+		p.RegPlugin(name, iPlugin)
 	}
-	p.RegAllPlugin(iPluginList...)
+}
+
+// NewPluginByName 通过插件名创建插件实例 优先查找内置插件
+func (p *PluginManager) NewPluginByName(name string) IPlugin {
+	newPluginFunc, exist := BuiltinPluginMap[name]
+	if exist {
+		return newPluginFunc()
+	}
+	pluginLua := NewPluginLua(name, config.GetConfig().Hk4e.LuaPluginPath)
+	if pluginLua == nil {
+		logger.Error("plugin not found, name: %v", name)
+		return nil
+	}
+	return pluginLua
 }
 
 // 事件定义
@@ -38,6 +63,17 @@ const (
 	PluginEventIdEvtBeingHit
 	PluginEventIdEvtCreateGadget
 	PluginEventIdEvtBulletHit
+	PluginEventIdLogin
+	PluginEventIdOffline
+	PluginEventIdPlayerChat
+	PluginEventIdPrivateChat
+	PluginEventIdAddItem
+	PluginEventIdCostItem
+	PluginEventIdQuestStart
+	PluginEventIdQuestFinish
+	PluginEventIdDoGacha
+	PluginEventIdMonsterDie
+	PluginEventIdPhysicsHit
 )
 
 // PluginEventMarkMap 地图标点
@@ -96,6 +132,100 @@ type PluginEventEvtBulletHit struct {
 	Ntf    *proto.EvtBulletHitNotify // 请求
 }
 
+// PluginEventLogin 玩家登录
+// 取消后拒绝本次登录
+type PluginEventLogin struct {
+	*PluginEvent
+	UserId uint32                // 玩家uid
+	Player *model.Player         // 玩家 新注册玩家为空
+	Req    *proto.PlayerLoginReq // 请求
+}
+
+// PluginEventOffline 玩家离线
+// 玩家已断开连接 取消无效
+type PluginEventOffline struct {
+	*PluginEvent
+	Player *model.Player // 玩家
+}
+
+// PluginEventPlayerChat 世界聊天
+type PluginEventPlayerChat struct {
+	*PluginEvent
+	Player *model.Player        // 玩家
+	Req    *proto.PlayerChatReq // 请求
+}
+
+// PluginEventPrivateChat 私聊
+type PluginEventPrivateChat struct {
+	*PluginEvent
+	Player *model.Player         // 玩家
+	Req    *proto.PrivateChatReq // 请求
+}
+
+// PluginEventAddItem 添加物品
+type PluginEventAddItem struct {
+	*PluginEvent
+	Player     *model.Player          // 玩家
+	ItemList   []*ChangeItem          // 物品列表
+	HintReason proto.ActionReasonType // 添加原因
+}
+
+// PluginEventCostItem 消耗物品
+type PluginEventCostItem struct {
+	*PluginEvent
+	Player   *model.Player // 玩家
+	ItemList []*ChangeItem // 物品列表
+}
+
+// PluginEventQuestStart 任务开始
+type PluginEventQuestStart struct {
+	*PluginEvent
+	Player  *model.Player // 玩家
+	QuestId uint32        // 任务id
+}
+
+// PluginEventQuestFinish 任务完成
+// 取消后不执行任务完成时的操作
+type PluginEventQuestFinish struct {
+	*PluginEvent
+	Player  *model.Player // 玩家
+	QuestId uint32        // 任务id
+}
+
+// PluginEventDoGacha 抽卡
+type PluginEventDoGacha struct {
+	*PluginEvent
+	Player *model.Player     // 玩家
+	Req    *proto.DoGachaReq // 请求
+}
+
+// PluginEventMonsterDie 怪物死亡
+// 取消后怪物不会死亡 血量已归零时恢复满血
+type PluginEventMonsterDie struct {
+	*PluginEvent
+	Player    *model.Player       // 玩家
+	EntityId  uint32              // 实体id
+	MonsterId uint32              // 怪物id
+	GroupId   uint32              // 场景小组id
+	ConfigId  uint32              // 场景配置id
+	DieType   proto.PlayerDieType // 死亡类型
+}
+
+// PluginEventPhysicsHit 物理引擎模拟的子弹命中
+// 子弹刚体已销毁 取消无效
+type PluginEventPhysicsHit struct {
+	*PluginEvent
+	Player         *model.Player // 子弹发射者 已离线时为空
+	WorldId        uint64        // 世界id
+	SceneId        uint32        // 场景id
+	EntityId       uint32        // 子弹实体id
+	GadgetId       uint32        // 子弹物件id
+	AvatarEntityId uint32        // 子弹发射者角色实体id
+	HitType        int           // 命中类型 PhysicsHitAvatar/PhysicsHitGadget/PhysicsHitStatic
+	HitEntityId    uint32        // 命中的实体id 静态碰撞体为0
+	HitPos         *alg.Vector3  // 命中点
+}
+
 type PluginEventFunc func(event IPluginEvent)
 
 // IPluginEvent 插件事件接口
@@ -127,7 +257,7 @@ func (p *PluginEvent) IsCancel() bool {
 // IPlugin 插件接口
 type IPlugin interface {
 	GetPlugin() *Plugin
-	OnEnable()
+	OnEnable() error
 	OnDisable()
 }
 
@@ -162,6 +292,7 @@ type PluginUserTimerFunc func(player *model.Player, data []any)
 
 // Plugin 插件结构
 type Plugin struct {
+	name                  string                               // 插件名
 	isEnable              bool                                 // 是否启用
 	eventMap              map[PluginEventId][]*PluginEventInfo // 事件集合
 	globalTickMap         map[PluginGlobalTick][]func()        // 全局tick集合
@@ -184,9 +315,15 @@ func (p *Plugin) GetPlugin() *Plugin {
 	return p
 }
 
+// GetName 获取插件名
+func (p *Plugin) GetName() string {
+	return p.name
+}
+
 // OnEnable 插件启用时的生命周期
-func (p *Plugin) OnEnable() {
+func (p *Plugin) OnEnable() error {
 	// 具体逻辑由插件来重写
+	return nil
 }
 
 // OnDisable 插件禁用时的生命周期
@@ -234,40 +371,42 @@ func (p *Plugin) CreateUserTimer(userId uint32, delay uint32, timerFunc PluginUs
 // RegCommandController 注册命令控制器
 func (p *Plugin) RegCommandController(controller *CommandController) {
 	COMMAND_MANAGER.RegController(controller)
+	// 记录插件注册的命令 以便卸载插件时一并卸载
+	p.commandControllerList = append(p.commandControllerList, controller)
 }
 
 type PluginManager struct {
-	pluginMap        map[reflect.Type]IPlugin // 插件集合
-	userTimerCounter uint64                   // 用户timer计数器
+	pluginMap        map[string]IPlugin // 插件集合 插件名 -> 插件
+	userTimerCounter uint64             // 用户timer计数器
 }
 
 func NewPluginManager() *PluginManager {
 	r := new(PluginManager)
-	r.pluginMap = make(map[reflect.Type]IPlugin)
+	r.pluginMap = make(map[string]IPlugin)
 	return r
 }
 
-// RegAllPlugin 注册全部插件
-func (p *PluginManager) RegAllPlugin(iPluginList ...IPlugin) {
-	for _, plugin := range iPluginList {
-		p.RegPlugin(plugin)
-	}
-}
-
 // RegPlugin 注册插件
-func (p *PluginManager) RegPlugin(iPlugin IPlugin) {
-	// 反射类型
-	refType := reflect.TypeOf(iPlugin)
+func (p *PluginManager) RegPlugin(name string, iPlugin IPlugin) {
 	// 校验插件名是否已被注册
-	_, exist := p.pluginMap[refType]
+	_, exist := p.pluginMap[name]
 	if exist {
-		logger.Error("plugin has been register,refType: %v", refType)
+		logger.Error("plugin has been register, name: %v", name)
+		return
+	}
+	logger.Info("plugin enable, name: %v, refType: %v", name, reflect.TypeOf(iPlugin))
+	plugin := iPlugin.GetPlugin()
+	plugin.name = name
+	// 调用插件启用的生命周期 启用失败则不注册插件
+	err := iPlugin.OnEnable()
+	if err != nil {
+		logger.Error("plugin enable error: %v, name: %v", err, name)
+		for _, controller := range plugin.commandControllerList {
+			COMMAND_MANAGER.DelAllController(controller)
+		}
 		return
 	}
-	logger.Info("plugin enable, refType: %v", refType)
-	// 调用插件启用的生命周期
-	iPlugin.OnEnable()
-	p.pluginMap[refType] = iPlugin
+	p.pluginMap[name] = iPlugin
 }
 
 // DelAllPlugin 卸载全部插件
@@ -279,15 +418,14 @@ func (p *PluginManager) DelAllPlugin() {
 
 // DelPlugin 卸载插件
 func (p *PluginManager) DelPlugin(iPlugin IPlugin) {
-	// 反射类型
-	refType := reflect.TypeOf(iPlugin)
+	name := iPlugin.GetPlugin().name
 	// 校验插件是否注册
-	_, exist := p.pluginMap[refType]
+	_, exist := p.pluginMap[name]
 	if !exist {
-		logger.Error("plugin not exist, refType: %v", refType)
+		logger.Error("plugin not exist, name: %v", name)
 		return
 	}
-	logger.Info("plugin disable, refType: %v", refType)
+	logger.Info("plugin disable, name: %v", name)
 	// 调用插件禁用的生命周期
 	iPlugin.OnDisable()
 	// 卸载插件注册的命令
@@ -295,7 +433,7 @@ func (p *PluginManager) DelPlugin(iPlugin IPlugin) {
 	for _, controller := range plugin.commandControllerList {
 		COMMAND_MANAGER.DelAllController(controller)
 	}
-	delete(p.pluginMap, refType)
+	delete(p.pluginMap, name)
 }
 
 // GetPlugin 获取插件实例
@@ -318,13 +456,18 @@ func (p *PluginManager) GetPlugin(value IPlugin) (plugin IPlugin, err error) {
 	}
 	refType := refValue.Type()
 	// 校验插件是否注册
-	exist := false
-	plugin, exist = p.pluginMap[refType]
-	if !exist {
-		err = errors.New(fmt.Sprintf("plugin not exist, refType: %v", refType))
-		return nil, err
+	for _, iPlugin := range p.pluginMap {
+		if reflect.TypeOf(iPlugin) == refType {
+			return iPlugin, nil
+		}
 	}
-	return plugin, nil
+	err = errors.New(fmt.Sprintf("plugin not exist, refType: %v", refType))
+	return nil, err
+}
+
+// GetPluginByName 通过插件名获取插件实例
+func (p *PluginManager) GetPluginByName(name string) IPlugin {
+	return p.pluginMap[name]
 }
 
 // TriggerEvent 触发事件
@@ -396,12 +539,12 @@ func (p *PluginManager) HandleUserTimer(player *model.Player, data []any) {
 		// 获取插件用户timer处理函数列表
 		timerFunc, exist := plugin.userTimerMap[userTimerId]
 		if !exist {
-			logger.Error("plugin timer not exist, id: %v", userTimerId)
 			continue
 		}
 		timerFunc(player, data)
 		delete(plugin.userTimerMap, userTimerId)
 		// 只需要执行一次
-		break
+		return
 	}
+	logger.Error("plugin timer not exist, id: %v", userTimerId)
 }
@@ -78,7 +78,7 @@ func NewPluginPubg() *PluginPubg {
 }
 
 // OnEnable 插件启用生命周期
-func (p *PluginPubg) OnEnable() {
+func (p *PluginPubg) OnEnable() error {
 	// 监听事件
 	p.ListenEvent(PluginEventIdMarkMap, PluginEventPriorityNormal, p.EventMarkMap)
 	p.ListenEvent(PluginEventIdAvatarDieAnimationEnd, PluginEventPriorityNormal, p.EventAvatarDieAnimationEnd)
@@ -86,13 +86,13 @@ func (p *PluginPubg) OnEnable() {
 	p.ListenEvent(PluginEventIdPostEnterScene, PluginEventPriorityNormal, p.EventPostEnterScene)
 	p.ListenEvent(PluginEventIdEvtDoSkillSucc, PluginEventPriorityNormal, p.EventEvtDoSkillSucc)
 	p.ListenEvent(PluginEventIdEvtBeingHit, PluginEventPriorityNormal, p.EventEvtBeingHit)
-	p.ListenEvent(PluginEventIdEvtCreateGadget, PluginEventPriorityNormal, p.EvtCreateGadget)
-	p.ListenEvent(PluginEventIdEvtBulletHit, PluginEventPriorityNormal, p.EvtBulletHit)
+	p.ListenEvent(PluginEventIdPhysicsHit, PluginEventPriorityNormal, p.EventPhysicsHit)
 	// 添加全局定时器
 	p.AddGlobalTick(PluginGlobalTickSecond, p.GlobalTickPubg)
 	p.AddGlobalTick(PluginGlobalTickMinuteChange, p.GlobalTickMinuteChange)
 	// 注册命令
 	p.RegCommandController(p.NewPubgCommandController())
+	return nil
 }
 
 /************************************************** 事件监听 **************************************************/
@@ -291,70 +291,22 @@ func (p *PluginPubg) EventEvtBeingHit(iEvent IPluginEvent) {
 	}
 }
 
-// EvtCreateGadget 创建物件实体事件
-func (p *PluginPubg) EvtCreateGadget(iEvent IPluginEvent) {
-	event := iEvent.(*PluginEventEvtCreateGadget)
-	player := event.Player
+// EventPhysicsHit 物理引擎子弹命中事件
+func (p *PluginPubg) EventPhysicsHit(iEvent IPluginEvent) {
+	event := iEvent.(*PluginEventPhysicsHit)
 	// 确保游戏开启
-	if !p.IsStartPubg() || p.world.GetId() != player.WorldId {
-		return
-	}
-	world := WORLD_MANAGER.GetWorldById(player.WorldId)
-	if world == nil {
-		logger.Error("get world is nil, worldId: %v, uid: %v", player.WorldId, player.PlayerId)
-		return
-	}
-	ntf := event.Ntf
-	gadgetDataConfig := gdconf.GetGadgetDataById(int32(ntf.ConfigId))
-	if gadgetDataConfig == nil {
-		logger.Error("gadget data config is nil, gadgetId: %v", ntf.ConfigId)
-		return
-	}
-	// 蓄力箭
-	if gadgetDataConfig.PrefabPath != "ART/Others/Bullet/Bullet_ArrowAiming" &&
-		gadgetDataConfig.PrefabPath != "ART/Others/Bullet/Bullet_Venti_ArrowAiming" {
-		return
-	}
-	pitchAngleRaw := ntf.InitEulerAngles.X
-	pitchAngle := float32(0.0)
-	if pitchAngleRaw < 90.0 {
-		pitchAngle = -pitchAngleRaw
-	} else if pitchAngleRaw > 270.0 {
-		pitchAngle = 360.0 - pitchAngleRaw
-	} else {
-		logger.Error("invalid raw pitch angle: %v, uid: %v", pitchAngleRaw, player.PlayerId)
+	if !p.IsStartPubg() || p.world.GetId() != event.WorldId {
 		return
 	}
-	yawAngle := ntf.InitEulerAngles.Y
-	bulletPhysicsEngine := world.GetBulletPhysicsEngine()
-	bulletPhysicsEngine.CreateRigidBody(
-		ntf.EntityId,
-		world.GetPlayerActiveAvatarEntity(player).GetId(),
-		player.GetSceneId(),
-		ntf.InitPos.X, ntf.InitPos.Y, ntf.InitPos.Z,
-		pitchAngle, yawAngle,
-	)
-}
-
-// EvtBulletHit 子弹命中事件
-func (p *PluginPubg) EvtBulletHit(iEvent IPluginEvent) {
-	event := iEvent.(*PluginEventEvtBulletHit)
-	player := event.Player
-	// 确保游戏开启
-	if !p.IsStartPubg() || p.world.GetId() != player.WorldId {
+	if event.HitType != PhysicsHitAvatar {
 		return
 	}
-	world := WORLD_MANAGER.GetWorldById(player.WorldId)
-	if world == nil {
-		logger.Error("get world is nil, worldId: %v, uid: %v", player.WorldId, player.PlayerId)
+	// 只有配置了抛射物的子弹(默认为蓄力箭)才会由物理引擎模拟 这里无需再按预制体过滤
+	scene := p.world.GetSceneById(event.SceneId)
+	if scene == nil {
 		return
 	}
-	ntf := event.Ntf
-	bulletPhysicsEngine := world.GetBulletPhysicsEngine()
-	if bulletPhysicsEngine.IsRigidBody(ntf.EntityId) {
-		bulletPhysicsEngine.DestroyRigidBody(ntf.EntityId)
-		_ = ntf.HitPoint
-	}
+	p.PubgHit(scene, event.HitEntityId, event.AvatarEntityId, true)
 }
 
 /************************************************** 全局定时器 **************************************************/
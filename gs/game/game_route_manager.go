@@ -66,6 +66,7 @@ func (r *RouteManager) initRoute() {
 		cmd.GetShopReq:                        GAME.GetShopReq,
 		cmd.BuyGoodsReq:                       GAME.BuyGoodsReq,
 		cmd.McoinExchangeHcoinReq:             GAME.McoinExchangeHcoinReq,
+		cmd.BuyResinReq:                       GAME.BuyResinReq,
 		cmd.AvatarChangeCostumeReq:            GAME.AvatarChangeCostumeReq,
 		cmd.AvatarWearFlycloakReq:             GAME.AvatarWearFlycloakReq,
 		cmd.PullRecentChatReq:                 GAME.PullRecentChatReq,
@@ -114,6 +115,7 @@ func (r *RouteManager) initRoute() {
 		cmd.DungeonEntryInfoReq:               GAME.DungeonEntryInfoReq,
 		cmd.PlayerEnterDungeonReq:             GAME.PlayerEnterDungeonReq,
 		cmd.PlayerQuitDungeonReq:              GAME.PlayerQuitDungeonReq,
+		cmd.DungeonGetStatueDropReq:           GAME.DungeonGetStatueDropReq,
 		cmd.GadgetInteractReq:                 GAME.GadgetInteractReq,
 		cmd.GmTalkReq:                         GAME.GmTalkReq,
 		cmd.SetEntityClientDataNotify:         GAME.SetEntityClientDataNotify,
@@ -193,6 +195,7 @@ func (r *RouteManager) RouteHandle(netMsg *mq.NetMsg) {
 		gameMsg := netMsg.GameMsg
 		switch netMsg.EventId {
 		case mq.NormalMsg:
+			metricsRouteMsg(gameMsg.CmdId)
 			if gameMsg.CmdId == cmd.PlayerLoginReq {
 				GAME.PlayerLoginReq(gameMsg.UserId, gameMsg.ClientSeq, netMsg.OriginServerAppId, gameMsg.PayloadMessage)
 				return
@@ -224,6 +227,8 @@ func (r *RouteManager) RouteHandle(netMsg *mq.NetMsg) {
 			GAME.ServerPlayerMpReq(serverMsg.PlayerMpInfo, netMsg.OriginServerAppId)
 		case mq.ServerPlayerMpRsp:
 			GAME.ServerPlayerMpRsp(serverMsg.PlayerMpInfo)
+		case mq.ServerMatchNotify:
+			GAME.ServerMatchNotify(serverMsg.MatchInfo)
 		case mq.ServerChatMsgNotify:
 			GAME.ServerChatMsgNotify(serverMsg.ChatMsgInfo)
 		case mq.ServerAddFriendNotify:
@@ -232,6 +237,16 @@ func (r *RouteManager) RouteHandle(netMsg *mq.NetMsg) {
 			GAME.ServerStopNotify()
 		case mq.ServerDispatchCancelNotify:
 			GAME.ServerDispatchCancelNotify(serverMsg.AppVersion)
+		case mq.ServerDrainNotify:
+			GAME.ServerDrainNotify()
+		case mq.ServerKickPlayerNotify:
+			GAME.KickPlayer(serverMsg.UserId, serverMsg.KickReason)
+		case mq.ServerPlayerMuteNotify:
+			GAME.SetPlayerMute(serverMsg.MuteInfo)
+		case mq.ServerSendMailNotify:
+			GAME.SendPlayerMail(serverMsg.MailInfo)
+		case mq.ServerAnticheatRollbackNotify:
+			GAME.AnticheatRollback(serverMsg.AnticheatInfo)
 		case mq.ServerGmCmdNotify:
 			commandTextInput := COMMAND_MANAGER.GetCommandMessageInput()
 			commandTextInput <- &CommandMessage{
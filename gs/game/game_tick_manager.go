@@ -93,6 +93,23 @@ func (t *TickManager) CreateUserTimer(userId uint32, action int, delay uint32, d
 		userId, action, time.Now().Add(time.Second*time.Duration(delay)).Format("2006-01-02 15:04:05"))
 }
 
+// DestroyUserSceneTimer 销毁玩家在指定场景创建的lua定时任务 lua定时任务的最后一个参数为场景id
+func (t *TickManager) DestroyUserSceneTimer(userId uint32, sceneId uint32) {
+	userTick, exist := t.userTickMap[userId]
+	if !exist {
+		return
+	}
+	for timerId, timer := range userTick.timerMap {
+		if timer.action != UserTimerActionLuaCreateMonster && timer.action != UserTimerActionLuaGroupTimerEvent {
+			continue
+		}
+		if timer.data[len(timer.data)-1].(uint32) != sceneId {
+			continue
+		}
+		delete(userTick.timerMap, timerId)
+	}
+}
+
 func (t *TickManager) onUserTickSecond(userId uint32, now int64) {
 }
 
@@ -105,6 +122,10 @@ func (t *TickManager) onUserTickMinute(userId uint32, now int64) {
 	if userId < PlayerBaseUid {
 		return
 	}
+	// 树脂恢复
+	GAME.ResinRecover(player, true)
+	// 过期邮件清理
+	GAME.ClearPlayerExpireMail(player, uint32(now/1000))
 	if uint32(now/1000)-player.LastKeepaliveTime > 60 {
 		logger.Error("remove keepalive timeout user, uid: %v", userId)
 		GAME.OnOffline(userId, &ChangeGsInfo{
@@ -134,11 +155,18 @@ func (t *TickManager) userTimerHandle(userId uint32, action int, data []any) {
 		logger.Debug("UserTimerActionLuaCreateMonster, groupId: %v, configId: %v, uid: %v", data[0], data[1], userId)
 		groupId := data[0].(uint32)
 		configId := data[1].(uint32)
+		if data[2].(uint32) != player.GetSceneId() {
+			// 玩家已离开创建定时器的场景
+			return
+		}
 		GAME.SceneGroupCreateEntity(player, groupId, configId, constant.ENTITY_TYPE_MONSTER)
 	case UserTimerActionLuaGroupTimerEvent:
 		logger.Debug("UserTimerActionLuaGroupTimerEvent, groupId: %v, source: %v, uid: %v", data[0], data[1], userId)
 		groupId := data[0].(uint32)
 		source := data[1].(string)
+		if data[2].(uint32) != player.GetSceneId() {
+			return
+		}
 		world := WORLD_MANAGER.GetWorldById(player.WorldId)
 		if world == nil {
 			logger.Error("get world is nil, worldId: %v, uid: %v", player.WorldId, userId)
@@ -163,6 +191,7 @@ func (t *TickManager) OnGameServerTick() {
 	t.globalTickCount++
 	tm := time.Now()
 	now := tm.UnixMilli()
+	t.onTickPhysics(now)
 	if t.globalTickCount%(50/ServerTickTime) == 0 {
 		t.onTick50MilliSecond(now)
 	}
@@ -232,6 +261,10 @@ func (t *TickManager) onMonthChange(now int64) {
 
 func (t *TickManager) onDayChange(now int64) {
 	logger.Info("on day change, time: %v", now)
+	// 刷新在线玩家的每日委托
+	for _, player := range USER_MANAGER.GetAllOnlineUserList() {
+		GAME.RefreshDailyTask(player, true)
+	}
 }
 
 func (t *TickManager) onHourChange(now int64) {
@@ -255,6 +288,7 @@ func (t *TickManager) onTickMinute(now int64) {
 }
 
 func (t *TickManager) onTick10Second(now int64) {
+	GAME.SyncMainMultiServerAppId()
 	for _, world := range WORLD_MANAGER.GetAllWorld() {
 		if world.GetOwner().SceneLoadState == model.SceneEnterDone {
 			GAME.SceneTimeNotify(world)
@@ -298,6 +332,8 @@ func (t *TickManager) onTickSecond(now int64) {
 		}
 		// 每个场景时间+1
 		for _, scene := range world.GetAllScene() {
+			// 地牢计时
+			GAME.DungeonTick(scene)
 			if world.GetOwner().Pause {
 				continue
 			}
@@ -308,6 +344,8 @@ func (t *TickManager) onTickSecond(now int64) {
 	for _, game := range GCG_MANAGER.gameMap {
 		game.onTick()
 	}
+	// 排空迁移玩家
+	GAME.DrainTick()
 }
 
 func (t *TickManager) onTick200MilliSecond(now int64) {
@@ -330,23 +368,8 @@ func (t *TickManager) onTick100MilliSecond(now int64) {
 				GAME.RestoreCountStaminaHandler(player)
 			}
 		}
-	}
-
-	iPlugin, err := PLUGIN_MANAGER.GetPlugin(&PluginPubg{})
-	if err != nil {
-		logger.Error("get plugin pubg error: %v", err)
-		return
-	}
-	pluginPubg := iPlugin.(*PluginPubg)
-	if !pluginPubg.IsStartPubg() {
-		return
-	}
-	world := WORLD_MANAGER.GetAiWorld()
-	bulletPhysicsEngine := world.GetBulletPhysicsEngine()
-	hitList := bulletPhysicsEngine.Update(now)
-	for _, rigidBody := range hitList {
-		scene := world.GetSceneById(rigidBody.sceneId)
-		pluginPubg.PubgHit(scene, rigidBody.hitAvatarEntityId, rigidBody.avatarEntityId, true)
+		// 服务器怪物ai
+		GAME.MonsterAiTick(world, now)
 	}
 }
 
@@ -363,3 +386,24 @@ func (t *TickManager) onTick50MilliSecond(now int64) {
 		}, 0)
 	}
 }
+
+// onTickPhysics 物理引擎更新 每次服务器tick都调用 引擎内部按固定步长推进
+func (t *TickManager) onTickPhysics(now int64) {
+	for _, world := range WORLD_MANAGER.GetAllWorld() {
+		hitList := world.GetPhysicsEngine().Update(now)
+		for _, rigidBody := range hitList {
+			PLUGIN_MANAGER.TriggerEvent(PluginEventIdPhysicsHit, &PluginEventPhysicsHit{
+				PluginEvent:    NewPluginEvent(),
+				Player:         USER_MANAGER.GetOnlineUser(rigidBody.ownerUid),
+				WorldId:        world.GetId(),
+				SceneId:        rigidBody.sceneId,
+				EntityId:       rigidBody.entityId,
+				GadgetId:       rigidBody.gadgetId,
+				AvatarEntityId: rigidBody.avatarEntityId,
+				HitType:        rigidBody.hit.HitType,
+				HitEntityId:    rigidBody.hit.EntityId,
+				HitPos:         rigidBody.hit.Pos,
+			})
+		}
+	}
+}
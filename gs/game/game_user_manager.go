@@ -14,6 +14,7 @@ import (
 	"hk4e/protocol/proto"
 
 	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // 玩家管理器
@@ -26,6 +27,7 @@ type UserManager struct {
 	db                  *dao.Dao                 // db对象
 	playerMap           map[uint32]*model.Player // 内存玩家数据
 	saveUserChan        chan *SaveUserData       // 用于主协程发送玩家数据给定时保存协程
+	mailDbOpChan        chan *MailDbOp           // 邮件db操作队列 单协程按提交顺序执行
 	remotePlayerMap     map[uint32]string        // 远程玩家 key:userId value:玩家所在gs的appid
 	remotePlayerMapLock sync.RWMutex
 }
@@ -35,8 +37,10 @@ func NewUserManager(db *dao.Dao) (r *UserManager) {
 	r.db = db
 	r.playerMap = make(map[uint32]*model.Player)
 	r.saveUserChan = make(chan *SaveUserData, 1)
+	r.mailDbOpChan = make(chan *MailDbOp, 1000)
 	r.remotePlayerMap = make(map[uint32]string)
 	go r.saveUserHandle()
+	go r.mailDbOpHandle()
 	r.syncRemotePlayerMap()
 	go r.autoSyncRemotePlayerMap()
 	return r
@@ -146,6 +150,7 @@ func (u *UserManager) UserLoginLoad(userId uint32, clientSeq uint32, gateAppId s
 			u.SaveUserToRedisSync(player)
 			u.ChangeUserDbState(player, model.DbNormal)
 			player.ChatMsgMap = u.LoadUserChatMsgFromDbSync(userId)
+			player.MailMap = u.LoadUserMailFromDbSync(player)
 			sceneBlockMap := GAME.LoadSceneBlockSync(player.PlayerId, player.GetSceneId(), player.GetPos())
 			if sceneBlockMap != nil {
 				player.SceneBlockMap = sceneBlockMap
@@ -186,6 +191,7 @@ func (u *UserManager) OnlineUser(player *model.Player) {
 type ChangeGsInfo struct {
 	IsChangeGs     bool
 	JoinHostUserId uint32
+	TargetGsAppId  string // 指定迁移的目标gs 为空则迁移到JoinHostUserId所在的gs
 }
 
 type PlayerOfflineInfo struct {
@@ -210,6 +216,7 @@ func (u *UserManager) UserOfflineSave(player *model.Player, changeGsInfo *Change
 	}
 	if player.OfflineClear {
 		go u.DeleteUserAllChatMsgToDbSync(player.PlayerId)
+		go u.DeleteUserAllGachaRecordToDbSync(player.PlayerId)
 		newPlayer := GAME.CreatePlayer(player.PlayerId)
 		newPlayer.DbState = player.DbState
 		player = newPlayer
@@ -280,7 +287,10 @@ func (u *UserManager) OfflineUser(player *model.Player, changeGsInfo *ChangeGsIn
 	})
 	atomic.AddInt32(&ONLINE_PLAYER_NUM, -1)
 	if changeGsInfo.IsChangeGs {
-		gsAppId := USER_MANAGER.GetRemoteUserGsAppId(changeGsInfo.JoinHostUserId)
+		gsAppId := changeGsInfo.TargetGsAppId
+		if gsAppId == "" {
+			gsAppId = USER_MANAGER.GetRemoteUserGsAppId(changeGsInfo.JoinHostUserId)
+		}
 		GAME.messageQueue.SendToGate(player.GateAppId, &mq.NetMsg{
 			MsgType: mq.MsgTypeServer,
 			EventId: mq.ServerUserGsChangeNotify,
@@ -567,6 +577,7 @@ func (u *UserManager) saveUserHandle() {
 	go func() {
 		for {
 			saveUserData := <-u.saveUserChan
+			startTime := time.Now().UnixNano()
 			insertPlayerList := make([]*model.Player, 0)
 			updatePlayerList := make([]*model.Player, 0)
 			setPlayerList := make([]*model.Player, 0)
@@ -592,7 +603,10 @@ func (u *UserManager) saveUserHandle() {
 			}
 			u.SaveUserListToDbSync(insertPlayerList, updatePlayerList)
 			u.SaveUserListToRedisSync(setPlayerList)
+			METRICS_SAVE_COST.Observe(float64(time.Now().UnixNano()-startTime) / 1e9)
 			if saveUserData.exitSave {
+				// 等待邮件写入队列执行完毕
+				u.waitMailDbOp()
 				// 停服落地玩家数据完毕 通知APP主协程关闭程序
 				EXIT_SAVE_FIN_CHAN <- true
 			}
@@ -807,6 +821,163 @@ func (u *UserManager) DeleteUserAllChatMsgToDbSync(uid uint32) {
 	}
 }
 
+// LoadUserMailFromDbSync 加载玩家邮件 删除过期邮件 为离线投递的邮件分配id 领取新的全服邮件
+func (u *UserManager) LoadUserMailFromDbSync(player *model.Player) map[uint32]*model.Mail {
+	// 先等待本gs之前提交的邮件写入完成 避免读到旧数据
+	u.waitMailDbOp()
+	mailMap := make(map[uint32]*model.Mail)
+	mailList, err := u.db.QueryMailListByUid(player.PlayerId)
+	if err != nil {
+		logger.Error("query mail list error: %v", err)
+		return mailMap
+	}
+	now := uint32(time.Now().Unix())
+	dbMail := player.GetDbMail()
+	// 存档可能晚于邮件写入 以邮件表中最大的id为准
+	for _, mail := range mailList {
+		if mail.MailId > dbMail.MailIdSeq {
+			dbMail.MailIdSeq = mail.MailId
+		}
+	}
+	deleteIdList := make([]primitive.ObjectID, 0)
+	updateMailList := make([]*model.Mail, 0)
+	for _, mail := range mailList {
+		if mail.ExpireTime <= now {
+			deleteIdList = append(deleteIdList, mail.ID)
+			continue
+		}
+		_, exist := mailMap[mail.MailId]
+		if mail.MailId == 0 || exist {
+			mail.MailId = dbMail.NextMailId()
+			updateMailList = append(updateMailList, mail)
+		}
+		mailMap[mail.MailId] = mail
+	}
+	insertMailList := make([]*model.Mail, 0)
+	globalMailList, err := u.db.QueryGlobalMailList(dbMail.GlobalMailId, now)
+	if err != nil {
+		logger.Error("query global mail list error: %v", err)
+	} else {
+		for _, globalMail := range globalMailList {
+			mail := newGlobalMail(player.PlayerId, globalMail)
+			mail.MailId = dbMail.NextMailId()
+			mailMap[mail.MailId] = mail
+			insertMailList = append(insertMailList, mail)
+			dbMail.GlobalMailId = globalMail.GlobalMailId
+		}
+	}
+	u.DeleteMailListToDbSync(deleteIdList)
+	u.UpdateMailListToDbSync(updateMailList)
+	u.InsertMailListToDbSync(insertMailList)
+	return mailMap
+}
+
+// LoadGlobalMailFromDbSync 查询id大于globalMailId且未过期的全服邮件
+func (u *UserManager) LoadGlobalMailFromDbSync(globalMailId uint32, now uint32) []*model.GlobalMail {
+	globalMailList, err := u.db.QueryGlobalMailList(globalMailId, now)
+	if err != nil {
+		logger.Error("query global mail list error: %v", err)
+		return nil
+	}
+	return globalMailList
+}
+
+// MailDbOp 邮件db操作 同一个gs上的邮件写入都经过同一个队列 保证同一封邮件的插入 修改和删除按顺序执行
+type MailDbOp struct {
+	insertMailList []*model.Mail
+	updateMailList []*model.Mail
+	deleteIdList   []primitive.ObjectID
+	doneChan       chan struct{} // 执行到此处时关闭 用于等待之前提交的操作完成
+}
+
+func (u *UserManager) mailDbOpHandle() {
+	for {
+		mailDbOp := <-u.mailDbOpChan
+		u.DeleteMailListToDbSync(mailDbOp.deleteIdList)
+		u.UpdateMailListToDbSync(mailDbOp.updateMailList)
+		u.InsertMailListToDbSync(mailDbOp.insertMailList)
+		if mailDbOp.doneChan != nil {
+			close(mailDbOp.doneChan)
+		}
+	}
+}
+
+// waitMailDbOp 等待之前提交的邮件db操作全部完成 不能在主协程调用
+func (u *UserManager) waitMailDbOp() {
+	doneChan := make(chan struct{})
+	u.mailDbOpChan <- &MailDbOp{doneChan: doneChan}
+	<-doneChan
+}
+
+func (u *UserManager) InsertMailListToDbAsync(mailList []*model.Mail) {
+	if len(mailList) == 0 {
+		return
+	}
+	u.mailDbOpChan <- &MailDbOp{insertMailList: mailList}
+}
+
+func (u *UserManager) UpdateMailListToDbAsync(mailList []*model.Mail) {
+	if len(mailList) == 0 {
+		return
+	}
+	u.mailDbOpChan <- &MailDbOp{updateMailList: mailList}
+}
+
+func (u *UserManager) DeleteMailListToDbAsync(idList []primitive.ObjectID) {
+	if len(idList) == 0 {
+		return
+	}
+	u.mailDbOpChan <- &MailDbOp{deleteIdList: idList}
+}
+
+func (u *UserManager) InsertMailListToDbSync(mailList []*model.Mail) {
+	err := u.db.InsertMailList(mailList)
+	if err != nil {
+		logger.Error("insert mail list error: %v", err)
+		return
+	}
+}
+
+func (u *UserManager) UpdateMailListToDbSync(mailList []*model.Mail) {
+	err := u.db.UpdateMailList(mailList)
+	if err != nil {
+		logger.Error("update mail list error: %v", err)
+		return
+	}
+}
+
+func (u *UserManager) DeleteMailListToDbSync(idList []primitive.ObjectID) {
+	err := u.db.DeleteMailList(idList)
+	if err != nil {
+		logger.Error("delete mail list error: %v", err)
+		return
+	}
+}
+
+func (u *UserManager) SaveUserGachaRecordListToDbSync(gachaRecordList []*model.GachaRecord) {
+	err := u.db.InsertGachaRecordList(gachaRecordList)
+	if err != nil {
+		logger.Error("insert gacha record list error: %v", err)
+		return
+	}
+}
+
+func (u *UserManager) SaveChatAuditToDbSync(chatAudit *model.ChatAudit) {
+	err := u.db.InsertChatAudit(chatAudit)
+	if err != nil {
+		logger.Error("insert chat audit error: %v", err)
+		return
+	}
+}
+
+func (u *UserManager) DeleteUserAllGachaRecordToDbSync(uid uint32) {
+	err := u.db.DeleteAllGachaRecordByUid(uid)
+	if err != nil {
+		logger.Error("delete gacha record error: %v", err)
+		return
+	}
+}
+
 func (u *UserManager) LoadUserFromRedisSync(userId uint32) *model.Player {
 	player := u.db.GetRedisPlayer(userId)
 	return player
@@ -0,0 +1,216 @@
+package game
+
+import (
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gdconf"
+)
+
+const (
+	DungeonStateRunning = iota // 进行中
+	DungeonStateSuccess        // 通关成功
+	DungeonStateFail           // 通关失败
+)
+
+// Dungeon 地牢副本实例 跟随地牢场景创建和销毁
+type Dungeon struct {
+	dungeonId        uint32
+	scene            *Scene
+	state            int
+	beginTime        int64                        // 开始时间
+	settleTime       int64                        // 结算时间
+	killMonsterCount uint32                       // 击杀怪物数量
+	passCondMap      map[int]bool                 // 已满足的通关条件 key:条件索引
+	challengeMap     map[uint32]*DungeonChallenge // 挑战 key:挑战索引
+	statueDropMap    map[uint32]bool              // 已领取神像奖励的玩家 key:uid
+}
+
+// DungeonChallenge 地牢挑战
+type DungeonChallenge struct {
+	challengeIndex uint32
+	challengeId    uint32
+	challengeType  int32
+	groupId        uint32   // 发起挑战的group
+	paramList      []uint32 // 挑战参数
+	targetGroupId  uint32   // 计数的group
+	goal           uint32   // 目标数量
+	timeLimit      uint32   // 时间限制 单位秒 0为不限时
+	progress       uint32   // 当前进度
+	beginTime      int64    // 开始时间
+	finished       bool
+}
+
+func (s *Scene) GetDungeon() *Dungeon {
+	return s.dungeon
+}
+
+func (s *Scene) CreateDungeon(dungeonId uint32) *Dungeon {
+	s.dungeon = &Dungeon{
+		dungeonId:        dungeonId,
+		scene:            s,
+		state:            DungeonStateRunning,
+		beginTime:        time.Now().UnixMilli(),
+		settleTime:       0,
+		killMonsterCount: 0,
+		passCondMap:      make(map[int]bool),
+		challengeMap:     make(map[uint32]*DungeonChallenge),
+		statueDropMap:    make(map[uint32]bool),
+	}
+	return s.dungeon
+}
+
+func (d *Dungeon) GetDungeonId() uint32 {
+	return d.dungeonId
+}
+
+func (d *Dungeon) GetScene() *Scene {
+	return d.scene
+}
+
+func (d *Dungeon) GetState() int {
+	return d.state
+}
+
+func (d *Dungeon) IsRunning() bool {
+	return d.state == DungeonStateRunning
+}
+
+// GetUseTime 获取已用时间 单位秒
+func (d *Dungeon) GetUseTime() uint32 {
+	endTime := time.Now().UnixMilli()
+	if d.settleTime != 0 {
+		endTime = d.settleTime
+	}
+	return uint32((endTime - d.beginTime) / 1000)
+}
+
+func (d *Dungeon) Settle(success bool) {
+	if success {
+		d.state = DungeonStateSuccess
+	} else {
+		d.state = DungeonStateFail
+	}
+	d.settleTime = time.Now().UnixMilli()
+}
+
+func (d *Dungeon) GetDungeonDataConfig() *gdconf.DungeonData {
+	return gdconf.GetDungeonDataById(int32(d.dungeonId))
+}
+
+func (d *Dungeon) GetChallenge(challengeIndex uint32) *DungeonChallenge {
+	return d.challengeMap[challengeIndex]
+}
+
+func (d *Dungeon) GetAllChallenge() map[uint32]*DungeonChallenge {
+	return d.challengeMap
+}
+
+func (d *Dungeon) AddChallenge(challenge *DungeonChallenge) {
+	d.challengeMap[challenge.challengeIndex] = challenge
+}
+
+func (d *Dungeon) AddKillMonsterCount() uint32 {
+	d.killMonsterCount++
+	return d.killMonsterCount
+}
+
+func (d *Dungeon) SetPassCond(index int) {
+	d.passCondMap[index] = true
+}
+
+func (d *Dungeon) IsPassCond(index int) bool {
+	return d.passCondMap[index]
+}
+
+// UpdatePassCond 根据触发的条件类型和参数标记已满足的通关条件
+func (d *Dungeon) UpdatePassCond(passData *gdconf.DungeonPassData, condType int32, param []int32) {
+	for index, passCond := range passData.CondList {
+		if passCond.Type != condType || d.IsPassCond(index) {
+			continue
+		}
+		switch condType {
+		case constant.DUNGEON_PASS_COND_KILL_MONSTER_COUNT:
+			// 击杀数量 参数1:目标数量
+			if len(passCond.Param) < 1 || len(param) < 1 || param[0] < passCond.Param[0] {
+				continue
+			}
+		default:
+			if !matchParamPrefix(passCond.Param, param) {
+				continue
+			}
+		}
+		d.SetPassCond(index)
+	}
+}
+
+// CheckPass 检查通关条件是否满足 限时条件只作为失败条件 不参与通关判断
+func (d *Dungeon) CheckPass(passData *gdconf.DungeonPassData) bool {
+	passCount, condCount := 0, 0
+	for index, passCond := range passData.CondList {
+		if passCond.Type == constant.DUNGEON_PASS_COND_IN_TIME {
+			continue
+		}
+		condCount++
+		if d.IsPassCond(index) {
+			passCount++
+		}
+	}
+	if condCount == 0 || passCount == 0 {
+		return false
+	}
+	if passData.CondCompose != gdconf.DungeonPassCondComposeOr && passCount != condCount {
+		return false
+	}
+	return true
+}
+
+func (d *Dungeon) IsStatueDropTaken(uid uint32) bool {
+	return d.statueDropMap[uid]
+}
+
+func (d *Dungeon) SetStatueDropTaken(uid uint32) {
+	d.statueDropMap[uid] = true
+}
+
+// GetElapsedTime 获取挑战已进行的时间 单位秒
+func (c *DungeonChallenge) GetElapsedTime() uint32 {
+	return uint32((time.Now().UnixMilli() - c.beginTime) / 1000)
+}
+
+// AddKillProgress 击杀计数类挑战增加进度 返回是否计入
+func (c *DungeonChallenge) AddKillProgress(groupId uint32) bool {
+	if c.finished {
+		return false
+	}
+	if c.challengeType != constant.CHALLENGE_TYPE_KILL_COUNT &&
+		c.challengeType != constant.CHALLENGE_TYPE_KILL_COUNT_IN_TIME {
+		return false
+	}
+	if c.targetGroupId != groupId {
+		return false
+	}
+	c.progress++
+	return true
+}
+
+func (c *DungeonChallenge) IsGoalReached() bool {
+	return c.progress >= c.goal
+}
+
+// IsTimeout 限时挑战是否已到时间
+func (c *DungeonChallenge) IsTimeout() bool {
+	return c.timeLimit != 0 && c.GetElapsedTime() >= c.timeLimit
+}
+
+// goalParamIndex 挑战目标数量在参数列表中的索引
+func (c *DungeonChallenge) goalParamIndex() uint32 {
+	switch c.challengeType {
+	case constant.CHALLENGE_TYPE_KILL_COUNT:
+		return 1
+	case constant.CHALLENGE_TYPE_KILL_COUNT_IN_TIME:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,159 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gdconf"
+)
+
+func newTestDungeon() *Dungeon {
+	scene := &Scene{}
+	return scene.CreateDungeon(1001)
+}
+
+func TestDungeonSettle(t *testing.T) {
+	dungeon := newTestDungeon()
+	if !dungeon.IsRunning() {
+		t.Fatalf("new dungeon should be running")
+	}
+	dungeon.beginTime = time.Now().UnixMilli() - 5000
+	dungeon.Settle(true)
+	if dungeon.IsRunning() || dungeon.GetState() != DungeonStateSuccess {
+		t.Fatalf("dungeon state error, state: %v", dungeon.GetState())
+	}
+	// 结算后用时不再增长
+	useTime := dungeon.GetUseTime()
+	if useTime != 5 {
+		t.Fatalf("use time error, useTime: %v", useTime)
+	}
+	dungeon.beginTime -= 10000
+	dungeon.settleTime -= 10000
+	if dungeon.GetUseTime() != useTime {
+		t.Fatalf("use time changed after settle")
+	}
+	dungeon = newTestDungeon()
+	dungeon.Settle(false)
+	if dungeon.GetState() != DungeonStateFail {
+		t.Fatalf("dungeon state error, state: %v", dungeon.GetState())
+	}
+}
+
+func TestDungeonPassCondAnd(t *testing.T) {
+	passData := &gdconf.DungeonPassData{
+		CondCompose: gdconf.DungeonPassCondComposeAnd,
+		CondList: []*gdconf.DungeonPassCond{
+			{Type: constant.DUNGEON_PASS_COND_KILL_MONSTER, Param: []int32{20010101}},
+			{Type: constant.DUNGEON_PASS_COND_FINISH_CHALLENGE, Param: []int32{1}},
+			{Type: constant.DUNGEON_PASS_COND_IN_TIME, Param: []int32{300}},
+		},
+	}
+	dungeon := newTestDungeon()
+	dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_KILL_MONSTER, []int32{20010102})
+	if dungeon.IsPassCond(0) || dungeon.CheckPass(passData) {
+		t.Fatalf("other monster should not match")
+	}
+	dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_KILL_MONSTER, []int32{20010101})
+	if !dungeon.IsPassCond(0) {
+		t.Fatalf("kill monster cond should be passed")
+	}
+	if dungeon.CheckPass(passData) {
+		t.Fatalf("and compose should wait all conds")
+	}
+	dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_FINISH_CHALLENGE, []int32{1})
+	// 限时条件不参与通关判断
+	if !dungeon.CheckPass(passData) {
+		t.Fatalf("all conds passed but check pass failed")
+	}
+}
+
+func TestDungeonPassCondOr(t *testing.T) {
+	passData := &gdconf.DungeonPassData{
+		CondCompose: gdconf.DungeonPassCondComposeOr,
+		CondList: []*gdconf.DungeonPassCond{
+			{Type: constant.DUNGEON_PASS_COND_KILL_GROUP_MONSTER, Param: []int32{240001001}},
+			{Type: constant.DUNGEON_PASS_COND_KILL_MONSTER_COUNT, Param: []int32{3}},
+		},
+	}
+	dungeon := newTestDungeon()
+	for count := int32(1); count < 3; count++ {
+		dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_KILL_MONSTER_COUNT, []int32{count})
+	}
+	if dungeon.CheckPass(passData) {
+		t.Fatalf("kill count not reached")
+	}
+	dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_KILL_MONSTER_COUNT, []int32{3})
+	if !dungeon.CheckPass(passData) {
+		t.Fatalf("or compose should pass with one cond")
+	}
+}
+
+func TestDungeonPassCondOnlyInTime(t *testing.T) {
+	passData := &gdconf.DungeonPassData{
+		CondCompose: gdconf.DungeonPassCondComposeAnd,
+		CondList: []*gdconf.DungeonPassCond{
+			{Type: constant.DUNGEON_PASS_COND_IN_TIME, Param: []int32{60}},
+		},
+	}
+	dungeon := newTestDungeon()
+	dungeon.UpdatePassCond(passData, constant.DUNGEON_PASS_COND_IN_TIME, []int32{60})
+	if dungeon.CheckPass(passData) {
+		t.Fatalf("in time cond should not pass dungeon")
+	}
+}
+
+func TestDungeonChallengeKillProgress(t *testing.T) {
+	challenge := &DungeonChallenge{
+		challengeType: constant.CHALLENGE_TYPE_KILL_COUNT,
+		targetGroupId: 100,
+		goal:          2,
+		beginTime:     time.Now().UnixMilli(),
+	}
+	if challenge.AddKillProgress(200) {
+		t.Fatalf("other group kill should not count")
+	}
+	if !challenge.AddKillProgress(100) || challenge.IsGoalReached() {
+		t.Fatalf("challenge progress error, progress: %v", challenge.progress)
+	}
+	if !challenge.AddKillProgress(100) || !challenge.IsGoalReached() {
+		t.Fatalf("challenge goal should be reached, progress: %v", challenge.progress)
+	}
+	challenge.finished = true
+	if challenge.AddKillProgress(100) {
+		t.Fatalf("finished challenge should not count")
+	}
+	if challenge.goalParamIndex() != 1 {
+		t.Fatalf("goal param index error")
+	}
+	survive := &DungeonChallenge{
+		challengeType: constant.CHALLENGE_TYPE_SURVIVE,
+		timeLimit:     10,
+		beginTime:     time.Now().UnixMilli(),
+	}
+	if survive.AddKillProgress(0) {
+		t.Fatalf("survive challenge should not count kill")
+	}
+}
+
+func TestDungeonChallengeTimeout(t *testing.T) {
+	challenge := &DungeonChallenge{
+		challengeType: constant.CHALLENGE_TYPE_KILL_COUNT_IN_TIME,
+		timeLimit:     30,
+		beginTime:     time.Now().UnixMilli(),
+	}
+	if challenge.IsTimeout() {
+		t.Fatalf("challenge should not timeout")
+	}
+	challenge.beginTime -= 31 * 1000
+	if !challenge.IsTimeout() {
+		t.Fatalf("challenge should timeout")
+	}
+	noLimit := &DungeonChallenge{
+		challengeType: constant.CHALLENGE_TYPE_KILL_COUNT,
+		beginTime:     time.Now().UnixMilli() - 3600*1000,
+	}
+	if noLimit.IsTimeout() {
+		t.Fatalf("challenge without time limit should not timeout")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"time"
 
+	"hk4e/common/config"
 	"hk4e/common/constant"
 	"hk4e/gdconf"
 	"hk4e/gs/model"
@@ -27,6 +28,8 @@ type WorldManager struct {
 	aiWorld             *World                             // 本服的Ai玩家世界
 	sceneBlockAoiMap    map[uint32]*alg.AoiManager         // 场景区块aoi
 	sceneEntityAoiMap   map[uint32]map[int]*alg.AoiManager // 场景实体aoi
+	sceneNavMeshMap     map[uint32]*alg.NavMesh            // 场景导航网格
+	physicsConfig       *PhysicsConfig                     // 物理引擎配置
 	multiplayerWorldNum uint32                             // 本服当前的多人世界数量
 }
 
@@ -35,6 +38,13 @@ func NewWorldManager(snowflake *alg.SnowflakeWorker) (r *WorldManager) {
 	r.worldMap = make(map[uint64]*World)
 	r.snowflake = snowflake
 	r.LoadSceneAoi()
+	r.LoadSceneNavMesh()
+	physicsConfig, err := LoadPhysicsConfig()
+	if err != nil {
+		logger.Error("load physics config error: %v", err)
+		physicsConfig = NewDefaultPhysicsConfig()
+	}
+	r.physicsConfig = physicsConfig
 	r.multiplayerWorldNum = 0
 	return r
 }
@@ -63,13 +73,16 @@ func (w *WorldManager) CreateWorld(owner *model.Player) *World {
 		chatMsgList:          make([]*proto.ChatInfo, 0),
 		playerFirstEnterMap:  make(map[uint32]int64),
 		waitEnterPlayerMap:   make(map[uint32]int64),
+		matchDungeonMap:      make(map[uint32]uint32),
 		multiplayerTeam:      CreateMultiplayerTeam(),
 		peerList:             make([]*model.Player, 0),
 		aiWorldAoi:           nil,
-		bulletPhysicsEngine:  nil,
+		physicsEngine:        nil,
+		monsterAi:            nil,
 	}
 	world.mpLevelEntityId = world.GetNextWorldEntityId(constant.ENTITY_TYPE_MP_LEVEL)
 	w.worldMap[worldId] = world
+	world.NewPhysicsEngine(w.physicsConfig)
 
 	if w.IsAiWorld(world) {
 		aoiManager := alg.NewAoiManager()
@@ -77,7 +90,9 @@ func (w *WorldManager) CreateWorld(owner *model.Player) *World {
 		aoiManager.Init3DRectAoiManager(120, 12, 120, true)
 		world.aiWorldAoi = aoiManager
 		logger.Info("ai world aoi init finish")
-		world.NewPhysicsEngine()
+		if config.GetConfig().Hk4e.MonsterAiEnable {
+			world.NewMonsterAi()
+		}
 	}
 
 	return world
@@ -192,6 +207,43 @@ func (w *WorldManager) LoadSceneAoi() {
 	}
 }
 
+// LoadSceneNavMesh 加载导航网格目录下的全部场景导航网格
+func (w *WorldManager) LoadSceneNavMesh() {
+	navMeshMap, err := alg.LoadNavMeshDir(config.GetConfig().Hk4e.NavMeshPath)
+	if navMeshMap == nil {
+		w.sceneNavMeshMap = make(map[uint32]*alg.NavMesh)
+		logger.Warn("read nav mesh dir error: %v", err)
+		return
+	}
+	if err != nil {
+		logger.Error("load nav mesh error: %v", err)
+	}
+	w.sceneNavMeshMap = navMeshMap
+	logger.Info("load scene nav mesh finish, scene num: %v", len(w.sceneNavMeshMap))
+}
+
+// GetSceneNavMesh 获取场景导航网格 没有则返回空
+func (w *WorldManager) GetSceneNavMesh(sceneId uint32) *alg.NavMesh {
+	return w.sceneNavMeshMap[sceneId]
+}
+
+func (w *WorldManager) GetPhysicsConfig() *PhysicsConfig {
+	return w.physicsConfig
+}
+
+// ReloadPhysicsConfig 重新加载物理引擎配置 失败时保留原有配置 已存在的刚体沿用创建时的抛射物配置
+func (w *WorldManager) ReloadPhysicsConfig() error {
+	physicsConfig, err := LoadPhysicsConfig()
+	if err != nil {
+		return err
+	}
+	w.physicsConfig = physicsConfig
+	for _, world := range w.worldMap {
+		world.GetPhysicsEngine().SetPhysicsConfig(physicsConfig)
+	}
+	return nil
+}
+
 func (w *World) IsValidScenePos(sceneId uint32, x, y, z float32) bool {
 	aoiManager, exist := WORLD_MANAGER.sceneBlockAoiMap[sceneId]
 	if !exist {
@@ -234,14 +286,16 @@ type World struct {
 	chatMsgList          []*proto.ChatInfo             // 世界聊天消息列表
 	playerFirstEnterMap  map[uint32]int64              // 玩家第一次进入世界的时间 key:uid value:进入时间
 	waitEnterPlayerMap   map[uint32]int64              // 进入世界的玩家等待列表 key:uid value:开始时间
+	matchDungeonMap      map[uint32]uint32             // 匹配成功后等待进入地牢的玩家 key:uid value:地牢id
 	multiplayerTeam      *MultiplayerTeam              // 多人队伍
 	peerList             []*model.Player               // 玩家编号列表
 	aiWorldAoi           *alg.AoiManager               // ai世界的aoi管理器
-	bulletPhysicsEngine  *PhysicsEngine                // 蓄力箭子弹物理引擎
+	physicsEngine        *PhysicsEngine                // 子弹物理引擎
+	monsterAi            *MonsterAi                    // 服务器怪物ai 为空则怪物由客户端驱动
 }
 
-func (w *World) GetBulletPhysicsEngine() *PhysicsEngine {
-	return w.bulletPhysicsEngine
+func (w *World) GetPhysicsEngine() *PhysicsEngine {
+	return w.physicsEngine
 }
 
 func (w *World) GetId() uint64 {
@@ -856,6 +910,20 @@ func (w *World) RemoveWaitPlayer(uid uint32) {
 	delete(w.waitEnterPlayerMap, uid)
 }
 
+func (w *World) AddMatchDungeonPlayer(uid uint32, dungeonId uint32) {
+	w.matchDungeonMap[uid] = dungeonId
+}
+
+// PopMatchDungeonId 取出玩家等待进入的匹配地牢 没有则返回0
+func (w *World) PopMatchDungeonId(uid uint32) uint32 {
+	dungeonId, exist := w.matchDungeonMap[uid]
+	if !exist {
+		return 0
+	}
+	delete(w.matchDungeonMap, uid)
+	return dungeonId
+}
+
 func (w *World) CreateScene(sceneId uint32) *Scene {
 	scene := &Scene{
 		id:         sceneId,
@@ -871,6 +939,33 @@ func (w *World) CreateScene(sceneId uint32) *Scene {
 	return scene
 }
 
+// DestroyScene 销毁场景 同时清理场景内的实体 group 地牢实例 以及场景相关的定时器 怪物ai和物理刚体
+func (w *World) DestroyScene(sceneId uint32) {
+	scene, exist := w.sceneMap[sceneId]
+	if !exist {
+		return
+	}
+	logger.Info("destroy scene, sceneId: %v, worldId: %v", sceneId, w.id)
+	for groupId, group := range scene.groupMap {
+		for suiteId := range group.suiteMap {
+			scene.RemoveGroupSuite(groupId, suiteId)
+		}
+	}
+	for entityId := range scene.entityMap {
+		scene.DestroyEntity(entityId)
+		w.physicsEngine.DestroyRigidBody(entityId)
+		if w.monsterAi != nil {
+			w.monsterAi.RemoveBrain(entityId)
+		}
+	}
+	// 场景区块和实体的aoi是按场景id共享的静态数据 玩家离开场景时已移出 这里只需清理玩家身上挂的场景定时器
+	for _, player := range w.playerMap {
+		TICK_MANAGER.DestroyUserSceneTimer(player.PlayerId, sceneId)
+	}
+	scene.dungeon = nil
+	delete(w.sceneMap, sceneId)
+}
+
 func (w *World) GetSceneById(sceneId uint32) *Scene {
 	// 场景是取时创建 可以简化代码不判空
 	scene, exist := w.sceneMap[sceneId]
@@ -23,6 +23,7 @@ type Scene struct {
 	createTime  int64              // 场景创建时间
 	meeoIndex   uint32             // 客户端风元素染色同步协议的计数器
 	monsterWudi bool               // 是否开启场景内怪物无敌
+	dungeon     *Dungeon           // 地牢副本实例 非地牢场景为空
 }
 
 func (s *Scene) GetId() uint32 {
@@ -97,6 +98,10 @@ func (s *Scene) RemovePlayer(player *model.Player) {
 		s.DestroyEntity(worldAvatar.avatarEntityId)
 		s.DestroyEntity(worldAvatar.weaponEntityId)
 	}
+	// 地牢场景所有玩家都离开后销毁
+	if s.dungeon != nil && len(s.playerMap) == 0 {
+		s.world.DestroyScene(s.id)
+	}
 }
 
 func (s *Scene) CreateEntityAvatar(player *model.Player, avatarId uint32) uint32 {
@@ -147,6 +147,9 @@ func RegLuaScriptLibFunc() {
 	gdconf.RegScriptLibFunc("RemoveExtraGroupSuite", RemoveExtraGroupSuite)
 	gdconf.RegScriptLibFunc("ShowReminder", ShowReminder)
 	gdconf.RegScriptLibFunc("KillGroupEntity", KillGroupEntity)
+	gdconf.RegScriptLibFunc("ActiveChallenge", ActiveChallenge)
+	gdconf.RegScriptLibFunc("CauseDungeonSuccess", CauseDungeonSuccess)
+	gdconf.RegScriptLibFunc("CauseDungeonFail", CauseDungeonFail)
 }
 
 type CommonLuaTableParam struct {
@@ -489,7 +492,7 @@ func CreateMonster(luaState *lua.LState) int {
 	luaTableParam := new(CommonLuaTableParam)
 	gdconf.ParseLuaTableToObject[*CommonLuaTableParam](luaTable, luaTableParam)
 	TICK_MANAGER.CreateUserTimer(player.PlayerId, UserTimerActionLuaCreateMonster, uint32(luaTableParam.DelayTime),
-		uint32(groupId), uint32(luaTableParam.ConfigId))
+		uint32(groupId), uint32(luaTableParam.ConfigId), player.GetSceneId())
 	luaState.Push(lua.LNumber(0))
 	return 1
 }
@@ -828,7 +831,7 @@ func CreateGroupTimerEvent(luaState *lua.LState) int {
 	source := luaState.ToString(3)
 	delay := luaState.ToInt(4)
 	TICK_MANAGER.CreateUserTimer(player.PlayerId, UserTimerActionLuaGroupTimerEvent, uint32(delay),
-		uint32(groupId), source)
+		uint32(groupId), source, player.GetSceneId())
 	luaState.Push(lua.LNumber(0))
 	return 1
 }
@@ -981,3 +984,63 @@ func KillGroupEntity(luaState *lua.LState) int {
 	luaState.Push(lua.LNumber(0))
 	return 1
 }
+
+func ActiveChallenge(luaState *lua.LState) int {
+	ctx, ok := luaState.Get(1).(*lua.LTable)
+	if !ok {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	player := GetContextPlayer(ctx, luaState)
+	if player == nil {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	groupId, ok := luaState.GetField(ctx, "groupId").(lua.LNumber)
+	if !ok {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	challengeIndex := luaState.ToInt(2)
+	challengeId := luaState.ToInt(3)
+	paramList := make([]uint32, 0)
+	for i := 4; i <= luaState.GetTop(); i++ {
+		paramList = append(paramList, uint32(luaState.ToInt(i)))
+	}
+	ok = GAME.DungeonStartChallenge(player, uint32(groupId), uint32(challengeIndex), uint32(challengeId), paramList)
+	if !ok {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	luaState.Push(lua.LNumber(0))
+	return 1
+}
+
+func CauseDungeonSuccess(luaState *lua.LState) int {
+	return causeDungeonSettle(luaState, true)
+}
+
+func CauseDungeonFail(luaState *lua.LState) int {
+	return causeDungeonSettle(luaState, false)
+}
+
+func causeDungeonSettle(luaState *lua.LState, success bool) int {
+	ctx, ok := luaState.Get(1).(*lua.LTable)
+	if !ok {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	player := GetContextPlayer(ctx, luaState)
+	if player == nil {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	dungeon := GAME.GetPlayerDungeon(player)
+	if dungeon == nil {
+		luaState.Push(lua.LNumber(-1))
+		return 1
+	}
+	GAME.DungeonSettle(player, dungeon, success)
+	luaState.Push(lua.LNumber(0))
+	return 1
+}
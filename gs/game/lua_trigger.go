@@ -1,6 +1,8 @@
 package game
 
 import (
+	"strconv"
+
 	"hk4e/common/constant"
 	"hk4e/gdconf"
 	"hk4e/gs/model"
@@ -367,3 +369,44 @@ func (g *Game) TimerEventTriggerCheck(player *model.Player, group *Group, source
 		}
 	})
 }
+
+// ChallengeSuccessTriggerCheck 挑战成功触发器检测
+func (g *Game) ChallengeSuccessTriggerCheck(player *model.Player, group *Group, challengeIndex uint32) {
+	g.challengeTriggerCheck(player, group, challengeIndex, constant.LUA_EVENT_CHALLENGE_SUCCESS)
+}
+
+// ChallengeFailTriggerCheck 挑战失败触发器检测
+func (g *Game) ChallengeFailTriggerCheck(player *model.Player, group *Group, challengeIndex uint32) {
+	g.challengeTriggerCheck(player, group, challengeIndex, constant.LUA_EVENT_CHALLENGE_FAIL)
+}
+
+func (g *Game) challengeTriggerCheck(player *model.Player, group *Group, challengeIndex uint32, event int32) {
+	source := strconv.Itoa(int(challengeIndex))
+	forEachGroupTrigger(player, group, func(triggerConfig *gdconf.Trigger, groupConfig *gdconf.Group) {
+		if triggerConfig.Event != event {
+			return
+		}
+		if triggerConfig.Source != "" {
+			if triggerConfig.Source != source {
+				return
+			}
+		}
+		if triggerConfig.Condition != "" {
+			cond := CallLuaFunc(groupConfig.GetLuaState(), triggerConfig.Condition,
+				&LuaCtx{uid: player.PlayerId, groupId: uint32(groupConfig.Id)},
+				&LuaEvt{param1: int32(challengeIndex), sourceName: source})
+			if !cond {
+				return
+			}
+		}
+		if triggerConfig.Action != "" {
+			logger.Debug("scene group trigger do action, trigger: %+v, uid: %v", triggerConfig, player.PlayerId)
+			ok := CallLuaFunc(groupConfig.GetLuaState(), triggerConfig.Action,
+				&LuaCtx{uid: player.PlayerId, groupId: uint32(groupConfig.Id)},
+				&LuaEvt{param1: int32(challengeIndex), sourceName: source})
+			if !ok {
+				logger.Error("trigger action fail, trigger: %+v, uid: %v", triggerConfig, player.PlayerId)
+			}
+		}
+	})
+}
@@ -91,6 +91,32 @@ func (g *Game) PrivateChatReq(player *model.Player, payloadMsg pb.Message) {
 	targetUid := req.TargetUid
 	content := req.Content
 
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdPrivateChat, &PluginEventPrivateChat{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		Req:         req,
+	}) {
+		g.SendError(cmd.PrivateChatRsp, player, &proto.PrivateChatRsp{})
+		return
+	}
+
+	dbMute := player.GetDbMute()
+	if dbMute.IsMuteNow(uint32(time.Now().Unix())) {
+		g.SendError(cmd.PrivateChatRsp, player, &proto.PrivateChatRsp{
+			ChatForbiddenEndtime: dbMute.ChatForbiddenEndTime(),
+		}, proto.Retcode_RET_CHAT_FORBIDDEN)
+		return
+	}
+
+	// 发给系统的是命令 不做过滤
+	isSystem := targetUid == COMMAND_MANAGER.system.PlayerId
+	now := uint32(time.Now().Unix())
+	if !isSystem && !CHAT_FILTER_MANAGER.CheckRate(player, targetUid, now) {
+		g.SendError(cmd.PrivateChatRsp, player, &proto.PrivateChatRsp{}, proto.Retcode_RET_CHAT_FREQUENTLY)
+		return
+	}
+
 	// 根据发送的类型发送消息
 	switch content.(type) {
 	case *proto.PrivateChatReq_Text:
@@ -99,6 +125,14 @@ func (g *Game) PrivateChatReq(player *model.Player, payloadMsg pb.Message) {
 			g.SendError(cmd.PrivateChatRsp, player, &proto.PrivateChatRsp{}, proto.Retcode_RET_PRIVATE_CHAT_CONTENT_TOO_LONG)
 			return
 		}
+		if !isSystem {
+			filterText, ok := CHAT_FILTER_MANAGER.FilterText(player, targetUid, text, now)
+			if !ok {
+				g.SendError(cmd.PrivateChatRsp, player, &proto.PrivateChatRsp{}, proto.Retcode_RET_CHAT_FORBIDDEN)
+				return
+			}
+			text = filterText
+		}
 		// 发送私聊文本消息
 		g.SendPrivateChat(player, targetUid, text)
 		// 输入命令 会检测是否为命令的
@@ -139,8 +173,32 @@ func (g *Game) PlayerChatReq(player *model.Player, payloadMsg pb.Message) {
 	channelId := req.ChannelId
 	chatInfo := req.ChatInfo
 
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdPlayerChat, &PluginEventPlayerChat{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		Req:         req,
+	}) {
+		g.SendError(cmd.PlayerChatRsp, player, &proto.PlayerChatRsp{})
+		return
+	}
+
+	dbMute := player.GetDbMute()
+	if dbMute.IsMuteNow(uint32(time.Now().Unix())) {
+		g.SendError(cmd.PlayerChatRsp, player, &proto.PlayerChatRsp{
+			ChatForbiddenEndtime: dbMute.ChatForbiddenEndTime(),
+		}, proto.Retcode_RET_CHAT_FORBIDDEN)
+		return
+	}
+
+	now := uint32(time.Now().Unix())
+	if !CHAT_FILTER_MANAGER.CheckRate(player, 0, now) {
+		g.SendError(cmd.PlayerChatRsp, player, &proto.PlayerChatRsp{}, proto.Retcode_RET_CHAT_FREQUENTLY)
+		return
+	}
+
 	sendChatInfo := &proto.ChatInfo{
-		Time:    uint32(time.Now().Unix()),
+		Time:    now,
 		Uid:     player.PlayerId,
 		Content: nil,
 	}
@@ -150,6 +208,11 @@ func (g *Game) PlayerChatReq(player *model.Player, payloadMsg pb.Message) {
 		if len(text) == 0 {
 			return
 		}
+		text, ok := CHAT_FILTER_MANAGER.FilterText(player, 0, text, now)
+		if !ok {
+			g.SendError(cmd.PlayerChatRsp, player, &proto.PlayerChatRsp{}, proto.Retcode_RET_CHAT_FORBIDDEN)
+			return
+		}
 		sendChatInfo.Content = &proto.ChatInfo_Text{
 			Text: text,
 		}
@@ -367,4 +430,47 @@ func (g *Game) ServerChatMsgNotify(chatMsgInfo *mq.ChatMsgInfo) {
 	}
 }
 
+// SetPlayerMute 设置玩家禁言状态 玩家在其它gs在线时转发过去 全服离线时直接修改离线存档
+func (g *Game) SetPlayerMute(muteInfo *mq.MuteInfo) {
+	if muteInfo == nil {
+		return
+	}
+	userId := muteInfo.UserId
+	player := USER_MANAGER.GetOnlineUser(userId)
+	if player == nil {
+		if USER_MANAGER.GetRemoteUserOnlineState(userId) {
+			gsAppId := USER_MANAGER.GetRemoteUserGsAppId(userId)
+			g.messageQueue.SendToGs(gsAppId, &mq.NetMsg{
+				MsgType: mq.MsgTypeServer,
+				EventId: mq.ServerPlayerMuteNotify,
+				ServerMsg: &mq.ServerMsg{
+					MuteInfo: muteInfo,
+				},
+			})
+			return
+		}
+		player = USER_MANAGER.LoadTempOfflineUser(userId, true)
+		if player == nil {
+			logger.Error("set mute offline player is nil, uid: %v", userId)
+			return
+		}
+		g.updatePlayerMute(player, muteInfo)
+		USER_MANAGER.SaveTempOfflineUser(player)
+		return
+	}
+	g.updatePlayerMute(player, muteInfo)
+}
+
+func (g *Game) updatePlayerMute(player *model.Player, muteInfo *mq.MuteInfo) {
+	dbMute := player.GetDbMute()
+	dbMute.IsMute = muteInfo.IsMute
+	dbMute.EndTime = 0
+	dbMute.Reason = ""
+	if muteInfo.IsMute {
+		dbMute.EndTime = muteInfo.EndTime
+		dbMute.Reason = muteInfo.Reason
+	}
+	logger.Info("set player mute, uid: %v, isMute: %v, endTime: %v, reason: %v", player.PlayerId, dbMute.IsMute, dbMute.EndTime, dbMute.Reason)
+}
+
 /************************************************** 打包封装 **************************************************/
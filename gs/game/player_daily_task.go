@@ -0,0 +1,168 @@
+package game
+
+import (
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gdconf"
+	"hk4e/gs/model"
+	"hk4e/pkg/logger"
+	"hk4e/pkg/random"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+)
+
+const (
+	DailyTaskNum = 4 // 每日委托数量
+)
+
+/************************************************** 游戏功能 **************************************************/
+
+// RefreshDailyTask 刷新每日委托 到达刷新时间才会重新抽取
+func (g *Game) RefreshDailyTask(player *model.Player, notifyClient bool) {
+	dbDailyTask := player.GetDbDailyTask()
+	now := time.Now()
+	if uint32(now.Unix()) < dbDailyTask.NextRefreshTime {
+		return
+	}
+	playerLevel := int32(player.PropMap[constant.PLAYER_PROP_PLAYER_LEVEL])
+	// 按冒险等级筛选委托池
+	poolList := make([]*gdconf.DailyTaskData, 0)
+	weightAll := int32(0)
+	for _, dailyTaskDataConfig := range gdconf.GetDailyTaskDataMap() {
+		if playerLevel < dailyTaskDataConfig.MinPlayerLevel || playerLevel > dailyTaskDataConfig.MaxPlayerLevel {
+			continue
+		}
+		poolList = append(poolList, dailyTaskDataConfig)
+		weightAll += dailyTaskDataConfig.Weight
+	}
+	// 按权重不放回抽取
+	taskList := make([]*model.DailyTask, 0, DailyTaskNum)
+	for len(taskList) < DailyTaskNum && len(poolList) > 0 {
+		randNum := random.GetRandomInt32(0, weightAll-1)
+		sumWeight := int32(0)
+		index := len(poolList) - 1
+		for i, dailyTaskDataConfig := range poolList {
+			sumWeight += dailyTaskDataConfig.Weight
+			if sumWeight > randNum {
+				index = i
+				break
+			}
+		}
+		dailyTaskDataConfig := poolList[index]
+		taskList = append(taskList, &model.DailyTask{
+			DailyTaskId:    uint32(dailyTaskDataConfig.DailyTaskId),
+			RewardId:       uint32(dailyTaskDataConfig.RewardId),
+			Progress:       0,
+			FinishProgress: uint32(dailyTaskDataConfig.FinishProgress),
+			IsFinished:     false,
+		})
+		weightAll -= dailyTaskDataConfig.Weight
+		poolList = append(poolList[:index], poolList[index+1:]...)
+	}
+	scoreRewardId := uint32(0)
+	scoreRewardDataConfig := gdconf.GetDailyTaskScoreRewardDataByPlayerLevel(playerLevel)
+	if scoreRewardDataConfig != nil {
+		scoreRewardId = uint32(scoreRewardDataConfig.RewardId)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dbDailyTask.ResetDailyTask(taskList, scoreRewardId, uint32(today.AddDate(0, 0, 1).Unix()))
+	logger.Info("refresh daily task, taskCount: %v, uid: %v", len(taskList), player.PlayerId)
+	if !notifyClient {
+		return
+	}
+	g.DailyTaskNotify(player)
+}
+
+// DailyTaskNotify 通知客户端全部每日委托
+func (g *Game) DailyTaskNotify(player *model.Player) {
+	g.SendMsg(cmd.DailyTaskDataNotify, player.PlayerId, player.ClientSeq, g.PacketDailyTaskDataNotify(player))
+	for _, task := range player.GetDbDailyTask().TaskMap {
+		g.SendMsg(cmd.DailyTaskProgressNotify, player.PlayerId, player.ClientSeq, &proto.DailyTaskProgressNotify{
+			Info: g.PacketDailyTaskInfo(task),
+		})
+	}
+}
+
+// TriggerDailyTask 每日委托进度触发 与任务共用完成条件 由TriggerQuest调用
+func (g *Game) TriggerDailyTask(player *model.Player, cond int32, complexParam string, param ...int32) {
+	dbDailyTask := player.GetDbDailyTask()
+	finishTaskList := make([]*model.DailyTask, 0)
+	for _, task := range dbDailyTask.TaskMap {
+		if task.IsFinished {
+			continue
+		}
+		dailyTaskDataConfig := gdconf.GetDailyTaskDataById(int32(task.DailyTaskId))
+		if dailyTaskDataConfig == nil {
+			continue
+		}
+		if dailyTaskDataConfig.FinishCondType != cond {
+			continue
+		}
+		if dailyTaskDataConfig.FinishCondComplexParam != "" && dailyTaskDataConfig.FinishCondComplexParam != complexParam {
+			continue
+		}
+		// 配置参数为空则匹配任意参数
+		if !matchParamPrefix(dailyTaskDataConfig.FinishCondParam, param) {
+			continue
+		}
+		if task.AddProgress(1) {
+			finishTaskList = append(finishTaskList, task)
+		}
+		g.SendMsg(cmd.DailyTaskProgressNotify, player.PlayerId, player.ClientSeq, &proto.DailyTaskProgressNotify{
+			Info: g.PacketDailyTaskInfo(task),
+		})
+	}
+	if len(finishTaskList) == 0 {
+		return
+	}
+	for _, task := range finishTaskList {
+		logger.Info("daily task finish, dailyTaskId: %v, uid: %v", task.DailyTaskId, player.PlayerId)
+		if !g.AddPlayerRewardItem(player, task.RewardId, proto.ActionReasonType_ACTION_REASON_DAILY_TASK_HOST) {
+			logger.Error("add daily task reward fail, rewardId: %v, uid: %v", task.RewardId, player.PlayerId)
+		}
+	}
+	// 全部完成发放积分奖励 发放失败时不标记领取
+	if dbDailyTask.IsAllFinished() && !dbDailyTask.IsTakenScoreReward &&
+		g.AddPlayerRewardItem(player, dbDailyTask.ScoreRewardId, proto.ActionReasonType_ACTION_REASON_DAILY_TASK_SCORE) {
+		dbDailyTask.IsTakenScoreReward = true
+		g.SendMsg(cmd.DailyTaskScoreRewardNotify, player.PlayerId, player.ClientSeq, &proto.DailyTaskScoreRewardNotify{
+			RewardId: dbDailyTask.ScoreRewardId,
+		})
+	}
+	g.SendMsg(cmd.DailyTaskDataNotify, player.PlayerId, player.ClientSeq, g.PacketDailyTaskDataNotify(player))
+}
+
+// matchParamPrefix 配置参数为触发参数的前缀
+func matchParamPrefix(confParam []int32, param []int32) bool {
+	if len(confParam) > len(param) {
+		return false
+	}
+	for i := 0; i < len(confParam); i++ {
+		if confParam[i] != param[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/************************************************** 打包封装 **************************************************/
+
+func (g *Game) PacketDailyTaskDataNotify(player *model.Player) *proto.DailyTaskDataNotify {
+	dbDailyTask := player.GetDbDailyTask()
+	return &proto.DailyTaskDataNotify{
+		ScoreRewardId:      dbDailyTask.ScoreRewardId,
+		FinishedNum:        dbDailyTask.GetFinishedNum(),
+		IsTakenScoreReward: dbDailyTask.IsTakenScoreReward,
+	}
+}
+
+func (g *Game) PacketDailyTaskInfo(task *model.DailyTask) *proto.DailyTaskInfo {
+	return &proto.DailyTaskInfo{
+		RewardId:       task.RewardId,
+		Progress:       task.Progress,
+		FinishProgress: task.FinishProgress,
+		DailyTaskId:    task.DailyTaskId,
+		IsFinished:     task.IsFinished,
+	}
+}
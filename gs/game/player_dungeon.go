@@ -0,0 +1,414 @@
+package game
+
+import (
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gdconf"
+	"hk4e/gs/model"
+	"hk4e/pkg/logger"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+
+	pb "google.golang.org/protobuf/proto"
+)
+
+/************************************************** 接口请求 **************************************************/
+
+// DungeonGetStatueDropReq 地牢领取神像奖励请求
+func (g *Game) DungeonGetStatueDropReq(player *model.Player, payloadMsg pb.Message) {
+	dungeon := g.GetPlayerDungeon(player)
+	if dungeon == nil || dungeon.GetState() != DungeonStateSuccess {
+		g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{}, proto.Retcode_RET_DUNGEON_NOT_SUCCEED)
+		return
+	}
+	if dungeon.IsStatueDropTaken(player.PlayerId) {
+		g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{}, proto.Retcode_RET_GADGET_STATUE_OPENED)
+		return
+	}
+	dungeonDataConfig := dungeon.GetDungeonDataConfig()
+	if dungeonDataConfig == nil {
+		logger.Error("get dungeon data config is nil, dungeonId: %v, uid: %v", dungeon.GetDungeonId(), player.PlayerId)
+		g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{})
+		return
+	}
+	dropDataConfig := gdconf.GetDropDataById(dungeonDataConfig.StatueDropId)
+	if dropDataConfig == nil {
+		logger.Error("get drop data config is nil, dropId: %v, uid: %v", dungeonDataConfig.StatueDropId, player.PlayerId)
+		g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{})
+		return
+	}
+	// 扣除开启神像的消耗
+	costItemId := uint32(dungeonDataConfig.StatueCostItemId)
+	costCount := uint32(dungeonDataConfig.StatueCostCount)
+	if costItemId == constant.ITEM_ID_RESIN {
+		if !g.CostPlayerResin(player, costCount) {
+			g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{}, proto.Retcode_RET_RESIN_NOT_ENOUGH)
+			return
+		}
+	} else if costItemId != 0 {
+		ok := g.CostPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: costItemId, ChangeCount: costCount}})
+		if !ok {
+			g.SendError(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{}, proto.Retcode_RET_ITEM_COUNT_NOT_ENOUGH)
+			return
+		}
+	}
+	dungeon.SetStatueDropTaken(player.PlayerId)
+	itemMap := g.doRandDropFull(dropDataConfig)
+	itemList := make([]*ChangeItem, 0, len(itemMap))
+	for itemId, count := range itemMap {
+		itemList = append(itemList, &ChangeItem{
+			ItemId:      itemId,
+			ChangeCount: count,
+		})
+	}
+	g.AddPlayerItem(player.PlayerId, itemList, proto.ActionReasonType_ACTION_REASON_DUNGEON_STATUE_DROP)
+
+	g.SendSucc(cmd.DungeonGetStatueDropRsp, player, &proto.DungeonGetStatueDropRsp{})
+}
+
+/************************************************** 游戏功能 **************************************************/
+
+// GetPlayerDungeon 获取玩家当前所在的地牢实例
+func (g *Game) GetPlayerDungeon(player *model.Player) *Dungeon {
+	world := WORLD_MANAGER.GetWorldById(player.WorldId)
+	if world == nil {
+		return nil
+	}
+	scene := world.GetSceneById(player.GetSceneId())
+	return scene.GetDungeon()
+}
+
+// EnterDungeon 玩家进入地牢 pointId为离开地牢时返回的传送点
+func (g *Game) EnterDungeon(player *model.Player, dungeonId uint32, pointId uint32) bool {
+	dungeonDataConfig := gdconf.GetDungeonDataById(int32(dungeonId))
+	if dungeonDataConfig == nil {
+		logger.Error("get dungeon data config is nil, dungeonId: %v, uid: %v", dungeonId, player.PlayerId)
+		return false
+	}
+	sceneLuaConfig := gdconf.GetSceneLuaConfigById(dungeonDataConfig.SceneId)
+	if sceneLuaConfig == nil {
+		logger.Error("get scene lua config is nil, sceneId: %v, uid: %v", dungeonDataConfig.SceneId, player.PlayerId)
+		return false
+	}
+	sceneConfig := sceneLuaConfig.SceneConfig
+	world := WORLD_MANAGER.GetWorldById(player.WorldId)
+	if world == nil {
+		logger.Error("get world is nil, worldId: %v, uid: %v", player.WorldId, player.PlayerId)
+		return false
+	}
+	// 创建地牢实例
+	dungeonScene := world.GetSceneById(uint32(dungeonDataConfig.SceneId))
+	if dungeonScene.GetDungeon() == nil || len(dungeonScene.GetAllPlayer()) == 0 {
+		dungeonScene.CreateDungeon(dungeonId)
+	}
+	g.TeleportPlayer(
+		player,
+		proto.EnterReason_ENTER_REASON_DUNGEON_ENTER,
+		uint32(dungeonDataConfig.SceneId),
+		&model.Vector{X: float64(sceneConfig.BornPos.X), Y: float64(sceneConfig.BornPos.Y), Z: float64(sceneConfig.BornPos.Z)},
+		&model.Vector{X: float64(sceneConfig.BornRot.X), Y: float64(sceneConfig.BornRot.Y), Z: float64(sceneConfig.BornRot.Z)},
+		dungeonId,
+		pointId,
+	)
+	return true
+}
+
+// EnterMatchDungeon 匹配成功的玩家进入地牢 离开地牢时返回当前场景中该地牢的入口传送点
+func (g *Game) EnterMatchDungeon(player *model.Player, dungeonId uint32) {
+	dungeonDataConfig := gdconf.GetDungeonDataById(int32(dungeonId))
+	if dungeonDataConfig == nil {
+		logger.Error("get dungeon data config is nil, dungeonId: %v, uid: %v", dungeonId, player.PlayerId)
+		return
+	}
+	if player.GetSceneId() == uint32(dungeonDataConfig.SceneId) {
+		// 跟随房主直接进入了地牢场景
+		return
+	}
+	pointId := uint32(0)
+	for _, pointData := range gdconf.GetScenePointMapBySceneId(int32(player.GetSceneId())) {
+		for _, id := range pointData.DungeonIds {
+			if uint32(id) == dungeonId {
+				pointId = uint32(pointData.Id)
+				break
+			}
+		}
+		if pointId != 0 {
+			break
+		}
+	}
+	g.EnterDungeon(player, dungeonId, pointId)
+}
+
+// DungeonStartChallenge 地牢开启挑战
+func (g *Game) DungeonStartChallenge(player *model.Player, groupId uint32, challengeIndex uint32, challengeId uint32, paramList []uint32) bool {
+	dungeon := g.GetPlayerDungeon(player)
+	if dungeon == nil || !dungeon.IsRunning() {
+		return false
+	}
+	challengeDataConfig := gdconf.GetDungeonChallengeDataById(int32(challengeId))
+	if challengeDataConfig == nil {
+		logger.Error("get dungeon challenge data config is nil, challengeId: %v, uid: %v", challengeId, player.PlayerId)
+		return false
+	}
+	challenge := &DungeonChallenge{
+		challengeIndex: challengeIndex,
+		challengeId:    challengeId,
+		challengeType:  challengeDataConfig.ChallengeType,
+		groupId:        groupId,
+		paramList:      paramList,
+		targetGroupId:  0,
+		goal:           0,
+		timeLimit:      0,
+		progress:       0,
+		beginTime:      time.Now().UnixMilli(),
+		finished:       false,
+	}
+	switch challenge.challengeType {
+	case constant.CHALLENGE_TYPE_KILL_COUNT:
+		if len(paramList) < 2 {
+			logger.Error("challenge param error, challengeId: %v, paramList: %v", challengeId, paramList)
+			return false
+		}
+		challenge.targetGroupId = paramList[0]
+		challenge.goal = paramList[1]
+	case constant.CHALLENGE_TYPE_KILL_COUNT_IN_TIME:
+		if len(paramList) < 3 {
+			logger.Error("challenge param error, challengeId: %v, paramList: %v", challengeId, paramList)
+			return false
+		}
+		challenge.timeLimit = paramList[0]
+		challenge.targetGroupId = paramList[1]
+		challenge.goal = paramList[2]
+	case constant.CHALLENGE_TYPE_SURVIVE:
+		if len(paramList) < 1 {
+			logger.Error("challenge param error, challengeId: %v, paramList: %v", challengeId, paramList)
+			return false
+		}
+		challenge.timeLimit = paramList[0]
+	default:
+		logger.Error("not support challenge type: %v, challengeId: %v", challenge.challengeType, challengeId)
+		return false
+	}
+	dungeon.AddChallenge(challenge)
+	logger.Debug("dungeon start challenge, challengeIndex: %v, challengeId: %v, uid: %v", challengeIndex, challengeId, player.PlayerId)
+	scene := dungeon.GetScene()
+	uidList := make([]uint32, 0)
+	for _, scenePlayer := range scene.GetAllPlayer() {
+		uidList = append(uidList, scenePlayer.PlayerId)
+	}
+	ntf := &proto.DungeonChallengeBeginNotify{
+		ChallengeId:    challengeId,
+		ChallengeIndex: challengeIndex,
+		GroupId:        groupId,
+		ParamList:      paramList,
+		UidList:        uidList,
+	}
+	g.SendToSceneA(scene, cmd.DungeonChallengeBeginNotify, 0, ntf, 0)
+	return true
+}
+
+// DungeonMonsterDie 地牢内怪物死亡
+func (g *Game) DungeonMonsterDie(player *model.Player, group *Group, monsterId uint32) {
+	dungeon := g.GetPlayerDungeon(player)
+	if dungeon == nil || !dungeon.IsRunning() {
+		return
+	}
+	scene := dungeon.GetScene()
+	// 挑战进度
+	for _, challenge := range dungeon.GetAllChallenge() {
+		if !challenge.AddKillProgress(group.GetId()) {
+			continue
+		}
+		g.SendToSceneA(scene, cmd.ChallengeDataNotify, 0, &proto.ChallengeDataNotify{
+			ChallengeIndex: challenge.challengeIndex,
+			ParamIndex:     challenge.goalParamIndex(),
+			Value:          challenge.progress,
+		}, 0)
+		if challenge.IsGoalReached() {
+			g.DungeonChallengeFinish(player, dungeon, challenge, true)
+		}
+	}
+	if !dungeon.IsRunning() {
+		return
+	}
+	// 通关条件
+	g.DungeonPassCondCheck(player, constant.DUNGEON_PASS_COND_KILL_MONSTER, int32(monsterId))
+	g.DungeonPassCondCheck(player, constant.DUNGEON_PASS_COND_KILL_MONSTER_COUNT, int32(dungeon.AddKillMonsterCount()))
+	for _, entity := range group.GetAllEntity() {
+		if entity.GetEntityType() == constant.ENTITY_TYPE_MONSTER {
+			return
+		}
+	}
+	g.DungeonPassCondCheck(player, constant.DUNGEON_PASS_COND_KILL_GROUP_MONSTER, int32(group.GetId()))
+}
+
+// DungeonAvatarDie 地牢内角色死亡 全队阵亡则通关失败
+func (g *Game) DungeonAvatarDie(player *model.Player, scene *Scene) {
+	dungeon := scene.GetDungeon()
+	if dungeon == nil || !dungeon.IsRunning() {
+		return
+	}
+	world := scene.GetWorld()
+	for _, scenePlayer := range scene.GetAllPlayer() {
+		for _, worldAvatar := range world.GetPlayerWorldAvatarList(scenePlayer) {
+			entity := scene.GetEntity(worldAvatar.GetAvatarEntityId())
+			if entity == nil {
+				continue
+			}
+			if entity.GetLifeState() == constant.LIFE_STATE_ALIVE {
+				return
+			}
+		}
+	}
+	g.DungeonSettle(player, dungeon, false)
+}
+
+// DungeonPassCondCheck 地牢通关条件检查
+func (g *Game) DungeonPassCondCheck(player *model.Player, condType int32, param ...int32) {
+	dungeon := g.GetPlayerDungeon(player)
+	if dungeon == nil || !dungeon.IsRunning() {
+		return
+	}
+	dungeonDataConfig := dungeon.GetDungeonDataConfig()
+	if dungeonDataConfig == nil {
+		return
+	}
+	dungeonPassDataConfig := gdconf.GetDungeonPassDataById(dungeonDataConfig.PassCond)
+	if dungeonPassDataConfig == nil {
+		return
+	}
+	dungeon.UpdatePassCond(dungeonPassDataConfig, condType, param)
+	if !dungeon.CheckPass(dungeonPassDataConfig) {
+		return
+	}
+	g.DungeonSettle(player, dungeon, true)
+}
+
+// DungeonChallengeFinish 地牢挑战结束
+func (g *Game) DungeonChallengeFinish(player *model.Player, dungeon *Dungeon, challenge *DungeonChallenge, success bool) {
+	if challenge.finished {
+		return
+	}
+	challenge.finished = true
+	logger.Debug("dungeon challenge finish, challengeIndex: %v, success: %v, uid: %v", challenge.challengeIndex, success, player.PlayerId)
+	scene := dungeon.GetScene()
+	finishType := proto.ChallengeFinishType_CHALLENGE_FINISH_TYPE_SUCC
+	if !success {
+		finishType = proto.ChallengeFinishType_CHALLENGE_FINISH_TYPE_FAIL
+	}
+	ntf := &proto.DungeonChallengeFinishNotify{
+		ChallengeIndex: challenge.challengeIndex,
+		IsSuccess:      success,
+		FinishType:     finishType,
+		TimeCost:       challenge.GetElapsedTime(),
+		CurrentValue:   challenge.progress,
+	}
+	g.SendToSceneA(scene, cmd.DungeonChallengeFinishNotify, 0, ntf, 0)
+	group := scene.GetGroupById(challenge.groupId)
+	if success {
+		if group != nil {
+			g.ChallengeSuccessTriggerCheck(player, group, challenge.challengeIndex)
+		}
+		g.DungeonPassCondCheck(player, constant.DUNGEON_PASS_COND_FINISH_CHALLENGE, int32(challenge.challengeIndex))
+	} else {
+		if group != nil {
+			g.ChallengeFailTriggerCheck(player, group, challenge.challengeIndex)
+		}
+		g.DungeonSettle(player, dungeon, false)
+	}
+}
+
+// DungeonTick 地牢定时检查 挑战计时和限时通关
+func (g *Game) DungeonTick(scene *Scene) {
+	dungeon := scene.GetDungeon()
+	if dungeon == nil || !dungeon.IsRunning() {
+		return
+	}
+	var player *model.Player = nil
+	for _, scenePlayer := range scene.GetAllPlayer() {
+		player = scenePlayer
+		break
+	}
+	if player == nil {
+		return
+	}
+	for _, challenge := range dungeon.GetAllChallenge() {
+		if challenge.finished || !challenge.IsTimeout() {
+			continue
+		}
+		// 存活挑战坚持到时间即成功 其余超时失败
+		g.DungeonChallengeFinish(player, dungeon, challenge, challenge.challengeType == constant.CHALLENGE_TYPE_SURVIVE)
+		if !dungeon.IsRunning() {
+			return
+		}
+	}
+	dungeonDataConfig := dungeon.GetDungeonDataConfig()
+	if dungeonDataConfig == nil {
+		return
+	}
+	dungeonPassDataConfig := gdconf.GetDungeonPassDataById(dungeonDataConfig.PassCond)
+	if dungeonPassDataConfig == nil {
+		return
+	}
+	for _, passCond := range dungeonPassDataConfig.CondList {
+		if passCond.Type != constant.DUNGEON_PASS_COND_IN_TIME || len(passCond.Param) < 1 {
+			continue
+		}
+		if dungeon.GetUseTime() >= uint32(passCond.Param[0]) {
+			g.DungeonSettle(player, dungeon, false)
+			return
+		}
+	}
+}
+
+// DungeonSettle 地牢结算
+func (g *Game) DungeonSettle(player *model.Player, dungeon *Dungeon, success bool) {
+	if !dungeon.IsRunning() {
+		return
+	}
+	dungeon.Settle(success)
+	logger.Info("dungeon settle, dungeonId: %v, success: %v, useTime: %v, uid: %v",
+		dungeon.GetDungeonId(), success, dungeon.GetUseTime(), player.PlayerId)
+	dungeonDataConfig := dungeon.GetDungeonDataConfig()
+	closeTime := uint32(0)
+	if dungeonDataConfig != nil {
+		if success {
+			closeTime = uint32(dungeonDataConfig.SettleCountdownTime)
+		} else {
+			closeTime = uint32(dungeonDataConfig.FailSettleCountdownTime)
+		}
+	}
+	result := uint32(0)
+	if success {
+		result = 1
+	}
+	for _, scenePlayer := range dungeon.GetScene().GetAllPlayer() {
+		if success {
+			dungeonRecord := scenePlayer.GetDbDungeon().GetDungeonRecord(dungeon.GetDungeonId())
+			dungeonRecord.PassCount++
+			if dungeonRecord.FirstPassTime == 0 {
+				dungeonRecord.FirstPassTime = uint32(time.Now().Unix())
+				if dungeonDataConfig != nil {
+					if !g.AddPlayerRewardItem(scenePlayer, uint32(dungeonDataConfig.FirstPassRewardId), proto.ActionReasonType_ACTION_REASON_DUNGEON_FIRST_PASS) {
+						logger.Error("add dungeon first pass reward fail, dungeonId: %v, uid: %v", dungeon.GetDungeonId(), scenePlayer.PlayerId)
+					}
+				}
+			}
+		}
+		ntf := &proto.DungeonSettleNotify{
+			DungeonId:       dungeon.GetDungeonId(),
+			IsSuccess:       success,
+			UseTime:         dungeon.GetUseTime(),
+			CloseTime:       closeTime,
+			Result:          result,
+			CreatePlayerUid: dungeon.GetScene().GetWorld().GetOwner().PlayerId,
+		}
+		g.SendMsg(cmd.DungeonSettleNotify, scenePlayer.PlayerId, scenePlayer.ClientSeq, ntf)
+		if success {
+			g.TriggerQuest(scenePlayer, constant.QUEST_FINISH_COND_TYPE_FINISH_DUNGEON, "", int32(dungeon.GetDungeonId()))
+		} else {
+			g.TriggerQuest(scenePlayer, constant.QUEST_FINISH_COND_TYPE_FAIL_DUNGEON, "", int32(dungeon.GetDungeonId()))
+		}
+	}
+}
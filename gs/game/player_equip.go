@@ -189,6 +189,8 @@ func (g *Game) WearPlayerAvatarReliquary(userId uint32, avatarId uint32, reliqua
 		// 更新目标圣遗物角色的装备
 		avatarEquipChangeNotify := g.PacketAvatarEquipChangeNotifyByReliquary(targetReliquaryAvatar, uint8(reliquaryConfig.ReliquaryType))
 		g.SendMsg(cmd.AvatarEquipChangeNotify, userId, player.ClientSeq, avatarEquipChangeNotify)
+		// 目标圣遗物角色的套装件数发生变化 更新面板
+		g.UpdatePlayerAvatarFightProp(userId, targetReliquaryAvatar.AvatarId)
 	} else if avatarCurReliquary != nil {
 		// 角色当前有圣遗物则卸下
 		dbAvatar.TakeOffReliquary(avatarId, avatarCurReliquary)
@@ -252,6 +254,8 @@ func (g *Game) WearPlayerAvatarWeapon(userId uint32, avatarId uint32, weaponId u
 			}
 			avatarEquipChangeNotify := g.PacketAvatarEquipChangeNotifyByWeapon(targetWeaponAvatar, targetWeaponAvatar.EquipWeapon, weaponEntityId)
 			g.SendMsg(cmd.AvatarEquipChangeNotify, userId, player.ClientSeq, avatarEquipChangeNotify)
+			// 目标武器角色的武器词缀发生变化 更新面板
+			g.UpdatePlayerAvatarFightProp(userId, targetWeaponAvatar.AvatarId)
 		} else {
 			// 角色当前有武器则卸下
 			dbAvatar.TakeOffWeapon(avatarId, avatarCurWeapon)
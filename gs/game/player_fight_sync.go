@@ -213,6 +213,9 @@ func (g *Game) handleEvtBeingHit(player *model.Player, scene *Scene, hitInfo *pr
 		g.EntityFightPropUpdateNotifyBroadcast(scene, defEntity)
 		if currHp == 0.0 {
 			g.KillEntity(player, scene, defEntity.GetId(), proto.PlayerDieType_PLAYER_DIE_GM)
+		} else {
+			// 服务器怪物ai仇恨
+			g.MonsterAiBeingHit(player, scene, defEntity, attackResult.Damage)
 		}
 		if defEntity.GetGroupId() == 0 {
 			return
@@ -296,6 +299,10 @@ func (g *Game) handleEntityMove(player *model.Player, world *World, scene *Scene
 			worldWeaponEntity.SetRot(rot)
 		}
 	}
+	if entity.GetEntityType() == constant.ENTITY_TYPE_MONSTER && world.GetMonsterAi() != nil && world.GetMonsterAi().IsServerAiMonster(entityId) {
+		// 服务器ai驱动的怪物不接受客户端的移动同步
+		return
+	}
 	// 更新场景实体的位置信息
 	entity.SetPos(pos)
 	entity.SetRot(rot)
@@ -842,6 +849,8 @@ func (g *Game) EvtBulletHitNotify(player *model.Player, payloadMsg pb.Message) {
 	}
 	scene := world.GetSceneById(player.GetSceneId())
 	g.SendToSceneA(scene, cmd.EvtBulletHitNotify, player.ClientSeq, ntf, 0)
+	// 以客户端上报的命中为准 不再继续模拟
+	world.GetPhysicsEngine().DestroyRigidBody(ntf.EntityId)
 
 	// 触发事件
 	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdEvtBulletHit, &PluginEventEvtBulletHit{
@@ -894,6 +903,23 @@ func (g *Game) EvtCreateGadgetNotify(player *model.Player, payloadMsg pb.Message
 		return
 	}
 	g.AddSceneEntityNotify(player, proto.VisionType_VISION_BORN, []uint32{ntf.EntityId}, true, true)
+	// 配置了抛射物的子弹由服务器物理引擎模拟
+	if world.GetPhysicsEngine().GetPhysicsConfig().GetProjectileConfig(ntf.ConfigId) != nil {
+		activeAvatarEntity := world.GetPlayerActiveAvatarEntity(player)
+		if activeAvatarEntity == nil {
+			logger.Error("get active avatar entity is nil, uid: %v", player.PlayerId)
+		} else {
+			world.GetPhysicsEngine().CreateRigidBody(
+				ntf.EntityId,
+				ntf.ConfigId,
+				activeAvatarEntity.GetId(),
+				player.PlayerId,
+				player.GetSceneId(),
+				&alg.Vector3{X: ntf.InitPos.X, Y: ntf.InitPos.Y, Z: ntf.InitPos.Z},
+				&alg.Vector3{X: ntf.InitEulerAngles.X, Y: ntf.InitEulerAngles.Y, Z: ntf.InitEulerAngles.Z},
+			)
+		}
+	}
 
 	// 触发事件
 	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdEvtCreateGadget, &PluginEventEvtCreateGadget{
@@ -917,6 +943,7 @@ func (g *Game) EvtDestroyGadgetNotify(player *model.Player, payloadMsg pb.Messag
 	}
 	scene := world.GetSceneById(player.GetSceneId())
 	scene.DestroyEntity(ntf.EntityId)
+	world.GetPhysicsEngine().DestroyRigidBody(ntf.EntityId)
 	g.RemoveSceneEntityNotifyBroadcast(scene, proto.VisionType_VISION_MISS, []uint32{ntf.EntityId}, 0)
 }
 
@@ -1,8 +1,12 @@
 package game
 
 import (
+	"sort"
+	"strconv"
 	"time"
 
+	"hk4e/common/config"
+	"hk4e/common/constant"
 	"hk4e/gdconf"
 	"hk4e/gs/model"
 	"hk4e/pkg/logger"
@@ -23,7 +27,7 @@ type UserInfo struct {
 
 // GetGachaInfoReq 获取卡池信息
 func (g *Game) GetGachaInfoReq(player *model.Player, payloadMsg pb.Message) {
-	serverAddr := "http://223.5.5.5"
+	serverAddr := config.GetConfig().Hk4e.GachaHistoryServer
 	userInfo := &UserInfo{
 		UserId: player.PlayerId,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -32,163 +36,39 @@ func (g *Game) GetGachaInfoReq(player *model.Player, payloadMsg pb.Message) {
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, userInfo)
-	jwtStr, err := token.SignedString([]byte("flswld"))
-	if err != nil {
-		logger.Error("generate jwt error: %v", err)
-		jwtStr = "default.jwt.token"
+	// 未配置签名密钥时不签发 抽卡记录页面无法通过验证
+	jwtStr := "default.jwt.token"
+	jwtKey := config.GetConfig().Hk4e.GachaHistoryJwtKey
+	if jwtKey == "" {
+		logger.Error("gacha history jwt key not config, uid: %v", player.PlayerId)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS512, userInfo)
+		signedStr, err := token.SignedString([]byte(jwtKey))
+		if err != nil {
+			logger.Error("generate jwt error: %v", err)
+		} else {
+			jwtStr = signedStr
+		}
 	}
 	getGachaInfoRsp := new(proto.GetGachaInfoRsp)
 	getGachaInfoRsp.GachaRandom = 12345
-	getGachaInfoRsp.GachaInfoList = []*proto.GachaInfo{
-		// 温迪
-		{
-			GachaType:              300,
-			ScheduleId:             823,
-			BeginTime:              0,
-			EndTime:                2051193600,
-			GachaSortId:            9998,
-			GachaPrefabPath:        "GachaShowPanel_A019",
-			GachaPreviewPrefabPath: "UI_Tab_GachaShowPanel_A019",
-			TitleTextmap:           "UI_GACHA_SHOW_PANEL_A019_TITLE",
-			LeftGachaTimes:         2147483647,
-			GachaTimesLimit:        2147483647,
-			CostItemId:             223,
-			CostItemNum:            1,
-			TenCostItemId:          223,
-			TenCostItemNum:         10,
-			GachaRecordUrl:         serverAddr + "/gacha?gachaType=300&jwt=" + jwtStr,
-			GachaRecordUrlOversea:  serverAddr + "/gacha?gachaType=300&jwt=" + jwtStr,
-			GachaProbUrl:           serverAddr + "/gacha/details?scheduleId=823&jwt=" + jwtStr,
-			GachaProbUrlOversea:    serverAddr + "/gacha/details?scheduleId=823&jwt=" + jwtStr,
-			GachaUpInfoList: []*proto.GachaUpInfo{
-				{
-					ItemParentType: 1,
-					ItemIdList:     []uint32{1022},
-				},
-				{
-					ItemParentType: 2,
-					ItemIdList:     []uint32{1023, 1031, 1014},
-				},
-			},
-			DisplayUp4ItemList: []uint32{1023},
-			DisplayUp5ItemList: []uint32{1022},
-			WishItemId:         0,
-			WishProgress:       0,
-			WishMaxProgress:    0,
-			IsNewWish:          false,
-		},
-		// 可莉
-		{
-			GachaType:              400,
-			ScheduleId:             833,
-			BeginTime:              0,
-			EndTime:                2051193600,
-			GachaSortId:            9998,
-			GachaPrefabPath:        "GachaShowPanel_A018",
-			GachaPreviewPrefabPath: "UI_Tab_GachaShowPanel_A018",
-			TitleTextmap:           "UI_GACHA_SHOW_PANEL_A018_TITLE",
-			LeftGachaTimes:         2147483647,
-			GachaTimesLimit:        2147483647,
-			CostItemId:             223,
-			CostItemNum:            1,
-			TenCostItemId:          223,
-			TenCostItemNum:         10,
-			GachaRecordUrl:         serverAddr + "/gacha?gachaType=400&jwt=" + jwtStr,
-			GachaRecordUrlOversea:  serverAddr + "/gacha?gachaType=400&jwt=" + jwtStr,
-			GachaProbUrl:           serverAddr + "/gacha/details?scheduleId=833&jwt=" + jwtStr,
-			GachaProbUrlOversea:    serverAddr + "/gacha/details?scheduleId=833&jwt=" + jwtStr,
-			GachaUpInfoList: []*proto.GachaUpInfo{
-				{
-					ItemParentType: 1,
-					ItemIdList:     []uint32{1029},
-				},
-				{
-					ItemParentType: 2,
-					ItemIdList:     []uint32{1025, 1034, 1043},
-				},
-			},
-			DisplayUp4ItemList: []uint32{1025},
-			DisplayUp5ItemList: []uint32{1029},
-			WishItemId:         0,
-			WishProgress:       0,
-			WishMaxProgress:    0,
-			IsNewWish:          false,
-		},
-		// 阿莫斯之弓&天空之傲
-		{
-			GachaType:              431,
-			ScheduleId:             1143,
-			BeginTime:              0,
-			EndTime:                2051193600,
-			GachaSortId:            9997,
-			GachaPrefabPath:        "GachaShowPanel_A030",
-			GachaPreviewPrefabPath: "UI_Tab_GachaShowPanel_A030",
-			TitleTextmap:           "UI_GACHA_SHOW_PANEL_A030_TITLE",
-			LeftGachaTimes:         2147483647,
-			GachaTimesLimit:        2147483647,
-			CostItemId:             223,
-			CostItemNum:            1,
-			TenCostItemId:          223,
-			TenCostItemNum:         10,
-			GachaRecordUrl:         serverAddr + "/gacha?gachaType=431&jwt=" + jwtStr,
-			GachaRecordUrlOversea:  serverAddr + "/gacha?gachaType=431&jwt=" + jwtStr,
-			GachaProbUrl:           serverAddr + "/gacha/details?scheduleId=1143&jwt=" + jwtStr,
-			GachaProbUrlOversea:    serverAddr + "/gacha/details?scheduleId=1143&jwt=" + jwtStr,
-			GachaUpInfoList: []*proto.GachaUpInfo{
-				{
-					ItemParentType: 1,
-					ItemIdList:     []uint32{15502, 12501},
-				},
-				{
-					ItemParentType: 2,
-					ItemIdList:     []uint32{11403, 12402, 13401, 14409, 15401},
-				},
-			},
-			DisplayUp4ItemList: []uint32{11403},
-			DisplayUp5ItemList: []uint32{15502, 12501},
-			WishItemId:         0,
-			WishProgress:       0,
-			WishMaxProgress:    0,
-			IsNewWish:          false,
-		},
-		// 常驻
-		{
-			GachaType:              201,
-			ScheduleId:             813,
-			BeginTime:              0,
-			EndTime:                2051193600,
-			GachaSortId:            1000,
-			GachaPrefabPath:        "GachaShowPanel_A017",
-			GachaPreviewPrefabPath: "UI_Tab_GachaShowPanel_A017",
-			TitleTextmap:           "UI_GACHA_SHOW_PANEL_A017_TITLE",
-			LeftGachaTimes:         2147483647,
-			GachaTimesLimit:        2147483647,
-			CostItemId:             224,
-			CostItemNum:            1,
-			TenCostItemId:          224,
-			TenCostItemNum:         10,
-			GachaRecordUrl:         serverAddr + "/gacha?gachaType=201&jwt=" + jwtStr,
-			GachaRecordUrlOversea:  serverAddr + "/gacha?gachaType=201&jwt=" + jwtStr,
-			GachaProbUrl:           serverAddr + "/gacha/details?scheduleId=813&jwt=" + jwtStr,
-			GachaProbUrlOversea:    serverAddr + "/gacha/details?scheduleId=813&jwt=" + jwtStr,
-			GachaUpInfoList: []*proto.GachaUpInfo{
-				{
-					ItemParentType: 1,
-					ItemIdList:     []uint32{1003, 1016},
-				},
-				{
-					ItemParentType: 2,
-					ItemIdList:     []uint32{1021, 1006, 1015},
-				},
-			},
-			DisplayUp4ItemList: []uint32{1021},
-			DisplayUp5ItemList: []uint32{1003, 1016},
-			WishItemId:         0,
-			WishProgress:       0,
-			WishMaxProgress:    0,
-			IsNewWish:          false,
-		},
+	getGachaInfoRsp.GachaInfoList = make([]*proto.GachaInfo, 0)
+	now := uint32(time.Now().Unix())
+	gachaPoolDataList := make([]*gdconf.GachaPoolData, 0)
+	for _, gachaPoolData := range gdconf.GetGachaPoolDataMap() {
+		if !g.IsGachaPoolOpen(gachaPoolData, now) {
+			continue
+		}
+		gachaPoolDataList = append(gachaPoolDataList, gachaPoolData)
+	}
+	sort.Slice(gachaPoolDataList, func(i, j int) bool {
+		if gachaPoolDataList[i].SortId != gachaPoolDataList[j].SortId {
+			return gachaPoolDataList[i].SortId < gachaPoolDataList[j].SortId
+		}
+		return gachaPoolDataList[i].ScheduleId < gachaPoolDataList[j].ScheduleId
+	})
+	for _, gachaPoolData := range gachaPoolDataList {
+		getGachaInfoRsp.GachaInfoList = append(getGachaInfoRsp.GachaInfoList, g.PacketGachaInfo(gachaPoolData, serverAddr, jwtStr))
 	}
 	g.SendMsg(cmd.GetGachaInfoRsp, player.PlayerId, player.ClientSeq, getGachaInfoRsp)
 }
@@ -197,29 +77,35 @@ func (g *Game) DoGachaReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.DoGachaReq)
 	gachaScheduleId := req.GachaScheduleId
 	gachaTimes := req.GachaTimes
-	gachaType := uint32(0)
-	costItemId := uint32(0)
-	switch gachaScheduleId {
-	case 823:
-		// 温迪
-		gachaType = 300
-		costItemId = 223
-	case 833:
-		// 可莉
-		gachaType = 400
-		costItemId = 223
-	case 1143:
-		// 阿莫斯之弓&天空之傲
-		gachaType = 431
-		costItemId = 223
-	case 813:
-		// 常驻
-		gachaType = 201
-		costItemId = 224
+	if gachaTimes != 1 && gachaTimes != 10 {
+		g.SendError(cmd.DoGachaRsp, player, &proto.DoGachaRsp{}, proto.Retcode_RET_GACHA_INVALID_TIMES)
+		return
+	}
+	gachaPoolData := gdconf.GetGachaPoolDataByScheduleId(int32(gachaScheduleId))
+	if gachaPoolData == nil || !g.IsGachaPoolOpen(gachaPoolData, uint32(time.Now().Unix())) {
+		logger.Error("gacha pool not open, scheduleId: %v, uid: %v", gachaScheduleId, player.PlayerId)
+		g.SendError(cmd.DoGachaRsp, player, &proto.DoGachaRsp{}, proto.Retcode_RET_GACHA_INAVAILABLE)
+		return
+	}
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdDoGacha, &PluginEventDoGacha{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		Req:         req,
+	}) {
+		g.SendError(cmd.DoGachaRsp, player, &proto.DoGachaRsp{}, proto.Retcode_RET_GACHA_INAVAILABLE)
+		return
+	}
+	gachaType := uint32(gachaPoolData.GachaType)
+	costItemId := uint32(gachaPoolData.CostItemId)
+	if g.GetPlayerItemCount(player.PlayerId, costItemId) < gachaTimes {
+		g.SendError(cmd.DoGachaRsp, player, &proto.DoGachaRsp{}, proto.Retcode_RET_GACHA_COST_ITEM_NOT_ENOUGH)
+		return
 	}
 	// 先扣掉粉球或蓝球再进行抽卡
 	ok := g.CostPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: costItemId, ChangeCount: gachaTimes}})
 	if !ok {
+		g.SendError(cmd.DoGachaRsp, player, &proto.DoGachaRsp{}, proto.Retcode_RET_GACHA_COST_ITEM_NOT_ENOUGH)
 		return
 	}
 	doGachaRsp := &proto.DoGachaRsp{
@@ -235,28 +121,22 @@ func (g *Game) DoGachaReq(player *model.Player, payloadMsg pb.Message) {
 		TenCostItemNum:  10,
 		GachaItemList:   make([]*proto.GachaItem, 0),
 	}
+	gachaRecordList := make([]*model.GachaRecord, 0)
+	now := uint32(time.Now().Unix())
 	for i := uint32(0); i < gachaTimes; i++ {
 		var ok bool
 		var itemId uint32
-		if gachaType == 400 {
-			// 可莉
+		switch gachaPoolData.GachaMode {
+		case gdconf.GachaModeRandomAll:
 			ok, itemId = g.doGachaKlee()
-		} else if gachaType == 300 {
-			// 角色UP池
-			ok, itemId = g.doGachaOnce(player.PlayerId, gachaType, true, false)
-		} else if gachaType == 431 {
-			// 武器UP池
-			ok, itemId = g.doGachaOnce(player.PlayerId, gachaType, true, true)
-		} else if gachaType == 201 {
-			// 常驻
-			ok, itemId = g.doGachaOnce(player.PlayerId, gachaType, false, false)
-		} else {
-			ok, itemId = false, 0
+		default:
+			ok, itemId = g.doGachaOnce(player.PlayerId, gachaPoolData)
 		}
 		if !ok {
 			itemId = 11301
 		}
 		// 添加抽卡获得的道具
+		addOk := true
 		if itemId > 1000 && itemId < 2000 {
 			avatarId := (itemId % 1000) + 10000000
 			dbAvatar := player.GetDbAvatar()
@@ -266,37 +146,84 @@ func (g *Game) DoGachaReq(player *model.Player, payloadMsg pb.Message) {
 			} else {
 				constellationItemId := itemId + 100
 				if g.GetPlayerItemCount(player.PlayerId, constellationItemId) < 6 {
-					g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constellationItemId, ChangeCount: 1}}, proto.ActionReasonType_ACTION_REASON_GACHA)
+					addOk = g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constellationItemId, ChangeCount: 1}}, proto.ActionReasonType_ACTION_REASON_GACHA)
 				}
 			}
 		} else if itemId > 10000 && itemId < 20000 {
 			g.AddPlayerWeapon(player.PlayerId, itemId)
 		} else {
-			g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: itemId, ChangeCount: 1}}, proto.ActionReasonType_ACTION_REASON_GACHA)
+			addOk = g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: itemId, ChangeCount: 1}}, proto.ActionReasonType_ACTION_REASON_GACHA)
+		}
+		if !addOk {
+			// 获得道具被取消 退还这一抽的消耗
+			logger.Error("add gacha item fail, itemId: %v, uid: %v", itemId, player.PlayerId)
+			g.RefundPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: costItemId, ChangeCount: 1}})
+			continue
 		}
+		// 记录抽卡结果
+		gachaRecordList = append(gachaRecordList, &model.GachaRecord{
+			Uid:        player.PlayerId,
+			GachaType:  gachaType,
+			ScheduleId: gachaScheduleId,
+			ItemId:     itemId,
+			Rarity:     g.GetGachaItemRarity(itemId),
+			Time:       now,
+		})
 		// 计算星尘星辉
 		xc := uint32(random.GetRandomInt32(0, 10))
 		xh := uint32(random.GetRandomInt32(0, 10))
 		gachaItem := new(proto.GachaItem)
 		gachaItem.GachaItem = &proto.ItemParam{ItemId: itemId, Count: 1}
 		// 星尘
-		if xc != 0 {
-			g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 222, ChangeCount: xc}}, proto.ActionReasonType_ACTION_REASON_GACHA)
+		if xc != 0 && g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 222, ChangeCount: xc}}, proto.ActionReasonType_ACTION_REASON_GACHA) {
 			gachaItem.TokenItemList = []*proto.ItemParam{{ItemId: 222, Count: xc}}
 		}
 		// 星辉
-		if xh != 0 {
-			g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 221, ChangeCount: xh}}, proto.ActionReasonType_ACTION_REASON_GACHA)
+		if xh != 0 && g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 221, ChangeCount: xh}}, proto.ActionReasonType_ACTION_REASON_GACHA) {
 			gachaItem.TransferItems = []*proto.GachaTransferItem{{Item: &proto.ItemParam{ItemId: 221, Count: xh}}}
 		}
 		doGachaRsp.GachaItemList = append(doGachaRsp.GachaItemList, gachaItem)
 	}
+	go USER_MANAGER.SaveUserGachaRecordListToDbSync(gachaRecordList)
 	logger.Debug("doGachaRsp: %v", doGachaRsp.String())
 	g.SendMsg(cmd.DoGachaRsp, player.PlayerId, player.ClientSeq, doGachaRsp)
 }
 
 /************************************************** 游戏功能 **************************************************/
 
+// IsGachaPoolOpen 卡池是否处于开启时间内
+func (g *Game) IsGachaPoolOpen(gachaPoolData *gdconf.GachaPoolData, now uint32) bool {
+	if gachaPoolData.BeginTime != 0 && now < gachaPoolData.BeginTime {
+		return false
+	}
+	if gachaPoolData.EndTime != 0 && now >= gachaPoolData.EndTime {
+		return false
+	}
+	return true
+}
+
+// GetGachaItemRarity 获取抽卡道具的星级
+func (g *Game) GetGachaItemRarity(gachaItemId uint32) uint32 {
+	if gachaItemId > 1000 && gachaItemId < 2000 {
+		avatarId := (gachaItemId % 1000) + 10000000
+		avatarDataConfig := gdconf.GetAvatarDataById(int32(avatarId))
+		if avatarDataConfig == nil {
+			logger.Error("avatar data config not found, avatar id: %v", avatarId)
+			return 0
+		}
+		return uint32(avatarDataConfig.QualityType)
+	}
+	itemDataConfig := gdconf.GetItemDataById(int32(gachaItemId))
+	if itemDataConfig == nil {
+		logger.Error("item data config not found, item id: %v", gachaItemId)
+		return 0
+	}
+	if itemDataConfig.Type != constant.ITEM_TYPE_WEAPON {
+		return 0
+	}
+	return uint32(itemDataConfig.EquipLevel)
+}
+
 // 扣1给可莉刷烧烤酱
 func (g *Game) doGachaKlee() (bool, uint32) {
 	allAvatarList := make([]uint32, 0)
@@ -339,24 +266,14 @@ const (
 	Weapon
 )
 
-const (
-	StandardOrangeTimesFixThreshold uint32 = 74   // 标准池触发5星概率修正阈值的抽卡次数
-	StandardOrangeTimesFixValue     int32  = 600  // 标准池5星概率修正因子
-	StandardPurpleTimesFixThreshold uint32 = 9    // 标准池触发4星概率修正阈值的抽卡次数
-	StandardPurpleTimesFixValue     int32  = 5100 // 标准池4星概率修正因子
-	WeaponOrangeTimesFixThreshold   uint32 = 63   // 武器池触发5星概率修正阈值的抽卡次数
-	WeaponOrangeTimesFixValue       int32  = 700  // 武器池5星概率修正因子
-	WeaponPurpleTimesFixThreshold   uint32 = 8    // 武器池触发4星概率修正阈值的抽卡次数
-	WeaponPurpleTimesFixValue       int32  = 6000 // 武器池4星概率修正因子
-)
-
 // 单抽一次
-func (g *Game) doGachaOnce(userId uint32, gachaType uint32, mustGetUpEnable bool, weaponFix bool) (bool, uint32) {
+func (g *Game) doGachaOnce(userId uint32, gachaPoolData *gdconf.GachaPoolData) (bool, uint32) {
 	player := USER_MANAGER.GetOnlineUser(userId)
 	if player == nil {
 		logger.Error("player is nil, uid: %v", userId)
 		return false, 0
 	}
+	gachaType := uint32(gachaPoolData.GachaType)
 	// 找到卡池对应的掉落组
 	dropGroupDataConfig := gdconf.GetGachaDropGroupDataByDropId(int32(gachaType))
 	if dropGroupDataConfig == nil {
@@ -365,30 +282,15 @@ func (g *Game) doGachaOnce(userId uint32, gachaType uint32, mustGetUpEnable bool
 	}
 	// 获取用户的卡池保底信息
 	dbGacha := player.GetDbGacha()
-	gachaPoolInfo := dbGacha.GachaPoolInfo[gachaType]
-	if gachaPoolInfo == nil {
-		logger.Error("player gacha pool info not found, gacha type: %v", gachaType)
-		return false, 0
-	}
+	gachaPoolInfo := dbGacha.GetGachaPoolInfo(gachaType)
 	// 保底计数+1
 	gachaPoolInfo.OrangeTimes++
 	gachaPoolInfo.PurpleTimes++
 	// 4星和5星概率修正
-	OrangeTimesFixThreshold := uint32(0)
-	OrangeTimesFixValue := int32(0)
-	PurpleTimesFixThreshold := uint32(0)
-	PurpleTimesFixValue := int32(0)
-	if !weaponFix {
-		OrangeTimesFixThreshold = StandardOrangeTimesFixThreshold
-		OrangeTimesFixValue = StandardOrangeTimesFixValue
-		PurpleTimesFixThreshold = StandardPurpleTimesFixThreshold
-		PurpleTimesFixValue = StandardPurpleTimesFixValue
-	} else {
-		OrangeTimesFixThreshold = WeaponOrangeTimesFixThreshold
-		OrangeTimesFixValue = WeaponOrangeTimesFixValue
-		PurpleTimesFixThreshold = WeaponPurpleTimesFixThreshold
-		PurpleTimesFixValue = WeaponPurpleTimesFixValue
-	}
+	OrangeTimesFixThreshold := uint32(gachaPoolData.OrangeFixThreshold)
+	OrangeTimesFixValue := gachaPoolData.OrangeFixValue
+	PurpleTimesFixThreshold := uint32(gachaPoolData.PurpleFixThreshold)
+	PurpleTimesFixValue := gachaPoolData.PurpleFixValue
 	if gachaPoolInfo.OrangeTimes >= OrangeTimesFixThreshold || gachaPoolInfo.PurpleTimes >= PurpleTimesFixThreshold {
 		fixDropGroupDataConfig := new(gdconf.GachaDropGroupData)
 		fixDropGroupDataConfig.DropId = dropGroupDataConfig.DropId
@@ -491,55 +393,49 @@ func (g *Game) doGachaOnce(userId uint32, gachaType uint32, mustGetUpEnable bool
 	case Orange:
 		// 重置5星保底计数
 		gachaPoolInfo.OrangeTimes = 0
-		if mustGetUpEnable {
+		if gachaPoolData.MustGetUpEnable {
 			// 找到UP的5星对应的掉落组id 要求配置表的UP的5星掉落组id规则固定为(卡池类型*100+12)
 			upOrangeDropId := int32(gachaType*100 + 12)
 			// 替换本次结果为5星大保底
 			if gachaPoolInfo.MustGetUpOrange {
 				logger.Debug("trigger must get up orange, uid: %v", userId)
-				upOrangeDropGroupDataConfig := gdconf.GetGachaDropGroupDataByDropId(upOrangeDropId)
-				if upOrangeDropGroupDataConfig == nil {
-					logger.Error("drop group not found, drop id: %v", upOrangeDropId)
-					return false, 0
-				}
-				upOrangeOk, upOrangeDrop := g.doGachaRandDropFull(upOrangeDropGroupDataConfig)
+				upOrangeOk, upOrangeGachaItemId := g.doGachaUpDrop(gachaPoolData.UpOrangeList, upOrangeDropId)
 				if !upOrangeOk {
 					return false, 0
 				}
 				gachaPoolInfo.MustGetUpOrange = false
-				upOrangeGachaItemId := uint32(upOrangeDrop.Result)
 				return upOrangeOk, upOrangeGachaItemId
 			}
 			// 触发5星大保底
 			if drop.DropId != upOrangeDropId {
 				gachaPoolInfo.MustGetUpOrange = true
+			} else if len(gachaPoolData.UpOrangeList) != 0 {
+				// 卡池配置了UP列表则以卡池配置为准
+				return g.doGachaUpDrop(gachaPoolData.UpOrangeList, upOrangeDropId)
 			}
 		}
 	case Purple:
 		// 重置4星保底计数
 		gachaPoolInfo.PurpleTimes = 0
-		if mustGetUpEnable {
+		if gachaPoolData.MustGetUpEnable {
 			// 找到UP的4星对应的掉落组id 要求配置表的UP的4星掉落组id规则固定为(卡池类型*100+22)
 			upPurpleDropId := int32(gachaType*100 + 22)
 			// 替换本次结果为4星大保底
 			if gachaPoolInfo.MustGetUpPurple {
 				logger.Debug("trigger must get up purple, uid: %v", userId)
-				upPurpleDropGroupDataConfig := gdconf.GetGachaDropGroupDataByDropId(upPurpleDropId)
-				if upPurpleDropGroupDataConfig == nil {
-					logger.Error("drop group not found, drop id: %v", upPurpleDropId)
-					return false, 0
-				}
-				upPurpleOk, upPurpleDrop := g.doGachaRandDropFull(upPurpleDropGroupDataConfig)
+				upPurpleOk, upPurpleGachaItemId := g.doGachaUpDrop(gachaPoolData.UpPurpleList, upPurpleDropId)
 				if !upPurpleOk {
 					return false, 0
 				}
 				gachaPoolInfo.MustGetUpPurple = false
-				upPurpleGachaItemId := uint32(upPurpleDrop.Result)
 				return upPurpleOk, upPurpleGachaItemId
 			}
 			// 触发4星大保底
 			if drop.DropId != upPurpleDropId {
 				gachaPoolInfo.MustGetUpPurple = true
+			} else if len(gachaPoolData.UpPurpleList) != 0 {
+				// 卡池配置了UP列表则以卡池配置为准
+				return g.doGachaUpDrop(gachaPoolData.UpPurpleList, upPurpleDropId)
 			}
 		}
 	default:
@@ -547,6 +443,24 @@ func (g *Game) doGachaOnce(userId uint32, gachaType uint32, mustGetUpEnable bool
 	return ok, gachaItemId
 }
 
+// 抽取UP道具 卡池配置了UP列表则从中等概率随机 否则走UP掉落组
+func (g *Game) doGachaUpDrop(upItemList gdconf.IntArray, upDropId int32) (bool, uint32) {
+	if len(upItemList) != 0 {
+		index := random.GetRandomInt32(0, int32(len(upItemList)-1))
+		return true, uint32(upItemList[index])
+	}
+	upDropGroupDataConfig := gdconf.GetGachaDropGroupDataByDropId(upDropId)
+	if upDropGroupDataConfig == nil {
+		logger.Error("drop group not found, drop id: %v", upDropId)
+		return false, 0
+	}
+	ok, upDrop := g.doGachaRandDropFull(upDropGroupDataConfig)
+	if !ok {
+		return false, 0
+	}
+	return true, uint32(upDrop.Result)
+}
+
 // 走一次完整流程的掉落组
 func (g *Game) doGachaRandDropFull(gachaDropGroupDataConfig *gdconf.GachaDropGroupData) (bool, *gdconf.GachaDrop) {
 	for i := 0; i < 1000; i++ {
@@ -584,3 +498,57 @@ func (g *Game) doGachaRandDropOnce(dropGroupDataConfig *gdconf.GachaDropGroupDat
 }
 
 /************************************************** 打包封装 **************************************************/
+
+func (g *Game) PacketGachaInfo(gachaPoolData *gdconf.GachaPoolData, serverAddr string, jwtStr string) *proto.GachaInfo {
+	gachaType := strconv.Itoa(int(gachaPoolData.GachaType))
+	scheduleId := strconv.Itoa(int(gachaPoolData.ScheduleId))
+	endTime := gachaPoolData.EndTime
+	if endTime == 0 {
+		endTime = 2051193600
+	}
+	gachaInfo := &proto.GachaInfo{
+		GachaType:              uint32(gachaPoolData.GachaType),
+		ScheduleId:             uint32(gachaPoolData.ScheduleId),
+		BeginTime:              gachaPoolData.BeginTime,
+		EndTime:                endTime,
+		GachaSortId:            uint32(gachaPoolData.SortId),
+		GachaPrefabPath:        gachaPoolData.PrefabPath,
+		GachaPreviewPrefabPath: gachaPoolData.PreviewPrefabPath,
+		TitleTextmap:           gachaPoolData.TitleTextmap,
+		LeftGachaTimes:         2147483647,
+		GachaTimesLimit:        2147483647,
+		CostItemId:             uint32(gachaPoolData.CostItemId),
+		CostItemNum:            1,
+		TenCostItemId:          uint32(gachaPoolData.CostItemId),
+		TenCostItemNum:         10,
+		GachaRecordUrl:         serverAddr + "/gacha?gachaType=" + gachaType + "&jwt=" + jwtStr,
+		GachaRecordUrlOversea:  serverAddr + "/gacha?gachaType=" + gachaType + "&jwt=" + jwtStr,
+		GachaProbUrl:           serverAddr + "/gacha/details?scheduleId=" + scheduleId + "&jwt=" + jwtStr,
+		GachaProbUrlOversea:    serverAddr + "/gacha/details?scheduleId=" + scheduleId + "&jwt=" + jwtStr,
+		GachaUpInfoList: []*proto.GachaUpInfo{
+			{
+				ItemParentType: 1,
+				ItemIdList:     g.gachaIntArrayToUint32List(gachaPoolData.UpOrangeList),
+			},
+			{
+				ItemParentType: 2,
+				ItemIdList:     g.gachaIntArrayToUint32List(gachaPoolData.UpPurpleList),
+			},
+		},
+		DisplayUp4ItemList: g.gachaIntArrayToUint32List(gachaPoolData.DisplayUp4List),
+		DisplayUp5ItemList: g.gachaIntArrayToUint32List(gachaPoolData.DisplayUp5List),
+		WishItemId:         0,
+		WishProgress:       0,
+		WishMaxProgress:    0,
+		IsNewWish:          false,
+	}
+	return gachaInfo
+}
+
+func (g *Game) gachaIntArrayToUint32List(intArray gdconf.IntArray) []uint32 {
+	uint32List := make([]uint32, 0, len(intArray))
+	for _, v := range intArray {
+		uint32List = append(uint32List, uint32(v))
+	}
+	return uint32List
+}
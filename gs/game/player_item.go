@@ -61,6 +61,13 @@ func (g *Game) UseItem(userId uint32, itemId uint32, targetParam ...uint64) {
 		logger.Error("item data config is nil, itemId: %v", itemId)
 		return
 	}
+	if itemId == constant.ITEM_ID_FRAGILE_RESIN {
+		// 脆弱树脂 增加树脂被取消时退还已消耗的脆弱树脂
+		if !g.AddPlayerResin(player, constant.RESIN_FRAGILE_ADD, proto.ActionReasonType_ACTION_REASON_PLAYER_USE_ITEM) {
+			g.RefundPlayerItem(userId, []*ChangeItem{{ItemId: itemId, ChangeCount: 1}})
+		}
+		return
+	}
 	for _, itemUse := range itemDataConfig.ItemUseList {
 		switch itemUse.UseOption {
 		case constant.ITEM_USE_GAIN_AVATAR:
@@ -219,6 +226,33 @@ func (g *Game) AddPlayerItem(userId uint32, itemList []*ChangeItem, hintReason p
 		logger.Error("player is nil, uid: %v", userId)
 		return false
 	}
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdAddItem, &PluginEventAddItem{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		ItemList:    itemList,
+		HintReason:  hintReason,
+	}) {
+		return false
+	}
+	return g.addPlayerItem(player, itemList, hintReason)
+}
+
+// RefundPlayerItem 退还已扣除的消耗 用于获得物品被插件取消或失败后回滚交易 不触发插件事件
+func (g *Game) RefundPlayerItem(userId uint32, itemList []*ChangeItem) {
+	player := USER_MANAGER.GetOnlineUser(userId)
+	if player == nil {
+		logger.Error("player is nil, uid: %v", userId)
+		return
+	}
+	logger.Info("refund player item, itemList: %v, uid: %v", itemList, userId)
+	if !g.addPlayerItem(player, itemList, proto.ActionReasonType_ACTION_REASON_NONE) {
+		logger.Error("refund player item fail, itemList: %v, uid: %v", itemList, userId)
+	}
+}
+
+func (g *Game) addPlayerItem(player *model.Player, itemList []*ChangeItem, hintReason proto.ActionReasonType) bool {
+	userId := player.PlayerId
 	itemMap := make(map[uint32]uint32)
 	for _, changeItem := range itemList {
 		itemMap[changeItem.ItemId] += changeItem.ChangeCount
@@ -318,6 +352,26 @@ func (g *Game) AddPlayerItem(userId uint32, itemList []*ChangeItem, hintReason p
 	return true
 }
 
+// AddPlayerRewardItem 按奖励配置表给予玩家物品 没有配置奖励时视为成功
+func (g *Game) AddPlayerRewardItem(player *model.Player, rewardId uint32, hintReason proto.ActionReasonType) bool {
+	if rewardId == 0 {
+		return true
+	}
+	rewardDataConfig := gdconf.GetRewardDataById(int32(rewardId))
+	if rewardDataConfig == nil {
+		logger.Error("reward data config is nil, rewardId: %v, uid: %v", rewardId, player.PlayerId)
+		return false
+	}
+	rewardItemList := make([]*ChangeItem, 0, len(rewardDataConfig.RewardItemMap))
+	for itemId, count := range rewardDataConfig.RewardItemMap {
+		rewardItemList = append(rewardItemList, &ChangeItem{
+			ItemId:      itemId,
+			ChangeCount: count,
+		})
+	}
+	return g.AddPlayerItem(player.PlayerId, rewardItemList, hintReason)
+}
+
 // CostPlayerItem 消耗玩家物品
 func (g *Game) CostPlayerItem(userId uint32, itemList []*ChangeItem) bool {
 	player := USER_MANAGER.GetOnlineUser(userId)
@@ -325,6 +379,14 @@ func (g *Game) CostPlayerItem(userId uint32, itemList []*ChangeItem) bool {
 		logger.Error("player is nil, uid: %v", userId)
 		return false
 	}
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdCostItem, &PluginEventCostItem{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		ItemList:    itemList,
+	}) {
+		return false
+	}
 	itemMap := make(map[uint32]uint32)
 	for _, changeItem := range itemList {
 		itemMap[changeItem.ItemId] += changeItem.ChangeCount
@@ -80,6 +80,17 @@ func (g *Game) OnLogin(userId uint32, clientSeq uint32, gateAppId string, player
 		return
 	}
 
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdLogin, &PluginEventLogin{
+		PluginEvent: NewPluginEvent(),
+		UserId:      userId,
+		Player:      player,
+		Req:         req,
+	}) {
+		g.SendMsgToGate(cmd.PlayerLoginRsp, userId, clientSeq, gateAppId, &proto.PlayerLoginRsp{Retcode: int32(proto.Retcode_RET_LOGIN_INIT_FAIL)})
+		return
+	}
+
 	if player == nil {
 		logger.Info("reg new player, uid: %v", userId)
 		player = g.CreatePlayer(userId)
@@ -112,6 +123,8 @@ func (g *Game) OnLogin(userId uint32, clientSeq uint32, gateAppId string, player
 	}
 
 	g.TriggerOpenState(userId)
+	g.RefreshDailyTask(player, false)
+	g.ResinRecover(player, false)
 
 	if player.IsBorn {
 		g.LoginNotify(userId, clientSeq, player)
@@ -166,6 +179,7 @@ func (g *Game) CreatePlayer(userId uint32) *model.Player {
 	player.PropMap = make(map[uint32]uint32)
 	player.OpenStateMap = make(map[uint32]uint32)
 	player.ChatMsgMap = make(map[uint32][]*model.ChatMsg)
+	player.MailMap = make(map[uint32]*model.Mail)
 	player.SceneId = 3
 
 	player.PropMap[constant.PLAYER_PROP_PLAYER_WORLD_LEVEL] = 0
@@ -256,6 +270,12 @@ func (g *Game) OnOffline(userId uint32, changeGsInfo *ChangeGsInfo) {
 		return
 	}
 
+	// 触发事件
+	PLUGIN_MANAGER.TriggerEvent(PluginEventIdOffline, &PluginEventOffline{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+	})
+
 	// 回写当前血量和元素能量属性
 	dbAvatar := player.GetDbAvatar()
 	for _, avatar := range dbAvatar.GetAvatarMap() {
@@ -291,5 +311,7 @@ func (g *Game) LoginNotify(userId uint32, clientSeq uint32, player *model.Player
 	g.SendMsg(cmd.QuestListNotify, userId, clientSeq, g.PacketQuestListNotify(player))
 	g.SendMsg(cmd.FinishedParentQuestNotify, userId, clientSeq, g.PacketFinishedParentQuestNotify(player))
 	g.SendMsg(cmd.AllMarkPointNotify, player.PlayerId, player.ClientSeq, &proto.AllMarkPointNotify{MarkList: g.PacketMapMarkPointList(player)})
+	g.DailyTaskNotify(player)
+	g.SendMsg(cmd.ResinChangeNotify, userId, clientSeq, g.PacketResinChangeNotify(player))
 	g.GCGLogin(player) // 发送GCG登录相关的通知包
 }
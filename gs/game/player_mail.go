@@ -3,12 +3,14 @@ package game
 import (
 	"time"
 
+	"hk4e/common/mq"
 	"hk4e/gs/model"
 	"hk4e/pkg/logger"
 	"hk4e/pkg/object"
 	"hk4e/protocol/cmd"
 	"hk4e/protocol/proto"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	pb "google.golang.org/protobuf/proto"
 )
 
@@ -56,9 +58,52 @@ func (g *Game) DelMailReq(player *model.Player, payloadMsg pb.Message) {
 func (g *Game) GetMailItemReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.GetMailItemReq)
 
+	mailList := make([]*model.Mail, 0)
+	changeItemList := make([]*ChangeItem, 0)
+	pbItemList := make([]*proto.EquipParam, 0)
+	for _, mailId := range req.MailIdList {
+		mail, exist := player.MailMap[mailId]
+		if !exist || mail.IsItemGot || len(mail.ItemList) == 0 {
+			continue
+		}
+		mailList = append(mailList, mail)
+		for _, mailItem := range mail.ItemList {
+			changeItemList = append(changeItemList, &ChangeItem{
+				ItemId:      mailItem.ItemId,
+				ChangeCount: mailItem.ItemCount,
+			})
+			pbItemList = append(pbItemList, &proto.EquipParam{
+				ItemId:  mailItem.ItemId,
+				ItemNum: mailItem.ItemCount,
+			})
+		}
+	}
+	if len(changeItemList) > 0 {
+		ok := g.AddPlayerItem(player.PlayerId, changeItemList, proto.ActionReasonType_ACTION_REASON_MAIL_ATTACHMENT)
+		if !ok {
+			g.SendError(cmd.GetMailItemRsp, player, &proto.GetMailItemRsp{})
+			return
+		}
+	}
+	mailIdList := make([]uint32, 0, len(mailList))
+	pbMailList := make([]*proto.MailData, 0, len(mailList))
+	for _, mail := range mailList {
+		mail.IsItemGot = true
+		mail.IsRead = true
+		mailIdList = append(mailIdList, mail.MailId)
+		pbMailList = append(pbMailList, g.PacketMail(mail))
+	}
+	g.saveMailList(mailList)
+	if len(pbMailList) > 0 {
+		g.SendMsg(cmd.MailChangeNotify, player.PlayerId, player.ClientSeq, &proto.MailChangeNotify{
+			MailList:      pbMailList,
+			DelMailIdList: nil,
+		})
+	}
+
 	rsp := &proto.GetMailItemRsp{
-		MailIdList: req.MailIdList,
-		ItemList:   nil,
+		MailIdList: mailIdList,
+		ItemList:   pbItemList,
 	}
 	g.SendMsg(cmd.GetMailItemRsp, player.PlayerId, player.ClientSeq, rsp)
 }
@@ -75,26 +120,167 @@ func (g *Game) ChangeMailStarNotify(player *model.Player, payloadMsg pb.Message)
 
 /************************************************** 游戏功能 **************************************************/
 
-var MailIdSeq uint32 = 0
+const (
+	MailDefaultSender     = "flswld"
+	MailDefaultExpireTime = 30 * 24 * 3600 // 邮件默认有效期 单位秒
+)
 
-func (g *Game) AddPlayerMail(userId uint32, title string, content string) {
-	player := USER_MANAGER.GetOnlineUser(userId)
-	if player == nil {
-		logger.Error("player is nil, uid: %v", userId)
+// SendPlayerMail 发送邮件 在线玩家直接添加 其它gs上的玩家转发过去 离线玩家直接写入db 登录时加载
+func (g *Game) SendPlayerMail(mailInfo *mq.MailInfo) {
+	if mailInfo == nil {
+		return
+	}
+	if mailInfo.GlobalMailId != 0 {
+		g.sendGlobalMail(mailInfo)
 		return
 	}
-	MailIdSeq++
+	remoteUidMap := make(map[string][]uint32)
+	offlineMailList := make([]*model.Mail, 0)
+	for _, userId := range mailInfo.UserIdList {
+		player := USER_MANAGER.GetOnlineUser(userId)
+		if player != nil {
+			g.AddPlayerMail(player, g.newMail(userId, mailInfo))
+			continue
+		}
+		if USER_MANAGER.GetRemoteUserOnlineState(userId) {
+			gsAppId := USER_MANAGER.GetRemoteUserGsAppId(userId)
+			remoteUidMap[gsAppId] = append(remoteUidMap[gsAppId], userId)
+			continue
+		}
+		offlineMailList = append(offlineMailList, g.newMail(userId, mailInfo))
+	}
+	for gsAppId, uidList := range remoteUidMap {
+		remoteMailInfo := *mailInfo
+		remoteMailInfo.UserIdList = uidList
+		g.messageQueue.SendToGs(gsAppId, &mq.NetMsg{
+			MsgType: mq.MsgTypeServer,
+			EventId: mq.ServerSendMailNotify,
+			ServerMsg: &mq.ServerMsg{
+				MailInfo: &remoteMailInfo,
+			},
+		})
+	}
+	if len(offlineMailList) > 0 {
+		USER_MANAGER.InsertMailListToDbAsync(offlineMailList)
+	}
+	logger.Info("send player mail, title: %v, uid num: %v, offline num: %v", mailInfo.Title, len(mailInfo.UserIdList), len(offlineMailList))
+}
+
+// sendGlobalMail 全服邮件发给本gs的在线玩家 离线玩家登录时从db领取
+// 玩家已领取的id与本邮件不连续时 说明中间有漏收的全服邮件 从db补齐
+func (g *Game) sendGlobalMail(mailInfo *mq.MailInfo) {
+	needLoad := false
+	loadGlobalMailId := mailInfo.GlobalMailId
+	for _, player := range USER_MANAGER.GetAllOnlineUserList() {
+		if player.PlayerId < PlayerBaseUid {
+			continue
+		}
+		dbMail := player.GetDbMail()
+		if mailInfo.GlobalMailId <= dbMail.GlobalMailId {
+			continue
+		}
+		if mailInfo.GlobalMailId != dbMail.GlobalMailId+1 {
+			needLoad = true
+			if dbMail.GlobalMailId < loadGlobalMailId {
+				loadGlobalMailId = dbMail.GlobalMailId
+			}
+			continue
+		}
+		dbMail.GlobalMailId = mailInfo.GlobalMailId
+		g.AddPlayerMail(player, g.newMail(player.PlayerId, mailInfo))
+	}
+	logger.Info("send global mail, globalMailId: %v, title: %v", mailInfo.GlobalMailId, mailInfo.Title)
+	if !needLoad {
+		return
+	}
+	logger.Warn("global mail id not continuous, load from db, globalMailId: %v", loadGlobalMailId)
+	go func() {
+		globalMailList := USER_MANAGER.LoadGlobalMailFromDbSync(loadGlobalMailId, uint32(time.Now().Unix()))
+		if globalMailList == nil {
+			return
+		}
+		LOCAL_EVENT_MANAGER.GetLocalEventChan() <- &LocalEvent{
+			EventId: LoadGlobalMailFinish,
+			Msg:     globalMailList,
+		}
+	}()
+}
+
+// OnGlobalMailLoad 从db补齐的全服邮件 按id顺序发给还未领取的在线玩家
+func (g *Game) OnGlobalMailLoad(globalMailList []*model.GlobalMail) {
+	for _, player := range USER_MANAGER.GetAllOnlineUserList() {
+		if player.PlayerId < PlayerBaseUid {
+			continue
+		}
+		dbMail := player.GetDbMail()
+		for _, globalMail := range globalMailList {
+			if globalMail.GlobalMailId <= dbMail.GlobalMailId {
+				continue
+			}
+			dbMail.GlobalMailId = globalMail.GlobalMailId
+			g.AddPlayerMail(player, newGlobalMail(player.PlayerId, globalMail))
+		}
+	}
+}
+
+// newGlobalMail 全服邮件转为玩家的个人邮件 邮件id由调用方分配
+func newGlobalMail(userId uint32, globalMail *model.GlobalMail) *model.Mail {
+	return &model.Mail{
+		ID:         primitive.NewObjectID(),
+		Uid:        userId,
+		MailId:     0,
+		Title:      globalMail.Title,
+		Content:    globalMail.Content,
+		Sender:     globalMail.Sender,
+		SendTime:   globalMail.SendTime,
+		ExpireTime: globalMail.ExpireTime,
+		IsRead:     false,
+		IsStar:     false,
+		ItemList:   globalMail.ItemList,
+		IsItemGot:  false,
+	}
+}
+
+func (g *Game) newMail(userId uint32, mailInfo *mq.MailInfo) *model.Mail {
+	now := uint32(time.Now().Unix())
 	mail := &model.Mail{
-		MailId:     MailIdSeq,
-		Title:      title,
-		Content:    content,
-		Sender:     "flswld",
-		SendTime:   uint32(time.Now().Unix()),
-		ExpireTime: uint32(time.Now().Add(time.Hour * 24).Unix()),
+		ID:         primitive.NewObjectID(),
+		Uid:        userId,
+		MailId:     0,
+		Title:      mailInfo.Title,
+		Content:    mailInfo.Content,
+		Sender:     mailInfo.Sender,
+		SendTime:   mailInfo.SendTime,
+		ExpireTime: mailInfo.ExpireTime,
 		IsRead:     false,
 		IsStar:     false,
+		ItemList:   make([]*model.MailItem, 0, len(mailInfo.ItemList)),
+		IsItemGot:  false,
+	}
+	if mail.Sender == "" {
+		mail.Sender = MailDefaultSender
+	}
+	if mail.SendTime == 0 {
+		mail.SendTime = now
+	}
+	if mail.ExpireTime == 0 {
+		mail.ExpireTime = now + MailDefaultExpireTime
 	}
+	for _, item := range mailInfo.ItemList {
+		mail.ItemList = append(mail.ItemList, &model.MailItem{
+			ItemId:    item.ItemId,
+			ItemCount: item.ItemCount,
+		})
+	}
+	return mail
+}
+
+// AddPlayerMail 在线玩家添加邮件 分配玩家内的邮件id并写入db
+func (g *Game) AddPlayerMail(player *model.Player, mail *model.Mail) {
+	mail.MailId = player.GetDbMail().NextMailId()
 	player.MailMap[mail.MailId] = mail
+	mailCopy := *mail
+	USER_MANAGER.InsertMailListToDbAsync([]*model.Mail{&mailCopy})
 	ntf := &proto.MailChangeNotify{
 		MailList:      []*proto.MailData{g.PacketMail(mail)},
 		DelMailIdList: nil,
@@ -108,29 +294,58 @@ func (g *Game) DelPlayerMail(userId uint32, mailIdList []uint32) {
 		logger.Error("player is nil, uid: %v", userId)
 		return
 	}
+	delMailIdList := make([]uint32, 0, len(mailIdList))
+	idList := make([]primitive.ObjectID, 0, len(mailIdList))
 	for _, mailId := range mailIdList {
+		mail, exist := player.MailMap[mailId]
+		if !exist {
+			continue
+		}
 		delete(player.MailMap, mailId)
+		delMailIdList = append(delMailIdList, mailId)
+		idList = append(idList, mail.ID)
+	}
+	if len(delMailIdList) == 0 {
+		return
 	}
+	USER_MANAGER.DeleteMailListToDbAsync(idList)
 	ntf := &proto.MailChangeNotify{
 		MailList:      nil,
-		DelMailIdList: mailIdList,
+		DelMailIdList: delMailIdList,
 	}
 	g.SendMsg(cmd.MailChangeNotify, player.PlayerId, player.ClientSeq, ntf)
 }
 
+// ClearPlayerExpireMail 删除玩家已过期的邮件
+func (g *Game) ClearPlayerExpireMail(player *model.Player, now uint32) {
+	expireMailIdList := make([]uint32, 0)
+	for mailId, mail := range player.MailMap {
+		if mail.ExpireTime <= now {
+			expireMailIdList = append(expireMailIdList, mailId)
+		}
+	}
+	if len(expireMailIdList) == 0 {
+		return
+	}
+	g.DelPlayerMail(player.PlayerId, expireMailIdList)
+}
+
 func (g *Game) ReadPlayerMail(userId uint32, mailIdList []uint32) {
 	player := USER_MANAGER.GetOnlineUser(userId)
 	if player == nil {
 		logger.Error("player is nil, uid: %v", userId)
 		return
 	}
+	mailList := make([]*model.Mail, 0)
 	for _, mailId := range mailIdList {
 		mail, exist := player.MailMap[mailId]
-		if !exist {
-			return
+		if !exist || mail.IsRead {
+			continue
 		}
 		mail.IsRead = true
+		mailList = append(mailList, mail)
 	}
+	g.saveMailList(mailList)
 }
 
 func (g *Game) StarPlayerMail(userId uint32, mailIdList []uint32, isStar bool) {
@@ -139,19 +354,18 @@ func (g *Game) StarPlayerMail(userId uint32, mailIdList []uint32, isStar bool) {
 		logger.Error("player is nil, uid: %v", userId)
 		return
 	}
+	mailList := make([]*model.Mail, 0)
 	pbMailList := make([]*proto.MailData, 0)
 	for _, mailId := range mailIdList {
 		mail, exist := player.MailMap[mailId]
 		if !exist {
-			return
+			continue
 		}
 		mail.IsStar = isStar
-		pbMail := g.PacketMail(mail)
-		if pbMail == nil {
-			return
-		}
-		pbMailList = append(pbMailList, pbMail)
+		mailList = append(mailList, mail)
+		pbMailList = append(pbMailList, g.PacketMail(mail))
 	}
+	g.saveMailList(mailList)
 	ntf := &proto.MailChangeNotify{
 		MailList:      pbMailList,
 		DelMailIdList: nil,
@@ -159,9 +373,31 @@ func (g *Game) StarPlayerMail(userId uint32, mailIdList []uint32, isStar bool) {
 	g.SendMsg(cmd.MailChangeNotify, player.PlayerId, player.ClientSeq, ntf)
 }
 
+// saveMailList 异步保存修改过的邮件 复制一份避免和主协程并发读写
+func (g *Game) saveMailList(mailList []*model.Mail) {
+	if len(mailList) == 0 {
+		return
+	}
+	mailCopyList := make([]*model.Mail, 0, len(mailList))
+	for _, mail := range mailList {
+		mailCopy := *mail
+		mailCopyList = append(mailCopyList, &mailCopy)
+	}
+	USER_MANAGER.UpdateMailListToDbAsync(mailCopyList)
+}
+
 /************************************************** 打包封装 **************************************************/
 
 func (g *Game) PacketMail(mail *model.Mail) *proto.MailData {
+	pbItemList := make([]*proto.MailItem, 0, len(mail.ItemList))
+	for _, mailItem := range mail.ItemList {
+		pbItemList = append(pbItemList, &proto.MailItem{
+			EquipParam: &proto.EquipParam{
+				ItemId:  mailItem.ItemId,
+				ItemNum: mailItem.ItemCount,
+			},
+		})
+	}
 	return &proto.MailData{
 		MailId: mail.MailId,
 		MailTextContent: &proto.MailTextContent{
@@ -169,12 +405,12 @@ func (g *Game) PacketMail(mail *model.Mail) *proto.MailData {
 			Content: mail.Content,
 			Sender:  mail.Sender,
 		},
-		ItemList:        nil,
+		ItemList:        pbItemList,
 		SendTime:        mail.SendTime,
 		ExpireTime:      mail.ExpireTime,
 		Importance:      uint32(object.ConvBoolToInt64(mail.IsStar)),
 		IsRead:          mail.IsRead,
-		IsAttachmentGot: false,
+		IsAttachmentGot: mail.IsItemGot,
 		ConfigId:        0,
 		ArgumentList:    nil,
 		CollectState:    proto.MailCollectState_MAIL_NOT_COLLECTIBLE,
@@ -153,14 +153,108 @@ func (g *Game) JoinPlayerSceneReq(player *model.Player, payloadMsg pb.Message) {
 
 // PlayerStartMatchReq 开始匹配请求
 func (g *Game) PlayerStartMatchReq(player *model.Player, payloadMsg pb.Message) {
+	req := payloadMsg.(*proto.PlayerStartMatchReq)
+	// 匹配队列全服统一在主多功能服务器上 不使用玩家自己分配到的多功能服务器
+	if g.mainMultiAppid == "" {
+		g.SendError(cmd.PlayerStartMatchRsp, player, &proto.PlayerStartMatchRsp{}, proto.Retcode_RET_MP_MATCH_PLAY_NOT_OPEN)
+		return
+	}
+	if req.MatchType != proto.MatchType_MATCH_TYPE_DUNGEON && req.MatchType != proto.MatchType_MATCH_TYPE_MP_PLAY && req.MatchType != proto.MatchType_MATCH_TYPE_GENERAL {
+		g.SendError(cmd.PlayerStartMatchRsp, player, &proto.PlayerStartMatchRsp{}, proto.Retcode_RET_MP_MATCH_PLAY_NOT_OPEN)
+		return
+	}
+	world := WORLD_MANAGER.GetWorldById(player.WorldId)
+	if world == nil {
+		logger.Error("world is nil, worldId: %v, uid: %v", player.WorldId, player.PlayerId)
+		return
+	}
+	if world.IsMultiplayerWorld() {
+		g.SendError(cmd.PlayerStartMatchRsp, player, &proto.PlayerStartMatchRsp{}, proto.Retcode_RET_MP_IN_MP_MODE)
+		return
+	}
+	player.MatchServerAppId = g.mainMultiAppid
+	g.messageQueue.SendToMulti(player.MatchServerAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerMatchReq,
+		ServerMsg: &mq.ServerMsg{
+			MatchInfo: &mq.MatchInfo{
+				MatchCmd:   mq.MatchCmdStartReq,
+				UserId:     player.PlayerId,
+				MatchType:  uint32(req.MatchType),
+				DungeonId:  req.DungeonId,
+				MpPlayId:   req.MpPlayId,
+				WorldLevel: player.PropMap[constant.PLAYER_PROP_PLAYER_WORLD_LEVEL],
+				PlayerInfo: &mq.PlayerBaseInfo{
+					UserId:         player.PlayerId,
+					Nickname:       player.NickName,
+					PlayerLevel:    player.PropMap[constant.PLAYER_PROP_PLAYER_LEVEL],
+					MpSettingType:  uint8(player.PropMap[constant.PLAYER_PROP_PLAYER_MP_SETTING_TYPE]),
+					NameCardId:     player.GetDbSocial().NameCard,
+					Signature:      player.Signature,
+					HeadImageId:    player.HeadImage,
+					WorldPlayerNum: uint32(world.GetWorldPlayerNum()),
+					WorldLevel:     player.PropMap[constant.PLAYER_PROP_PLAYER_WORLD_LEVEL],
+				},
+			},
+		},
+	})
+	rsp := &proto.PlayerStartMatchRsp{
+		MatchType: req.MatchType,
+		DungeonId: req.DungeonId,
+		MpPlayId:  req.MpPlayId,
+		MatchId:   req.MatchId,
+	}
+	g.SendMsg(cmd.PlayerStartMatchRsp, player.PlayerId, player.ClientSeq, rsp)
 }
 
 // PlayerCancelMatchReq 取消匹配请求
 func (g *Game) PlayerCancelMatchReq(player *model.Player, payloadMsg pb.Message) {
+	req := payloadMsg.(*proto.PlayerCancelMatchReq)
+	if player.MatchServerAppId == "" {
+		g.SendError(cmd.PlayerCancelMatchRsp, player, &proto.PlayerCancelMatchRsp{}, proto.Retcode_RET_MATCH_NOT_IN_MATCH)
+		return
+	}
+	g.messageQueue.SendToMulti(player.MatchServerAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerMatchReq,
+		ServerMsg: &mq.ServerMsg{
+			MatchInfo: &mq.MatchInfo{
+				MatchCmd:  mq.MatchCmdCancelReq,
+				UserId:    player.PlayerId,
+				MatchType: uint32(req.MatchType),
+			},
+		},
+	})
+	rsp := &proto.PlayerCancelMatchRsp{
+		MatchType: req.MatchType,
+	}
+	g.SendMsg(cmd.PlayerCancelMatchRsp, player.PlayerId, player.ClientSeq, rsp)
 }
 
 // PlayerConfirmMatchReq 确认匹配请求
 func (g *Game) PlayerConfirmMatchReq(player *model.Player, payloadMsg pb.Message) {
+	req := payloadMsg.(*proto.PlayerConfirmMatchReq)
+	if player.MatchServerAppId == "" {
+		g.SendError(cmd.PlayerConfirmMatchRsp, player, &proto.PlayerConfirmMatchRsp{}, proto.Retcode_RET_MATCH_NOT_IN_MATCH)
+		return
+	}
+	g.messageQueue.SendToMulti(player.MatchServerAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerMatchReq,
+		ServerMsg: &mq.ServerMsg{
+			MatchInfo: &mq.MatchInfo{
+				MatchCmd:  mq.MatchCmdConfirmReq,
+				UserId:    player.PlayerId,
+				MatchType: uint32(req.MatchType),
+				IsAgreed:  req.IsAgreed,
+			},
+		},
+	})
+	rsp := &proto.PlayerConfirmMatchRsp{
+		MatchType: req.MatchType,
+		IsAgreed:  req.IsAgreed,
+	}
+	g.SendMsg(cmd.PlayerConfirmMatchRsp, player.PlayerId, player.ClientSeq, rsp)
 }
 
 /************************************************** 游戏功能 **************************************************/
@@ -725,4 +819,116 @@ func (g *Game) ServerPlayerMpRsp(playerMpInfo *mq.PlayerMpInfo) {
 	}
 }
 
+// 多功能服务器匹配相关通知
+
+func (g *Game) ServerMatchNotify(matchInfo *mq.MatchInfo) {
+	player := USER_MANAGER.GetOnlineUser(matchInfo.UserId)
+	if player == nil {
+		logger.Error("player is nil, uid: %v", matchInfo.UserId)
+		return
+	}
+	switch matchInfo.MatchCmd {
+	case mq.MatchCmdInfoNotify:
+		playerMatchInfoNotify := &proto.PlayerMatchInfoNotify{
+			MatchId:        matchInfo.MatchId,
+			MatchBeginTime: matchInfo.MatchBeginTime,
+			DungeonId:      matchInfo.DungeonId,
+			MatchType:      proto.MatchType(matchInfo.MatchType),
+			MpPlayId:       matchInfo.MpPlayId,
+		}
+		g.SendMsg(cmd.PlayerMatchInfoNotify, player.PlayerId, player.ClientSeq, playerMatchInfoNotify)
+	case mq.MatchCmdSuccNotify:
+		playerMatchSuccNotify := &proto.PlayerMatchSuccNotify{
+			GeneralMatchInfo: &proto.GeneralMatchInfo{
+				MatchId:    matchInfo.MatchId,
+				PlayerList: g.PacketMatchPlayerInfoList(matchInfo.PlayerList),
+			},
+			MpPlayId:       matchInfo.MpPlayId,
+			HostUid:        matchInfo.HostUserId,
+			MatchType:      proto.MatchType(matchInfo.MatchType),
+			ConfirmEndTime: matchInfo.ConfirmEndTime,
+			DungeonId:      matchInfo.DungeonId,
+		}
+		g.SendMsg(cmd.PlayerMatchSuccNotify, player.PlayerId, player.ClientSeq, playerMatchSuccNotify)
+	case mq.MatchCmdConfirmNotify:
+		playerGeneralMatchConfirmNotify := &proto.PlayerGeneralMatchConfirmNotify{
+			MatchId: matchInfo.MatchId,
+			IsAgree: matchInfo.IsAgreed,
+			Uid:     matchInfo.PlayerInfo.UserId,
+		}
+		g.SendMsg(cmd.PlayerGeneralMatchConfirmNotify, player.PlayerId, player.ClientSeq, playerGeneralMatchConfirmNotify)
+	case mq.MatchCmdDismissNotify:
+		playerGeneralMatchDismissNotify := &proto.PlayerGeneralMatchDismissNotify{
+			UidList: make([]uint32, 0, len(matchInfo.PlayerList)),
+			Reason:  proto.MatchReason(matchInfo.Reason),
+			MatchId: matchInfo.MatchId,
+		}
+		for _, matchPlayerInfo := range matchInfo.PlayerList {
+			playerGeneralMatchDismissNotify.UidList = append(playerGeneralMatchDismissNotify.UidList, matchPlayerInfo.PlayerInfo.UserId)
+		}
+		g.SendMsg(cmd.PlayerGeneralMatchDismissNotify, player.PlayerId, player.ClientSeq, playerGeneralMatchDismissNotify)
+	case mq.MatchCmdStopNotify:
+		playerMatchStopNotify := &proto.PlayerMatchStopNotify{
+			Reason: proto.MatchReason(matchInfo.Reason),
+		}
+		player.MatchServerAppId = ""
+		g.SendMsg(cmd.PlayerMatchStopNotify, player.PlayerId, player.ClientSeq, playerMatchStopNotify)
+	case mq.MatchCmdFinishNotify:
+		player.MatchServerAppId = ""
+		if player.PlayerId != matchInfo.HostUserId {
+			playerMatchAgreedResultNotify := &proto.PlayerMatchAgreedResultNotify{
+				TargetUid: matchInfo.HostUserId,
+				MatchType: proto.MatchType(matchInfo.MatchType),
+				Reason:    proto.PlayerMatchAgreedResultNotify_SUCC,
+			}
+			g.SendMsg(cmd.PlayerMatchAgreedResultNotify, player.PlayerId, player.ClientSeq, playerMatchAgreedResultNotify)
+			return
+		}
+		world := WORLD_MANAGER.GetWorldById(player.WorldId)
+		if world == nil {
+			logger.Error("world is nil, worldId: %v, uid: %v", player.WorldId, player.PlayerId)
+			return
+		}
+		if proto.MatchType(matchInfo.MatchType) == proto.MatchType_MATCH_TYPE_DUNGEON && matchInfo.DungeonId != 0 {
+			// 全员进入房主的多人世界后再各自进入地牢
+			for _, matchPlayerInfo := range matchInfo.PlayerList {
+				world.AddMatchDungeonPlayer(matchPlayerInfo.PlayerInfo.UserId, matchInfo.DungeonId)
+			}
+		}
+		g.HostEnterMpWorld(player)
+		// 房主直接同意其他玩家进入自己的世界 走原有的跨服多人世界流程
+		for _, matchPlayerInfo := range matchInfo.PlayerList {
+			otherUid := matchPlayerInfo.PlayerInfo.UserId
+			if otherUid == player.PlayerId {
+				continue
+			}
+			player.CoopApplyMap[otherUid] = time.Now().UnixNano()
+			g.PlayerDealEnterWorld(player, otherUid, true)
+		}
+	}
+}
+
 /************************************************** 打包封装 **************************************************/
+
+func (g *Game) PacketMatchPlayerInfoList(matchPlayerInfoList []*mq.MatchPlayerInfo) []*proto.MatchPlayerInfo {
+	pbMatchPlayerInfoList := make([]*proto.MatchPlayerInfo, 0, len(matchPlayerInfoList))
+	for _, matchPlayerInfo := range matchPlayerInfoList {
+		playerInfo := matchPlayerInfo.PlayerInfo
+		pbMatchPlayerInfoList = append(pbMatchPlayerInfoList, &proto.MatchPlayerInfo{
+			IsAgreed: matchPlayerInfo.IsAgreed,
+			PlayerInfo: &proto.OnlinePlayerInfo{
+				Uid:                 playerInfo.UserId,
+				Nickname:            playerInfo.Nickname,
+				PlayerLevel:         playerInfo.PlayerLevel,
+				AvatarId:            playerInfo.HeadImageId,
+				MpSettingType:       proto.MpSettingType(playerInfo.MpSettingType),
+				NameCardId:          playerInfo.NameCardId,
+				Signature:           playerInfo.Signature,
+				ProfilePicture:      &proto.ProfilePicture{AvatarId: playerInfo.HeadImageId},
+				CurPlayerNumInWorld: playerInfo.WorldPlayerNum,
+				WorldLevel:          playerInfo.WorldLevel,
+			},
+		})
+	}
+	return pbMatchPlayerInfoList
+}
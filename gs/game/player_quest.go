@@ -226,6 +226,14 @@ func (g *Game) AcceptQuest(player *model.Player, notifyClient bool) {
 // StartQuest 开始任务
 func (g *Game) StartQuest(player *model.Player, questId uint32, notifyClient bool) {
 	g.EndlessLoopCheck(EndlessLoopCheckTypeStartQuest)
+	// 触发事件
+	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdQuestStart, &PluginEventQuestStart{
+		PluginEvent: NewPluginEvent(),
+		Player:      player,
+		QuestId:     questId,
+	}) {
+		return
+	}
 	dbQuest := player.GetDbQuest()
 	dbQuest.StartQuest(questId)
 
@@ -255,6 +263,15 @@ func (g *Game) ExecQuest(player *model.Player, questId uint32, questExecType int
 	var questExecList []*gdconf.QuestExec = nil
 	switch questExecType {
 	case QuestExecTypeFinish:
+		// 触发事件
+		if PLUGIN_MANAGER.TriggerEvent(PluginEventIdQuestFinish, &PluginEventQuestFinish{
+			PluginEvent: NewPluginEvent(),
+			Player:      player,
+			QuestId:     questId,
+		}) {
+			return
+		}
+		g.DungeonPassCondCheck(player, constant.DUNGEON_PASS_COND_FINISH_QUEST, int32(questId))
 		questExecList = questDataConfig.ExecList
 	case QuestExecTypeFail:
 		questExecList = questDataConfig.FailExecList
@@ -424,6 +441,10 @@ func (g *Game) ExecQuest(player *model.Player, questId uint32, questExecType int
 // TriggerQuest 触发任务
 func (g *Game) TriggerQuest(player *model.Player, cond int32, complexParam string, param ...int32) {
 	g.EndlessLoopCheck(EndlessLoopCheckTypeTriggerQuest)
+	// 每日委托与任务共用完成条件 可被客户端伪造的条件不计入委托进度
+	if !constant.QUEST_FINISH_COND_CLIENT_FORGEABLE[cond] {
+		g.TriggerDailyTask(player, cond, complexParam, param...)
+	}
 	dbQuest := player.GetDbQuest()
 	updateQuestIdList := make([]uint32, 0)
 	for _, quest := range dbQuest.GetQuestMap() {
@@ -468,6 +489,22 @@ func (g *Game) TriggerQuest(player *model.Player, cond int32, complexParam strin
 				}
 				dbQuest.ForceFinishQuest(quest.QuestId)
 				updateQuestIdList = append(updateQuestIdList, quest.QuestId)
+			case constant.QUEST_FINISH_COND_TYPE_FINISH_DUNGEON:
+				// 地牢通关 参数1:地牢id
+				ok := matchParamEqual(questCond.Param, param, 1)
+				if !ok {
+					continue
+				}
+				dbQuest.ForceFinishQuest(quest.QuestId)
+				updateQuestIdList = append(updateQuestIdList, quest.QuestId)
+			case constant.QUEST_FINISH_COND_TYPE_FAIL_DUNGEON:
+				// 地牢失败 参数1:地牢id
+				ok := matchParamEqual(questCond.Param, param, 1)
+				if !ok {
+					continue
+				}
+				dbQuest.ForceFinishQuest(quest.QuestId)
+				updateQuestIdList = append(updateQuestIdList, quest.QuestId)
 			case constant.QUEST_FINISH_COND_TYPE_UNLOCK_TRANS_POINT:
 				// 解锁传送锚点 参数1:场景id 参数2:传送锚点id
 				ok := matchParamEqual(questCond.Param, param, 2)
@@ -62,6 +62,11 @@ func (g *Game) ReliquaryUpgradeReq(player *model.Player, payloadMsg pb.Message)
 	}
 
 	g.SendMsg(cmd.StoreItemChangeNotify, player.PlayerId, player.ClientSeq, g.PacketStoreItemChangeNotifyByReliquary(reliquary))
+	// 主属性随等级成长 被装备时更新面板
+	avatar := player.GetDbAvatar().GetAvatarById(reliquary.AvatarId)
+	if avatar != nil {
+		g.UpdatePlayerAvatarFightProp(player.PlayerId, avatar.AvatarId)
+	}
 
 	rsp := &proto.ReliquaryUpgradeRsp{
 		OldLevel:            uint32(oldLevel),
@@ -74,10 +79,22 @@ func (g *Game) ReliquaryUpgradeReq(player *model.Player, payloadMsg pb.Message)
 	g.SendMsg(cmd.ReliquaryUpgradeRsp, player.PlayerId, player.ClientSeq, rsp)
 }
 
+// ReliquaryPromoteReq 圣遗物突破请求
+// gdconf中的圣遗物配置表(ReliquaryData ReliquaryLevelData)只有强化等级数据 没有突破的材料和等阶配置 突破暂不支持
 func (g *Game) ReliquaryPromoteReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.ReliquaryPromoteReq)
-	logger.Debug("ReliquaryPromoteReq: %+v", req)
-	g.SendMsg(cmd.ReliquaryPromoteRsp, player.PlayerId, player.ClientSeq, new(proto.ReliquaryPromoteRsp))
+	reliquary, ok := player.GameObjectGuidMap[req.TargetGuid].(*model.Reliquary)
+	if !ok {
+		logger.Error("reliquary error, reliquaryGuid: %v", req.TargetGuid)
+		g.SendError(cmd.ReliquaryPromoteRsp, player, &proto.ReliquaryPromoteRsp{}, proto.Retcode_RET_ITEM_NOT_EXIST)
+		return
+	}
+	logger.Error("reliquary promote not support, itemId: %v, uid: %v", reliquary.ItemId, player.PlayerId)
+	g.SendError(cmd.ReliquaryPromoteRsp, player, &proto.ReliquaryPromoteRsp{
+		TargetReliquaryGuid: req.TargetGuid,
+		OldPromoteLevel:     uint32(reliquary.Promote),
+		CurPromoteLevel:     uint32(reliquary.Promote),
+	}, proto.Retcode_RET_NOT_SUPPORT_ITEM)
 }
 
 /************************************************** 游戏功能 **************************************************/
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"time"
+
+	"hk4e/common/constant"
+	"hk4e/gs/model"
+	"hk4e/pkg/logger"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+
+	pb "google.golang.org/protobuf/proto"
+)
+
+/************************************************** 接口请求 **************************************************/
+
+// BuyResinReq 原石购买树脂请求
+func (g *Game) BuyResinReq(player *model.Player, payloadMsg pb.Message) {
+	dbResin := player.GetDbResin()
+	g.resetResinBuyCount(player)
+	if dbResin.BuyCount >= uint32(len(constant.RESIN_BUY_COST_LIST)) {
+		logger.Error("resin buy count exceeded, buyCount: %v, uid: %v", dbResin.BuyCount, player.PlayerId)
+		g.SendError(cmd.BuyResinRsp, player, &proto.BuyResinRsp{}, proto.Retcode_RET_RESIN_BOUGHT_COUNT_EXCEEDED)
+		return
+	}
+	costHcoin := constant.RESIN_BUY_COST_LIST[dbResin.BuyCount]
+	ok := g.CostPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constant.ITEM_ID_HCOIN, ChangeCount: costHcoin}})
+	if !ok {
+		logger.Error("hcoin not enough, cost: %v, uid: %v", costHcoin, player.PlayerId)
+		g.SendError(cmd.BuyResinRsp, player, &proto.BuyResinRsp{}, proto.Retcode_RET_HCOIN_NOT_ENOUGH)
+		return
+	}
+	if !g.AddPlayerResin(player, constant.RESIN_BUY_ADD, proto.ActionReasonType_ACTION_REASON_BUY_RESIN) {
+		g.RefundPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constant.ITEM_ID_HCOIN, ChangeCount: costHcoin}})
+		g.SendError(cmd.BuyResinRsp, player, &proto.BuyResinRsp{}, proto.Retcode_RET_RESIN_GAIN_FAILED)
+		return
+	}
+	dbResin.BuyCount++
+
+	rsp := &proto.BuyResinRsp{
+		CurValue: player.PropMap[constant.PLAYER_PROP_PLAYER_RESIN],
+	}
+	g.SendMsg(cmd.BuyResinRsp, player.PlayerId, player.ClientSeq, rsp)
+}
+
+/************************************************** 游戏功能 **************************************************/
+
+// ResinRecover 树脂自然恢复 离线期间的恢复也会在上线时补上
+func (g *Game) ResinRecover(player *model.Player, notifyClient bool) {
+	dbResin := player.GetDbResin()
+	now := uint32(time.Now().Unix())
+	resin := player.PropMap[constant.PLAYER_PROP_PLAYER_RESIN]
+	oldResin := resin
+	oldNextRecoverTime := dbResin.NextRecoverTime
+	if resin < constant.RESIN_MAX {
+		if dbResin.NextRecoverTime == 0 {
+			dbResin.NextRecoverTime = now + constant.RESIN_RECOVER_INTERVAL
+		}
+		for resin < constant.RESIN_MAX && now >= dbResin.NextRecoverTime {
+			resin++
+			dbResin.NextRecoverTime += constant.RESIN_RECOVER_INTERVAL
+		}
+	}
+	if resin >= constant.RESIN_MAX {
+		dbResin.NextRecoverTime = 0
+	}
+	if resin == oldResin && dbResin.NextRecoverTime == oldNextRecoverTime {
+		return
+	}
+	player.PropMap[constant.PLAYER_PROP_PLAYER_RESIN] = resin
+	if !notifyClient {
+		return
+	}
+	if resin != oldResin {
+		g.SendMsg(cmd.PlayerPropNotify, player.PlayerId, player.ClientSeq, g.PacketPlayerPropNotify(player, constant.PLAYER_PROP_PLAYER_RESIN))
+	}
+	g.SendMsg(cmd.ResinChangeNotify, player.PlayerId, player.ClientSeq, g.PacketResinChangeNotify(player))
+}
+
+// AddPlayerResin 增加树脂 可超出自然恢复上限
+func (g *Game) AddPlayerResin(player *model.Player, count uint32, hintReason proto.ActionReasonType) bool {
+	ok := g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constant.ITEM_ID_RESIN, ChangeCount: count}}, hintReason)
+	if !ok {
+		return false
+	}
+	g.ResinRecover(player, false)
+	g.SendMsg(cmd.ResinChangeNotify, player.PlayerId, player.ClientSeq, g.PacketResinChangeNotify(player))
+	return true
+}
+
+// CostPlayerResin 消耗树脂
+func (g *Game) CostPlayerResin(player *model.Player, count uint32) bool {
+	if player.PropMap[constant.PLAYER_PROP_PLAYER_RESIN] < count {
+		return false
+	}
+	ok := g.CostPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: constant.ITEM_ID_RESIN, ChangeCount: count}})
+	if !ok {
+		return false
+	}
+	g.ResinRecover(player, false)
+	g.SendMsg(cmd.ResinChangeNotify, player.PlayerId, player.ClientSeq, g.PacketResinChangeNotify(player))
+	return true
+}
+
+// resetResinBuyCount 跨天重置树脂购买次数
+func (g *Game) resetResinBuyCount(player *model.Player) {
+	dbResin := player.GetDbResin()
+	now := time.Now()
+	if uint32(now.Unix()) < dbResin.BuyCountResetTime {
+		return
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dbResin.BuyCount = 0
+	dbResin.BuyCountResetTime = uint32(today.AddDate(0, 0, 1).Unix())
+}
+
+/************************************************** 打包封装 **************************************************/
+
+func (g *Game) PacketResinChangeNotify(player *model.Player) *proto.ResinChangeNotify {
+	g.resetResinBuyCount(player)
+	dbResin := player.GetDbResin()
+	return &proto.ResinChangeNotify{
+		NextAddTimestamp: dbResin.NextRecoverTime,
+		CurBuyCount:      dbResin.BuyCount,
+		CurValue:         player.PropMap[constant.PLAYER_PROP_PLAYER_RESIN],
+	}
+}
@@ -442,6 +442,12 @@ func (g *Game) PostEnterSceneReq(player *model.Player, payloadMsg pb.Message) {
 	}
 	g.SendMsg(cmd.PostEnterSceneRsp, player.PlayerId, player.ClientSeq, rsp)
 
+	// 匹配成功的玩家进入房主世界后再进入地牢
+	matchDungeonId := world.PopMatchDungeonId(player.PlayerId)
+	if matchDungeonId != 0 {
+		g.EnterMatchDungeon(player, matchDungeonId)
+	}
+
 	// 触发事件
 	if PLUGIN_MANAGER.TriggerEvent(PluginEventIdPostEnterScene, &PluginEventPostEnterScene{
 		PluginEvent: NewPluginEvent(),
@@ -871,6 +877,26 @@ func (g *Game) KillEntity(player *model.Player, scene *Scene, entityId uint32, d
 	if entity == nil {
 		return
 	}
+	if entity.GetEntityType() == constant.ENTITY_TYPE_MONSTER {
+		// 触发事件
+		if PLUGIN_MANAGER.TriggerEvent(PluginEventIdMonsterDie, &PluginEventMonsterDie{
+			PluginEvent: NewPluginEvent(),
+			Player:      player,
+			EntityId:    entity.GetId(),
+			MonsterId:   entity.GetMonsterEntity().GetMonsterId(),
+			GroupId:     entity.GetGroupId(),
+			ConfigId:    entity.GetConfigId(),
+			DieType:     dieType,
+		}) {
+			// 调用方可能已将血量扣到0 恢复满血以免怪物处于血量为0却存活的状态
+			fightProp := entity.GetFightProp()
+			if fightProp[constant.FIGHT_PROP_CUR_HP] <= 0.0 {
+				fightProp[constant.FIGHT_PROP_CUR_HP] = fightProp[constant.FIGHT_PROP_MAX_HP]
+				g.EntityFightPropUpdateNotifyBroadcast(scene, entity)
+			}
+			return
+		}
+	}
 	// 设置血量
 	entity.SetLastDieType(int32(dieType))
 	entity.SetLifeState(constant.LIFE_STATE_DEAD)
@@ -884,6 +910,8 @@ func (g *Game) KillEntity(player *model.Player, scene *Scene, entityId uint32, d
 	g.SendToSceneA(scene, cmd.LifeStateChangeNotify, 0, ntf, 0)
 
 	if entity.GetEntityType() == constant.ENTITY_TYPE_AVATAR {
+		// 地牢全队阵亡检测
+		g.DungeonAvatarDie(player, scene)
 		return
 	}
 
@@ -910,8 +938,11 @@ func (g *Game) KillEntity(player *model.Player, scene *Scene, entityId uint32, d
 	case constant.ENTITY_TYPE_MONSTER:
 		// 随机掉落
 		g.monsterDrop(player, MonsterDropTypeKill, 0, entity)
+		g.TriggerQuest(player, constant.QUEST_FINISH_COND_TYPE_MONSTER_DIE, "", int32(entity.GetMonsterEntity().GetMonsterId()))
 		// 怪物死亡触发器检测
 		g.MonsterDieTriggerCheck(player, group)
+		// 地牢挑战及通关条件
+		g.DungeonMonsterDie(player, group, entity.GetMonsterEntity().GetMonsterId())
 	case constant.ENTITY_TYPE_GADGET:
 		// 物件死亡触发器检测
 		g.GadgetDieTriggerCheck(player, group, entity.GetConfigId())
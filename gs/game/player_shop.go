@@ -1,15 +1,24 @@
 package game
 
 import (
+	"math"
+	"sort"
 	"time"
 
+	"hk4e/common/constant"
+	"hk4e/gdconf"
 	"hk4e/gs/model"
+	"hk4e/pkg/logger"
 	"hk4e/protocol/cmd"
 	"hk4e/protocol/proto"
 
 	pb "google.golang.org/protobuf/proto"
 )
 
+const (
+	SHOP_BUY_COUNT_MAX = 9999 // 单次购买商品的最大数量
+)
+
 /************************************************** 接口请求 **************************************************/
 
 func (g *Game) GetShopmallDataReq(player *model.Player, payloadMsg pb.Message) {
@@ -21,76 +30,89 @@ func (g *Game) GetShopmallDataReq(player *model.Player, payloadMsg pb.Message) {
 
 func (g *Game) GetShopReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.GetShopReq)
-	shopType := req.ShopType
-
-	if shopType != 1001 {
+	shopDataConfig := gdconf.GetShopDataByShopType(int32(req.ShopType))
+	if shopDataConfig == nil {
+		logger.Error("get shop data config is nil, shopType: %v, uid: %v", req.ShopType, player.PlayerId)
+		g.SendError(cmd.GetShopRsp, player, &proto.GetShopRsp{}, proto.Retcode_RET_SHOP_NOT_OPEN)
 		return
 	}
 
-	nextRefreshTime := uint32(time.Now().Add(time.Hour * 24 * 30).Unix())
-
 	getShopRsp := &proto.GetShopRsp{
-		Shop: &proto.Shop{
-			GoodsList: []*proto.ShopGoods{
-				{
-					MinLevel:        1,
-					EndTime:         2051193600,
-					Hcoin:           160,
-					GoodsId:         102001,
-					NextRefreshTime: nextRefreshTime,
-					MaxLevel:        99,
-					BeginTime:       1575129600,
-					GoodsItem: &proto.ItemParam{
-						ItemId: 223,
-						Count:  1,
-					},
-				},
-				{
-					MinLevel:        1,
-					EndTime:         2051193600,
-					Hcoin:           160,
-					GoodsId:         102002,
-					NextRefreshTime: nextRefreshTime,
-					MaxLevel:        99,
-					BeginTime:       1575129600,
-					GoodsItem: &proto.ItemParam{
-						ItemId: 224,
-						Count:  1,
-					},
-				},
-			},
-			NextRefreshTime: nextRefreshTime,
-			ShopType:        1001,
-		},
+		Shop: g.PacketShop(player, shopDataConfig),
 	}
 	g.SendMsg(cmd.GetShopRsp, player.PlayerId, player.ClientSeq, getShopRsp)
 }
 
 func (g *Game) BuyGoodsReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.BuyGoodsReq)
-	buyItemId := req.Goods.GoodsItem.ItemId
-	buyItemCount := req.BuyCount
-	costHcoinCount := req.Goods.Hcoin * buyItemCount
-
-	if buyItemId != 223 && buyItemId != 224 {
+	if req.Goods == nil || req.BuyCount == 0 || req.BuyCount > SHOP_BUY_COUNT_MAX {
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_BUY_NUM_ERROR)
 		return
 	}
-
-	if g.GetPlayerItemCount(player.PlayerId, 201) < costHcoinCount {
+	goodsDataConfig := gdconf.GetShopGoodsDataById(int32(req.Goods.GoodsId))
+	if goodsDataConfig == nil || uint32(goodsDataConfig.ShopType) != req.ShopType {
+		logger.Error("get shop goods data config is nil, goodsId: %v, shopType: %v, uid: %v", req.Goods.GoodsId, req.ShopType, player.PlayerId)
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_NOT_EXIST)
+		return
+	}
+	now := time.Now()
+	// 检查上下架时间
+	if !g.IsShopGoodsInTime(goodsDataConfig, uint32(now.Unix())) {
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_NOT_IN_TIME)
+		return
+	}
+	// 检查冒险等级
+	playerLevel := player.PropMap[constant.PLAYER_PROP_PLAYER_LEVEL]
+	if (goodsDataConfig.MinPlayerLevel != 0 && playerLevel < uint32(goodsDataConfig.MinPlayerLevel)) ||
+		(goodsDataConfig.MaxPlayerLevel != 0 && playerLevel > uint32(goodsDataConfig.MaxPlayerLevel)) {
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_PLAYER_LEVEL_LESS_THAN)
+		return
+	}
+	// 检查限购数量
+	dbShop := player.GetDbShop()
+	goods := dbShop.GetShop(req.ShopType).GetGoods(req.Goods.GoodsId)
+	g.RefreshShopGoods(goodsDataConfig, goods, now)
+	if goodsDataConfig.BuyLimit != 0 && uint64(goods.BoughtNum)+uint64(req.BuyCount) > uint64(goodsDataConfig.BuyLimit) {
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_BUY_NUM_NOT_ENOUGH)
 		return
 	}
-	ok := g.CostPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 201, ChangeCount: costHcoinCount}})
+	// 获得的商品数量 用uint64计算防止溢出
+	addCount := uint64(goodsDataConfig.ItemCount) * uint64(req.BuyCount)
+	if addCount > math.MaxUint32 {
+		logger.Error("buy goods count overflow, goodsId: %v, buyCount: %v, uid: %v", req.Goods.GoodsId, req.BuyCount, player.PlayerId)
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_BUY_NUM_ERROR)
+		return
+	}
+	// 消耗货币和物品
+	costItemList := make([]*ChangeItem, 0)
+	for itemId, count := range g.GetShopGoodsCostItemMap(goodsDataConfig) {
+		costCount := uint64(count) * uint64(req.BuyCount)
+		if uint64(g.GetPlayerItemCount(player.PlayerId, itemId)) < costCount {
+			g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_MATERIAL_NOT_ENOUGH)
+			return
+		}
+		costItemList = append(costItemList, &ChangeItem{ItemId: itemId, ChangeCount: uint32(costCount)})
+	}
+	ok := g.CostPlayerItem(player.PlayerId, costItemList)
 	if !ok {
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{}, proto.Retcode_RET_GOODS_MATERIAL_NOT_ENOUGH)
 		return
 	}
+	ok = g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: uint32(goodsDataConfig.ItemId), ChangeCount: uint32(addCount)}}, proto.ActionReasonType_ACTION_REASON_SHOP)
+	if !ok {
+		// 获得商品失败 退还消耗的货币和物品
+		g.RefundPlayerItem(player.PlayerId, costItemList)
+		g.SendError(cmd.BuyGoodsRsp, player, &proto.BuyGoodsRsp{})
+		return
+	}
+	goods.BoughtNum += req.BuyCount
 
-	g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: buyItemId, ChangeCount: buyItemCount}}, proto.ActionReasonType_ACTION_REASON_SHOP)
-	req.Goods.BoughtNum = g.GetPlayerItemCount(player.PlayerId, buyItemId)
-
+	pbGoods := g.PacketShopGoods(goodsDataConfig, goods)
 	buyGoodsRsp := &proto.BuyGoodsRsp{
 		ShopType:  req.ShopType,
 		BuyCount:  req.BuyCount,
-		GoodsList: []*proto.ShopGoods{req.Goods},
+		Goods:     pbGoods,
+		GoodsList: []*proto.ShopGoods{pbGoods},
 	}
 	g.SendMsg(cmd.BuyGoodsRsp, player.PlayerId, player.ClientSeq, buyGoodsRsp)
 }
@@ -110,7 +132,11 @@ func (g *Game) McoinExchangeHcoinReq(player *model.Player, payloadMsg pb.Message
 		return
 	}
 
-	g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 201, ChangeCount: count}}, proto.ActionReasonType_ACTION_REASON_SHOP)
+	ok = g.AddPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 201, ChangeCount: count}}, proto.ActionReasonType_ACTION_REASON_SHOP)
+	if !ok {
+		g.RefundPlayerItem(player.PlayerId, []*ChangeItem{{ItemId: 203, ChangeCount: count}})
+		return
+	}
 
 	mcoinExchangeHcoinRsp := &proto.McoinExchangeHcoinRsp{
 		Hcoin:     req.Hcoin,
@@ -121,4 +147,125 @@ func (g *Game) McoinExchangeHcoinReq(player *model.Player, payloadMsg pb.Message
 
 /************************************************** 游戏功能 **************************************************/
 
+// GetShopNextRefreshTime 计算商店或商品的下一次刷新时间 不刷新返回0
+func (g *Game) GetShopNextRefreshTime(refreshType int32, refreshParam int32, now time.Time) uint32 {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch refreshType {
+	case gdconf.ShopRefreshTypeDaily:
+		return uint32(today.AddDate(0, 0, 1).Unix())
+	case gdconf.ShopRefreshTypeWeekly:
+		// 刷新参数为星期几 1-7 对应周一到周日
+		weekday := int(refreshParam) % 7
+		days := (weekday - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return uint32(today.AddDate(0, 0, days).Unix())
+	case gdconf.ShopRefreshTypeMonthly:
+		// 刷新参数为每月几号
+		day := int(refreshParam)
+		if day < 1 {
+			day = 1
+		}
+		next := time.Date(now.Year(), now.Month(), day, 0, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = time.Date(now.Year(), now.Month()+1, day, 0, 0, 0, 0, now.Location())
+		}
+		return uint32(next.Unix())
+	default:
+		return 0
+	}
+}
+
+// RefreshShopGoods 到达刷新时间时重置商品的已购买数量
+func (g *Game) RefreshShopGoods(goodsDataConfig *gdconf.ShopGoodsData, goods *model.ShopGoods, now time.Time) {
+	if goods.NextRefreshTime != 0 && uint32(now.Unix()) >= goods.NextRefreshTime {
+		goods.BoughtNum = 0
+		goods.NextRefreshTime = 0
+	}
+	if goods.NextRefreshTime == 0 {
+		goods.NextRefreshTime = g.GetShopNextRefreshTime(goodsDataConfig.RefreshType, goodsDataConfig.RefreshParam, now)
+	}
+}
+
+// IsShopGoodsInTime 商品是否处于上架时间内
+func (g *Game) IsShopGoodsInTime(goodsDataConfig *gdconf.ShopGoodsData, now uint32) bool {
+	if goodsDataConfig.BeginTime != 0 && now < goodsDataConfig.BeginTime {
+		return false
+	}
+	if goodsDataConfig.EndTime != 0 && now >= goodsDataConfig.EndTime {
+		return false
+	}
+	return true
+}
+
+// GetShopGoodsCostItemMap 获取购买一份商品需要消耗的全部货币和物品
+func (g *Game) GetShopGoodsCostItemMap(goodsDataConfig *gdconf.ShopGoodsData) map[uint32]uint32 {
+	costItemMap := make(map[uint32]uint32)
+	if goodsDataConfig.CostScoin != 0 {
+		costItemMap[constant.ITEM_ID_SCOIN] += uint32(goodsDataConfig.CostScoin)
+	}
+	if goodsDataConfig.CostHcoin != 0 {
+		costItemMap[constant.ITEM_ID_HCOIN] += uint32(goodsDataConfig.CostHcoin)
+	}
+	if goodsDataConfig.CostMcoin != 0 {
+		costItemMap[constant.ITEM_ID_MCOIN] += uint32(goodsDataConfig.CostMcoin)
+	}
+	for itemId, count := range goodsDataConfig.CostItemMap {
+		costItemMap[itemId] += count
+	}
+	return costItemMap
+}
+
 /************************************************** 打包封装 **************************************************/
+
+// PacketShop 打包玩家商店信息
+func (g *Game) PacketShop(player *model.Player, shopDataConfig *gdconf.ShopData) *proto.Shop {
+	now := time.Now()
+	shopType := uint32(shopDataConfig.ShopType)
+	pbShop := &proto.Shop{
+		ShopType:        shopType,
+		GoodsList:       make([]*proto.ShopGoods, 0),
+		NextRefreshTime: g.GetShopNextRefreshTime(shopDataConfig.RefreshType, shopDataConfig.RefreshParam, now),
+	}
+	shop := player.GetDbShop().GetShop(shopType)
+	goodsDataConfigList := make([]*gdconf.ShopGoodsData, 0)
+	for _, goodsDataConfig := range gdconf.GetShopGoodsDataMapByShopType(shopDataConfig.ShopType) {
+		if !g.IsShopGoodsInTime(goodsDataConfig, uint32(now.Unix())) {
+			continue
+		}
+		goodsDataConfigList = append(goodsDataConfigList, goodsDataConfig)
+	}
+	sort.Slice(goodsDataConfigList, func(i, j int) bool {
+		return goodsDataConfigList[i].GoodsId < goodsDataConfigList[j].GoodsId
+	})
+	for _, goodsDataConfig := range goodsDataConfigList {
+		goods := shop.GetGoods(uint32(goodsDataConfig.GoodsId))
+		g.RefreshShopGoods(goodsDataConfig, goods, now)
+		pbShop.GoodsList = append(pbShop.GoodsList, g.PacketShopGoods(goodsDataConfig, goods))
+	}
+	return pbShop
+}
+
+// PacketShopGoods 打包商品信息
+func (g *Game) PacketShopGoods(goodsDataConfig *gdconf.ShopGoodsData, goods *model.ShopGoods) *proto.ShopGoods {
+	pbGoods := &proto.ShopGoods{
+		GoodsId:         uint32(goodsDataConfig.GoodsId),
+		GoodsItem:       &proto.ItemParam{ItemId: uint32(goodsDataConfig.ItemId), Count: uint32(goodsDataConfig.ItemCount)},
+		Scoin:           uint32(goodsDataConfig.CostScoin),
+		Hcoin:           uint32(goodsDataConfig.CostHcoin),
+		Mcoin:           uint32(goodsDataConfig.CostMcoin),
+		CostItemList:    make([]*proto.ItemParam, 0, len(goodsDataConfig.CostItemMap)),
+		BuyLimit:        uint32(goodsDataConfig.BuyLimit),
+		BoughtNum:       goods.BoughtNum,
+		NextRefreshTime: goods.NextRefreshTime,
+		BeginTime:       goodsDataConfig.BeginTime,
+		EndTime:         goodsDataConfig.EndTime,
+		MinLevel:        uint32(goodsDataConfig.MinPlayerLevel),
+		MaxLevel:        uint32(goodsDataConfig.MaxPlayerLevel),
+	}
+	for itemId, count := range goodsDataConfig.CostItemMap {
+		pbGoods.CostItemList = append(pbGoods.CostItemList, &proto.ItemParam{ItemId: itemId, Count: count})
+	}
+	return pbGoods
+}
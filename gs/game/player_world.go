@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"hk4e/common/constant"
+	"hk4e/common/mq"
 	"hk4e/gdconf"
 	"hk4e/gs/model"
 	"hk4e/pkg/logger"
@@ -336,26 +337,9 @@ func (g *Game) DungeonEntryInfoReq(player *model.Player, payloadMsg pb.Message)
 // PlayerEnterDungeonReq 玩家进入秘境请求
 func (g *Game) PlayerEnterDungeonReq(player *model.Player, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.PlayerEnterDungeonReq)
-	dungeonDataConfig := gdconf.GetDungeonDataById(int32(req.DungeonId))
-	if dungeonDataConfig == nil {
-		logger.Error("get dungeon data config is nil, dungeonId: %v, uid: %v", req.DungeonId, player.PlayerId)
+	if !g.EnterDungeon(player, req.DungeonId, req.PointId) {
 		return
 	}
-	sceneLuaConfig := gdconf.GetSceneLuaConfigById(dungeonDataConfig.SceneId)
-	if sceneLuaConfig == nil {
-		logger.Error("get scene lua config is nil, sceneId: %v, uid: %v", dungeonDataConfig.SceneId, player.PlayerId)
-		return
-	}
-	sceneConfig := sceneLuaConfig.SceneConfig
-	g.TeleportPlayer(
-		player,
-		proto.EnterReason_ENTER_REASON_DUNGEON_ENTER,
-		uint32(dungeonDataConfig.SceneId),
-		&model.Vector{X: float64(sceneConfig.BornPos.X), Y: float64(sceneConfig.BornPos.Y), Z: float64(sceneConfig.BornPos.Z)},
-		&model.Vector{X: float64(sceneConfig.BornRot.X), Y: float64(sceneConfig.BornRot.Y), Z: float64(sceneConfig.BornRot.Z)},
-		req.DungeonId,
-		req.PointId,
-	)
 
 	rsp := &proto.PlayerEnterDungeonRsp{
 		DungeonId: req.DungeonId,
@@ -449,6 +433,12 @@ func (g *Game) GadgetInteractReq(player *model.Player, payloadMsg pb.Message) {
 			interactType = proto.InteractType_INTERACT_OPEN_CHEST
 			// 宝箱交互结束 开启宝箱
 			if req.OpType == proto.InterOpType_INTER_OP_FINISH {
+				// 征讨之花需要消耗树脂
+				if g.IsBlossomChest(entity) && !g.CostPlayerResin(player, constant.RESIN_COST_BLOSSOM_CHEST) {
+					logger.Error("resin not enough, uid: %v", player.PlayerId)
+					g.SendError(cmd.GadgetInteractRsp, player, &proto.GadgetInteractRsp{}, proto.Retcode_RET_RESIN_NOT_ENOUGH)
+					return
+				}
 				// 随机掉落
 				g.chestDrop(player, entity)
 				// 更新宝箱状态
@@ -643,6 +633,19 @@ func (g *Game) monsterDrop(player *model.Player, monsterDropType int, hpDropId i
 	}
 }
 
+// IsBlossomChest 是否为需要消耗树脂领取的宝箱
+func (g *Game) IsBlossomChest(entity *Entity) bool {
+	sceneGroupConfig := gdconf.GetSceneGroup(int32(entity.GetGroupId()))
+	if sceneGroupConfig == nil {
+		return false
+	}
+	gadgetConfig, exist := sceneGroupConfig.GadgetMap[int32(entity.GetConfigId())]
+	if !exist {
+		return false
+	}
+	return gadgetConfig.IsBlossomChest
+}
+
 func (g *Game) chestDrop(player *model.Player, entity *Entity) {
 	sceneGroupConfig := gdconf.GetSceneGroup(int32(entity.GetGroupId()))
 	if sceneGroupConfig == nil {
@@ -757,6 +760,31 @@ func (g *Game) doRandDropOnce(dropDataConfig *gdconf.DropData) map[int32]int32 {
 	return dropMap
 }
 
+// AnticheatRollback 反作弊回退玩家位置 玩家正在加载或已切换场景时忽略
+func (g *Game) AnticheatRollback(anticheatInfo *mq.AnticheatInfo) {
+	if anticheatInfo == nil {
+		return
+	}
+	player := USER_MANAGER.GetOnlineUser(anticheatInfo.UserId)
+	if player == nil {
+		logger.Error("player is nil, uid: %v", anticheatInfo.UserId)
+		return
+	}
+	if player.SceneLoadState != model.SceneEnterDone || player.GetSceneId() != anticheatInfo.SceneId {
+		return
+	}
+	logger.Warn("anticheat rollback player pos, rule: %v, uid: %v", anticheatInfo.Rule, player.PlayerId)
+	g.TeleportPlayer(
+		player,
+		proto.EnterReason_ENTER_REASON_FORCE_DRAG_BACK,
+		anticheatInfo.SceneId,
+		&model.Vector{X: anticheatInfo.PosX, Y: anticheatInfo.PosY, Z: anticheatInfo.PosZ},
+		player.GetRot(),
+		0,
+		0,
+	)
+}
+
 // TeleportPlayer 传送玩家通用接口
 func (g *Game) TeleportPlayer(
 	player *model.Player, enterReason proto.EnterReason,
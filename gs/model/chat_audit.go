@@ -0,0 +1,24 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ChatAuditActionMask      = "mask"       // 屏蔽后发送
+	ChatAuditActionBlock     = "block"      // 拦截
+	ChatAuditActionFlag      = "flag"       // 原样发送 仅标记
+	ChatAuditActionRateLimit = "rate_limit" // 发言频率超限
+)
+
+// ChatAudit 聊天审计记录 只记录被过滤或标记的消息
+type ChatAudit struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Uid        uint32             `bson:"uid"`
+	TargetUid  uint32             `bson:"target_uid"`  // 私聊对象uid 世界聊天为0
+	Text       string             `bson:"text"`        // 原始文本
+	FilterText string             `bson:"filter_text"` // 过滤后的文本
+	Action     string             `bson:"action"`      // 处理方式
+	HitList    []string           `bson:"hit_list"`    // 命中的屏蔽词和规则名
+	Time       uint32             `bson:"time"`
+}
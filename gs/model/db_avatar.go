@@ -105,20 +105,125 @@ func (a *DbAvatar) UpdateAvatarFightProp(avatar *Avatar) {
 		logger.Error("avatarDataConfig error, avatarId: %v", avatar.AvatarId)
 		return
 	}
+	// 重置上一次计算的装备属性 元素能量以及当前值不受影响
+	for propType := range avatar.FightPropMap {
+		if propType >= constant.FIGHT_PROP_MAX_FIRE_ENERGY && propType <= constant.FIGHT_PROP_MAX_ROCK_ENERGY {
+			continue
+		}
+		if propType >= constant.FIGHT_PROP_CUR_FIRE_ENERGY {
+			continue
+		}
+		avatar.FightPropMap[propType] = 0.0
+	}
+	// 圣遗物和武器提供的属性加成
+	addPropMap := a.GetAvatarReliquaryAddPropMap(avatar)
+	for propType, propValue := range a.GetAvatarWeaponAddPropMap(avatar) {
+		addPropMap[propType] += propValue
+	}
+	for propType, propValue := range addPropMap {
+		avatar.FightPropMap[propType] += propValue
+	}
 	avatar.FightPropMap[constant.FIGHT_PROP_NONE] = 0.0
 	// 白字攻防血
-	avatar.FightPropMap[constant.FIGHT_PROP_BASE_ATTACK] = avatarDataConfig.GetBaseAttackByLevel(avatar.Level)
-	avatar.FightPropMap[constant.FIGHT_PROP_BASE_DEFENSE] = avatarDataConfig.GetBaseDefenseByLevel(avatar.Level)
-	avatar.FightPropMap[constant.FIGHT_PROP_BASE_HP] = avatarDataConfig.GetBaseHpByLevel(avatar.Level)
+	baseAttack := avatarDataConfig.GetBaseAttackByLevel(avatar.Level)
+	baseDefense := avatarDataConfig.GetBaseDefenseByLevel(avatar.Level)
+	baseHp := avatarDataConfig.GetBaseHpByLevel(avatar.Level)
+	avatar.FightPropMap[constant.FIGHT_PROP_BASE_ATTACK] = baseAttack
+	avatar.FightPropMap[constant.FIGHT_PROP_BASE_DEFENSE] = baseDefense
+	avatar.FightPropMap[constant.FIGHT_PROP_BASE_HP] = baseHp
 	// 白字+绿字攻防血
-	avatar.FightPropMap[constant.FIGHT_PROP_CUR_ATTACK] = avatarDataConfig.GetBaseAttackByLevel(avatar.Level)
-	avatar.FightPropMap[constant.FIGHT_PROP_CUR_DEFENSE] = avatarDataConfig.GetBaseDefenseByLevel(avatar.Level)
-	avatar.FightPropMap[constant.FIGHT_PROP_MAX_HP] = avatarDataConfig.GetBaseHpByLevel(avatar.Level)
+	avatar.FightPropMap[constant.FIGHT_PROP_CUR_ATTACK] = baseAttack*(1+addPropMap[constant.FIGHT_PROP_ATTACK_PERCENT]) + addPropMap[constant.FIGHT_PROP_ATTACK]
+	avatar.FightPropMap[constant.FIGHT_PROP_CUR_DEFENSE] = baseDefense*(1+addPropMap[constant.FIGHT_PROP_DEFENSE_PERCENT]) + addPropMap[constant.FIGHT_PROP_DEFENSE]
+	avatar.FightPropMap[constant.FIGHT_PROP_MAX_HP] = baseHp*(1+addPropMap[constant.FIGHT_PROP_HP_PERCENT]) + addPropMap[constant.FIGHT_PROP_HP]
 	// 双暴
-	avatar.FightPropMap[constant.FIGHT_PROP_CRITICAL] = avatarDataConfig.Critical
-	avatar.FightPropMap[constant.FIGHT_PROP_CRITICAL_HURT] = avatarDataConfig.CriticalHurt
+	avatar.FightPropMap[constant.FIGHT_PROP_CRITICAL] = avatarDataConfig.Critical + addPropMap[constant.FIGHT_PROP_CRITICAL]
+	avatar.FightPropMap[constant.FIGHT_PROP_CRITICAL_HURT] = avatarDataConfig.CriticalHurt + addPropMap[constant.FIGHT_PROP_CRITICAL_HURT]
 	// 元素充能
-	avatar.FightPropMap[constant.FIGHT_PROP_CHARGE_EFFICIENCY] = 1.0
+	avatar.FightPropMap[constant.FIGHT_PROP_CHARGE_EFFICIENCY] = 1.0 + addPropMap[constant.FIGHT_PROP_CHARGE_EFFICIENCY]
+}
+
+// GetAvatarReliquaryAddPropMap 获取角色已装备圣遗物的主属性 追加属性以及套装效果提供的属性加成
+func (a *DbAvatar) GetAvatarReliquaryAddPropMap(avatar *Avatar) map[uint32]float32 {
+	addPropMap := make(map[uint32]float32)
+	// 各套装已装备的件数
+	setCountMap := make(map[int32]int32)
+	for _, reliquary := range avatar.EquipReliquaryMap {
+		reliquaryConfig := gdconf.GetItemDataById(int32(reliquary.ItemId))
+		if reliquaryConfig == nil {
+			logger.Error("reliquary config error, itemId: %v", reliquary.ItemId)
+			continue
+		}
+		// 主属性 数值随圣遗物阶数和等级成长
+		reliquaryMainConfig := gdconf.GetReliquaryMainDataByDepotIdAndPropId(reliquaryConfig.MainPropDepotId, int32(reliquary.MainPropId))
+		if reliquaryMainConfig == nil {
+			logger.Error("reliquary main config error, mainPropDepotId: %v, propId: %v", reliquaryConfig.MainPropDepotId, reliquary.MainPropId)
+			continue
+		}
+		reliquaryLevelConfig := gdconf.GetReliquaryLevelDataByRankAndLevel(reliquaryConfig.Rank, int32(reliquary.Level))
+		if reliquaryLevelConfig == nil {
+			logger.Error("reliquary level config error, rank: %v, level: %v", reliquaryConfig.Rank, reliquary.Level)
+			continue
+		}
+		addPropMap[uint32(reliquaryMainConfig.PropType)] += reliquaryLevelConfig.AddPropMap[uint32(reliquaryMainConfig.PropType)]
+		// 追加属性
+		for _, appendPropId := range reliquary.AppendPropIdList {
+			reliquaryAffixConfig := gdconf.GetReliquaryAffixDataByDepotIdAndPropId(reliquaryConfig.AppendPropDepotId, int32(appendPropId))
+			if reliquaryAffixConfig == nil {
+				logger.Error("reliquary affix config error, appendPropDepotId: %v, propId: %v", reliquaryConfig.AppendPropDepotId, appendPropId)
+				continue
+			}
+			addPropMap[uint32(reliquaryAffixConfig.PropType)] += reliquaryAffixConfig.PropValue
+		}
+		if reliquaryConfig.SetId != 0 {
+			setCountMap[reliquaryConfig.SetId]++
+		}
+	}
+	// 套装效果 按激活件数依次生效
+	for setId, count := range setCountMap {
+		reliquarySetConfig := gdconf.GetReliquarySetDataById(setId)
+		if reliquarySetConfig == nil {
+			logger.Error("reliquary set config error, setId: %v", setId)
+			continue
+		}
+		for index, needNum := range reliquarySetConfig.SetNeedNum {
+			if count < needNum {
+				continue
+			}
+			// 套装激活档位即为词缀等级
+			equipAffixConfig := gdconf.GetEquipAffixDataByIdAndLevel(reliquarySetConfig.EquipAffixId, int32(index))
+			if equipAffixConfig == nil {
+				logger.Error("equip affix config error, equipAffixId: %v, level: %v", reliquarySetConfig.EquipAffixId, index)
+				continue
+			}
+			// 只计算固定属性加成 条件触发类的效果由客户端能力系统处理
+			for propType, propValue := range equipAffixConfig.AddPropMap {
+				addPropMap[propType] += propValue
+			}
+		}
+	}
+	return addPropMap
+}
+
+// GetAvatarWeaponAddPropMap 获取角色已装备武器的词缀按精炼等阶提供的属性加成
+func (a *DbAvatar) GetAvatarWeaponAddPropMap(avatar *Avatar) map[uint32]float32 {
+	addPropMap := make(map[uint32]float32)
+	weapon := avatar.EquipWeapon
+	if weapon == nil {
+		return addPropMap
+	}
+	for _, affixId := range weapon.AffixIdList {
+		// 精炼等阶即为词缀等级
+		equipAffixConfig := gdconf.GetEquipAffixDataByIdAndLevel(int32(affixId), int32(weapon.Refinement))
+		if equipAffixConfig == nil {
+			logger.Error("equip affix config error, affixId: %v, refinement: %v", affixId, weapon.Refinement)
+			continue
+		}
+		// 只计算固定属性加成 条件触发类的效果由客户端能力系统处理
+		for propType, propValue := range equipAffixConfig.AddPropMap {
+			addPropMap[propType] += propValue
+		}
+	}
+	return addPropMap
 }
 
 func (a *DbAvatar) AddAvatar(player *Player, avatarId uint32) {
@@ -0,0 +1,69 @@
+package model
+
+// DbDailyTask 玩家每日委托数据
+type DbDailyTask struct {
+	TaskMap            map[uint32]*DailyTask // 当日委托列表 key:委托id value:委托
+	NextRefreshTime    uint32                // 下一次刷新委托的时间
+	ScoreRewardId      uint32                // 当日积分奖励id
+	IsTakenScoreReward bool                  // 是否已领取积分奖励
+}
+
+// DailyTask 每日委托
+type DailyTask struct {
+	DailyTaskId    uint32 // 委托id
+	RewardId       uint32 // 奖励id
+	Progress       uint32 // 当前进度
+	FinishProgress uint32 // 完成所需进度
+	IsFinished     bool   // 是否完成
+}
+
+func (p *Player) GetDbDailyTask() *DbDailyTask {
+	if p.DbDailyTask == nil {
+		p.DbDailyTask = new(DbDailyTask)
+	}
+	if p.DbDailyTask.TaskMap == nil {
+		p.DbDailyTask.TaskMap = make(map[uint32]*DailyTask)
+	}
+	return p.DbDailyTask
+}
+
+// ResetDailyTask 重置每日委托
+func (d *DbDailyTask) ResetDailyTask(taskList []*DailyTask, scoreRewardId uint32, nextRefreshTime uint32) {
+	d.TaskMap = make(map[uint32]*DailyTask)
+	for _, task := range taskList {
+		d.TaskMap[task.DailyTaskId] = task
+	}
+	d.NextRefreshTime = nextRefreshTime
+	d.ScoreRewardId = scoreRewardId
+	d.IsTakenScoreReward = false
+}
+
+// GetFinishedNum 获取已完成的委托数量
+func (d *DbDailyTask) GetFinishedNum() uint32 {
+	finishedNum := uint32(0)
+	for _, task := range d.TaskMap {
+		if task.IsFinished {
+			finishedNum++
+		}
+	}
+	return finishedNum
+}
+
+// IsAllFinished 当日委托是否全部完成
+func (d *DbDailyTask) IsAllFinished() bool {
+	return len(d.TaskMap) > 0 && d.GetFinishedNum() == uint32(len(d.TaskMap))
+}
+
+// AddProgress 增加委托进度 返回是否刚好完成
+func (t *DailyTask) AddProgress(count uint32) bool {
+	if t.IsFinished {
+		return false
+	}
+	t.Progress += count
+	if t.Progress >= t.FinishProgress {
+		t.Progress = t.FinishProgress
+		t.IsFinished = true
+		return true
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package model
+
+// DbDungeon 玩家地牢数据
+type DbDungeon struct {
+	DungeonMap map[uint32]*DungeonRecord // 地牢通关记录 key:地牢id value:通关记录
+}
+
+// DungeonRecord 地牢通关记录
+type DungeonRecord struct {
+	DungeonId     uint32 // 地牢id
+	PassCount     uint32 // 通关次数
+	FirstPassTime uint32 // 首次通关时间
+}
+
+func (p *Player) GetDbDungeon() *DbDungeon {
+	if p.DbDungeon == nil {
+		p.DbDungeon = new(DbDungeon)
+	}
+	if p.DbDungeon.DungeonMap == nil {
+		p.DbDungeon.DungeonMap = make(map[uint32]*DungeonRecord)
+	}
+	return p.DbDungeon
+}
+
+// GetDungeonRecord 获取地牢通关记录 不存在自动创建
+func (d *DbDungeon) GetDungeonRecord(dungeonId uint32) *DungeonRecord {
+	record, exist := d.DungeonMap[dungeonId]
+	if !exist {
+		record = &DungeonRecord{
+			DungeonId:     dungeonId,
+			PassCount:     0,
+			FirstPassTime: 0,
+		}
+		d.DungeonMap[dungeonId] = record
+	}
+	return record
+}
@@ -14,42 +14,26 @@ type GachaPoolInfo struct {
 
 func (p *Player) GetDbGacha() *DbGacha {
 	if p.DbGacha == nil {
-		p.DbGacha = &DbGacha{
-			GachaPoolInfo: map[uint32]*GachaPoolInfo{
-				300: {
-					// 温迪
-					GachaType:       300,
-					OrangeTimes:     0,
-					PurpleTimes:     0,
-					MustGetUpOrange: false,
-					MustGetUpPurple: false,
-				},
-				400: {
-					// 可莉
-					GachaType:       400,
-					OrangeTimes:     0,
-					PurpleTimes:     0,
-					MustGetUpOrange: false,
-					MustGetUpPurple: false,
-				},
-				431: {
-					// 阿莫斯之弓&天空之傲
-					GachaType:       431,
-					OrangeTimes:     0,
-					PurpleTimes:     0,
-					MustGetUpOrange: false,
-					MustGetUpPurple: false,
-				},
-				201: {
-					// 常驻
-					GachaType:       201,
-					OrangeTimes:     0,
-					PurpleTimes:     0,
-					MustGetUpOrange: false,
-					MustGetUpPurple: false,
-				},
-			},
-		}
+		p.DbGacha = new(DbGacha)
+	}
+	if p.DbGacha.GachaPoolInfo == nil {
+		p.DbGacha.GachaPoolInfo = make(map[uint32]*GachaPoolInfo)
 	}
 	return p.DbGacha
 }
+
+// GetGachaPoolInfo 获取卡池保底信息 卡池首次出现时自动创建
+func (g *DbGacha) GetGachaPoolInfo(gachaType uint32) *GachaPoolInfo {
+	gachaPoolInfo, exist := g.GachaPoolInfo[gachaType]
+	if !exist {
+		gachaPoolInfo = &GachaPoolInfo{
+			GachaType:       gachaType,
+			OrangeTimes:     0,
+			PurpleTimes:     0,
+			MustGetUpOrange: false,
+			MustGetUpPurple: false,
+		}
+		g.GachaPoolInfo[gachaType] = gachaPoolInfo
+	}
+	return gachaPoolInfo
+}
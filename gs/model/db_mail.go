@@ -0,0 +1,20 @@
+package model
+
+// DbMail 玩家邮件数据 邮件本身单独存放在mail表
+type DbMail struct {
+	MailIdSeq    uint32 // 邮件id序列
+	GlobalMailId uint32 // 已领取的最大全服邮件id
+}
+
+func (p *Player) GetDbMail() *DbMail {
+	if p.DbMail == nil {
+		p.DbMail = new(DbMail)
+	}
+	return p.DbMail
+}
+
+// NextMailId 分配新的邮件id
+func (d *DbMail) NextMailId() uint32 {
+	d.MailIdSeq++
+	return d.MailIdSeq
+}
@@ -0,0 +1,31 @@
+package model
+
+// DbMute 玩家禁言数据
+type DbMute struct {
+	IsMute  bool   // 是否禁言
+	EndTime uint32 // 禁言结束时间 0为永久禁言
+	Reason  string // 禁言原因
+}
+
+func (p *Player) GetDbMute() *DbMute {
+	if p.DbMute == nil {
+		p.DbMute = new(DbMute)
+	}
+	return p.DbMute
+}
+
+// IsMuteNow 当前是否处于禁言状态
+func (d *DbMute) IsMuteNow(now uint32) bool {
+	if !d.IsMute {
+		return false
+	}
+	return d.EndTime == 0 || now < d.EndTime
+}
+
+// ChatForbiddenEndTime 返回给客户端的禁言结束时间 永久禁言时返回最大值
+func (d *DbMute) ChatForbiddenEndTime() uint32 {
+	if d.EndTime == 0 {
+		return 0xFFFFFFFF
+	}
+	return d.EndTime
+}
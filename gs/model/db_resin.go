@@ -0,0 +1,15 @@
+package model
+
+// DbResin 玩家树脂数据 树脂数量本身存放在玩家属性中
+type DbResin struct {
+	NextRecoverTime   uint32 // 下一次恢复树脂的时间 0为已满不恢复
+	BuyCount          uint32 // 当日已购买次数
+	BuyCountResetTime uint32 // 下一次重置购买次数的时间
+}
+
+func (p *Player) GetDbResin() *DbResin {
+	if p.DbResin == nil {
+		p.DbResin = new(DbResin)
+	}
+	return p.DbResin
+}
@@ -0,0 +1,59 @@
+package model
+
+// DbShop 玩家商店数据
+type DbShop struct {
+	ShopMap map[uint32]*Shop // 商店列表 key:商店类型 value:商店
+}
+
+// Shop 商店
+type Shop struct {
+	ShopType uint32                // 商店类型
+	GoodsMap map[uint32]*ShopGoods // 商品购买记录 key:商品id value:商品
+}
+
+// ShopGoods 商品购买记录
+type ShopGoods struct {
+	GoodsId         uint32 // 商品id
+	BoughtNum       uint32 // 当前周期已购买数量
+	NextRefreshTime uint32 // 下一次刷新购买数量的时间 0为不刷新
+}
+
+func (p *Player) GetDbShop() *DbShop {
+	if p.DbShop == nil {
+		p.DbShop = new(DbShop)
+	}
+	if p.DbShop.ShopMap == nil {
+		p.DbShop.ShopMap = make(map[uint32]*Shop)
+	}
+	return p.DbShop
+}
+
+// GetShop 获取商店 不存在自动创建
+func (s *DbShop) GetShop(shopType uint32) *Shop {
+	shop, exist := s.ShopMap[shopType]
+	if !exist {
+		shop = new(Shop)
+		s.ShopMap[shopType] = shop
+	}
+	if shop.ShopType == 0 {
+		shop.ShopType = shopType
+	}
+	if shop.GoodsMap == nil {
+		shop.GoodsMap = make(map[uint32]*ShopGoods)
+	}
+	return shop
+}
+
+// GetGoods 获取商品购买记录 不存在自动创建
+func (s *Shop) GetGoods(goodsId uint32) *ShopGoods {
+	goods, exist := s.GoodsMap[goodsId]
+	if !exist {
+		goods = &ShopGoods{
+			GoodsId:         goodsId,
+			BoughtNum:       0,
+			NextRefreshTime: 0,
+		}
+		s.GoodsMap[goodsId] = goods
+	}
+	return goods
+}
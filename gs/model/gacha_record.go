@@ -0,0 +1,16 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GachaRecord 抽卡记录
+type GachaRecord struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Uid        uint32             `bson:"uid"`
+	GachaType  uint32             `bson:"gacha_type"`
+	ScheduleId uint32             `bson:"schedule_id"`
+	ItemId     uint32             `bson:"item_id"`
+	Rarity     uint32             `bson:"rarity"`
+	Time       uint32             `bson:"time"`
+}
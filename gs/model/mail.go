@@ -6,7 +6,8 @@ import (
 
 type Mail struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	MailId     uint32             `bson:"mail_id"`
+	Uid        uint32             `bson:"uid"`
+	MailId     uint32             `bson:"mail_id"` // 玩家内唯一 离线投递的邮件为0 登录时分配
 	Title      string             `bson:"title"`
 	Content    string             `bson:"content"`
 	Sender     string             `bson:"sender"`
@@ -14,4 +15,23 @@ type Mail struct {
 	ExpireTime uint32             `bson:"expire_time"`
 	IsRead     bool               `bson:"is_read"`
 	IsStar     bool               `bson:"is_star"`
+	ItemList   []*MailItem        `bson:"item_list"` // 附件
+	IsItemGot  bool               `bson:"is_item_got"`
+}
+
+type MailItem struct {
+	ItemId    uint32 `bson:"item_id"`
+	ItemCount uint32 `bson:"item_count"`
+}
+
+// GlobalMail 全服邮件 由gm写入 玩家在线时或登录时领取到个人邮件
+type GlobalMail struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	GlobalMailId uint32             `bson:"global_mail_id"`
+	Title        string             `bson:"title"`
+	Content      string             `bson:"content"`
+	Sender       string             `bson:"sender"`
+	SendTime     uint32             `bson:"send_time"`
+	ExpireTime   uint32             `bson:"expire_time"`
+	ItemList     []*MailItem        `bson:"item_list"`
 }
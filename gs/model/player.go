@@ -49,6 +49,12 @@ type Player struct {
 	DbGacha         *DbGacha           // 卡池
 	DbQuest         *DbQuest           // 任务
 	DbWorld         *DbWorld           // 大世界
+	DbShop          *DbShop            // 商店
+	DbDailyTask     *DbDailyTask       // 每日委托
+	DbResin         *DbResin           // 树脂
+	DbDungeon       *DbDungeon         // 地牢
+	DbMute          *DbMute            // 禁言
+	DbMail          *DbMail            // 邮件
 	// 在线数据 请随意 记得加忽略字段的tag
 	LastSaveTime          uint32                                   `bson:"-" msgpack:"-"` // 上一次存档保存时间
 	DbState               int                                      `bson:"-" msgpack:"-"` // 数据库存档状态
@@ -71,6 +77,7 @@ type Player struct {
 	AbilityInvokeHandler  *InvokeHandler[proto.AbilityInvokeEntry] `bson:"-" msgpack:"-"` // ability转发器
 	GateAppId             string                                   `bson:"-" msgpack:"-"` // 网关服务器的appid
 	MultiServerAppId      string                                   `bson:"-" msgpack:"-"` // 多功能服务器的appid
+	MatchServerAppId      string                                   `bson:"-" msgpack:"-"` // 正在进行匹配的多功能服务器的appid
 	GCGCurGameGuid        uint32                                   `bson:"-" msgpack:"-"` // GCG玩家所在的游戏guid
 	GCGInfo               *GCGInfo                                 `bson:"-" msgpack:"-"` // 七圣召唤信息
 	XLuaDebug             bool                                     `bson:"-" msgpack:"-"` // 是否开启客户端XLUA调试
@@ -89,6 +96,8 @@ type Player struct {
 	MpPos                 *Vector                                  `bson:"-" msgpack:"-"` // 多人世界坐标
 	MpRot                 *Vector                                  `bson:"-" msgpack:"-"` // 多人世界朝向
 	SceneBlockAsyncLoad   bool                                     `bson:"-" msgpack:"-"` // 是否正在异步加载场景区块存档
+	ChatTimeList          []uint32                                 `bson:"-" msgpack:"-"` // 最近的发言时间 用于聊天频率限制
+	ChatLimitAuditTime    uint32                                   `bson:"-" msgpack:"-"` // 最近一次写入发言频率限制审计记录的时间
 	// 特殊数据
 	ChatMsgMap           map[uint32][]*ChatMsg  `bson:"-" msgpack:"-"` // 聊天信息 只从db读写 不保存到redis
 	RemoteWorldPlayerNum uint32                 `bson:"-"`             // 远程展示世界内人数 不保存到db 在线同步到redis
@@ -180,10 +189,12 @@ func (p *Player) InitOnlineData() {
 	dbReliquary.InitDbReliquary(p)
 	dbWeapon := p.GetDbWeapon()
 	dbWeapon.InitDbWeapon(p)
+	// 装备初始化完毕后重新计算角色面板
+	for _, avatar := range dbAvatar.GetAvatarMap() {
+		dbAvatar.UpdateAvatarFightProp(avatar)
+	}
 	dbItem := p.GetDbItem()
 	dbItem.InitDbItem(p)
-
-	p.MailMap = make(map[uint32]*Mail)
 }
 
 type Vector struct {
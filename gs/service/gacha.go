@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"hk4e/gs/api"
+	"hk4e/gs/dao"
+	"hk4e/pkg/logger"
+)
+
+var _ api.GachaNATSRPCServer = (*GachaService)(nil)
+
+// GachaService 抽卡记录查询 供dispatch的抽卡记录页面使用
+type GachaService struct {
+	db *dao.Dao
+}
+
+func (s *GachaService) QueryGachaRecord(ctx context.Context, req *api.QueryGachaRecordReq) (*api.QueryGachaRecordRsp, error) {
+	gachaRecordList, total, err := s.db.QueryGachaRecordPage(req.Uid, req.GachaType, req.Page, req.Size)
+	if err != nil {
+		logger.Error("query gacha record error: %v, uid: %v", err, req.Uid)
+		return &api.QueryGachaRecordRsp{Code: -1}, nil
+	}
+	rsp := &api.QueryGachaRecordRsp{
+		Code:       0,
+		Total:      total,
+		RecordList: make([]*api.GachaRecord, 0, len(gachaRecordList)),
+	}
+	for _, gachaRecord := range gachaRecordList {
+		rsp.RecordList = append(rsp.RecordList, &api.GachaRecord{
+			GachaType:  gachaRecord.GachaType,
+			ScheduleId: gachaRecord.ScheduleId,
+			ItemId:     gachaRecord.ItemId,
+			Rarity:     gachaRecord.Rarity,
+			Time:       gachaRecord.Time,
+		})
+	}
+	return rsp, nil
+}
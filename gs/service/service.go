@@ -2,6 +2,7 @@ package service
 
 import (
 	"hk4e/gs/api"
+	"hk4e/gs/dao"
 
 	"github.com/byebyebruce/natsrpc"
 	"github.com/nats-io/nats.go"
@@ -10,7 +11,7 @@ import (
 
 type Service struct{}
 
-func NewService(conn *nats.Conn, gsId uint32) (*Service, error) {
+func NewService(conn *nats.Conn, gsId uint32, db *dao.Dao) (*Service, error) {
 	enc, err := nats.NewEncodedConn(conn, protobuf.PROTOBUF_ENCODER)
 	if err != nil {
 		return nil, err
@@ -24,6 +25,12 @@ func NewService(conn *nats.Conn, gsId uint32) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 不指定服务id 所有gs在同一订阅组内 每个请求只由其中一个gs处理
+	gacha := &GachaService{db: db}
+	_, err = api.RegisterGachaNATSRPCServer(svr, gacha)
+	if err != nil {
+		return nil, err
+	}
 	return &Service{}, nil
 }
 
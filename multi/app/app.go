@@ -12,6 +12,7 @@ import (
 	"hk4e/common/mq"
 	"hk4e/common/rpc"
 	"hk4e/gdconf"
+	"hk4e/multi/dao"
 	"hk4e/multi/handle"
 	"hk4e/node/api"
 	"hk4e/pkg/logger"
@@ -66,10 +67,16 @@ func Run(ctx context.Context, configFile string) error {
 
 	gdconf.InitGameDataConfig()
 
+	db, err := dao.NewDao()
+	if err != nil {
+		return err
+	}
+	defer db.CloseDao()
+
 	messageQueue := mq.NewMessageQueue(api.MULTI, APPID, discoveryClient)
 	defer messageQueue.Close()
 
-	_ = handle.NewHandle(messageQueue)
+	h := handle.NewHandle(messageQueue, db)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT)
@@ -84,6 +91,7 @@ func Run(ctx context.Context, configFile string) error {
 				logger.Warn("multi exit, appid: %v", APPID)
 				return nil
 			case syscall.SIGHUP:
+				h.ReloadAnticheatConfig()
 			default:
 				return nil
 			}
@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnticheatViolation 反作弊违规记录
+type AnticheatViolation struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Uid     uint32             `bson:"uid"`
+	SceneId uint32             `bson:"scene_id"`
+	Rule    string             `bson:"rule"`   // 触发的规则名
+	Action  string             `bson:"action"` // 实际执行的处理方式
+	Value   float64            `bson:"value"`  // 检测值
+	Limit   float64            `bson:"limit"`  // 规则阈值
+	Score   float64            `bson:"score"`  // 本次违规后的违规分
+	PosX    float64            `bson:"pos_x"`
+	PosY    float64            `bson:"pos_y"`
+	PosZ    float64            `bson:"pos_z"`
+	Time    uint32             `bson:"time"`
+}
+
+func (d *Dao) InsertAnticheatViolation(violation *AnticheatViolation) error {
+	db := d.db.Collection("anticheat_violation")
+	_, err := db.InsertOne(context.TODO(), violation)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateGateAccountForbid 更新网关账号的封号状态
+func (d *Dao) UpdateGateAccountForbid(uid uint32, isForbid bool, forbidEndTime uint32, forbidReason string) error {
+	db := d.gateDb.Collection("account")
+	_, err := db.UpdateOne(
+		context.TODO(),
+		bson.D{{"uid", uid}},
+		bson.D{{"$set", bson.D{
+			{"is_forbid", isForbid},
+			{"forbid_end_time", forbidEndTime},
+			{"forbid_reason", forbidReason},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -13,12 +13,8 @@ import (
 )
 
 const (
-	MoveVectorCacheNum        = 10
-	MaxMoveSpeed              = 100.0
-	JumpDistance              = 500.0
-	PointDistance             = 10.0
-	AttackCountLimitEntitySec = 10
-	KickCheatPlayer           = false
+	MoveVectorCacheNum = 10
+	PointDistance      = 10.0
 )
 
 type MoveVector struct {
@@ -33,25 +29,27 @@ type AttackEntity struct {
 
 type AnticheatContext struct {
 	sceneId         uint32
+	gsAppId         string // 玩家所在的gs 用于回退位置
 	moveVectorList  []*MoveVector
 	attackEntityMap map[uint32]*AttackEntity
+	score           float64 // 违规分
+	scoreTime       int64   // 违规分上次衰减的时间 毫秒
+	kicked          bool    // 已被踢下线 不再检测
 }
 
-func (a *AnticheatContext) Move(pos *proto.Vector) bool {
+// Move 记录移动 返回是否合法和距上次记录的距离 jumpDistance为0时不检测瞬移
+func (a *AnticheatContext) Move(pos *proto.Vector, jumpDistance float32) (bool, float32) {
 	now := time.Now().UnixMilli()
 	if len(a.moveVectorList) > 0 {
 		lastMoveVector := a.moveVectorList[len(a.moveVectorList)-1]
 		if now-lastMoveVector.time < 1000 {
-			return true
+			return true, 0.0
 		}
 		distance := GetDistance(pos, lastMoveVector.pos)
-		if distance > JumpDistance {
-			// 瞬时变化太大 判断是否为传送
+		if jumpDistance > 0 && distance > jumpDistance {
+			// 瞬时变化太大 判断是否为传送 场景没有传送点数据时无法判断 不算作瞬移
 			scenePointMap := gdconf.GetScenePointMapBySceneId(int32(a.sceneId))
-			if scenePointMap == nil {
-				return true
-			}
-			isJump := true
+			isJump := scenePointMap != nil
 			for _, pointData := range scenePointMap {
 				if pointData.TranPos == nil {
 					continue
@@ -67,10 +65,10 @@ func (a *AnticheatContext) Move(pos *proto.Vector) bool {
 				}
 			}
 			if isJump {
-				return false
-			} else {
-				a.moveVectorList = make([]*MoveVector, 0)
+				return false, distance
 			}
+			// 从新位置重新开始记录 避免跨越传送的两点被算进移动速度
+			a.moveVectorList = make([]*MoveVector, 0)
 		}
 	}
 	a.moveVectorList = append(a.moveVectorList, &MoveVector{
@@ -80,7 +78,20 @@ func (a *AnticheatContext) Move(pos *proto.Vector) bool {
 	if len(a.moveVectorList) > MoveVectorCacheNum {
 		a.moveVectorList = a.moveVectorList[len(a.moveVectorList)-MoveVectorCacheNum:]
 	}
-	return true
+	return true, 0.0
+}
+
+// GetRollbackPos 回退位置 取缓存中最早的正常移动记录
+func (a *AnticheatContext) GetRollbackPos() *proto.Vector {
+	if len(a.moveVectorList) == 0 {
+		return nil
+	}
+	return a.moveVectorList[0].pos
+}
+
+// ResetMove 清空移动记录 切换场景或回退位置后重新开始检测
+func (a *AnticheatContext) ResetMove() {
+	a.moveVectorList = make([]*MoveVector, 0)
 }
 
 func (a *AnticheatContext) GetMoveSpeed() float32 {
@@ -102,7 +113,11 @@ func (a *AnticheatContext) GetMoveSpeed() float32 {
 	return avgMoveSpeed
 }
 
-func (a *AnticheatContext) Attack(defEntityId uint32) bool {
+// Attack 记录攻击 返回是否合法和当前秒内的攻击次数 limit为0时不检测
+func (a *AnticheatContext) Attack(defEntityId uint32, limit uint32) (bool, uint32) {
+	if limit == 0 {
+		return true, 0
+	}
 	now := uint64(time.Now().UnixMilli())
 	attackEntity, exist := a.attackEntityMap[defEntityId]
 	if !exist {
@@ -113,15 +128,28 @@ func (a *AnticheatContext) Attack(defEntityId uint32) bool {
 		a.attackEntityMap[defEntityId] = attackEntity
 	}
 	attackEntity.attackCount++
-	if attackEntity.attackCount > AttackCountLimitEntitySec {
+	if attackEntity.attackCount > limit {
 		if now-attackEntity.attackStartTime < 1000 {
-			return false
+			return false, attackEntity.attackCount
 		} else {
 			attackEntity.attackStartTime = now
 			attackEntity.attackCount = 0
 		}
 	}
-	return true
+	return true, attackEntity.attackCount
+}
+
+// AddScore 违规分按时间衰减后累加 返回累加后的违规分
+func (a *AnticheatContext) AddScore(score float64, decay float64, now int64) float64 {
+	if a.scoreTime != 0 && decay > 0 {
+		a.score -= decay * float64(now-a.scoreTime) / 1000.0
+		if a.score < 0 {
+			a.score = 0
+		}
+	}
+	a.scoreTime = now
+	a.score += score
+	return a.score
 }
 
 func NewAnticheatContext() *AnticheatContext {
@@ -133,12 +161,16 @@ func NewAnticheatContext() *AnticheatContext {
 	return r
 }
 
-func (h *Handle) AddPlayerAcCtx(userId uint32) {
-	h.playerAcCtxMap[userId] = NewAnticheatContext()
+func (h *Handle) AddPlayerAcCtx(userId uint32, gsAppId string) {
+	ctx := NewAnticheatContext()
+	ctx.gsAppId = gsAppId
+	h.playerAcCtxMap[userId] = ctx
+	playerAcCtxNum.Set(float64(len(h.playerAcCtxMap)))
 }
 
 func (h *Handle) DelPlayerAcCtx(userId uint32) {
 	delete(h.playerAcCtxMap, userId)
+	playerAcCtxNum.Set(float64(len(h.playerAcCtxMap)))
 }
 
 func (h *Handle) GetPlayerAcCtx(userId uint32) *AnticheatContext {
@@ -152,7 +184,17 @@ func (h *Handle) CombatInvocationsNotify(userId uint32, gateAppId string, payloa
 		logger.Error("get player anticheat context is nil, uid: %v", userId)
 		return
 	}
+	if ctx.kicked {
+		return
+	}
+	sceneConfig := h.acConfig.GetSceneConfig(ctx.sceneId)
+	if sceneConfig == nil {
+		return
+	}
 	for _, entry := range req.InvokeList {
+		if ctx.kicked {
+			return
+		}
 		switch entry.ArgumentType {
 		case proto.CombatTypeArgument_ENTITY_MOVE:
 			entityMoveInfo := new(proto.EntityMoveInfo)
@@ -171,20 +213,23 @@ func (h *Handle) CombatInvocationsNotify(userId uint32, gateAppId string, payloa
 			if motionInfo.Pos == nil {
 				continue
 			}
-			// 玩家超速移动检测
-			if ctx.sceneId != 3 {
+			// 玩家瞬移检测
+			ok, distance := ctx.Move(motionInfo.Pos, sceneConfig.JumpDistance)
+			if !ok {
+				h.Violation(userId, gateAppId, ctx, AnticheatRuleMoveJump, distance, sceneConfig.JumpDistance, motionInfo.Pos)
+				// 以新位置重新开始检测 避免之后的每次移动都被判定为瞬移
+				ctx.ResetMove()
 				continue
 			}
-			ok := ctx.Move(motionInfo.Pos)
-			if !ok {
-				logger.Warn("player move jump, pos: %v, uid: %v", motionInfo.Pos, userId)
-				h.KickPlayer(userId, gateAppId)
+			// 玩家超速移动检测
+			if sceneConfig.MaxMoveSpeed == 0 {
 				continue
 			}
 			moveSpeed := ctx.GetMoveSpeed()
-			if moveSpeed > MaxMoveSpeed {
-				logger.Warn("player move overspeed, speed: %v, uid: %v", moveSpeed, userId)
-				h.KickPlayer(userId, gateAppId)
+			if moveSpeed > sceneConfig.MaxMoveSpeed {
+				h.Violation(userId, gateAppId, ctx, AnticheatRuleMoveSpeed, moveSpeed, sceneConfig.MaxMoveSpeed, motionInfo.Pos)
+				// 重新采样 避免同一段移动重复计分
+				ctx.ResetMove()
 				continue
 			}
 		case proto.CombatTypeArgument_COMBAT_EVT_BEING_HIT:
@@ -201,10 +246,9 @@ func (h *Handle) CombatInvocationsNotify(userId uint32, gateAppId string, payloa
 			if GetEntityType(attackResult.DefenseId) != constant.ENTITY_TYPE_MONSTER {
 				continue
 			}
-			ok := ctx.Attack(attackResult.DefenseId)
+			ok, attackCount := ctx.Attack(attackResult.DefenseId, sceneConfig.AttackCountLimit)
 			if !ok {
-				logger.Warn("player attack monster feq too high, uid: %v", userId)
-				h.KickPlayer(userId, gateAppId)
+				h.Violation(userId, gateAppId, ctx, AnticheatRuleAttackFreq, float32(attackCount), float32(sceneConfig.AttackCountLimit), nil)
 				continue
 			}
 		}
@@ -219,6 +263,7 @@ func (h *Handle) ToTheMoonEnterSceneReq(userId uint32, gateAppId string, payload
 		return
 	}
 	ctx.sceneId = req.SceneId
+	ctx.ResetMove()
 	logger.Info("player enter scene: %v, uid: %v", req.SceneId, userId)
 }
 
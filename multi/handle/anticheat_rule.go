@@ -0,0 +1,259 @@
+package handle
+
+import (
+	"errors"
+	"time"
+
+	"hk4e/common/config"
+	"hk4e/common/mq"
+	"hk4e/multi/dao"
+	"hk4e/pkg/logger"
+	"hk4e/protocol/proto"
+
+	"github.com/BurntSushi/toml"
+)
+
+// 反作弊规则引擎
+// 检测阈值按场景配置 规则命中后执行配置的处理方式并累加违规分 违规分随时间衰减 达到阈值时升级为踢下线或封号
+
+const (
+	AnticheatRuleMoveJump   = "move_jump"   // 瞬移
+	AnticheatRuleMoveSpeed  = "move_speed"  // 移动超速
+	AnticheatRuleAttackFreq = "attack_freq" // 攻击频率过高
+)
+
+// 处理方式 数值越大越严厉
+const (
+	AnticheatActionLog      = iota // 只记录日志
+	AnticheatActionFlag            // 写入违规记录
+	AnticheatActionRollback        // 写入违规记录并回退位置
+	AnticheatActionKick            // 写入违规记录并踢下线
+	AnticheatActionBan             // 写入违规记录并封号
+)
+
+var anticheatActionNameMap = map[int]string{
+	AnticheatActionLog:      "log",
+	AnticheatActionFlag:     "flag",
+	AnticheatActionRollback: "rollback",
+	AnticheatActionKick:     "kick",
+	AnticheatActionBan:      "ban",
+}
+
+type AnticheatConfig struct {
+	ScoreDecay float64                 `toml:"score_decay"` // 违规分每秒衰减值
+	KickScore  float64                 `toml:"kick_score"`  // 违规分达到后踢下线 0为不启用
+	BanScore   float64                 `toml:"ban_score"`   // 违规分达到后封号 0为不启用
+	BanTime    uint32                  `toml:"ban_time"`    // 封号时长 单位秒 0为永久封号
+	Default    *AnticheatSceneConfig   `toml:"default"`     // 未单独配置的场景使用的阈值
+	SceneList  []*AnticheatSceneConfig `toml:"scene"`       // 按场景配置的阈值
+	RuleList   []*AnticheatRuleConfig  `toml:"rule"`        // 规则处理方式 未配置的规则只记录日志
+	sceneMap   map[uint32]*AnticheatSceneConfig
+	ruleMap    map[string]*AnticheatRuleConfig
+}
+
+type AnticheatSceneConfig struct {
+	SceneId          uint32  `toml:"scene_id"`
+	Disable          bool    `toml:"disable"`            // 关闭该场景的检测
+	MaxMoveSpeed     float32 `toml:"max_move_speed"`     // 最大平均移动速度 0为不检测
+	JumpDistance     float32 `toml:"jump_distance"`      // 两次移动之间的最大距离 落点在传送点附近的除外 0为不检测
+	AttackCountLimit uint32  `toml:"attack_count_limit"` // 每秒对同一实体的最大攻击次数 0为不检测
+}
+
+type AnticheatRuleConfig struct {
+	Name   string  `toml:"name"`   // 规则名
+	Action string  `toml:"action"` // 处理方式 log/flag/rollback/kick/ban
+	Score  float64 `toml:"score"`  // 每次命中累加的违规分
+	action int
+}
+
+// NewDefaultAnticheatConfig 未配置规则文件时的默认配置 所有场景使用相同阈值 只记录日志
+func NewDefaultAnticheatConfig() *AnticheatConfig {
+	r := &AnticheatConfig{
+		Default: &AnticheatSceneConfig{
+			MaxMoveSpeed:     100.0,
+			JumpDistance:     500.0,
+			AttackCountLimit: 10,
+		},
+	}
+	_ = r.init()
+	return r
+}
+
+// LoadAnticheatConfig 加载反作弊规则配置
+func LoadAnticheatConfig() (*AnticheatConfig, error) {
+	fileName := config.GetConfig().Hk4e.AnticheatFile
+	if fileName == "" {
+		return NewDefaultAnticheatConfig(), nil
+	}
+	acConfig := new(AnticheatConfig)
+	_, err := toml.DecodeFile(fileName, acConfig)
+	if err != nil {
+		return nil, err
+	}
+	if acConfig.Default == nil {
+		acConfig.Default = NewDefaultAnticheatConfig().Default
+	}
+	err = acConfig.init()
+	if err != nil {
+		return nil, err
+	}
+	return acConfig, nil
+}
+
+func (a *AnticheatConfig) init() error {
+	a.sceneMap = make(map[uint32]*AnticheatSceneConfig)
+	for _, sceneConfig := range a.SceneList {
+		a.sceneMap[sceneConfig.SceneId] = sceneConfig
+	}
+	a.ruleMap = make(map[string]*AnticheatRuleConfig)
+	for _, ruleConfig := range a.RuleList {
+		switch ruleConfig.Name {
+		case AnticheatRuleMoveJump, AnticheatRuleMoveSpeed, AnticheatRuleAttackFreq:
+		default:
+			return errors.New("unknown anticheat rule: " + ruleConfig.Name)
+		}
+		ruleConfig.action = -1
+		for action, name := range anticheatActionNameMap {
+			if ruleConfig.Action == name {
+				ruleConfig.action = action
+				break
+			}
+		}
+		if ruleConfig.action == -1 {
+			return errors.New("unknown anticheat rule action: " + ruleConfig.Action)
+		}
+		a.ruleMap[ruleConfig.Name] = ruleConfig
+	}
+	return nil
+}
+
+// GetSceneConfig 获取场景的检测阈值 场景关闭检测时返回nil
+func (a *AnticheatConfig) GetSceneConfig(sceneId uint32) *AnticheatSceneConfig {
+	sceneConfig, exist := a.sceneMap[sceneId]
+	if !exist {
+		sceneConfig = a.Default
+	}
+	if sceneConfig.Disable {
+		return nil
+	}
+	return sceneConfig
+}
+
+// GetRule 获取规则的处理方式和违规分
+func (a *AnticheatConfig) GetRule(rule string) (int, float64) {
+	ruleConfig, exist := a.ruleMap[rule]
+	if !exist {
+		return AnticheatActionLog, 0.0
+	}
+	return ruleConfig.action, ruleConfig.Score
+}
+
+// ReloadAnticheatConfig 通知处理协程重新加载反作弊规则配置
+func (h *Handle) ReloadAnticheatConfig() {
+	h.acReloadChan <- true
+}
+
+func (h *Handle) loadAnticheatConfig() {
+	acConfig, err := LoadAnticheatConfig()
+	if err != nil {
+		logger.Error("load anticheat config error: %v", err)
+		return
+	}
+	h.acConfig = acConfig
+	logger.Info("load anticheat config finish, scene num: %v, rule num: %v", len(acConfig.SceneList), len(acConfig.RuleList))
+}
+
+// Violation 规则命中处理 按违规分升级处理方式
+func (h *Handle) Violation(userId uint32, gateAppId string, ctx *AnticheatContext, rule string, value float32, limit float32, pos *proto.Vector) {
+	action, score := h.acConfig.GetRule(rule)
+	now := time.Now().UnixMilli()
+	totalScore := ctx.AddScore(score, h.acConfig.ScoreDecay, now)
+	if h.acConfig.BanScore > 0 && totalScore >= h.acConfig.BanScore {
+		action = AnticheatActionBan
+	} else if h.acConfig.KickScore > 0 && totalScore >= h.acConfig.KickScore && action < AnticheatActionKick {
+		action = AnticheatActionKick
+	}
+	if action == AnticheatActionRollback && rule == AnticheatRuleAttackFreq {
+		// 攻击频率规则没有可回退的位置
+		action = AnticheatActionFlag
+	}
+	actionName := anticheatActionNameMap[action]
+	violationCount.WithLabelValues(rule, actionName).Inc()
+	logger.Warn("anticheat violation, rule: %v, action: %v, value: %v, limit: %v, score: %v, sceneId: %v, pos: %v, uid: %v",
+		rule, actionName, value, limit, totalScore, ctx.sceneId, pos, userId)
+	if action >= AnticheatActionFlag {
+		violation := &dao.AnticheatViolation{
+			Uid:     userId,
+			SceneId: ctx.sceneId,
+			Rule:    rule,
+			Action:  actionName,
+			Value:   float64(value),
+			Limit:   float64(limit),
+			Score:   totalScore,
+			Time:    uint32(now / 1000),
+		}
+		if pos != nil {
+			violation.PosX = float64(pos.X)
+			violation.PosY = float64(pos.Y)
+			violation.PosZ = float64(pos.Z)
+		}
+		go h.saveAnticheatViolation(violation)
+	}
+	switch action {
+	case AnticheatActionRollback:
+		h.RollbackPlayer(userId, ctx, rule)
+	case AnticheatActionKick:
+		h.KickPlayer(userId, gateAppId)
+		ctx.kicked = true
+	case AnticheatActionBan:
+		h.BanPlayer(userId, gateAppId, rule)
+		ctx.kicked = true
+	}
+}
+
+func (h *Handle) saveAnticheatViolation(violation *dao.AnticheatViolation) {
+	err := h.db.InsertAnticheatViolation(violation)
+	if err != nil {
+		logger.Error("insert anticheat violation error: %v", err)
+		return
+	}
+}
+
+// RollbackPlayer 通知玩家所在的gs将玩家传送回最近一次记录的正常位置
+func (h *Handle) RollbackPlayer(userId uint32, ctx *AnticheatContext, rule string) {
+	pos := ctx.GetRollbackPos()
+	ctx.ResetMove()
+	if pos == nil || ctx.gsAppId == "" {
+		return
+	}
+	h.messageQueue.SendToGs(ctx.gsAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerAnticheatRollbackNotify,
+		ServerMsg: &mq.ServerMsg{
+			AnticheatInfo: &mq.AnticheatInfo{
+				UserId:  userId,
+				SceneId: ctx.sceneId,
+				Rule:    rule,
+				PosX:    float64(pos.X),
+				PosY:    float64(pos.Y),
+				PosZ:    float64(pos.Z),
+			},
+		},
+	})
+}
+
+// BanPlayer 封号并踢下线
+func (h *Handle) BanPlayer(userId uint32, gateAppId string, rule string) {
+	forbidEndTime := uint32(0)
+	if h.acConfig.BanTime != 0 {
+		forbidEndTime = uint32(time.Now().Unix()) + h.acConfig.BanTime
+	}
+	go func() {
+		err := h.db.UpdateGateAccountForbid(userId, true, forbidEndTime, "anticheat "+rule)
+		if err != nil {
+			logger.Error("update gate account forbid error: %v, uid: %v", err, userId)
+			return
+		}
+	}()
+	h.KickPlayer(userId, gateAppId)
+}
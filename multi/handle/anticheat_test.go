@@ -0,0 +1,166 @@
+package handle
+
+import (
+	"math"
+	"testing"
+
+	"hk4e/gdconf"
+	"hk4e/protocol/proto"
+)
+
+const (
+	testSceneId        = 3
+	testNoPointSceneId = 99
+)
+
+func initTestScenePoint() {
+	gdconf.CONF = &gdconf.GameDataConfig{
+		ScenePointMap: map[int32]*gdconf.ScenePoint{
+			testSceneId: {
+				PointMap: map[int32]*gdconf.PointData{
+					1: {Id: 1, TranPos: &gdconf.Position{X: 1000.0, Y: 0.0, Z: 0.0}},
+				},
+			},
+		},
+	}
+}
+
+// backdateMove 把最后一次移动记录提前 跳过一秒内的移动采样间隔
+func backdateMove(ctx *AnticheatContext, ms int64) {
+	for _, moveVector := range ctx.moveVectorList {
+		moveVector.time -= ms
+	}
+}
+
+func TestAnticheatMoveJump(t *testing.T) {
+	initTestScenePoint()
+	ctx := NewAnticheatContext()
+	ctx.sceneId = testSceneId
+	if ok, _ := ctx.Move(&proto.Vector{X: 0, Y: 0, Z: 0}, 500.0); !ok {
+		t.Fatalf("first move should be ok")
+	}
+	// 一秒内的移动不采样
+	if ok, _ := ctx.Move(&proto.Vector{X: 5000, Y: 0, Z: 0}, 500.0); !ok || len(ctx.moveVectorList) != 1 {
+		t.Fatalf("move in one second should be skipped, len: %v", len(ctx.moveVectorList))
+	}
+	backdateMove(ctx, 2000)
+	ok, distance := ctx.Move(&proto.Vector{X: 2000, Y: 0, Z: 0}, 500.0)
+	if ok || distance != 2000 {
+		t.Fatalf("jump should be detected, ok: %v, distance: %v", ok, distance)
+	}
+	// 落点在传送点附近 视为传送 从新位置重新记录
+	ok, _ = ctx.Move(&proto.Vector{X: 1000, Y: 0, Z: 5}, 500.0)
+	if !ok {
+		t.Fatalf("teleport should not be detected as jump")
+	}
+	if len(ctx.moveVectorList) != 1 || ctx.GetRollbackPos().X != 1000 {
+		t.Fatalf("move list should restart from teleport pos, len: %v", len(ctx.moveVectorList))
+	}
+}
+
+func TestAnticheatMoveNoPointData(t *testing.T) {
+	initTestScenePoint()
+	ctx := NewAnticheatContext()
+	ctx.sceneId = testNoPointSceneId
+	ctx.Move(&proto.Vector{X: 0, Y: 0, Z: 0}, 500.0)
+	backdateMove(ctx, 2000)
+	ok, _ := ctx.Move(&proto.Vector{X: 2000, Y: 0, Z: 0}, 500.0)
+	if !ok {
+		t.Fatalf("scene without point data should not detect jump")
+	}
+	// 新位置需要被记录 否则之后每次移动都会和旧位置比较
+	if len(ctx.moveVectorList) != 1 || ctx.GetRollbackPos().X != 2000 {
+		t.Fatalf("move pos should be recorded, len: %v", len(ctx.moveVectorList))
+	}
+	backdateMove(ctx, 2000)
+	ok, _ = ctx.Move(&proto.Vector{X: 2010, Y: 0, Z: 0}, 500.0)
+	if !ok || len(ctx.moveVectorList) != 2 {
+		t.Fatalf("normal move after no point data jump error, len: %v", len(ctx.moveVectorList))
+	}
+}
+
+func TestAnticheatMoveCacheLimit(t *testing.T) {
+	initTestScenePoint()
+	ctx := NewAnticheatContext()
+	ctx.sceneId = testSceneId
+	for i := 0; i < MoveVectorCacheNum+5; i++ {
+		backdateMove(ctx, 1000)
+		ctx.Move(&proto.Vector{X: float32(i * 10), Y: 0, Z: 0}, 0)
+	}
+	if len(ctx.moveVectorList) != MoveVectorCacheNum {
+		t.Fatalf("move cache len error, len: %v", len(ctx.moveVectorList))
+	}
+	if ctx.GetRollbackPos().X != 50 {
+		t.Fatalf("rollback pos should be the oldest cached move, pos: %v", ctx.GetRollbackPos())
+	}
+	ctx.ResetMove()
+	if ctx.GetRollbackPos() != nil {
+		t.Fatalf("rollback pos should be nil after reset")
+	}
+}
+
+func TestAnticheatAddScore(t *testing.T) {
+	ctx := NewAnticheatContext()
+	now := int64(1000000)
+	if score := ctx.AddScore(10.0, 1.0, now); score != 10.0 {
+		t.Fatalf("first score error, score: %v", score)
+	}
+	// 5秒衰减5分
+	if score := ctx.AddScore(10.0, 1.0, now+5000); math.Abs(score-15.0) > 1e-9 {
+		t.Fatalf("decay score error, score: %v", score)
+	}
+	// 衰减不会低于0
+	if score := ctx.AddScore(1.0, 1.0, now+3600*1000); score != 1.0 {
+		t.Fatalf("decay below zero error, score: %v", score)
+	}
+	// 不衰减
+	noDecay := NewAnticheatContext()
+	noDecay.AddScore(3.0, 0.0, now)
+	if score := noDecay.AddScore(3.0, 0.0, now+10000); score != 6.0 {
+		t.Fatalf("no decay score error, score: %v", score)
+	}
+}
+
+func TestAnticheatConfigInit(t *testing.T) {
+	acConfig := &AnticheatConfig{
+		Default: &AnticheatSceneConfig{MaxMoveSpeed: 100.0},
+		SceneList: []*AnticheatSceneConfig{
+			{SceneId: 3, MaxMoveSpeed: 50.0},
+			{SceneId: 5, Disable: true},
+		},
+		RuleList: []*AnticheatRuleConfig{
+			{Name: AnticheatRuleMoveJump, Action: "rollback", Score: 5.0},
+			{Name: AnticheatRuleAttackFreq, Action: "kick", Score: 1.0},
+		},
+	}
+	if err := acConfig.init(); err != nil {
+		t.Fatalf("init config error: %v", err)
+	}
+	if acConfig.GetSceneConfig(3).MaxMoveSpeed != 50.0 {
+		t.Fatalf("scene config error")
+	}
+	if acConfig.GetSceneConfig(1) != acConfig.Default {
+		t.Fatalf("not configured scene should use default")
+	}
+	if acConfig.GetSceneConfig(5) != nil {
+		t.Fatalf("disabled scene should return nil")
+	}
+	if action, score := acConfig.GetRule(AnticheatRuleMoveJump); action != AnticheatActionRollback || score != 5.0 {
+		t.Fatalf("rule config error, action: %v, score: %v", action, score)
+	}
+	if action, score := acConfig.GetRule(AnticheatRuleMoveSpeed); action != AnticheatActionLog || score != 0.0 {
+		t.Fatalf("not configured rule should only log, action: %v, score: %v", action, score)
+	}
+	badRule := &AnticheatConfig{RuleList: []*AnticheatRuleConfig{{Name: "fly", Action: "log"}}}
+	if err := badRule.init(); err == nil {
+		t.Fatalf("unknown rule should return error")
+	}
+	badAction := &AnticheatConfig{RuleList: []*AnticheatRuleConfig{{Name: AnticheatRuleMoveSpeed, Action: "jail"}}}
+	if err := badAction.init(); err == nil {
+		t.Fatalf("unknown action should return error")
+	}
+	defaultConfig := NewDefaultAnticheatConfig()
+	if defaultConfig.GetSceneConfig(3) == nil || defaultConfig.GetSceneConfig(3).JumpDistance != 500.0 {
+		t.Fatalf("default config error")
+	}
+}
@@ -1,8 +1,11 @@
 package handle
 
 import (
+	"time"
+
 	"hk4e/common/mq"
 	"hk4e/gate/kcp"
+	"hk4e/multi/dao"
 	"hk4e/node/api"
 	"hk4e/pkg/logger"
 	"hk4e/protocol/cmd"
@@ -12,24 +15,45 @@ import (
 
 type Handle struct {
 	messageQueue   *mq.MessageQueue
+	db             *dao.Dao
 	playerAcCtxMap map[uint32]*AnticheatContext
+	obstacleMap    map[uint32]*PlayerObstacle
+	acConfig       *AnticheatConfig
+	acReloadChan   chan bool
 	worldStatic    *WorldStatic
+	matchCtx       *MatchContext
 }
 
-func NewHandle(messageQueue *mq.MessageQueue) (r *Handle) {
+func NewHandle(messageQueue *mq.MessageQueue, db *dao.Dao) (r *Handle) {
 	r = new(Handle)
 	r.messageQueue = messageQueue
+	r.db = db
 	r.playerAcCtxMap = make(map[uint32]*AnticheatContext)
+	r.acConfig = NewDefaultAnticheatConfig()
+	r.loadAnticheatConfig()
+	r.acReloadChan = make(chan bool, 1)
 	r.worldStatic = NewWorldStatic()
-	r.worldStatic.InitTerrain()
+	r.worldStatic.InitNavMesh()
+	r.obstacleMap = make(map[uint32]*PlayerObstacle)
+	r.matchCtx = NewMatchContext()
 	go r.run()
 	return r
 }
 
 func (h *Handle) run() {
 	logger.Info("start handle")
+	matchTicker := time.NewTicker(MatchTickInterval)
 	for {
-		netMsg := <-h.messageQueue.GetNetMsg()
+		var netMsg *mq.NetMsg = nil
+		select {
+		case netMsg = <-h.messageQueue.GetNetMsg():
+		case <-matchTicker.C:
+			h.MatchTick()
+			continue
+		case <-h.acReloadChan:
+			h.loadAnticheatConfig()
+			continue
+		}
 		switch netMsg.MsgType {
 		case mq.MsgTypeGame:
 			if netMsg.OriginServerType != api.GATE {
@@ -39,6 +63,7 @@ func (h *Handle) run() {
 				continue
 			}
 			gameMsg := netMsg.GameMsg
+			start := time.Now().UnixNano()
 			switch gameMsg.CmdId {
 			case cmd.CombatInvocationsNotify:
 				h.CombatInvocationsNotify(gameMsg.UserId, netMsg.OriginServerAppId, gameMsg.PayloadMessage)
@@ -49,25 +74,30 @@ func (h *Handle) run() {
 			case cmd.ObstacleModifyNotify:
 				h.ObstacleModifyNotify(gameMsg.UserId, netMsg.OriginServerAppId, gameMsg.PayloadMessage)
 			}
+			metricsMsg(gameMsg.CmdId, time.Now().UnixNano()-start)
 		case mq.MsgTypeServer:
 			serverMsg := netMsg.ServerMsg
 			switch netMsg.EventId {
 			case mq.ServerUserOnlineStateChangeNotify:
 				logger.Info("player online state change, state: %v, uid: %v", serverMsg.IsOnline, serverMsg.UserId)
 				if serverMsg.IsOnline {
-					h.AddPlayerAcCtx(serverMsg.UserId)
+					h.AddPlayerAcCtx(serverMsg.UserId, netMsg.OriginServerAppId)
 				} else {
 					h.DelPlayerAcCtx(serverMsg.UserId)
+					h.DelPlayerObstacle(serverMsg.UserId)
+					h.MatchPlayerOffline(serverMsg.UserId)
+				}
+			case mq.ServerMatchReq:
+				if netMsg.OriginServerType != api.GS {
+					continue
 				}
+				h.ServerMatchReq(serverMsg.MatchInfo, netMsg.OriginServerAppId)
 			}
 		}
 	}
 }
 
 func (h *Handle) KickPlayer(userId uint32, gateAppId string) {
-	if !KickCheatPlayer {
-		return
-	}
 	h.messageQueue.SendToGate(gateAppId, &mq.NetMsg{
 		MsgType: mq.MsgTypeConnCtrl,
 		EventId: mq.KickPlayerNotify,
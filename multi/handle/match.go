@@ -0,0 +1,348 @@
+package handle
+
+import (
+	"time"
+
+	"hk4e/common/mq"
+	"hk4e/pkg/logger"
+	"hk4e/protocol/proto"
+)
+
+const (
+	MatchPlayerNumMax = 4                 // 单次匹配最大人数
+	MatchPlayerNumMin = 2                 // 单次匹配最小人数
+	MatchWaitFullTime = time.Second * 30  // 等待满员的时间 超过后人数达到最小人数即可成组
+	MatchTimeout      = time.Minute * 5   // 匹配超时时间
+	MatchConfirmTime  = time.Second * 15  // 匹配成功后的确认时间
+	MatchTickInterval = time.Second * 1   // 匹配检查间隔
+	MatchIdStart      = uint32(100000000) // 匹配id起始值
+)
+
+// MatchKey 匹配队列索引 相同索引的玩家才能匹配到一起
+type MatchKey struct {
+	MatchType  uint32
+	DungeonId  uint32
+	MpPlayId   uint32
+	WorldLevel uint32
+}
+
+type MatchPlayer struct {
+	userId     uint32
+	gsAppId    string
+	playerInfo *mq.PlayerBaseInfo
+	beginTime  time.Time
+	matchId    uint32 // 所在匹配组id 0为在队列中
+	isAgreed   bool
+}
+
+type MatchGroup struct {
+	matchId        uint32
+	key            MatchKey
+	playerList     []*MatchPlayer
+	confirmEndTime time.Time
+}
+
+type MatchContext struct {
+	queueMap     map[MatchKey][]*MatchPlayer
+	groupMap     map[uint32]*MatchGroup
+	playerMap    map[uint32]*MatchPlayer
+	playerKeyMap map[uint32]MatchKey
+	nextMatchId  uint32
+}
+
+func NewMatchContext() (r *MatchContext) {
+	r = new(MatchContext)
+	r.queueMap = make(map[MatchKey][]*MatchPlayer)
+	r.groupMap = make(map[uint32]*MatchGroup)
+	r.playerMap = make(map[uint32]*MatchPlayer)
+	r.playerKeyMap = make(map[uint32]MatchKey)
+	r.nextMatchId = MatchIdStart
+	return r
+}
+
+// ServerMatchReq gs转发的玩家匹配请求
+func (h *Handle) ServerMatchReq(matchInfo *mq.MatchInfo, gsAppId string) {
+	if matchInfo == nil {
+		return
+	}
+	switch matchInfo.MatchCmd {
+	case mq.MatchCmdStartReq:
+		h.StartMatch(matchInfo, gsAppId)
+	case mq.MatchCmdCancelReq:
+		h.CancelMatch(matchInfo.UserId, proto.MatchReason_MATCH_PLAYER_CANCEL)
+	case mq.MatchCmdConfirmReq:
+		h.ConfirmMatch(matchInfo.UserId, matchInfo.IsAgreed)
+	}
+}
+
+func (h *Handle) StartMatch(matchInfo *mq.MatchInfo, gsAppId string) {
+	ctx := h.matchCtx
+	_, exist := ctx.playerMap[matchInfo.UserId]
+	if exist {
+		logger.Error("player already in match, uid: %v", matchInfo.UserId)
+		return
+	}
+	key := MatchKey{
+		MatchType:  matchInfo.MatchType,
+		DungeonId:  matchInfo.DungeonId,
+		MpPlayId:   matchInfo.MpPlayId,
+		WorldLevel: matchInfo.WorldLevel,
+	}
+	matchPlayer := &MatchPlayer{
+		userId:     matchInfo.UserId,
+		gsAppId:    gsAppId,
+		playerInfo: matchInfo.PlayerInfo,
+		beginTime:  time.Now(),
+		matchId:    0,
+		isAgreed:   false,
+	}
+	ctx.playerMap[matchPlayer.userId] = matchPlayer
+	ctx.playerKeyMap[matchPlayer.userId] = key
+	ctx.queueMap[key] = append(ctx.queueMap[key], matchPlayer)
+	logger.Info("player start match, uid: %v, key: %+v", matchPlayer.userId, key)
+	h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+		MatchCmd:       mq.MatchCmdInfoNotify,
+		UserId:         matchPlayer.userId,
+		MatchType:      key.MatchType,
+		DungeonId:      key.DungeonId,
+		MpPlayId:       key.MpPlayId,
+		MatchBeginTime: uint32(matchPlayer.beginTime.Unix()),
+	})
+}
+
+func (h *Handle) CancelMatch(userId uint32, reason proto.MatchReason) {
+	ctx := h.matchCtx
+	matchPlayer, exist := ctx.playerMap[userId]
+	if !exist {
+		return
+	}
+	if matchPlayer.matchId != 0 {
+		// 已经匹配成组 视为拒绝确认
+		h.ConfirmMatch(userId, false)
+		return
+	}
+	h.removeMatchQueuePlayer(userId)
+	h.stopMatchPlayer(matchPlayer, reason)
+}
+
+func (h *Handle) ConfirmMatch(userId uint32, isAgreed bool) {
+	ctx := h.matchCtx
+	matchPlayer, exist := ctx.playerMap[userId]
+	if !exist || matchPlayer.matchId == 0 {
+		logger.Error("player not in match group, uid: %v", userId)
+		return
+	}
+	matchGroup := ctx.groupMap[matchPlayer.matchId]
+	if matchGroup == nil {
+		logger.Error("match group not found, matchId: %v", matchPlayer.matchId)
+		return
+	}
+	matchPlayer.isAgreed = isAgreed
+	for _, groupPlayer := range matchGroup.playerList {
+		h.SendMatchNotify(groupPlayer, &mq.MatchInfo{
+			MatchCmd: mq.MatchCmdConfirmNotify,
+			UserId:   groupPlayer.userId,
+			MatchId:  matchGroup.matchId,
+			IsAgreed: isAgreed,
+			PlayerInfo: &mq.PlayerBaseInfo{
+				UserId: userId,
+			},
+		})
+	}
+	if !isAgreed {
+		h.dismissMatchGroup(matchGroup, proto.MatchReason_MATCH_PLAYER_CONFIRM)
+		return
+	}
+	for _, groupPlayer := range matchGroup.playerList {
+		if !groupPlayer.isAgreed {
+			return
+		}
+	}
+	h.finishMatchGroup(matchGroup)
+}
+
+// MatchPlayerOffline 玩家离线时退出匹配
+func (h *Handle) MatchPlayerOffline(userId uint32) {
+	h.CancelMatch(userId, proto.MatchReason_MATCH_INTERRUPTED)
+}
+
+// MatchTick 匹配定时检查
+func (h *Handle) MatchTick() {
+	ctx := h.matchCtx
+	now := time.Now()
+	for key, queue := range ctx.queueMap {
+		// 匹配超时
+		aliveQueue := make([]*MatchPlayer, 0, len(queue))
+		for _, matchPlayer := range queue {
+			if now.Sub(matchPlayer.beginTime) > MatchTimeout {
+				h.stopMatchPlayer(matchPlayer, proto.MatchReason_MATCH_TIMEOUT)
+				continue
+			}
+			aliveQueue = append(aliveQueue, matchPlayer)
+		}
+		ctx.queueMap[key] = aliveQueue
+		// 满员成组
+		for len(ctx.queueMap[key]) >= MatchPlayerNumMax {
+			h.createMatchGroup(key, MatchPlayerNumMax)
+		}
+		// 等待足够久后未满员也成组
+		queue = ctx.queueMap[key]
+		if len(queue) >= MatchPlayerNumMin && now.Sub(queue[0].beginTime) > MatchWaitFullTime {
+			h.createMatchGroup(key, len(queue))
+		}
+		if len(ctx.queueMap[key]) == 0 {
+			delete(ctx.queueMap, key)
+		}
+	}
+	for _, matchGroup := range ctx.groupMap {
+		if now.After(matchGroup.confirmEndTime) {
+			h.dismissMatchGroup(matchGroup, proto.MatchReason_MATCH_CONFIRM_TIMEOUT)
+		}
+	}
+}
+
+// 从匹配队列头部取出指定人数组成匹配组
+func (h *Handle) createMatchGroup(key MatchKey, num int) {
+	ctx := h.matchCtx
+	queue := ctx.queueMap[key]
+	matchGroup := &MatchGroup{
+		matchId:        ctx.nextMatchId,
+		key:            key,
+		playerList:     make([]*MatchPlayer, 0, num),
+		confirmEndTime: time.Now().Add(MatchConfirmTime),
+	}
+	ctx.nextMatchId++
+	for _, matchPlayer := range queue[:num] {
+		matchPlayer.matchId = matchGroup.matchId
+		matchPlayer.isAgreed = false
+		matchGroup.playerList = append(matchGroup.playerList, matchPlayer)
+	}
+	ctx.queueMap[key] = queue[num:]
+	ctx.groupMap[matchGroup.matchId] = matchGroup
+	logger.Info("create match group, matchId: %v, key: %+v, player num: %v", matchGroup.matchId, key, num)
+	playerList := h.packMatchPlayerList(matchGroup)
+	for _, matchPlayer := range matchGroup.playerList {
+		h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+			MatchCmd:       mq.MatchCmdSuccNotify,
+			UserId:         matchPlayer.userId,
+			MatchType:      key.MatchType,
+			DungeonId:      key.DungeonId,
+			MpPlayId:       key.MpPlayId,
+			MatchId:        matchGroup.matchId,
+			ConfirmEndTime: uint32(matchGroup.confirmEndTime.Unix()),
+			HostUserId:     matchGroup.playerList[0].userId,
+			PlayerList:     playerList,
+		})
+	}
+}
+
+// 解散匹配组 已确认的玩家重新回到匹配队列
+func (h *Handle) dismissMatchGroup(matchGroup *MatchGroup, reason proto.MatchReason) {
+	ctx := h.matchCtx
+	delete(ctx.groupMap, matchGroup.matchId)
+	uidList := make([]uint32, 0, len(matchGroup.playerList))
+	for _, matchPlayer := range matchGroup.playerList {
+		uidList = append(uidList, matchPlayer.userId)
+	}
+	requeueList := make([]*MatchPlayer, 0)
+	for _, matchPlayer := range matchGroup.playerList {
+		h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+			MatchCmd:   mq.MatchCmdDismissNotify,
+			UserId:     matchPlayer.userId,
+			MatchId:    matchGroup.matchId,
+			Reason:     uint32(reason),
+			PlayerList: h.packMatchPlayerList(matchGroup),
+		})
+		if matchPlayer.isAgreed {
+			matchPlayer.matchId = 0
+			matchPlayer.isAgreed = false
+			requeueList = append(requeueList, matchPlayer)
+		} else {
+			h.stopMatchPlayer(matchPlayer, reason)
+		}
+	}
+	// 保留原有的排队顺序
+	ctx.queueMap[matchGroup.key] = append(requeueList, ctx.queueMap[matchGroup.key]...)
+	for _, matchPlayer := range requeueList {
+		h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+			MatchCmd:       mq.MatchCmdInfoNotify,
+			UserId:         matchPlayer.userId,
+			MatchType:      matchGroup.key.MatchType,
+			DungeonId:      matchGroup.key.DungeonId,
+			MpPlayId:       matchGroup.key.MpPlayId,
+			MatchBeginTime: uint32(matchPlayer.beginTime.Unix()),
+		})
+	}
+	logger.Info("dismiss match group, matchId: %v, reason: %v, uidList: %v", matchGroup.matchId, reason, uidList)
+}
+
+// 全员确认 由房主所在的gs拉其他玩家进入房主世界 地牢匹配再由房主世界带全员进入地牢
+func (h *Handle) finishMatchGroup(matchGroup *MatchGroup) {
+	ctx := h.matchCtx
+	delete(ctx.groupMap, matchGroup.matchId)
+	hostUserId := matchGroup.playerList[0].userId
+	playerList := h.packMatchPlayerList(matchGroup)
+	for _, matchPlayer := range matchGroup.playerList {
+		delete(ctx.playerMap, matchPlayer.userId)
+		delete(ctx.playerKeyMap, matchPlayer.userId)
+		h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+			MatchCmd:   mq.MatchCmdFinishNotify,
+			UserId:     matchPlayer.userId,
+			MatchType:  matchGroup.key.MatchType,
+			DungeonId:  matchGroup.key.DungeonId,
+			MpPlayId:   matchGroup.key.MpPlayId,
+			MatchId:    matchGroup.matchId,
+			HostUserId: hostUserId,
+			PlayerList: playerList,
+		})
+	}
+	logger.Info("finish match group, matchId: %v, hostUserId: %v", matchGroup.matchId, hostUserId)
+}
+
+func (h *Handle) removeMatchQueuePlayer(userId uint32) {
+	ctx := h.matchCtx
+	key, exist := ctx.playerKeyMap[userId]
+	if !exist {
+		return
+	}
+	queue := ctx.queueMap[key]
+	for index, matchPlayer := range queue {
+		if matchPlayer.userId == userId {
+			ctx.queueMap[key] = append(queue[:index], queue[index+1:]...)
+			break
+		}
+	}
+}
+
+func (h *Handle) stopMatchPlayer(matchPlayer *MatchPlayer, reason proto.MatchReason) {
+	ctx := h.matchCtx
+	delete(ctx.playerMap, matchPlayer.userId)
+	delete(ctx.playerKeyMap, matchPlayer.userId)
+	h.SendMatchNotify(matchPlayer, &mq.MatchInfo{
+		MatchCmd: mq.MatchCmdStopNotify,
+		UserId:   matchPlayer.userId,
+		Reason:   uint32(reason),
+	})
+}
+
+func (h *Handle) packMatchPlayerList(matchGroup *MatchGroup) []*mq.MatchPlayerInfo {
+	playerList := make([]*mq.MatchPlayerInfo, 0, len(matchGroup.playerList))
+	for _, matchPlayer := range matchGroup.playerList {
+		playerList = append(playerList, &mq.MatchPlayerInfo{
+			PlayerInfo: matchPlayer.playerInfo,
+			IsAgreed:   matchPlayer.isAgreed,
+		})
+	}
+	return playerList
+}
+
+// SendMatchNotify 发送匹配通知到玩家所在的gs
+func (h *Handle) SendMatchNotify(matchPlayer *MatchPlayer, matchInfo *mq.MatchInfo) {
+	h.messageQueue.SendToGs(matchPlayer.gsAppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerMatchNotify,
+		ServerMsg: &mq.ServerMsg{
+			MatchInfo: matchInfo,
+		},
+	})
+}
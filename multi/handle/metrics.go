@@ -0,0 +1,35 @@
+package handle
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 多功能服务器监控指标
+
+var (
+	msgCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hk4e_multi_msg_total",
+		Help: "按协议号统计的处理客户端消息数",
+	}, []string{"cmd_id"})
+	msgCostHist = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "hk4e_multi_msg_seconds",
+		Help:    "单条客户端消息处理耗时",
+		Buckets: prometheus.DefBuckets,
+	})
+	playerAcCtxNum = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "hk4e_multi_player_num",
+		Help: "反作弊跟踪的在线玩家数",
+	})
+	violationCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hk4e_multi_anticheat_violation_total",
+		Help: "按规则和处理方式统计的反作弊违规次数",
+	}, []string{"rule", "action"})
+)
+
+func metricsMsg(cmdId uint16, costNanos int64) {
+	msgCounter.WithLabelValues(strconv.Itoa(int(cmdId))).Inc()
+	msgCostHist.Observe(float64(costNanos) / 1e9)
+}
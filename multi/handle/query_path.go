@@ -1,10 +1,10 @@
 package handle
 
 import (
-	"bytes"
-	"encoding/gob"
-	"os"
+	"math"
+	"time"
 
+	"hk4e/common/config"
 	"hk4e/pkg/alg"
 	"hk4e/pkg/logger"
 	"hk4e/protocol/cmd"
@@ -13,62 +13,125 @@ import (
 	pb "google.golang.org/protobuf/proto"
 )
 
-func (h *Handle) ConvPbVecToMeshVec(pbVec *proto.Vector) alg.MeshVector {
-	return alg.MeshVector{
-		X: int16(pbVec.X),
-		Y: int16(pbVec.Y),
-		Z: int16(pbVec.Z),
+const (
+	QueryPathTimeBudget     = time.Millisecond * 5 // 单次寻路请求的时间预算 超时返回部分路径
+	QueryPathDefaultExtent  = 2.0                  // 客户端未指定时起点终点吸附到网格的搜索范围
+	QueryPathMaxExtent      = 20.0
+	QueryPathMaxObstacleNum = 1000 // 单个玩家的最大动态障碍数量
+)
+
+func ConvPbVecToAlgVec(pbVec *proto.Vector) *alg.Vector3 {
+	return &alg.Vector3{
+		X: pbVec.X,
+		Y: pbVec.Y,
+		Z: pbVec.Z,
 	}
 }
 
-func (h *Handle) ConvMeshVecToPbVec(meshVec alg.MeshVector) *proto.Vector {
-	return &proto.Vector{
-		X: float32(meshVec.X),
-		Y: float32(meshVec.Y),
-		Z: float32(meshVec.Z),
+func ConvAlgVecListToPbVecList(algVecList []*alg.Vector3) []*proto.Vector {
+	ret := make([]*proto.Vector, 0, len(algVecList))
+	for _, algVec := range algVecList {
+		ret = append(ret, &proto.Vector{X: algVec.X, Y: algVec.Y, Z: algVec.Z})
 	}
+	return ret
 }
 
-func (h *Handle) ConvPbVecListToMeshVecList(pbVecList []*proto.Vector) []alg.MeshVector {
-	ret := make([]alg.MeshVector, 0)
-	for _, pbVec := range pbVecList {
-		ret = append(ret, h.ConvPbVecToMeshVec(pbVec))
+// GetQuaternionYaw 四元数绕Y轴的旋转角度 弧度
+func GetQuaternionYaw(q *proto.MathQuaternion) float32 {
+	if q == nil {
+		return 0.0
 	}
-	return ret
+	return float32(math.Atan2(float64(2.0*(q.W*q.Y+q.X*q.Z)), float64(1.0-2.0*(q.X*q.X+q.Y*q.Y))))
 }
 
-func (h *Handle) ConvMeshVecListToPbVecList(meshVecList []alg.MeshVector) []*proto.Vector {
-	ret := make([]*proto.Vector, 0)
-	for _, meshVec := range meshVecList {
-		ret = append(ret, h.ConvMeshVecToPbVec(meshVec))
+// PlayerObstacle 玩家所在场景的动态障碍层 障碍由客户端上报 每个玩家独立
+type PlayerObstacle struct {
+	sceneId uint32
+	layer   *alg.NavObstacleLayer
+}
+
+// GetPlayerObstacleLayer 获取玩家在场景内的动态障碍层 玩家切换场景后重置
+func (h *Handle) GetPlayerObstacleLayer(userId uint32, sceneId uint32, navMesh *alg.NavMesh) *alg.NavObstacleLayer {
+	playerObstacle, exist := h.obstacleMap[userId]
+	if exist && playerObstacle.sceneId == sceneId {
+		return playerObstacle.layer
 	}
-	return ret
+	playerObstacle = &PlayerObstacle{
+		sceneId: sceneId,
+		layer:   alg.NewNavObstacleLayer(navMesh),
+	}
+	h.obstacleMap[userId] = playerObstacle
+	return playerObstacle.layer
+}
+
+func (h *Handle) DelPlayerObstacle(userId uint32) {
+	delete(h.obstacleMap, userId)
 }
 
 func (h *Handle) QueryPath(userId uint32, gateAppId string, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.QueryPathReq)
 	logger.Debug("query path req: %v, uid: %v, gateAppId: %v", req, userId, gateAppId)
-	var ok = false
-	var path []alg.MeshVector = nil
-	for _, destinationPos := range req.DestinationPos {
-		ok, path = h.worldStatic.Pathfinding(h.ConvPbVecToMeshVec(req.SourcePos), h.ConvPbVecToMeshVec(destinationPos))
-		if ok {
-			break
-		}
+	if req.SourcePos == nil || len(req.DestinationPos) == 0 {
+		h.SendMsg(cmd.QueryPathRsp, userId, gateAppId, &proto.QueryPathRsp{
+			QueryId:     req.QueryId,
+			QueryStatus: proto.QueryPathRsp_STATUS_FAIL,
+		})
+		return
 	}
-	if !ok {
-		queryPathRsp := &proto.QueryPathRsp{
+	navMesh := h.worldStatic.GetNavMesh(req.SceneId)
+	if navMesh == nil {
+		// 没有该场景的导航网格 直接走向目标点
+		h.SendMsg(cmd.QueryPathRsp, userId, gateAppId, &proto.QueryPathRsp{
 			QueryId:     req.QueryId,
 			QueryStatus: proto.QueryPathRsp_STATUS_SUCC,
 			Corners:     []*proto.Vector{req.DestinationPos[0]},
-		}
-		h.SendMsg(cmd.QueryPathRsp, userId, gateAppId, queryPathRsp)
+		})
 		return
 	}
+	extent := float32(QueryPathDefaultExtent)
+	if req.SourceExtend != nil && req.SourceExtend.X > 0 && req.SourceExtend.Z > 0 {
+		extent = float32(req.SourceExtend.X)
+		if req.SourceExtend.Z > req.SourceExtend.X {
+			extent = float32(req.SourceExtend.Z)
+		}
+		if extent > QueryPathMaxExtent {
+			extent = QueryPathMaxExtent
+		}
+	}
+	layer := h.GetPlayerObstacleLayer(userId, req.SceneId, navMesh)
+	startPos := ConvPbVecToAlgVec(req.SourcePos)
+	deadline := time.Now().Add(QueryPathTimeBudget)
+	status := alg.NavPathFail
+	var path []*alg.Vector3 = nil
+	// 按顺序尝试每个目标点 找到完整路径即返回 否则返回第一个部分路径
+	for _, destinationPos := range req.DestinationPos {
+		budget := time.Until(deadline)
+		if budget <= 0 {
+			break
+		}
+		ret, retPath := navMesh.FindPath(startPos, ConvPbVecToAlgVec(destinationPos), extent, layer, budget)
+		if ret == alg.NavPathSucc {
+			status, path = ret, retPath
+			break
+		}
+		if ret == alg.NavPathPartial && status == alg.NavPathFail {
+			status, path = ret, retPath
+		}
+	}
 	queryPathRsp := &proto.QueryPathRsp{
-		QueryId:     req.QueryId,
-		QueryStatus: proto.QueryPathRsp_STATUS_SUCC,
-		Corners:     h.ConvMeshVecListToPbVecList(path),
+		QueryId: req.QueryId,
+	}
+	switch status {
+	case alg.NavPathSucc:
+		queryPathRsp.QueryStatus = proto.QueryPathRsp_STATUS_SUCC
+		queryPathRsp.Corners = ConvAlgVecListToPbVecList(path)
+	case alg.NavPathPartial:
+		queryPathRsp.QueryStatus = proto.QueryPathRsp_STATUS_PARTIAL
+		queryPathRsp.Corners = ConvAlgVecListToPbVecList(path)
+	default:
+		logger.Debug("could not find path, sceneId: %v, source: %v, destination: %v, uid: %v",
+			req.SceneId, req.SourcePos, req.DestinationPos, userId)
+		queryPathRsp.QueryStatus = proto.QueryPathRsp_STATUS_FAIL
 	}
 	h.SendMsg(cmd.QueryPathRsp, userId, gateAppId, queryPathRsp)
 }
@@ -76,87 +139,68 @@ func (h *Handle) QueryPath(userId uint32, gateAppId string, payloadMsg pb.Messag
 func (h *Handle) ObstacleModifyNotify(userId uint32, gateAppId string, payloadMsg pb.Message) {
 	req := payloadMsg.(*proto.ObstacleModifyNotify)
 	logger.Debug("obstacle modify req: %v, uid: %v, gateAppId: %v", req, userId, gateAppId)
+	navMesh := h.worldStatic.GetNavMesh(req.SceneId)
+	if navMesh == nil {
+		return
+	}
+	layer := h.GetPlayerObstacleLayer(userId, req.SceneId, navMesh)
+	for _, obstacleId := range req.RemoveObstacleIds {
+		layer.RemoveObstacle(obstacleId)
+	}
+	for _, obstacleInfo := range req.AddObstacles {
+		if obstacleInfo.Center == nil || obstacleInfo.Extents == nil {
+			continue
+		}
+		if layer.GetObstacleNum() >= QueryPathMaxObstacleNum {
+			logger.Error("player obstacle num exceed limit, uid: %v", userId)
+			break
+		}
+		obstacle := &alg.NavObstacle{
+			Shape:  alg.NavObstacleBox,
+			Center: *ConvPbVecToAlgVec(obstacleInfo.Center),
+			HalfExtents: alg.Vector3{
+				X: float32(obstacleInfo.Extents.X),
+				Y: float32(obstacleInfo.Extents.Y),
+				Z: float32(obstacleInfo.Extents.Z),
+			},
+			Yaw: GetQuaternionYaw(obstacleInfo.Rotation),
+		}
+		if obstacleInfo.Shape == proto.ObstacleInfo_OBSTACLE_SHAPE_CAPSULE {
+			obstacle.Shape = alg.NavObstacleCapsule
+		}
+		layer.AddObstacle(obstacleInfo.ObstacleId, obstacle)
+	}
 }
 
+// WorldStatic 世界静态数据 按场景加载的导航网格 加载后只读
 type WorldStatic struct {
-	// x y z -> if terrain exist
-	terrain map[alg.MeshVector]bool
+	// 场景id -> 导航网格
+	navMeshMap map[uint32]*alg.NavMesh
 }
 
 func NewWorldStatic() (r *WorldStatic) {
 	r = new(WorldStatic)
-	r.terrain = make(map[alg.MeshVector]bool)
+	r.navMeshMap = make(map[uint32]*alg.NavMesh)
 	return r
 }
 
-func (w *WorldStatic) InitTerrain() bool {
-	data, err := os.ReadFile("./world_terrain.bin")
-	if err != nil {
-		logger.Error("read world terrain file error: %v", err)
-		return false
-	}
-	decoder := gob.NewDecoder(bytes.NewReader(data))
-	err = decoder.Decode(&w.terrain)
-	if err != nil {
-		logger.Error("unmarshal world terrain data error: %v", err)
+// InitNavMesh 加载导航网格目录下的全部场景导航网格
+func (w *WorldStatic) InitNavMesh() bool {
+	navMeshMap, err := alg.LoadNavMeshDir(config.GetConfig().Hk4e.NavMeshPath)
+	if navMeshMap == nil {
+		logger.Error("read nav mesh dir error: %v", err)
 		return false
 	}
-	return true
-}
-
-func (w *WorldStatic) SaveTerrain() bool {
-	var buffer bytes.Buffer
-	encoder := gob.NewEncoder(&buffer)
-	err := encoder.Encode(w.terrain)
 	if err != nil {
-		logger.Error("marshal world terrain data error: %v", err)
-		return false
+		logger.Error("load nav mesh error: %v", err)
 	}
-	err = os.WriteFile("./world_terrain.bin", buffer.Bytes(), 0644)
-	if err != nil {
-		logger.Error("write world terrain file error: %v", err)
-		return false
+	for sceneId, navMesh := range navMeshMap {
+		w.navMeshMap[sceneId] = navMesh
+		logger.Info("load nav mesh finish, sceneId: %v, vert num: %v, poly num: %v", sceneId, navMesh.GetVertNum(), navMesh.GetPolyNum())
 	}
 	return true
 }
 
-func (w *WorldStatic) GetTerrain(x int16, y int16, z int16) (exist bool) {
-	pos := alg.MeshVector{
-		X: x,
-		Y: y,
-		Z: z,
-	}
-	exist = w.terrain[pos]
-	return exist
-}
-
-func (w *WorldStatic) SetTerrain(x int16, y int16, z int16) {
-	pos := alg.MeshVector{
-		X: x,
-		Y: y,
-		Z: z,
-	}
-	w.terrain[pos] = true
-}
-
-func (w *WorldStatic) Pathfinding(startPos alg.MeshVector, endPos alg.MeshVector) (bool, []alg.MeshVector) {
-	defer func() {
-		if err := recover(); err != nil {
-			logger.Error("pathfinding error, panic, startPos: %v, endPos: %v", startPos, endPos)
-		}
-	}()
-	bfs := alg.NewBFS()
-	bfs.InitMap(
-		w.terrain,
-		startPos,
-		endPos,
-		0,
-	)
-	pathVectorList := bfs.Pathfinding()
-	if pathVectorList == nil {
-		logger.Error("could not find path")
-		return false, nil
-	}
-	logger.Debug("find path success, path: %v", pathVectorList)
-	return true, pathVectorList
+func (w *WorldStatic) GetNavMesh(sceneId uint32) *alg.NavMesh {
+	return w.navMeshMap[sceneId]
 }
@@ -9,3 +9,11 @@ const (
 	GM       = "GM"
 	ROBOT    = "ROBOT"
 )
+
+// 游戏服务器排空状态
+const (
+	ServerDrainNone     = 0 // 未排空
+	ServerDrainRunning  = 1 // 排空中
+	ServerDrainFinish   = 2 // 排空完成 在线玩家已全部迁移
+	ServerDrainNoTarget = 3 // 没有可迁移的目标游戏服务器 排空停止
+)
@@ -102,3 +102,157 @@ func (d *Dao) QueryStopServerInfo() (*StopServerInfo, error) {
 	}
 	return stopServerInfo, nil
 }
+
+type ServerInstance struct {
+	ServerType        string   `bson:"server_type"`
+	AppId             string   `bson:"app_id"`
+	AppVersion        string   `bson:"app_version"`
+	GateServerKcpAddr string   `bson:"gate_server_kcp_addr"`
+	GateServerKcpPort uint32   `bson:"gate_server_kcp_port"`
+	GateServerMqAddr  string   `bson:"gate_server_mq_addr"`
+	GateServerMqPort  uint32   `bson:"gate_server_mq_port"`
+	GameVersionList   []string `bson:"game_version_list"`
+	GsId              uint32   `bson:"gs_id"`
+	LoadCount         uint32   `bson:"load_count"`
+	DispatchCancel    bool     `bson:"dispatch_cancel"`
+	Weight            uint32   `bson:"weight"`
+	Zone              string   `bson:"zone"`
+}
+
+// SaveServerInstanceList 每个服务器实例单独一条记录 覆盖写入并删除已不存在的实例
+func (d *Dao) SaveServerInstanceList(serverInstanceList []*ServerInstance) error {
+	db := d.db.Collection("server_instance")
+	appIdList := make([]string, 0, len(serverInstanceList))
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, serverInstance := range serverInstanceList {
+		appIdList = append(appIdList, serverInstance.AppId)
+		modelOperate := mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{"app_id", serverInstance.AppId}}).
+			SetReplacement(serverInstance).
+			SetUpsert(true)
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	if len(modelOperateList) != 0 {
+		_, err := db.BulkWrite(context.TODO(), modelOperateList)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := db.DeleteMany(context.TODO(), bson.D{{"app_id", bson.D{{"$nin", appIdList}}}})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) QueryServerInstanceList() ([]*ServerInstance, error) {
+	db := d.db.Collection("server_instance")
+	find, err := db.Find(context.TODO(), bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ServerInstance, 0)
+	for find.Next(context.TODO()) {
+		item := new(ServerInstance)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+type GsOnline struct {
+	Uid     uint32 `bson:"uid"`
+	GsAppId string `bson:"gs_app_id"`
+}
+
+// SaveGsOnlineList 增量写入玩家在线信息 每个玩家单独一条记录 上线的覆盖写入 下线的删除
+func (d *Dao) SaveGsOnlineList(onlineList []*GsOnline, offlineUidList []uint32) error {
+	db := d.db.Collection("gs_online")
+	modelOperateList := make([]mongo.WriteModel, 0)
+	for _, gsOnline := range onlineList {
+		modelOperate := mongo.NewReplaceOneModel().
+			SetFilter(bson.D{{"uid", gsOnline.Uid}}).
+			SetReplacement(gsOnline).
+			SetUpsert(true)
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	for _, uid := range offlineUidList {
+		modelOperate := mongo.NewDeleteOneModel().SetFilter(bson.D{{"uid", uid}})
+		modelOperateList = append(modelOperateList, modelOperate)
+	}
+	if len(modelOperateList) == 0 {
+		return nil
+	}
+	_, err := db.BulkWrite(context.TODO(), modelOperateList)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) QueryGsOnlineList() ([]*GsOnline, error) {
+	db := d.db.Collection("gs_online")
+	find, err := db.Find(context.TODO(), bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*GsOnline, 0)
+	for find.Next(context.TODO()) {
+		item := new(GsOnline)
+		err = find.Decode(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+type NodeState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	LeaderNodeId string             `bson:"leader_node_id"`
+	UpdateTime   int64              `bson:"update_time"`
+}
+
+func (d *Dao) InsertNodeState(nodeState *NodeState) error {
+	db := d.db.Collection("node_state")
+	_, err := db.InsertOne(context.TODO(), nodeState)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) UpdateNodeState(nodeState *NodeState) error {
+	db := d.db.Collection("node_state")
+	_, err := db.UpdateMany(
+		context.TODO(),
+		bson.D{},
+		bson.D{{"$set", nodeState}},
+	)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *Dao) QueryNodeState() (*NodeState, error) {
+	db := d.db.Collection("node_state")
+	result := db.FindOne(
+		context.TODO(),
+		bson.D{},
+	)
+	nodeState := new(NodeState)
+	err := result.Decode(nodeState)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return nodeState, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"hk4e/pkg/logger"
+	"hk4e/pkg/random"
+)
+
+const (
+	BalancePolicyMinLoad  = "min_load" // 负载数最小
+	BalancePolicyWeighted = "weighted" // 负载数除以权重最小 适用于机器配置不一致的情况
+	BalancePolicyRandom   = "random"   // 随机
+)
+
+const (
+	DefaultServerWeight = 100 // 未声明权重的服务器默认权重
+)
+
+const (
+	GsStickyTimeout = time.Minute * 10 // 玩家下线后保留上次所在gs的时长 超时后不再粘滞分配
+)
+
+// lastGsInfo 玩家上次所在的gs
+type lastGsInfo struct {
+	gsAppId    string
+	logoutTime int64 // 下线时间 在线时为0
+}
+
+// BalancePolicy 负载均衡策略 从候选服务器实例中选出一个
+type BalancePolicy func(instList []*ServerInstance) *ServerInstance
+
+var balancePolicyMap = map[string]BalancePolicy{
+	BalancePolicyMinLoad:  minLoadBalancePolicy,
+	BalancePolicyWeighted: weightedBalancePolicy,
+	BalancePolicyRandom:   randomBalancePolicy,
+}
+
+// GetBalancePolicy 获取负载均衡策略 未配置或配置错误时使用加权策略
+func GetBalancePolicy(name string) BalancePolicy {
+	if name == "" {
+		return weightedBalancePolicy
+	}
+	policy, exist := balancePolicyMap[name]
+	if !exist {
+		logger.Error("balance policy not exist, use default, name: %v", name)
+		return weightedBalancePolicy
+	}
+	return policy
+}
+
+func minLoadBalancePolicy(instList []*ServerInstance) *ServerInstance {
+	if len(instList) == 0 {
+		return nil
+	}
+	minLoadInstIndex := 0
+	minLoadInstCount := uint32(math.MaxUint32)
+	for index, inst := range instList {
+		if inst.loadCount < minLoadInstCount {
+			minLoadInstCount = inst.loadCount
+			minLoadInstIndex = index
+		}
+	}
+	return instList[minLoadInstIndex]
+}
+
+func weightedBalancePolicy(instList []*ServerInstance) *ServerInstance {
+	if len(instList) == 0 {
+		return nil
+	}
+	minLoadRateInstIndex := 0
+	minLoadRate := math.MaxFloat64
+	for index, inst := range instList {
+		// 数据库恢复的旧实例可能没有权重
+		weight := inst.weight
+		if weight < 1 {
+			weight = 1
+		}
+		loadRate := float64(inst.loadCount) / float64(weight)
+		if loadRate < minLoadRate {
+			minLoadRate = loadRate
+			minLoadRateInstIndex = index
+		}
+	}
+	return instList[minLoadRateInstIndex]
+}
+
+func randomBalancePolicy(instList []*ServerInstance) *ServerInstance {
+	if len(instList) == 0 {
+		return nil
+	}
+	index := random.GetRandomInt32(0, int32(len(instList)-1))
+	return instList[index]
+}
+
+// selectServerInstance 按策略选出服务器实例 过滤掉取消调度的实例 filter为空则不额外过滤
+func (s *DiscoveryService) selectServerInstance(instMap *sync.Map, policy BalancePolicy, filter func(inst *ServerInstance) bool) *ServerInstance {
+	instList := make([]*ServerInstance, 0)
+	instMap.Range(func(key, value any) bool {
+		serverInstance := value.(*ServerInstance)
+		if serverInstance.dispatchCancel {
+			return true
+		}
+		if filter != nil && !filter(serverInstance) {
+			return true
+		}
+		instList = append(instList, serverInstance)
+		return true
+	})
+	return policy(instList)
+}
+
+// getStickyGsServerInstance 获取玩家上次所在的gs 已下线或取消调度则返回空
+func (s *DiscoveryService) getStickyGsServerInstance(instMap *sync.Map, uid uint32) *ServerInstance {
+	value, exist := s.lastGsMap.Load(uid)
+	if !exist {
+		return nil
+	}
+	info := value.(*lastGsInfo)
+	if info.logoutTime != 0 && time.Now().Unix()-info.logoutTime > int64(GsStickyTimeout.Seconds()) {
+		return nil
+	}
+	inst, exist := instMap.Load(info.gsAppId)
+	if !exist {
+		return nil
+	}
+	serverInstance := inst.(*ServerInstance)
+	if serverInstance.dispatchCancel {
+		return nil
+	}
+	return serverInstance
+}
+
+// setLastGs 记录玩家上次所在的gs 下线时记录下线时间用于过期清理
+func (s *DiscoveryService) setLastGs(uid uint32, gsAppId string, isOnline bool) {
+	if isOnline {
+		s.lastGsMap.Store(uid, &lastGsInfo{gsAppId: gsAppId, logoutTime: 0})
+		return
+	}
+	value, exist := s.lastGsMap.Load(uid)
+	if !exist {
+		return
+	}
+	s.lastGsMap.Store(uid, &lastGsInfo{gsAppId: value.(*lastGsInfo).gsAppId, logoutTime: time.Now().Unix()})
+}
+
+// removeExpireLastGs 清理下线超时的玩家上次所在gs记录
+func (s *DiscoveryService) removeExpireLastGs(nowTime int64) {
+	s.lastGsMap.Range(func(uid, value any) bool {
+		info := value.(*lastGsInfo)
+		if info.logoutTime != 0 && nowTime-info.logoutTime > int64(GsStickyTimeout.Seconds()) {
+			s.lastGsMap.Delete(uid)
+		}
+		return true
+	})
+}
@@ -3,12 +3,12 @@ package service
 import (
 	"context"
 	"errors"
-	"math"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"hk4e/common/config"
 	"hk4e/common/mq"
 	"hk4e/node/api"
 	"hk4e/node/dao"
@@ -21,6 +21,11 @@ const (
 	UidBegin = 100000000
 )
 
+const (
+	UidReserveStep        = 1000            // uid分段预留数量 主节点故障切换时跳过未持久化的uid段
+	NodeStateSaveInterval = time.Second * 5 // 主节点状态持久化间隔
+)
+
 var _ api.DiscoveryNATSRPCServer = (*DiscoveryService)(nil)
 
 // ServerInstance 服务器实例
@@ -37,6 +42,11 @@ type ServerInstance struct {
 	gsId              uint32   // 游戏服务器编号
 	loadCount         uint32   // 负载数
 	dispatchCancel    bool     // 是否取消调度
+	weight            uint32   // 权重
+	zone              string   // 所在区域
+	drainState        uint32   // 排空状态
+	drainOnlineNum    uint32   // 排空上报时的在线玩家数
+	drainMsg          string   // 排空状态说明
 }
 
 // StopServerInfo 停服信息
@@ -54,49 +64,90 @@ type DiscoveryService struct {
 	serverInstanceMap map[string]*sync.Map // 全部服务器实例集合 key:服务器类型 value:服务器实例集合 -> key:appid value:服务器实例
 	serverAppIdMap    *sync.Map            // 服务器appid集合 key:appid value:是否存在
 	globalGsOnlineMap *sync.Map            // 全服玩家在线集合 key:uid value:gsAppid
+	gsOnlineChangeMap *sync.Map            // 待持久化的玩家在线变化 key:uid value:gsAppid 空字符串为下线
 	stopServerInfo    *StopServerInfo      // 停服信息
 	messageQueue      *mq.MessageQueue     // 消息队列实例
+	leader            int32                // 是否为主节点 只有主节点对外提供服务
+	nodeId            string               // 节点id
+	uidLock           sync.Mutex           // uid分配锁
+	uidLimit          uint32               // 已持久化预留的uid上限
+	gateBalancePolicy BalancePolicy        // 网关负载均衡策略
+	gsBalancePolicy   BalancePolicy        // gs负载均衡策略
+	gsStickyEnable    bool                 // 是否开启gs粘滞分配
+	lastGsMap         *sync.Map            // 玩家上次所在的gs key:uid value:*lastGsInfo
 }
 
 func NewDiscoveryService(db *dao.Dao, messageQueue *mq.MessageQueue) (*DiscoveryService, error) {
 	r := new(DiscoveryService)
 	r.db = db
-	region, err := r.db.QueryRegion()
+	err := r.loadRegion()
 	if err != nil {
-		logger.Error("load region from db error: %v", err)
 		return nil, err
 	}
+	r.serverInstanceMap = make(map[string]*sync.Map)
+	r.serverInstanceMap[api.GATE] = new(sync.Map)
+	r.serverInstanceMap[api.GS] = new(sync.Map)
+	r.serverInstanceMap[api.MULTI] = new(sync.Map)
+	r.serverInstanceMap[api.ROBOT] = new(sync.Map)
+	r.serverInstanceMap[api.DISPATCH] = new(sync.Map)
+	r.serverAppIdMap = new(sync.Map)
+	r.globalGsOnlineMap = new(sync.Map)
+	r.gsOnlineChangeMap = new(sync.Map)
+	r.gateBalancePolicy = GetBalancePolicy(config.GetConfig().Hk4e.GateBalancePolicy)
+	r.gsBalancePolicy = GetBalancePolicy(config.GetConfig().Hk4e.GsBalancePolicy)
+	r.gsStickyEnable = config.GetConfig().Hk4e.GsStickyEnable
+	r.lastGsMap = new(sync.Map)
+	err = r.loadStopServerInfo()
+	if err != nil {
+		return nil, err
+	}
+	r.messageQueue = messageQueue
+	go r.removeDeadServer()
+	go r.broadcastReceiver()
+	go r.serverState()
+	go r.saveNodeStateLoop()
+	metricsLeader(r)
+	return r, nil
+}
+
+// loadRegion 从数据库加载区服信息
+func (s *DiscoveryService) loadRegion() error {
+	region, err := s.db.QueryRegion()
+	if err != nil {
+		logger.Error("load region from db error: %v", err)
+		return err
+	}
 	if region == nil {
 		logger.Info("init region")
 		region = &dao.Region{
 			Ec2bData: random.NewEc2b().Bytes(),
 			NextUid:  UidBegin,
 		}
-		err := r.db.InsertRegion(region)
+		err := s.db.InsertRegion(region)
 		if err != nil {
 			logger.Error("save region to db error: %v", err)
-			return nil, err
+			return err
 		}
 	}
-	r.regionEc2b, err = random.LoadEc2bKey(region.Ec2bData)
+	s.regionEc2b, err = random.LoadEc2bKey(region.Ec2bData)
 	if err != nil {
 		logger.Error("parse ec2b data error: %v", err)
-		return nil, err
-	}
-	logger.Info("region ec2b load ok, seed: %v", r.regionEc2b.Seed())
-	r.nextUid = region.NextUid
-	r.serverInstanceMap = make(map[string]*sync.Map)
-	r.serverInstanceMap[api.GATE] = new(sync.Map)
-	r.serverInstanceMap[api.GS] = new(sync.Map)
-	r.serverInstanceMap[api.MULTI] = new(sync.Map)
-	r.serverInstanceMap[api.ROBOT] = new(sync.Map)
-	r.serverInstanceMap[api.DISPATCH] = new(sync.Map)
-	r.serverAppIdMap = new(sync.Map)
-	r.globalGsOnlineMap = new(sync.Map)
-	stopServerInfo, err := r.db.QueryStopServerInfo()
+		return err
+	}
+	logger.Info("region ec2b load ok, seed: %v", s.regionEc2b.Seed())
+	s.uidLock.Lock()
+	s.nextUid = region.NextUid
+	s.uidLimit = region.NextUid
+	s.uidLock.Unlock()
+	return nil
+}
+
+// loadStopServerInfo 从数据库加载停服信息和白名单
+func (s *DiscoveryService) loadStopServerInfo() error {
+	stopServerInfo, err := s.db.QueryStopServerInfo()
 	if err != nil {
 		logger.Error("load stop server info from db error: %v", err)
-		return nil, err
+		return err
 	}
 	if stopServerInfo == nil {
 		logger.Info("init stop server info")
@@ -106,37 +157,224 @@ func NewDiscoveryService(db *dao.Dao, messageQueue *mq.MessageQueue) (*Discovery
 			EndTime:         uint32(time.Now().AddDate(10, 0, 0).Unix()),
 			IpAddrWhiteList: make([]string, 0),
 		}
-		err := r.db.InsertStopServerInfo(stopServerInfo)
+		err := s.db.InsertStopServerInfo(stopServerInfo)
 		if err != nil {
 			logger.Error("save stop server info to db error: %v", err)
-			return nil, err
+			return err
 		}
 	}
-	r.stopServerInfo = &StopServerInfo{
+	s.stopServerInfo = &StopServerInfo{
 		stopServer:      stopServerInfo.StopServer,
 		startTime:       stopServerInfo.StartTime,
 		endTime:         stopServerInfo.EndTime,
 		ipAddrWhiteList: make(map[string]struct{}),
 	}
 	for _, ipAddr := range stopServerInfo.IpAddrWhiteList {
-		r.stopServerInfo.ipAddrWhiteList[ipAddr] = struct{}{}
+		s.stopServerInfo.ipAddrWhiteList[ipAddr] = struct{}{}
 	}
-	r.messageQueue = messageQueue
-	go r.removeDeadServer()
-	go r.broadcastReceiver()
-	go r.serverState()
-	return r, nil
+	return nil
+}
+
+// loadNodeState 从数据库加载上一个主节点持久化的服务器实例和玩家在线信息
+func (s *DiscoveryService) loadNodeState() error {
+	nodeState, err := s.db.QueryNodeState()
+	if err != nil {
+		logger.Error("load node state from db error: %v", err)
+		return err
+	}
+	if nodeState == nil {
+		logger.Info("init node state")
+		err := s.db.InsertNodeState(&dao.NodeState{
+			LeaderNodeId: s.nodeId,
+			UpdateTime:   time.Now().Unix(),
+		})
+		if err != nil {
+			logger.Error("save node state to db error: %v", err)
+			return err
+		}
+		return nil
+	}
+	logger.Info("load node state, last leader nodeId: %v, update time: %v", nodeState.LeaderNodeId, nodeState.UpdateTime)
+	serverInstanceList, err := s.db.QueryServerInstanceList()
+	if err != nil {
+		logger.Error("load server instance from db error: %v", err)
+		return err
+	}
+	gsOnlineList, err := s.db.QueryGsOnlineList()
+	if err != nil {
+		logger.Error("load gs online from db error: %v", err)
+		return err
+	}
+	// 接管后重置保活时间 给服务器留出重新保活的时间
+	nowTime := time.Now().Unix()
+	for _, dbInst := range serverInstanceList {
+		instMap, exist := s.serverInstanceMap[dbInst.ServerType]
+		if !exist {
+			continue
+		}
+		instMap.Store(dbInst.AppId, &ServerInstance{
+			serverType:        dbInst.ServerType,
+			appId:             dbInst.AppId,
+			appVersion:        dbInst.AppVersion,
+			gateServerKcpAddr: dbInst.GateServerKcpAddr,
+			gateServerKcpPort: dbInst.GateServerKcpPort,
+			gateServerMqAddr:  dbInst.GateServerMqAddr,
+			gateServerMqPort:  dbInst.GateServerMqPort,
+			gameVersionList:   dbInst.GameVersionList,
+			lastAliveTime:     nowTime,
+			gsId:              dbInst.GsId,
+			loadCount:         dbInst.LoadCount,
+			dispatchCancel:    dbInst.DispatchCancel,
+			weight:            dbInst.Weight,
+			zone:              dbInst.Zone,
+		})
+		s.serverAppIdMap.Store(dbInst.AppId, true)
+	}
+	// 备用节点运行期间通过广播同步的在线信息更新 优先保留
+	for _, gsOnline := range gsOnlineList {
+		if _, changed := s.gsOnlineChangeMap.Load(gsOnline.Uid); changed {
+			continue
+		}
+		s.globalGsOnlineMap.LoadOrStore(gsOnline.Uid, gsOnline.GsAppId)
+	}
+	return nil
+}
+
+func (s *DiscoveryService) saveNodeState() {
+	serverInstanceList := make([]*dao.ServerInstance, 0)
+	for _, instMap := range s.serverInstanceMap {
+		instMap.Range(func(appid, inst any) bool {
+			serverInstance := inst.(*ServerInstance)
+			serverInstanceList = append(serverInstanceList, &dao.ServerInstance{
+				ServerType:        serverInstance.serverType,
+				AppId:             serverInstance.appId,
+				AppVersion:        serverInstance.appVersion,
+				GateServerKcpAddr: serverInstance.gateServerKcpAddr,
+				GateServerKcpPort: serverInstance.gateServerKcpPort,
+				GateServerMqAddr:  serverInstance.gateServerMqAddr,
+				GateServerMqPort:  serverInstance.gateServerMqPort,
+				GameVersionList:   serverInstance.gameVersionList,
+				GsId:              serverInstance.gsId,
+				LoadCount:         serverInstance.loadCount,
+				DispatchCancel:    serverInstance.dispatchCancel,
+				Weight:            serverInstance.weight,
+				Zone:              serverInstance.zone,
+			})
+			return true
+		})
+	}
+	err := s.db.SaveServerInstanceList(serverInstanceList)
+	if err != nil {
+		logger.Error("save server instance to db error: %v", err)
+	}
+	// 玩家在线信息只写入上次持久化之后的变化
+	changeMap := make(map[uint32]string)
+	s.gsOnlineChangeMap.Range(func(uid, gsAppid any) bool {
+		value, exist := s.gsOnlineChangeMap.LoadAndDelete(uid)
+		if exist {
+			changeMap[uid.(uint32)] = value.(string)
+		}
+		return true
+	})
+	gsOnlineList := make([]*dao.GsOnline, 0)
+	offlineUidList := make([]uint32, 0)
+	for uid, gsAppid := range changeMap {
+		if gsAppid == "" {
+			offlineUidList = append(offlineUidList, uid)
+		} else {
+			gsOnlineList = append(gsOnlineList, &dao.GsOnline{
+				Uid:     uid,
+				GsAppId: gsAppid,
+			})
+		}
+	}
+	err = s.db.SaveGsOnlineList(gsOnlineList, offlineUidList)
+	if err != nil {
+		logger.Error("save gs online to db error: %v", err)
+		// 写入失败 放回等待下次持久化 期间产生的新变化优先
+		for uid, gsAppid := range changeMap {
+			s.gsOnlineChangeMap.LoadOrStore(uid, gsAppid)
+		}
+	}
+	err = s.db.UpdateNodeState(&dao.NodeState{
+		LeaderNodeId: s.nodeId,
+		UpdateTime:   time.Now().Unix(),
+	})
+	if err != nil {
+		logger.Error("save node state to db error: %v", err)
+	}
+}
+
+// 主节点定时持久化状态 供备用节点接管
+func (s *DiscoveryService) saveNodeStateLoop() {
+	ticker := time.NewTicker(NodeStateSaveInterval)
+	for {
+		<-ticker.C
+		if !s.isLeader() {
+			continue
+		}
+		s.saveNodeState()
+	}
+}
+
+func (s *DiscoveryService) isLeader() bool {
+	return atomic.LoadInt32(&s.leader) == 1
+}
+
+// becomeLeader 成为主节点 从数据库恢复最新状态
+func (s *DiscoveryService) becomeLeader(nodeId string) error {
+	s.nodeId = nodeId
+	err := s.loadRegion()
+	if err != nil {
+		return err
+	}
+	err = s.loadStopServerInfo()
+	if err != nil {
+		return err
+	}
+	err = s.loadNodeState()
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&s.leader, 1)
+	return nil
+}
+
+// becomeFollower 成为备用节点 清空服务器实例 玩家在线信息继续通过广播同步
+func (s *DiscoveryService) becomeFollower() {
+	atomic.StoreInt32(&s.leader, 0)
+	for _, instMap := range s.serverInstanceMap {
+		instMap.Range(func(appid, inst any) bool {
+			instMap.Delete(appid)
+			return true
+		})
+	}
+	s.serverAppIdMap.Range(func(appid, value any) bool {
+		s.serverAppIdMap.Delete(appid)
+		return true
+	})
+	metricsServerReset()
 }
 
 func (s *DiscoveryService) close() {
+	if !s.isLeader() {
+		return
+	}
+	s.saveNodeState()
+	s.uidLock.Lock()
 	region := &dao.Region{
 		Ec2bData: s.regionEc2b.Bytes(),
 		NextUid:  s.nextUid,
 	}
+	s.uidLock.Unlock()
 	err := s.db.UpdateRegion(region)
 	if err != nil {
 		logger.Error("save region to db error: %v", err)
 	}
+	s.saveStopServerInfo()
+}
+
+func (s *DiscoveryService) saveStopServerInfo() {
 	ipAddrWhiteList := make([]string, 0)
 	for ipAddr := range s.stopServerInfo.ipAddrWhiteList {
 		ipAddrWhiteList = append(ipAddrWhiteList, ipAddr)
@@ -147,7 +385,7 @@ func (s *DiscoveryService) close() {
 		EndTime:         s.stopServerInfo.endTime,
 		IpAddrWhiteList: ipAddrWhiteList,
 	}
-	err = s.db.UpdateStopServerInfo(stopServerInfo)
+	err := s.db.UpdateStopServerInfo(stopServerInfo)
 	if err != nil {
 		logger.Error("save stop server info to db error: %v", err)
 	}
@@ -168,8 +406,12 @@ func (s *DiscoveryService) broadcastReceiver() {
 		serverMsg := netMsg.ServerMsg
 		if serverMsg.IsOnline {
 			s.globalGsOnlineMap.Store(serverMsg.UserId, netMsg.OriginServerAppId)
+			s.gsOnlineChangeMap.Store(serverMsg.UserId, netMsg.OriginServerAppId)
+			s.setLastGs(serverMsg.UserId, netMsg.OriginServerAppId, true)
 		} else {
 			s.globalGsOnlineMap.Delete(serverMsg.UserId)
+			s.gsOnlineChangeMap.Store(serverMsg.UserId, "")
+			s.setLastGs(serverMsg.UserId, netMsg.OriginServerAppId, false)
 		}
 	}
 }
@@ -197,6 +439,11 @@ func (s *DiscoveryService) RegisterServer(ctx context.Context, req *api.Register
 		lastAliveTime:  time.Now().Unix(),
 		loadCount:      0,
 		dispatchCancel: false,
+		weight:         req.Weight,
+		zone:           req.Zone,
+	}
+	if inst.weight == 0 {
+		inst.weight = DefaultServerWeight
 	}
 	if req.ServerType == api.GATE {
 		logger.Info("register new gate server, ip: %v, port: %v", req.GateServerAddr.KcpAddr, req.GateServerAddr.KcpPort)
@@ -252,6 +499,7 @@ func (s *DiscoveryService) CancelServer(ctx context.Context, req *api.CancelServ
 		return nil, errors.New("server not exist")
 	}
 	instMap.Delete(req.AppId)
+	metricsServerRemove(req.ServerType, req.AppId)
 	return &api.NullMsg{}, nil
 }
 
@@ -270,6 +518,7 @@ func (s *DiscoveryService) KeepaliveServer(ctx context.Context, req *api.Keepali
 	serverInstance := inst.(*ServerInstance)
 	serverInstance.lastAliveTime = time.Now().Unix()
 	serverInstance.loadCount = req.LoadCount
+	metricsServerLoad(serverInstance)
 	logger.Debug("server instance: %+v", serverInstance)
 	return &api.NullMsg{}, nil
 }
@@ -285,10 +534,19 @@ func (s *DiscoveryService) GetServerAppId(ctx context.Context, req *api.GetServe
 		return nil, errors.New("no server found")
 	}
 	var inst *ServerInstance = nil
-	if req.ServerType == api.GATE || req.ServerType == api.GS {
-		inst = s.getMinLoadServerInstance(instMap)
-	} else {
-		inst = s.getRandomServerInstance(instMap)
+	switch req.ServerType {
+	case api.GATE:
+		inst = s.selectServerInstance(instMap, s.gateBalancePolicy, nil)
+	case api.GS:
+		// 玩家上次所在的gs仍然存活则优先分配
+		if s.gsStickyEnable && req.Uid != 0 {
+			inst = s.getStickyGsServerInstance(instMap, req.Uid)
+		}
+		if inst == nil {
+			inst = s.selectServerInstance(instMap, s.gsBalancePolicy, nil)
+		}
+	default:
+		inst = s.selectServerInstance(instMap, balancePolicyMap[BalancePolicyRandom], nil)
 	}
 	if inst == nil {
 		return nil, errors.New("no server found")
@@ -317,21 +575,24 @@ func (s *DiscoveryService) GetGateServerAddr(ctx context.Context, req *api.GetGa
 	if s.getServerInstanceMapLen(instMap) == 0 {
 		return nil, errors.New("no gate server found")
 	}
-	versionInstMap := sync.Map{}
-	instMap.Range(func(key, value any) bool {
-		serverInstance := value.(*ServerInstance)
-		for _, gameVersion := range serverInstance.gameVersionList {
+	versionFilter := func(inst *ServerInstance) bool {
+		for _, gameVersion := range inst.gameVersionList {
 			if gameVersion == req.GameVersion {
-				versionInstMap.Store(key, serverInstance)
 				return true
 			}
 		}
-		return true
-	})
-	if s.getServerInstanceMapLen(&versionInstMap) == 0 {
-		return nil, errors.New("no gate server found")
+		return false
+	}
+	var inst *ServerInstance = nil
+	// 优先分配同区域的网关 没有则在全部网关中分配
+	if req.Zone != "" {
+		inst = s.selectServerInstance(instMap, s.gateBalancePolicy, func(inst *ServerInstance) bool {
+			return inst.zone == req.Zone && versionFilter(inst)
+		})
+	}
+	if inst == nil {
+		inst = s.selectServerInstance(instMap, s.gateBalancePolicy, versionFilter)
 	}
-	inst := s.getMinLoadServerInstance(&versionInstMap)
 	if inst == nil {
 		return nil, errors.New("no gate server found")
 	}
@@ -395,6 +656,29 @@ func (s *DiscoveryService) GetMainGameServerAppId(ctx context.Context, req *api.
 	}, nil
 }
 
+// GetMainMultiServerAppId 获取主多功能服务器的appid 取appid最小的多功能服务器 保证所有游戏服务器选择一致
+func (s *DiscoveryService) GetMainMultiServerAppId(ctx context.Context, req *api.NullMsg) (*api.GetMainMultiServerAppIdRsp, error) {
+	logger.Debug("get main multi server appid")
+	instMap, exist := s.serverInstanceMap[api.MULTI]
+	if !exist {
+		return nil, errors.New("multi server not exist")
+	}
+	appid := ""
+	instMap.Range(func(key, value any) bool {
+		serverInstance := value.(*ServerInstance)
+		if appid == "" || serverInstance.appId < appid {
+			appid = serverInstance.appId
+		}
+		return true
+	})
+	if appid == "" {
+		return nil, errors.New("no multi server found")
+	}
+	return &api.GetMainMultiServerAppIdRsp{
+		AppId: appid,
+	}, nil
+}
+
 // GetGlobalGsOnlineMap 获取全服玩家GS在线列表
 func (s *DiscoveryService) GetGlobalGsOnlineMap(ctx context.Context, req *api.NullMsg) (*api.GlobalGsOnlineMap, error) {
 	copyMap := make(map[uint32]string)
@@ -425,6 +709,7 @@ func (s *DiscoveryService) SetStopServerInfo(ctx context.Context, req *api.StopS
 	s.stopServerInfo.stopServer = req.StopServer
 	s.stopServerInfo.startTime = req.StartTime
 	s.stopServerInfo.endTime = req.EndTime
+	s.saveStopServerInfo()
 	if shutdown {
 		s.messageQueue.SendToAll(&mq.NetMsg{
 			MsgType: mq.MsgTypeServer,
@@ -450,13 +735,31 @@ func (s *DiscoveryService) SetWhiteList(ctx context.Context, req *api.SetWhiteLi
 	} else {
 		delete(s.stopServerInfo.ipAddrWhiteList, req.IpAddr)
 	}
+	s.saveStopServerInfo()
 	return &api.NullMsg{}, nil
 }
 
 // GetNextUid 获取下一个自增uid
 func (s *DiscoveryService) GetNextUid(ctx context.Context, req *api.NullMsg) (*api.GetNextUidRsp, error) {
+	s.uidLock.Lock()
+	defer s.uidLock.Unlock()
+	uid := s.nextUid + 1
+	// 分配到预留上限时先持久化下一段 保证切换主节点后不会重复分配
+	if uid >= s.uidLimit {
+		uidLimit := uid + UidReserveStep
+		err := s.db.UpdateRegion(&dao.Region{
+			Ec2bData: s.regionEc2b.Bytes(),
+			NextUid:  uidLimit,
+		})
+		if err != nil {
+			logger.Error("save region to db error: %v", err)
+			return nil, err
+		}
+		s.uidLimit = uidLimit
+	}
+	s.nextUid = uid
 	return &api.GetNextUidRsp{
-		Uid: atomic.AddUint32(&s.nextUid, 1),
+		Uid: uid,
 	}, nil
 }
 
@@ -481,47 +784,54 @@ func (s *DiscoveryService) ServerDispatchCancel(ctx context.Context, req *api.Se
 	return &api.NullMsg{}, nil
 }
 
-func (s *DiscoveryService) getRandomServerInstance(instMap *sync.Map) *ServerInstance {
-	instList := make([]*ServerInstance, 0)
-	instMap.Range(func(key, value any) bool {
-		serverInstance := value.(*ServerInstance)
-		if serverInstance.dispatchCancel {
-			return true
-		}
-		instList = append(instList, serverInstance)
-		return true
-	})
-	if len(instList) == 0 {
-		return nil
+// ServerDrain 排空指定的游戏服务器 不再分配新玩家 并通知其将在线玩家迁移到其它游戏服务器
+func (s *DiscoveryService) ServerDrain(ctx context.Context, req *api.ServerDrainReq) (*api.NullMsg, error) {
+	instMap := s.serverInstanceMap[api.GS]
+	inst, exist := instMap.Load(req.AppId)
+	if !exist {
+		return nil, errors.New("game server not exist")
 	}
-	index := random.GetRandomInt32(0, int32(len(instList)-1))
-	inst := instList[index]
-	return inst
+	serverInstance := inst.(*ServerInstance)
+	serverInstance.dispatchCancel = true
+	serverInstance.drainState = api.ServerDrainRunning
+	serverInstance.drainMsg = ""
+	logger.Warn("game server drain, appid: %v", req.AppId)
+	s.messageQueue.SendToGs(req.AppId, &mq.NetMsg{
+		MsgType: mq.MsgTypeServer,
+		EventId: mq.ServerDrainNotify,
+	})
+	return &api.NullMsg{}, nil
 }
 
-func (s *DiscoveryService) getMinLoadServerInstance(instMap *sync.Map) *ServerInstance {
-	instList := make([]*ServerInstance, 0)
-	instMap.Range(func(key, value any) bool {
-		serverInstance := value.(*ServerInstance)
-		if serverInstance.dispatchCancel {
-			return true
-		}
-		instList = append(instList, serverInstance)
-		return true
-	})
-	if len(instList) == 0 {
-		return nil
+// ReportServerDrain 游戏服务器上报排空状态
+func (s *DiscoveryService) ReportServerDrain(ctx context.Context, req *api.ServerDrainState) (*api.NullMsg, error) {
+	instMap := s.serverInstanceMap[api.GS]
+	inst, exist := instMap.Load(req.AppId)
+	if !exist {
+		return nil, errors.New("game server not exist")
 	}
-	minLoadInstIndex := 0
-	minLoadInstCount := math.MaxUint32
-	for index, inst := range instList {
-		if inst.loadCount < uint32(minLoadInstCount) {
-			minLoadInstCount = int(inst.loadCount)
-			minLoadInstIndex = index
-		}
+	serverInstance := inst.(*ServerInstance)
+	serverInstance.drainState = req.State
+	serverInstance.drainOnlineNum = req.OnlineNum
+	serverInstance.drainMsg = req.Msg
+	logger.Warn("game server drain report, appid: %v, state: %v, online num: %v, msg: %v", req.AppId, req.State, req.OnlineNum, req.Msg)
+	return &api.NullMsg{}, nil
+}
+
+// GetServerDrainState 查询游戏服务器排空状态
+func (s *DiscoveryService) GetServerDrainState(ctx context.Context, req *api.ServerDrainReq) (*api.ServerDrainState, error) {
+	instMap := s.serverInstanceMap[api.GS]
+	inst, exist := instMap.Load(req.AppId)
+	if !exist {
+		return nil, errors.New("game server not exist")
 	}
-	inst := instList[minLoadInstIndex]
-	return inst
+	serverInstance := inst.(*ServerInstance)
+	return &api.ServerDrainState{
+		AppId:     req.AppId,
+		State:     serverInstance.drainState,
+		OnlineNum: serverInstance.drainOnlineNum,
+		Msg:       serverInstance.drainMsg,
+	}, nil
 }
 
 func (s *DiscoveryService) getServerInstanceMapLen(instMap *sync.Map) int {
@@ -539,11 +849,17 @@ func (s *DiscoveryService) removeDeadServer() {
 	for {
 		<-ticker.C
 		nowTime := time.Now().Unix()
+		// 备用节点同样通过广播记录玩家上次所在的gs 也需要清理
+		s.removeExpireLastGs(nowTime)
+		if !s.isLeader() {
+			continue
+		}
 		for _, instMap := range s.serverInstanceMap {
 			instMap.Range(func(appid, inst any) bool {
 				serverInstance := inst.(*ServerInstance)
 				if nowTime-serverInstance.lastAliveTime > 30 {
 					instMap.Delete(appid)
+					metricsServerRemove(serverInstance.serverType, serverInstance.appId)
 					s.handleServerDead(serverInstance)
 				}
 				return true
@@ -559,6 +875,13 @@ func (s *DiscoveryService) handleServerDead(serverInstance *ServerInstance) {
 		s.globalGsOnlineMap.Range(func(uid, gsAppid any) bool {
 			if serverInstance.appId == gsAppid.(string) {
 				s.globalGsOnlineMap.Delete(uid)
+				s.gsOnlineChangeMap.Store(uid, "")
+			}
+			return true
+		})
+		s.lastGsMap.Range(func(uid, value any) bool {
+			if serverInstance.appId == value.(*lastGsInfo).gsAppId {
+				s.lastGsMap.Delete(uid)
 			}
 			return true
 		})
@@ -569,6 +892,9 @@ func (s *DiscoveryService) serverState() {
 	ticker := time.NewTicker(time.Minute * 1)
 	for {
 		<-ticker.C
+		if !s.isLeader() {
+			continue
+		}
 		totalGateLoad := uint32(0)
 		totalGsLoad := uint32(0)
 		for _, instMap := range s.serverInstanceMap {
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"hk4e/pkg/logger"
+	"hk4e/pkg/random"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	NodeLeaderSubject     = "HK4E_NODE_LEADER" // 主备选举心跳主题
+	NodeHeartbeatInterval = time.Second        // 心跳间隔
+	NodeLeaderTimeout     = time.Second * 5    // 心跳超时 超时未收到主节点心跳则重新选举
+)
+
+// NodeHeartbeat 节点选举心跳
+type NodeHeartbeat struct {
+	NodeId    string `json:"node_id"`
+	StartTime int64  `json:"start_time"`
+	IsLeader  bool   `json:"is_leader"`
+}
+
+type peerNode struct {
+	nodeId        string
+	startTime     int64
+	isLeader      bool
+	lastAliveTime int64
+}
+
+// Election 基于nats心跳的node主备选举
+// 存在主节点时其余节点作为备用 主节点超时后由启动最早的节点接管
+type Election struct {
+	conn          *nats.Conn
+	nodeId        string
+	startTime     int64
+	leader        int32
+	peerMap       map[string]*peerNode
+	msgChan       chan *nats.Msg
+	sub           *nats.Subscription
+	onLeader      func(nodeId string) bool // 成为主节点回调 返回false则放弃本次接管
+	onFollower    func()                   // 成为备用节点回调
+	closeChan     chan struct{}
+	closeDoneChan chan struct{}
+}
+
+func NewElection(conn *nats.Conn, onLeader func(nodeId string) bool, onFollower func()) (*Election, error) {
+	e := new(Election)
+	e.conn = conn
+	e.nodeId = strings.ToLower(random.GetRandomStr(8))
+	e.startTime = time.Now().UnixMilli()
+	e.leader = 0
+	e.peerMap = make(map[string]*peerNode)
+	e.msgChan = make(chan *nats.Msg, 1000)
+	sub, err := conn.ChanSubscribe(NodeLeaderSubject, e.msgChan)
+	if err != nil {
+		logger.Error("nats subscribe error: %v", err)
+		return nil, err
+	}
+	e.sub = sub
+	e.onLeader = onLeader
+	e.onFollower = onFollower
+	e.closeChan = make(chan struct{})
+	e.closeDoneChan = make(chan struct{})
+	logger.Warn("node election start, nodeId: %v", e.nodeId)
+	go e.run()
+	return e, nil
+}
+
+func (e *Election) GetNodeId() string {
+	return e.nodeId
+}
+
+func (e *Election) IsLeader() bool {
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+func (e *Election) Close() {
+	close(e.closeChan)
+	<-e.closeDoneChan
+	_ = e.sub.Unsubscribe()
+}
+
+func (e *Election) run() {
+	ticker := time.NewTicker(NodeHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.closeChan:
+			if e.IsLeader() {
+				e.setLeader(false)
+			}
+			close(e.closeDoneChan)
+			return
+		case msg := <-e.msgChan:
+			e.handleHeartbeat(msg)
+		case <-ticker.C:
+			e.sendHeartbeat()
+			e.check()
+		}
+	}
+}
+
+func (e *Election) sendHeartbeat() {
+	data, err := json.Marshal(&NodeHeartbeat{
+		NodeId:    e.nodeId,
+		StartTime: e.startTime,
+		IsLeader:  e.IsLeader(),
+	})
+	if err != nil {
+		logger.Error("marshal node heartbeat error: %v", err)
+		return
+	}
+	err = e.conn.Publish(NodeLeaderSubject, data)
+	if err != nil {
+		logger.Error("publish node heartbeat error: %v", err)
+	}
+}
+
+func (e *Election) handleHeartbeat(msg *nats.Msg) {
+	heartbeat := new(NodeHeartbeat)
+	err := json.Unmarshal(msg.Data, heartbeat)
+	if err != nil {
+		logger.Error("unmarshal node heartbeat error: %v", err)
+		return
+	}
+	if heartbeat.NodeId == e.nodeId {
+		return
+	}
+	peer, exist := e.peerMap[heartbeat.NodeId]
+	if !exist {
+		logger.Info("find new node, nodeId: %v, isLeader: %v", heartbeat.NodeId, heartbeat.IsLeader)
+		peer = &peerNode{
+			nodeId:    heartbeat.NodeId,
+			startTime: heartbeat.StartTime,
+		}
+		e.peerMap[heartbeat.NodeId] = peer
+	}
+	peer.isLeader = heartbeat.IsLeader
+	peer.lastAliveTime = time.Now().UnixMilli()
+	// 出现多个主节点时 启动较晚的一方主动退让
+	if e.IsLeader() && peer.isLeader && !e.isBefore(peer) {
+		logger.Warn("find other leader node, step down, leader nodeId: %v", peer.nodeId)
+		e.setLeader(false)
+	}
+}
+
+func (e *Election) check() {
+	now := time.Now().UnixMilli()
+	hasLeader := false
+	for nodeId, peer := range e.peerMap {
+		if now-peer.lastAliveTime > NodeLeaderTimeout.Milliseconds() {
+			logger.Warn("remove dead node, nodeId: %v, isLeader: %v", nodeId, peer.isLeader)
+			delete(e.peerMap, nodeId)
+			continue
+		}
+		if peer.isLeader {
+			hasLeader = true
+		}
+	}
+	if e.IsLeader() || hasLeader {
+		return
+	}
+	// 启动后先观察一个超时周期 避免抢占已存在的主节点
+	if now-e.startTime < NodeLeaderTimeout.Milliseconds() {
+		return
+	}
+	for _, peer := range e.peerMap {
+		if !e.isBefore(peer) {
+			return
+		}
+	}
+	e.setLeader(true)
+}
+
+// isBefore 本节点是否比对方启动更早 启动时间相同则比较nodeId
+func (e *Election) isBefore(peer *peerNode) bool {
+	if e.startTime != peer.startTime {
+		return e.startTime < peer.startTime
+	}
+	return e.nodeId < peer.nodeId
+}
+
+func (e *Election) setLeader(isLeader bool) {
+	if isLeader {
+		if !e.onLeader(e.nodeId) {
+			logger.Error("node take over leader fail, nodeId: %v", e.nodeId)
+			return
+		}
+		logger.Warn("node become leader, nodeId: %v", e.nodeId)
+		atomic.StoreInt32(&e.leader, 1)
+	} else {
+		logger.Warn("node become follower, nodeId: %v", e.nodeId)
+		atomic.StoreInt32(&e.leader, 0)
+		e.onFollower()
+	}
+}
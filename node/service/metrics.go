@@ -0,0 +1,37 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 节点服务器监控指标 服务器负载只由主节点导出
+
+var serverLoadGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "hk4e_node_server_load",
+	Help: "各服务器上报的负载数",
+}, []string{"server_type", "app_id"})
+
+func metricsLeader(s *DiscoveryService) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "hk4e_node_is_leader",
+		Help: "是否为主节点",
+	}, func() float64 {
+		if !s.isLeader() {
+			return 0
+		}
+		return 1
+	})
+}
+
+func metricsServerLoad(serverInstance *ServerInstance) {
+	serverLoadGauge.WithLabelValues(serverInstance.serverType, serverInstance.appId).Set(float64(serverInstance.loadCount))
+}
+
+func metricsServerRemove(serverType string, appId string) {
+	serverLoadGauge.DeleteLabelValues(serverType, appId)
+}
+
+func metricsServerReset() {
+	serverLoadGauge.Reset()
+}
@@ -4,6 +4,7 @@ import (
 	"hk4e/common/mq"
 	"hk4e/node/api"
 	"hk4e/node/dao"
+	"hk4e/pkg/logger"
 
 	"github.com/byebyebruce/natsrpc"
 	"github.com/nats-io/nats.go"
@@ -13,6 +14,9 @@ import (
 type Service struct {
 	db               *dao.Dao
 	discoveryService *DiscoveryService
+	election         *Election
+	rpcServer        *natsrpc.Server
+	rpcService       natsrpc.IService // 只有主节点注册rpc服务
 }
 
 func NewService(db *dao.Dao, conn *nats.Conn, messageQueue *mq.MessageQueue) (*Service, error) {
@@ -28,17 +32,47 @@ func NewService(db *dao.Dao, conn *nats.Conn, messageQueue *mq.MessageQueue) (*S
 	if err != nil {
 		return nil, err
 	}
-	_, err = api.RegisterDiscoveryNATSRPCServer(svr, discoveryService)
-	if err != nil {
-		return nil, err
-	}
 	s := &Service{
 		db:               db,
 		discoveryService: discoveryService,
+		rpcServer:        svr,
+	}
+	election, err := NewElection(conn, s.onLeader, s.onFollower)
+	if err != nil {
+		return nil, err
 	}
+	s.election = election
 	return s, nil
 }
 
+// onLeader 成为主节点 恢复状态后开始提供rpc服务
+// 选举协程在NewElection返回前就已启动 节点id由回调参数传入 不能访问s.election
+func (s *Service) onLeader(nodeId string) bool {
+	err := s.discoveryService.becomeLeader(nodeId)
+	if err != nil {
+		logger.Error("discovery service take over error: %v", err)
+		return false
+	}
+	rpcService, err := api.RegisterDiscoveryNATSRPCServer(s.rpcServer, s.discoveryService)
+	if err != nil {
+		logger.Error("register discovery service error: %v", err)
+		s.discoveryService.becomeFollower()
+		return false
+	}
+	s.rpcService = rpcService
+	return true
+}
+
+// onFollower 成为备用节点 停止提供rpc服务
+func (s *Service) onFollower() {
+	if s.rpcService != nil {
+		s.rpcService.Close()
+		s.rpcService = nil
+	}
+	s.discoveryService.becomeFollower()
+}
+
 func (s *Service) Close() {
 	s.discoveryService.close()
+	s.election.Close()
 }
@@ -0,0 +1,153 @@
+package alg
+
+import (
+	"math"
+)
+
+// 碰撞体
+// 提供射线检测和球体扫掠检测 用于物理引擎的连续碰撞检测
+
+const (
+	ColliderSphere  = iota // 球体 半径为HalfExtents.X
+	ColliderBox            // 长方体 半尺寸为HalfExtents
+	ColliderCapsule        // 竖直胶囊体 半径为HalfExtents.X 半高为HalfExtents.Y(包含两端半球)
+)
+
+// Collider 碰撞体
+type Collider struct {
+	Shape       int
+	Center      Vector3
+	HalfExtents Vector3
+	Yaw         float32 // 绕Y轴的旋转角度 弧度 只对长方体生效
+}
+
+// GetBounds 轴对齐包围盒 用于粗检测
+func (c *Collider) GetBounds() (min Vector3, max Vector3) {
+	ext := c.HalfExtents
+	switch c.Shape {
+	case ColliderSphere:
+		ext = Vector3{X: c.HalfExtents.X, Y: c.HalfExtents.X, Z: c.HalfExtents.X}
+	case ColliderBox:
+		if c.Yaw != 0.0 {
+			r := float32(math.Sqrt(float64(c.HalfExtents.X*c.HalfExtents.X + c.HalfExtents.Z*c.HalfExtents.Z)))
+			ext = Vector3{X: r, Y: c.HalfExtents.Y, Z: r}
+		}
+	case ColliderCapsule:
+		ext = Vector3{X: c.HalfExtents.X, Y: c.HalfExtents.Y, Z: c.HalfExtents.X}
+	}
+	return *Vector3Sub(&c.Center, &ext), *Vector3Add(&c.Center, &ext)
+}
+
+// RayCast 射线检测 dir为单位向量 返回射线起点到命中点的距离 起点在碰撞体内时距离为0
+func (c *Collider) RayCast(origin *Vector3, dir *Vector3, maxDist float32) (float32, bool) {
+	return c.SphereCast(origin, dir, maxDist, 0.0)
+}
+
+// SphereCast 球体扫掠检测 等价于对外扩radius后的碰撞体做射线检测 长方体的棱角处按外扩后的长方体近似
+func (c *Collider) SphereCast(origin *Vector3, dir *Vector3, maxDist float32, radius float32) (float32, bool) {
+	o := [3]float64{float64(origin.X - c.Center.X), float64(origin.Y - c.Center.Y), float64(origin.Z - c.Center.Z)}
+	d := [3]float64{float64(dir.X), float64(dir.Y), float64(dir.Z)}
+	r := float64(radius)
+	var t float64
+	var ok bool
+	switch c.Shape {
+	case ColliderSphere:
+		t, ok = rayCastSphere(o, d, float64(c.HalfExtents.X)+r)
+	case ColliderBox:
+		if c.Yaw != 0.0 {
+			// 转换到长方体的局部坐标系
+			sin, cos := math.Sincos(float64(c.Yaw))
+			o[0], o[2] = o[0]*cos-o[2]*sin, o[0]*sin+o[2]*cos
+			d[0], d[2] = d[0]*cos-d[2]*sin, d[0]*sin+d[2]*cos
+		}
+		ext := [3]float64{float64(c.HalfExtents.X) + r, float64(c.HalfExtents.Y) + r, float64(c.HalfExtents.Z) + r}
+		t, ok = rayCastBox(o, d, ext, float64(maxDist))
+	case ColliderCapsule:
+		t, ok = rayCastCapsule(o, d, float64(c.HalfExtents.X)+r, float64(c.HalfExtents.Y)+r)
+	default:
+		return 0.0, false
+	}
+	if !ok || t > float64(maxDist) {
+		return 0.0, false
+	}
+	return float32(t), true
+}
+
+// rayCastSphere 射线与球心在原点的球体求交
+func rayCastSphere(o [3]float64, d [3]float64, radius float64) (float64, bool) {
+	b := o[0]*d[0] + o[1]*d[1] + o[2]*d[2]
+	c := o[0]*o[0] + o[1]*o[1] + o[2]*o[2] - radius*radius
+	if c <= 0.0 {
+		return 0.0, true
+	}
+	if b > 0.0 {
+		return 0.0, false
+	}
+	disc := b*b - c
+	if disc < 0.0 {
+		return 0.0, false
+	}
+	return -b - math.Sqrt(disc), true
+}
+
+// rayCastBox 射线与中心在原点的轴对齐长方体求交 slab方法
+func rayCastBox(o [3]float64, d [3]float64, ext [3]float64, maxDist float64) (float64, bool) {
+	tMin := 0.0
+	tMax := maxDist
+	for i := 0; i < 3; i++ {
+		if math.Abs(d[i]) < 1e-9 {
+			if o[i] < -ext[i] || o[i] > ext[i] {
+				return 0.0, false
+			}
+			continue
+		}
+		t1 := (-ext[i] - o[i]) / d[i]
+		t2 := (ext[i] - o[i]) / d[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin = math.Max(tMin, t1)
+		tMax = math.Min(tMax, t2)
+		if tMin > tMax {
+			return 0.0, false
+		}
+	}
+	return tMin, true
+}
+
+// rayCastCapsule 射线与中心在原点的竖直胶囊体求交 胶囊体为圆柱侧面与两端球体的并集 取最早命中的部分
+func rayCastCapsule(o [3]float64, d [3]float64, radius float64, halfHeight float64) (float64, bool) {
+	segHalf := halfHeight - radius
+	if segHalf <= 0.0 {
+		return rayCastSphere(o, d, radius)
+	}
+	best := math.Inf(1)
+	hit := false
+	// 圆柱侧面
+	a := d[0]*d[0] + d[2]*d[2]
+	c := o[0]*o[0] + o[2]*o[2] - radius*radius
+	t := -1.0
+	if c <= 0.0 {
+		t = 0.0
+	} else if a > 1e-12 {
+		b := o[0]*d[0] + o[2]*d[2]
+		disc := b*b - a*c
+		if b < 0.0 && disc >= 0.0 {
+			t = (-b - math.Sqrt(disc)) / a
+		}
+	}
+	if t >= 0.0 {
+		y := o[1] + d[1]*t
+		if y >= -segHalf && y <= segHalf {
+			best, hit = t, true
+		}
+	}
+	// 两端球体
+	for _, offset := range []float64{-segHalf, segHalf} {
+		t, ok := rayCastSphere([3]float64{o[0], o[1] - offset, o[2]}, d, radius)
+		if ok && t < best {
+			best, hit = t, true
+		}
+	}
+	return best, hit
+}
@@ -0,0 +1,88 @@
+package alg
+
+import (
+	"math"
+	"testing"
+)
+
+func floatEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestColliderRayCast(t *testing.T) {
+	origin := &Vector3{X: 0, Y: 0, Z: 0}
+	dir := &Vector3{X: 1, Y: 0, Z: 0}
+
+	sphere := &Collider{Shape: ColliderSphere, Center: Vector3{X: 10, Y: 0, Z: 0}, HalfExtents: Vector3{X: 2}}
+	dist, ok := sphere.RayCast(origin, dir, 100)
+	if !ok || !floatEqual(dist, 8) {
+		t.Fatalf("ray cast sphere error, dist: %v, ok: %v", dist, ok)
+	}
+	_, ok = sphere.RayCast(origin, dir, 5)
+	if ok {
+		t.Fatalf("ray cast sphere out of max dist should miss")
+	}
+	_, ok = sphere.RayCast(origin, &Vector3{X: -1, Y: 0, Z: 0}, 100)
+	if ok {
+		t.Fatalf("ray cast sphere behind origin should miss")
+	}
+
+	box := &Collider{Shape: ColliderBox, Center: Vector3{X: 10, Y: 0, Z: 0}, HalfExtents: Vector3{X: 1, Y: 5, Z: 5}}
+	dist, ok = box.RayCast(origin, dir, 100)
+	if !ok || !floatEqual(dist, 9) {
+		t.Fatalf("ray cast box error, dist: %v, ok: %v", dist, ok)
+	}
+	// 旋转90度后X方向的厚度变为HalfExtents.Z
+	box.Yaw = math.Pi / 2
+	dist, ok = box.RayCast(origin, dir, 100)
+	if !ok || !floatEqual(dist, 5) {
+		t.Fatalf("ray cast rotated box error, dist: %v, ok: %v", dist, ok)
+	}
+	_, ok = box.RayCast(&Vector3{X: 0, Y: 10, Z: 0}, dir, 100)
+	if ok {
+		t.Fatalf("ray cast above box should miss")
+	}
+
+	capsule := &Collider{Shape: ColliderCapsule, Center: Vector3{X: 10, Y: 0, Z: 0}, HalfExtents: Vector3{X: 0.5, Y: 1}}
+	dist, ok = capsule.RayCast(origin, dir, 100)
+	if !ok || !floatEqual(dist, 9.5) {
+		t.Fatalf("ray cast capsule error, dist: %v, ok: %v", dist, ok)
+	}
+	// 从正上方射向胶囊体顶端半球
+	dist, ok = capsule.RayCast(&Vector3{X: 10, Y: 10, Z: 0}, &Vector3{X: 0, Y: -1, Z: 0}, 100)
+	if !ok || !floatEqual(dist, 9) {
+		t.Fatalf("ray cast capsule top error, dist: %v, ok: %v", dist, ok)
+	}
+	_, ok = capsule.RayCast(&Vector3{X: 0, Y: 1.2, Z: 0.4}, dir, 100)
+	if ok {
+		t.Fatalf("ray cast capsule cap corner should miss")
+	}
+	dist, ok = capsule.RayCast(&Vector3{X: 10, Y: 0, Z: 0}, dir, 100)
+	if !ok || dist != 0 {
+		t.Fatalf("ray cast inside capsule error, dist: %v, ok: %v", dist, ok)
+	}
+}
+
+func TestColliderSphereCast(t *testing.T) {
+	origin := &Vector3{X: 0, Y: 0, Z: 0}
+	dir := &Vector3{X: 1, Y: 0, Z: 0}
+	box := &Collider{Shape: ColliderBox, Center: Vector3{X: 10, Y: 0, Z: 0}, HalfExtents: Vector3{X: 1, Y: 1, Z: 1}}
+	// 射线从长方体旁边擦过 球体扫掠会命中
+	_, ok := box.RayCast(&Vector3{X: 0, Y: 0, Z: 1.5}, dir, 100)
+	if ok {
+		t.Fatalf("ray cast beside box should miss")
+	}
+	dist, ok := box.SphereCast(&Vector3{X: 0, Y: 0, Z: 1.5}, dir, 100, 1)
+	if !ok || !floatEqual(dist, 8) {
+		t.Fatalf("sphere cast box error, dist: %v, ok: %v", dist, ok)
+	}
+	sphere := &Collider{Shape: ColliderSphere, Center: Vector3{X: 10, Y: 0, Z: 0}, HalfExtents: Vector3{X: 1}}
+	dist, ok = sphere.SphereCast(origin, dir, 100, 1)
+	if !ok || !floatEqual(dist, 8) {
+		t.Fatalf("sphere cast sphere error, dist: %v, ok: %v", dist, ok)
+	}
+	min, max := box.GetBounds()
+	if min != (Vector3{X: 9, Y: -1, Z: -1}) || max != (Vector3{X: 11, Y: 1, Z: 1}) {
+		t.Fatalf("box bounds error, min: %v, max: %v", min, max)
+	}
+}
@@ -0,0 +1,435 @@
+package alg
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"time"
+)
+
+// 导航网格寻路
+// 网格由凸多边形组成 多边形之间通过共享边连通 在多边形图上做A*搜索 再用漏斗算法拉直路径得到拐点
+// 默认为左手坐标系 Y轴向上 连通性只在XZ平面上判断 Y轴用于区分多层地面
+
+const (
+	NavPathFail    = iota // 找不到起点或终点所在的多边形
+	NavPathSucc           // 完整路径
+	NavPathPartial        // 不可达或超出时间预算 返回到离终点最近位置的路径
+)
+
+const (
+	NavMeshGridSize     = 32.0 // 多边形空间索引的网格边长
+	NavMeshMaxPolyVert  = 255  // 单个多边形最大顶点数
+	navPathCheckTimeNum = 64   // 每展开多少个节点检查一次时间预算
+)
+
+// NavPoly 导航网格多边形
+type NavPoly struct {
+	vertList     []int32 // 顶点索引 XZ平面逆时针顺序
+	neighborList []int32 // 第i条边(顶点i到顶点i+1)相邻的多边形 -1为边界
+	center       Vector3 // 顶点平均位置
+	minX         float32
+	minZ         float32
+	maxX         float32
+	maxZ         float32
+}
+
+// NavMesh 导航网格 创建后只读 可被多个协程同时查询
+type NavMesh struct {
+	vertList []Vector3
+	polyList []*NavPoly
+	grid     map[int64][]int32 // 网格坐标 -> 覆盖该网格的多边形
+}
+
+// NewNavMesh 由顶点和多边形顶点索引创建导航网格 多边形须为凸多边形 顶点顺序任意
+func NewNavMesh(vertList []Vector3, polyList [][]int32) (*NavMesh, error) {
+	m := &NavMesh{
+		vertList: make([]Vector3, len(vertList)),
+		polyList: make([]*NavPoly, 0, len(polyList)),
+		grid:     make(map[int64][]int32),
+	}
+	copy(m.vertList, vertList)
+	for _, indexList := range polyList {
+		if len(indexList) < 3 || len(indexList) > NavMeshMaxPolyVert {
+			return nil, errors.New("invalid nav poly vertex num")
+		}
+		poly := &NavPoly{
+			vertList:     make([]int32, len(indexList)),
+			neighborList: make([]int32, len(indexList)),
+		}
+		copy(poly.vertList, indexList)
+		for _, index := range poly.vertList {
+			if index < 0 || int(index) >= len(m.vertList) {
+				return nil, errors.New("nav poly vertex index out of range")
+			}
+		}
+		if m.polyArea(poly.vertList) < 0 {
+			for i, j := 0, len(poly.vertList)-1; i < j; i, j = i+1, j-1 {
+				poly.vertList[i], poly.vertList[j] = poly.vertList[j], poly.vertList[i]
+			}
+		}
+		m.initPoly(poly)
+		m.polyList = append(m.polyList, poly)
+	}
+	m.initNeighbor()
+	m.initGrid()
+	return m, nil
+}
+
+// polyArea XZ平面有向面积的两倍 逆时针为正
+func (m *NavMesh) polyArea(vertList []int32) float32 {
+	area := float32(0.0)
+	for i := range vertList {
+		v1 := &m.vertList[vertList[i]]
+		v2 := &m.vertList[vertList[(i+1)%len(vertList)]]
+		area += v1.X*v2.Z - v1.Z*v2.X
+	}
+	return area
+}
+
+func (m *NavMesh) initPoly(poly *NavPoly) {
+	first := &m.vertList[poly.vertList[0]]
+	poly.minX, poly.maxX = first.X, first.X
+	poly.minZ, poly.maxZ = first.Z, first.Z
+	for i, index := range poly.vertList {
+		v := &m.vertList[index]
+		poly.center.X += v.X
+		poly.center.Y += v.Y
+		poly.center.Z += v.Z
+		poly.minX = float32(math.Min(float64(poly.minX), float64(v.X)))
+		poly.maxX = float32(math.Max(float64(poly.maxX), float64(v.X)))
+		poly.minZ = float32(math.Min(float64(poly.minZ), float64(v.Z)))
+		poly.maxZ = float32(math.Max(float64(poly.maxZ), float64(v.Z)))
+		poly.neighborList[i] = -1
+	}
+	n := float32(len(poly.vertList))
+	poly.center.X /= n
+	poly.center.Y /= n
+	poly.center.Z /= n
+}
+
+// initNeighbor 通过共享边建立多边形连通关系
+func (m *NavMesh) initNeighbor() {
+	type polyEdge struct {
+		poly int32
+		edge int
+	}
+	edgeMap := make(map[[2]int32]polyEdge)
+	for polyIndex, poly := range m.polyList {
+		for i := range poly.vertList {
+			v1 := poly.vertList[i]
+			v2 := poly.vertList[(i+1)%len(poly.vertList)]
+			key := [2]int32{v1, v2}
+			if v1 > v2 {
+				key = [2]int32{v2, v1}
+			}
+			other, exist := edgeMap[key]
+			if !exist {
+				edgeMap[key] = polyEdge{poly: int32(polyIndex), edge: i}
+				continue
+			}
+			poly.neighborList[i] = other.poly
+			m.polyList[other.poly].neighborList[other.edge] = int32(polyIndex)
+		}
+	}
+}
+
+func navGridKey(x int32, z int32) int64 {
+	return int64(x)<<32 | int64(uint32(z))
+}
+
+func navGridCoord(v float32) int32 {
+	return int32(math.Floor(float64(v) / NavMeshGridSize))
+}
+
+func (m *NavMesh) initGrid() {
+	for polyIndex, poly := range m.polyList {
+		for x := navGridCoord(poly.minX); x <= navGridCoord(poly.maxX); x++ {
+			for z := navGridCoord(poly.minZ); z <= navGridCoord(poly.maxZ); z++ {
+				key := navGridKey(x, z)
+				m.grid[key] = append(m.grid[key], int32(polyIndex))
+			}
+		}
+	}
+}
+
+// queryPoly 遍历与XZ平面矩形范围相交的网格内的多边形 同一多边形可能被回调多次
+func (m *NavMesh) queryPoly(minX, minZ, maxX, maxZ float32, fn func(polyIndex int32)) {
+	for x := navGridCoord(minX); x <= navGridCoord(maxX); x++ {
+		for z := navGridCoord(minZ); z <= navGridCoord(maxZ); z++ {
+			for _, polyIndex := range m.grid[navGridKey(x, z)] {
+				fn(polyIndex)
+			}
+		}
+	}
+}
+
+// GetPolyNum 多边形数量
+func (m *NavMesh) GetPolyNum() int {
+	return len(m.polyList)
+}
+
+// GetVertNum 顶点数量
+func (m *NavMesh) GetVertNum() int {
+	return len(m.vertList)
+}
+
+// navTriArea XZ平面上c相对于a->b的方向 大于0在左侧 小于0在右侧
+func navTriArea(a, b, c *Vector3) float32 {
+	return (b.X-a.X)*(c.Z-a.Z) - (b.Z-a.Z)*(c.X-a.X)
+}
+
+// PolyContain XZ平面上点是否在多边形内 边界上也算
+func (m *NavMesh) PolyContain(polyIndex int32, pos *Vector3) bool {
+	poly := m.polyList[polyIndex]
+	for i := range poly.vertList {
+		v1 := &m.vertList[poly.vertList[i]]
+		v2 := &m.vertList[poly.vertList[(i+1)%len(poly.vertList)]]
+		if navTriArea(v1, v2, pos) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FindPoly 查找点所在的多边形 XZ平面上包含该点的多边形中取高度最接近的
+// 没有包含该点的多边形时在extent范围内取中心最近的 找不到返回-1
+func (m *NavMesh) FindPoly(pos *Vector3, extent float32) int32 {
+	best := int32(-1)
+	bestContain := false
+	bestDist := float32(math.MaxFloat32)
+	m.queryPoly(pos.X-extent, pos.Z-extent, pos.X+extent, pos.Z+extent, func(polyIndex int32) {
+		poly := m.polyList[polyIndex]
+		if m.PolyContain(polyIndex, pos) {
+			dist := float32(math.Abs(float64(pos.Y - poly.center.Y)))
+			if !bestContain || dist < bestDist {
+				best = polyIndex
+				bestContain = true
+				bestDist = dist
+			}
+			return
+		}
+		if bestContain {
+			return
+		}
+		dist := navDistance(pos, &poly.center)
+		if dist <= extent && dist < bestDist {
+			best = polyIndex
+			bestDist = dist
+		}
+	})
+	return best
+}
+
+func navDistance(v1 *Vector3, v2 *Vector3) float32 {
+	return Vector3Magnitude(Vector3Sub(v1, v2))
+}
+
+// navPortal 两个相邻多边形之间的共享边 左右以从当前多边形走向相邻多边形的方向为准
+func (m *NavMesh) navPortal(from int32, to int32) (*Vector3, *Vector3, bool) {
+	poly := m.polyList[from]
+	for i, neighbor := range poly.neighborList {
+		if neighbor != to {
+			continue
+		}
+		right := &m.vertList[poly.vertList[i]]
+		left := &m.vertList[poly.vertList[(i+1)%len(poly.vertList)]]
+		return left, right, true
+	}
+	return nil, nil, false
+}
+
+type navNode struct {
+	poly   int32
+	parent *navNode
+	pos    Vector3 // 进入该多边形的位置 取共享边中点
+	g      float32
+	f      float32
+	closed bool
+	index  int // 在开放列表堆中的下标
+}
+
+type navOpenList []*navNode
+
+func (l navOpenList) Len() int           { return len(l) }
+func (l navOpenList) Less(i, j int) bool { return l[i].f < l[j].f }
+func (l navOpenList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+	l[i].index = i
+	l[j].index = j
+}
+func (l *navOpenList) Push(x any) {
+	node := x.(*navNode)
+	node.index = len(*l)
+	*l = append(*l, node)
+}
+func (l *navOpenList) Pop() any {
+	old := *l
+	node := old[len(old)-1]
+	*l = old[:len(old)-1]
+	node.index = -1
+	return node
+}
+
+// FindPath 寻路 extent为起点终点吸附到网格的搜索范围 obstacle为动态障碍层 可为空 budget为时间预算 0为不限制
+// 返回寻路结果和拐点列表 拐点列表包含起点和终点
+func (m *NavMesh) FindPath(start *Vector3, end *Vector3, extent float32, obstacle *NavObstacleLayer, budget time.Duration) (int, []*Vector3) {
+	startPoly := m.FindPoly(start, extent)
+	endPoly := m.FindPoly(end, extent)
+	if startPoly == -1 || endPoly == -1 {
+		return NavPathFail, nil
+	}
+	deadline := time.Time{}
+	if budget > 0 {
+		deadline = time.Now().Add(budget)
+	}
+	nodeMap := make(map[int32]*navNode)
+	startNode := &navNode{poly: startPoly, pos: *start, g: 0, f: navDistance(start, end)}
+	nodeMap[startPoly] = startNode
+	openList := &navOpenList{}
+	heap.Push(openList, startNode)
+	bestNode := startNode
+	bestDist := startNode.f
+	var endNode *navNode = nil
+	expandCount := 0
+	for openList.Len() > 0 {
+		expandCount++
+		if !deadline.IsZero() && expandCount%navPathCheckTimeNum == 0 && time.Now().After(deadline) {
+			break
+		}
+		node := heap.Pop(openList).(*navNode)
+		node.closed = true
+		if node.poly == endPoly {
+			endNode = node
+			break
+		}
+		for i, neighbor := range m.polyList[node.poly].neighborList {
+			if neighbor == -1 {
+				continue
+			}
+			if obstacle != nil && obstacle.IsBlock(neighbor) {
+				continue
+			}
+			poly := m.polyList[node.poly]
+			v1 := &m.vertList[poly.vertList[i]]
+			v2 := &m.vertList[poly.vertList[(i+1)%len(poly.vertList)]]
+			pos := Vector3{X: (v1.X + v2.X) / 2.0, Y: (v1.Y + v2.Y) / 2.0, Z: (v1.Z + v2.Z) / 2.0}
+			if neighbor == endPoly {
+				pos = *end
+			}
+			g := node.g + navDistance(&node.pos, &pos)
+			neighborNode, exist := nodeMap[neighbor]
+			if exist && (neighborNode.closed || g >= neighborNode.g) {
+				continue
+			}
+			h := navDistance(&pos, end)
+			if !exist {
+				neighborNode = &navNode{poly: neighbor}
+				nodeMap[neighbor] = neighborNode
+			}
+			neighborNode.parent = node
+			neighborNode.pos = pos
+			neighborNode.g = g
+			neighborNode.f = g + h
+			if exist {
+				heap.Fix(openList, neighborNode.index)
+			} else {
+				heap.Push(openList, neighborNode)
+			}
+			if h < bestDist {
+				bestNode = neighborNode
+				bestDist = h
+			}
+		}
+	}
+	status := NavPathSucc
+	target := end
+	if endNode == nil {
+		status = NavPathPartial
+		endNode = bestNode
+		target = &m.polyList[bestNode.poly].center
+	}
+	polyPath := make([]int32, 0)
+	for node := endNode; node != nil; node = node.parent {
+		polyPath = append(polyPath, node.poly)
+	}
+	for i, j := 0, len(polyPath)-1; i < j; i, j = i+1, j-1 {
+		polyPath[i], polyPath[j] = polyPath[j], polyPath[i]
+	}
+	return status, m.stringPull(start, target, polyPath)
+}
+
+// stringPull 漏斗算法 沿多边形序列的共享边拉直路径
+func (m *NavMesh) stringPull(start *Vector3, end *Vector3, polyPath []int32) []*Vector3 {
+	leftList := make([]*Vector3, 0, len(polyPath)+1)
+	rightList := make([]*Vector3, 0, len(polyPath)+1)
+	leftList = append(leftList, start)
+	rightList = append(rightList, start)
+	for i := 0; i+1 < len(polyPath); i++ {
+		left, right, ok := m.navPortal(polyPath[i], polyPath[i+1])
+		if !ok {
+			continue
+		}
+		leftList = append(leftList, left)
+		rightList = append(rightList, right)
+	}
+	leftList = append(leftList, end)
+	rightList = append(rightList, end)
+
+	path := []*Vector3{{X: start.X, Y: start.Y, Z: start.Z}}
+	apex, left, right := start, leftList[0], rightList[0]
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+	for i := 1; i < len(leftList); i++ {
+		l, r := leftList[i], rightList[i]
+		// 收紧右边界
+		if navTriArea(apex, right, r) >= 0 {
+			if navVectorEqual(apex, right) || navTriArea(apex, left, r) < 0 {
+				right = r
+				rightIndex = i
+			} else {
+				// 右边界越过左边界 左边界顶点成为新的拐点
+				path = append(path, &Vector3{X: left.X, Y: left.Y, Z: left.Z})
+				apex = left
+				apexIndex = leftIndex
+				left, right = apex, apex
+				leftIndex, rightIndex = apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+		// 收紧左边界
+		if navTriArea(apex, left, l) <= 0 {
+			if navVectorEqual(apex, left) || navTriArea(apex, right, l) > 0 {
+				left = l
+				leftIndex = i
+			} else {
+				// 左边界越过右边界 右边界顶点成为新的拐点
+				path = append(path, &Vector3{X: right.X, Y: right.Y, Z: right.Z})
+				apex = right
+				apexIndex = rightIndex
+				left, right = apex, apex
+				leftIndex, rightIndex = apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+	}
+	last := path[len(path)-1]
+	if !navVectorEqual(last, end) || len(path) == 1 {
+		path = append(path, &Vector3{X: end.X, Y: end.Y, Z: end.Z})
+	}
+	// 路径恰好经过网格顶点时会产生共线的拐点 去掉
+	result := path[:1]
+	for i := 1; i < len(path)-1; i++ {
+		if navTriArea(result[len(result)-1], path[i], path[i+1]) == 0 {
+			continue
+		}
+		result = append(result, path[i])
+	}
+	result = append(result, path[len(path)-1])
+	return result
+}
+
+func navVectorEqual(v1 *Vector3, v2 *Vector3) bool {
+	return v1.X == v2.X && v1.Z == v2.Z
+}
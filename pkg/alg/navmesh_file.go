@@ -0,0 +1,252 @@
+package alg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 导航网格文件格式 小端序
+// 文件头 magic(4字节 HKNM) version(uint16) indexSize(uint16 顶点索引字节数 2或4) vertNum(uint32) polyNum(uint32)
+// 顶点 vertNum个 x y z float32
+// 多边形 polyNum个 顶点数(uint8) 顶点索引(indexSize字节)...
+// 多边形连通关系和空间索引在加载时重新计算 不写入文件
+
+const (
+	NavMeshFileVersion = 1
+	DefaultNavMeshPath = "./navmesh"
+	NavMeshFileExt     = ".navmesh"
+)
+
+var navMeshFileMagic = [4]byte{'H', 'K', 'N', 'M'}
+
+type navMeshFileHeader struct {
+	Magic     [4]byte
+	Version   uint16
+	IndexSize uint16
+	VertNum   uint32
+	PolyNum   uint32
+}
+
+// Encode 编码导航网格
+func (m *NavMesh) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := &navMeshFileHeader{
+		Magic:     navMeshFileMagic,
+		Version:   NavMeshFileVersion,
+		IndexSize: 4,
+		VertNum:   uint32(len(m.vertList)),
+		PolyNum:   uint32(len(m.polyList)),
+	}
+	if len(m.vertList) <= math.MaxUint16 {
+		header.IndexSize = 2
+	}
+	err := binary.Write(bw, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(bw, binary.LittleEndian, m.vertList)
+	if err != nil {
+		return err
+	}
+	for _, poly := range m.polyList {
+		err = bw.WriteByte(uint8(len(poly.vertList)))
+		if err != nil {
+			return err
+		}
+		for _, index := range poly.vertList {
+			if header.IndexSize == 2 {
+				err = binary.Write(bw, binary.LittleEndian, uint16(index))
+			} else {
+				err = binary.Write(bw, binary.LittleEndian, uint32(index))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// DecodeNavMesh 解码导航网格
+func DecodeNavMesh(r io.Reader) (*NavMesh, error) {
+	br := bufio.NewReader(r)
+	header := new(navMeshFileHeader)
+	err := binary.Read(br, binary.LittleEndian, header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Magic != navMeshFileMagic {
+		return nil, errors.New("invalid nav mesh file magic")
+	}
+	if header.Version != NavMeshFileVersion {
+		return nil, errors.New("unsupported nav mesh file version")
+	}
+	if header.IndexSize != 2 && header.IndexSize != 4 {
+		return nil, errors.New("invalid nav mesh index size")
+	}
+	vertList := make([]Vector3, header.VertNum)
+	err = binary.Read(br, binary.LittleEndian, vertList)
+	if err != nil {
+		return nil, err
+	}
+	polyList := make([][]int32, 0, header.PolyNum)
+	for i := uint32(0); i < header.PolyNum; i++ {
+		vertNum, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		indexList := make([]int32, vertNum)
+		for j := range indexList {
+			if header.IndexSize == 2 {
+				var index uint16
+				err = binary.Read(br, binary.LittleEndian, &index)
+				indexList[j] = int32(index)
+			} else {
+				var index uint32
+				err = binary.Read(br, binary.LittleEndian, &index)
+				indexList[j] = int32(index)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		polyList = append(polyList, indexList)
+	}
+	return NewNavMesh(vertList, polyList)
+}
+
+// DecodeNavMeshObj 从obj模型解码导航网格 用于将编辑器或recast导出的导航网格转换为HKNM格式
+// 只读取顶点(v)和面(f) 面的顶点索引从1开始 支持负数相对索引和v/vt/vn写法 其余行忽略
+func DecodeNavMeshObj(r io.Reader) (*NavMesh, error) {
+	vertList := make([]Vector3, 0)
+	polyList := make([][]int32, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fieldList := strings.Fields(scanner.Text())
+		if len(fieldList) == 0 {
+			continue
+		}
+		switch fieldList[0] {
+		case "v":
+			if len(fieldList) < 4 {
+				return nil, errors.New("invalid obj vertex")
+			}
+			var pos [3]float32
+			for i := range pos {
+				value, err := strconv.ParseFloat(fieldList[i+1], 32)
+				if err != nil {
+					return nil, err
+				}
+				pos[i] = float32(value)
+			}
+			vertList = append(vertList, Vector3{X: pos[0], Y: pos[1], Z: pos[2]})
+		case "f":
+			indexList := make([]int32, 0, len(fieldList)-1)
+			for _, field := range fieldList[1:] {
+				index, err := strconv.ParseInt(strings.SplitN(field, "/", 2)[0], 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				if index < 0 {
+					index += int64(len(vertList))
+				} else {
+					index--
+				}
+				indexList = append(indexList, int32(index))
+			}
+			polyList = append(polyList, indexList)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewNavMesh(vertList, polyList)
+}
+
+// ConvertNavMeshObjFile 将obj模型文件转换为导航网格文件
+func ConvertNavMeshObjFile(objFileName string, fileName string) (*NavMesh, error) {
+	file, err := os.Open(objFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	m, err := DecodeNavMeshObj(file)
+	if err != nil {
+		return nil, err
+	}
+	err = SaveNavMeshFile(m, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadNavMeshFile 从文件加载导航网格
+func LoadNavMeshFile(fileName string) (*NavMesh, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return DecodeNavMesh(file)
+}
+
+// SaveNavMeshFile 保存导航网格到文件
+func SaveNavMeshFile(m *NavMesh, fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	err = m.Encode(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// LoadNavMeshDir 加载目录下的全部场景导航网格 文件名为场景id.navmesh 目录为空时使用默认目录
+// 单个文件解析失败时跳过该文件继续加载 返回已加载的导航网格和汇总的错误
+func LoadNavMeshDir(path string) (map[uint32]*NavMesh, error) {
+	if path == "" {
+		path = DefaultNavMeshPath
+	}
+	fileList, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	navMeshMap := make(map[uint32]*NavMesh)
+	errList := make([]string, 0)
+	for _, file := range fileList {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), NavMeshFileExt) {
+			continue
+		}
+		sceneId, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), NavMeshFileExt), 10, 32)
+		if err != nil {
+			errList = append(errList, fmt.Sprintf("parse file name error: %v, file: %v", err, file.Name()))
+			continue
+		}
+		navMesh, err := LoadNavMeshFile(filepath.Join(path, file.Name()))
+		if err != nil {
+			errList = append(errList, fmt.Sprintf("load file error: %v, file: %v", err, file.Name()))
+			continue
+		}
+		navMeshMap[uint32(sceneId)] = navMesh
+	}
+	if len(errList) != 0 {
+		return navMeshMap, errors.New(strings.Join(errList, "; "))
+	}
+	return navMeshMap, nil
+}
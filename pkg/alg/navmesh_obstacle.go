@@ -0,0 +1,126 @@
+package alg
+
+import (
+	"math"
+)
+
+// 导航网格动态障碍
+// 障碍覆盖到的多边形在寻路时视为不可通行 障碍层与网格分离 同一份网格可对应多个障碍层
+
+const (
+	NavObstacleBox     = iota // 长方体 半尺寸为HalfExtents
+	NavObstacleCapsule        // 胶囊体 半径为HalfExtents.X 半高为HalfExtents.Y
+)
+
+// NavObstacle 动态障碍
+type NavObstacle struct {
+	Shape       int
+	Center      Vector3
+	HalfExtents Vector3
+	Yaw         float32 // 绕Y轴的旋转角度 弧度
+}
+
+// Contain 检测一个点是否在障碍内
+func (o *NavObstacle) Contain(pos *Vector3) bool {
+	if pos.Y < o.Center.Y-o.HalfExtents.Y || pos.Y > o.Center.Y+o.HalfExtents.Y {
+		return false
+	}
+	dx := pos.X - o.Center.X
+	dz := pos.Z - o.Center.Z
+	if o.Shape == NavObstacleCapsule {
+		return dx*dx+dz*dz <= o.HalfExtents.X*o.HalfExtents.X
+	}
+	// 转换到障碍的局部坐标系
+	sin, cos := math.Sincos(float64(o.Yaw))
+	localX := float32(float64(dx)*cos - float64(dz)*sin)
+	localZ := float32(float64(dx)*sin + float64(dz)*cos)
+	return float32(math.Abs(float64(localX))) <= o.HalfExtents.X && float32(math.Abs(float64(localZ))) <= o.HalfExtents.Z
+}
+
+// radius XZ平面上的外接圆半径
+func (o *NavObstacle) radius() float32 {
+	if o.Shape == NavObstacleCapsule {
+		return o.HalfExtents.X
+	}
+	return float32(math.Sqrt(float64(o.HalfExtents.X*o.HalfExtents.X + o.HalfExtents.Z*o.HalfExtents.Z)))
+}
+
+// NavObstacleLayer 动态障碍层 非并发安全
+type NavObstacleLayer struct {
+	mesh        *NavMesh
+	obstacleMap map[int32][]int32 // 障碍id -> 被阻挡的多边形
+	blockMap    map[int32]int32   // 多边形 -> 阻挡它的障碍数量
+}
+
+func NewNavObstacleLayer(mesh *NavMesh) *NavObstacleLayer {
+	return &NavObstacleLayer{
+		mesh:        mesh,
+		obstacleMap: make(map[int32][]int32),
+		blockMap:    make(map[int32]int32),
+	}
+}
+
+// AddObstacle 添加障碍 相同id的障碍会被替换
+// 阻挡粒度为整个多边形 与障碍重叠的多边形整体不可通行 不会裁剪出多边形中未被覆盖的部分
+// 大多边形上的小障碍会封住整个多边形 需要精细阻挡的区域应在生成导航网格时细分多边形
+func (l *NavObstacleLayer) AddObstacle(id int32, obstacle *NavObstacle) {
+	l.RemoveObstacle(id)
+	r := obstacle.radius()
+	polyMap := make(map[int32]bool)
+	l.mesh.queryPoly(obstacle.Center.X-r, obstacle.Center.Z-r, obstacle.Center.X+r, obstacle.Center.Z+r, func(polyIndex int32) {
+		if polyMap[polyIndex] {
+			return
+		}
+		if l.overlap(polyIndex, obstacle) {
+			polyMap[polyIndex] = true
+		}
+	})
+	polyList := make([]int32, 0, len(polyMap))
+	for polyIndex := range polyMap {
+		polyList = append(polyList, polyIndex)
+		l.blockMap[polyIndex]++
+	}
+	l.obstacleMap[id] = polyList
+}
+
+// overlap 障碍包含多边形中心或任一顶点 或多边形包含障碍中心时视为阻挡
+func (l *NavObstacleLayer) overlap(polyIndex int32, obstacle *NavObstacle) bool {
+	poly := l.mesh.polyList[polyIndex]
+	if obstacle.Contain(&poly.center) {
+		return true
+	}
+	for _, index := range poly.vertList {
+		if obstacle.Contain(&l.mesh.vertList[index]) {
+			return true
+		}
+	}
+	if math.Abs(float64(obstacle.Center.Y-poly.center.Y)) > float64(obstacle.HalfExtents.Y) {
+		return false
+	}
+	return l.mesh.PolyContain(polyIndex, &obstacle.Center)
+}
+
+// RemoveObstacle 移除障碍
+func (l *NavObstacleLayer) RemoveObstacle(id int32) {
+	polyList, exist := l.obstacleMap[id]
+	if !exist {
+		return
+	}
+	for _, polyIndex := range polyList {
+		l.blockMap[polyIndex]--
+		if l.blockMap[polyIndex] <= 0 {
+			delete(l.blockMap, polyIndex)
+		}
+	}
+	delete(l.obstacleMap, id)
+}
+
+// IsBlock 多边形是否被障碍阻挡
+func (l *NavObstacleLayer) IsBlock(polyIndex int32) bool {
+	return l.blockMap[polyIndex] > 0
+}
+
+// GetObstacleNum 障碍数量
+func (l *NavObstacleLayer) GetObstacleNum() int {
+	return len(l.obstacleMap)
+}
@@ -0,0 +1,251 @@
+package alg
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newGridNavMesh 创建size*size个边长10的正方形组成的网格 block中的格子不生成多边形
+func newGridNavMesh(t *testing.T, size int, block func(x, z int) bool) *NavMesh {
+	vertList := make([]Vector3, 0)
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			vertList = append(vertList, Vector3{X: float32(x * 10), Y: 0.0, Z: float32(z * 10)})
+		}
+	}
+	index := func(x, z int) int32 {
+		return int32(z*(size+1) + x)
+	}
+	polyList := make([][]int32, 0)
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			if block != nil && block(x, z) {
+				continue
+			}
+			polyList = append(polyList, []int32{index(x, z), index(x+1, z), index(x+1, z+1), index(x, z+1)})
+		}
+	}
+	navMesh, err := NewNavMesh(vertList, polyList)
+	if err != nil {
+		t.Fatalf("new nav mesh error: %v", err)
+	}
+	return navMesh
+}
+
+// 第5列除最上面一行外都是墙
+func wallBlock(x, z int) bool {
+	return x == 5 && z < 9
+}
+
+func TestNavMeshFindPath(t *testing.T) {
+	navMesh := newGridNavMesh(t, 10, nil)
+	status, path := navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 95, Y: 0, Z: 95}, 5.0, nil, 0)
+	if status != NavPathSucc {
+		t.Fatalf("find path status: %v", status)
+	}
+	if len(path) != 2 {
+		t.Fatalf("open area path should be straight, path: %v", path)
+	}
+
+	navMesh = newGridNavMesh(t, 10, wallBlock)
+	status, path = navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 95, Y: 0, Z: 5}, 5.0, nil, 0)
+	if status != NavPathSucc {
+		t.Fatalf("find path status: %v", status)
+	}
+	// 必须从墙上方的缺口绕过去
+	wallIndex := -1
+	for i := 0; i+1 < len(path); i++ {
+		if *path[i] == (Vector3{X: 50, Y: 0, Z: 90}) && *path[i+1] == (Vector3{X: 60, Y: 0, Z: 90}) {
+			wallIndex = i
+			break
+		}
+	}
+	if wallIndex == -1 {
+		t.Fatalf("path should go around the wall, path: %v", path)
+	}
+	for i, corner := range path {
+		if (i <= wallIndex && corner.X > 50) || (i > wallIndex && corner.X < 60) {
+			t.Fatalf("path cross the wall, corner: %v", *corner)
+		}
+	}
+	if *path[len(path)-1] != (Vector3{X: 95, Y: 0, Z: 5}) {
+		t.Fatalf("path end error: %v", *path[len(path)-1])
+	}
+
+	status, _ = navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 500, Y: 0, Z: 500}, 5.0, nil, 0)
+	if status != NavPathFail {
+		t.Fatalf("end out of mesh should fail, status: %v", status)
+	}
+}
+
+func TestNavMeshObstacle(t *testing.T) {
+	navMesh := newGridNavMesh(t, 10, wallBlock)
+	layer := NewNavObstacleLayer(navMesh)
+	// 堵住墙上方的通道
+	layer.AddObstacle(1, &NavObstacle{
+		Shape:       NavObstacleBox,
+		Center:      Vector3{X: 55, Y: 0, Z: 95},
+		HalfExtents: Vector3{X: 4, Y: 2, Z: 4},
+	})
+	start := &Vector3{X: 5, Y: 0, Z: 5}
+	end := &Vector3{X: 95, Y: 0, Z: 5}
+	status, path := navMesh.FindPath(start, end, 5.0, layer, 0)
+	if status != NavPathPartial {
+		t.Fatalf("blocked path should be partial, status: %v", status)
+	}
+	last := path[len(path)-1]
+	if last.X > 50 {
+		t.Fatalf("partial path should stop before the wall, path end: %v", *last)
+	}
+	layer.RemoveObstacle(1)
+	if layer.GetObstacleNum() != 0 {
+		t.Fatalf("obstacle num error: %v", layer.GetObstacleNum())
+	}
+	status, _ = navMesh.FindPath(start, end, 5.0, layer, 0)
+	if status != NavPathSucc {
+		t.Fatalf("find path after remove obstacle status: %v", status)
+	}
+}
+
+func TestNavMeshBudget(t *testing.T) {
+	navMesh := newGridNavMesh(t, 200, func(x, z int) bool {
+		return x%4 == 2 && z != 199 && z != 0
+	})
+	status, _ := navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 1005}, &Vector3{X: 1995, Y: 0, Z: 1005}, 5.0, nil, time.Nanosecond)
+	if status != NavPathPartial {
+		t.Fatalf("over budget path should be partial, status: %v", status)
+	}
+	startTime := time.Now()
+	status, path := navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 1005}, &Vector3{X: 1995, Y: 0, Z: 1005}, 5.0, nil, 0)
+	if status != NavPathSucc {
+		t.Fatalf("find path status: %v", status)
+	}
+	t.Logf("poly num: %v, corner num: %v, cost: %v", navMesh.GetPolyNum(), len(path), time.Since(startTime))
+}
+
+func TestNavMeshEncode(t *testing.T) {
+	navMesh := newGridNavMesh(t, 10, wallBlock)
+	var buffer bytes.Buffer
+	err := navMesh.Encode(&buffer)
+	if err != nil {
+		t.Fatalf("encode nav mesh error: %v", err)
+	}
+	t.Logf("nav mesh file size: %v", buffer.Len())
+	decodeNavMesh, err := DecodeNavMesh(&buffer)
+	if err != nil {
+		t.Fatalf("decode nav mesh error: %v", err)
+	}
+	if decodeNavMesh.GetPolyNum() != navMesh.GetPolyNum() || decodeNavMesh.GetVertNum() != navMesh.GetVertNum() {
+		t.Fatalf("decode nav mesh size error")
+	}
+	_, path := navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 95, Y: 0, Z: 5}, 5.0, nil, 0)
+	_, decodePath := decodeNavMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 95, Y: 0, Z: 5}, 5.0, nil, 0)
+	if len(path) != len(decodePath) {
+		t.Fatalf("decode nav mesh path error")
+	}
+	for i := range path {
+		if *path[i] != *decodePath[i] {
+			t.Fatalf("decode nav mesh path error")
+		}
+	}
+	_, err = DecodeNavMesh(bytes.NewReader([]byte("bad file")))
+	if err == nil {
+		t.Fatalf("decode bad file should fail")
+	}
+}
+
+func TestNavMeshObstacleWholePoly(t *testing.T) {
+	// 两个100x100的大多边形 中间的小障碍只覆盖左边多边形的一角
+	navMesh, err := NewNavMesh([]Vector3{
+		{X: 0, Y: 0, Z: 0}, {X: 100, Y: 0, Z: 0}, {X: 200, Y: 0, Z: 0},
+		{X: 0, Y: 0, Z: 100}, {X: 100, Y: 0, Z: 100}, {X: 200, Y: 0, Z: 100},
+	}, [][]int32{{0, 1, 4, 3}, {1, 2, 5, 4}})
+	if err != nil {
+		t.Fatalf("new nav mesh error: %v", err)
+	}
+	layer := NewNavObstacleLayer(navMesh)
+	layer.AddObstacle(1, &NavObstacle{
+		Shape:       NavObstacleBox,
+		Center:      Vector3{X: 5, Y: 0, Z: 5},
+		HalfExtents: Vector3{X: 6, Y: 2, Z: 6},
+	})
+	if !layer.IsBlock(0) || layer.IsBlock(1) {
+		t.Fatalf("obstacle should block the whole left poly only")
+	}
+	// 终点离障碍很远 但所在多边形整体被阻挡 只能到达右边多边形
+	status, path := navMesh.FindPath(&Vector3{X: 150, Y: 0, Z: 50}, &Vector3{X: 90, Y: 0, Z: 90}, 5.0, layer, 0)
+	if status != NavPathPartial {
+		t.Fatalf("path into blocked poly should be partial, status: %v", status)
+	}
+	if last := path[len(path)-1]; last.X < 100 {
+		t.Fatalf("partial path should stop at the blocked poly edge, path end: %v", *last)
+	}
+}
+
+func TestDecodeNavMeshObj(t *testing.T) {
+	obj := `# two quads
+o navmesh
+v 0 0 0
+v 10 0 0
+v 20 0 0
+v 0 0 10
+v 10 0 10
+v 20 0 10
+vn 0 1 0
+f 1//1 2//1 5//1 4//1
+f -5 -4 -1 -2
+`
+	navMesh, err := DecodeNavMeshObj(bytes.NewReader([]byte(obj)))
+	if err != nil {
+		t.Fatalf("decode obj error: %v", err)
+	}
+	if navMesh.GetVertNum() != 6 || navMesh.GetPolyNum() != 2 {
+		t.Fatalf("decode obj size error, vert num: %v, poly num: %v", navMesh.GetVertNum(), navMesh.GetPolyNum())
+	}
+	status, path := navMesh.FindPath(&Vector3{X: 5, Y: 0, Z: 5}, &Vector3{X: 15, Y: 0, Z: 5}, 5.0, nil, 0)
+	if status != NavPathSucc || len(path) != 2 {
+		t.Fatalf("find path on obj nav mesh error, status: %v, path: %v", status, path)
+	}
+	_, err = DecodeNavMeshObj(bytes.NewReader([]byte("v 0 0 0\nf 1 2 3\n")))
+	if err == nil {
+		t.Fatalf("decode obj with bad index should fail")
+	}
+}
+
+func TestLoadNavMeshDir(t *testing.T) {
+	path := t.TempDir()
+	err := SaveNavMeshFile(newGridNavMesh(t, 10, nil), filepath.Join(path, "3"+NavMeshFileExt))
+	if err != nil {
+		t.Fatalf("save nav mesh file error: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(path, "readme.txt"), []byte("not nav mesh"), 0644)
+	if err != nil {
+		t.Fatalf("write file error: %v", err)
+	}
+	navMeshMap, err := LoadNavMeshDir(path)
+	if err != nil {
+		t.Fatalf("load nav mesh dir error: %v", err)
+	}
+	if len(navMeshMap) != 1 || navMeshMap[3] == nil {
+		t.Fatalf("load nav mesh dir result error: %v", navMeshMap)
+	}
+	// 损坏的文件跳过 其余文件正常加载
+	err = os.WriteFile(filepath.Join(path, "5"+NavMeshFileExt), []byte("bad file"), 0644)
+	if err != nil {
+		t.Fatalf("write file error: %v", err)
+	}
+	navMeshMap, err = LoadNavMeshDir(path)
+	if err == nil {
+		t.Fatalf("load bad nav mesh file should return error")
+	}
+	if len(navMeshMap) != 1 || navMeshMap[3] == nil {
+		t.Fatalf("load nav mesh dir result error: %v", navMeshMap)
+	}
+	navMeshMap, err = LoadNavMeshDir(filepath.Join(path, "not_exist"))
+	if err == nil || navMeshMap != nil {
+		t.Fatalf("load not exist dir should fail")
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"encoding/pem"
 	"errors"
 	"hash"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func RsaParsePubKey(pubKeyPem []byte) (*rsa.PublicKey, error) {
@@ -166,6 +168,28 @@ func Md5Str(inputStr string) string {
 	return hashStr(h, inputStr)
 }
 
+// PasswordHash 使用bcrypt计算密码哈希 每次结果都带有随机盐
+func PasswordHash(password string) (string, error) {
+	hashData, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashData), nil
+}
+
+// PasswordVerify 校验密码 兼容旧版的md5密码哈希
+func PasswordVerify(passwordHash string, password string) bool {
+	if PasswordIsLegacyHash(passwordHash) {
+		return passwordHash == Md5Str(password)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) == nil
+}
+
+// PasswordIsLegacyHash 是否为旧版的md5密码哈希 需要在登录成功时升级
+func PasswordIsLegacyHash(passwordHash string) bool {
+	return len(passwordHash) == md5.Size*2 && !bytes.HasPrefix([]byte(passwordHash), []byte("$2"))
+}
+
 func hashStr(h hash.Hash, inputStr string) string {
 	h.Write([]byte(inputStr))
 	return hex.EncodeToString(h.Sum(nil))
@@ -34,3 +34,23 @@ func TestHk4eAbilityHashCode(t *testing.T) {
 	hashCode = Hk4eAbilityHashCode("Avatar_Ayato_ExtraAttack_CreateBullet")
 	fmt.Printf("Avatar_Ayato_ExtraAttack_CreateBullet hashCode: %v\n", hashCode)
 }
+
+func TestPasswordHash(t *testing.T) {
+	passwordHash, err := PasswordHash("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PasswordVerify(passwordHash, "password123") {
+		t.Fatal("verify password fail")
+	}
+	if PasswordVerify(passwordHash, "password124") {
+		t.Fatal("verify wrong password ok")
+	}
+	legacyHash := Md5Str("password123")
+	if !PasswordIsLegacyHash(legacyHash) || PasswordIsLegacyHash(passwordHash) {
+		t.Fatal("legacy hash check fail")
+	}
+	if !PasswordVerify(legacyHash, "password123") {
+		t.Fatal("verify legacy password fail")
+	}
+}
@@ -4,16 +4,19 @@ import (
 	"net/http"
 
 	"github.com/arl/statsviz"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Serve 性能检测
 // 原生pprof /debug/pprof
 // 可视化图表 /debug/statsviz
+// 监控指标 /metrics
 func Serve(addr string) error {
 	err := statsviz.RegisterDefault()
 	if err != nil {
 		return err
 	}
+	http.Handle("/metrics", promhttp.Handler())
 	err = http.ListenAndServe(addr, nil)
 	if err != nil {
 		return err
@@ -0,0 +1,173 @@
+package wordfilter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 敏感词过滤
+// 屏蔽词使用字典树匹配 忽略大小写和词中夹杂的空白符号 正则规则按原文匹配
+
+const (
+	MaskRune = '*'
+)
+
+// 正则规则命中后的处理方式
+const (
+	RuleActionMask  = iota // 屏蔽命中的部分
+	RuleActionBlock        // 拦截整条消息
+	RuleActionFlag         // 不做处理 只标记
+)
+
+type trieNode struct {
+	child map[rune]*trieNode
+	end   bool
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{child: make(map[rune]*trieNode)}
+}
+
+// Rule 正则规则
+type Rule struct {
+	Name   string
+	Regexp *regexp.Regexp
+	Action int
+}
+
+// Result 过滤结果
+type Result struct {
+	Text    string   // 屏蔽后的文本
+	HitList []string // 命中的屏蔽词和规则名
+	Block   bool     // 是否需要拦截整条消息
+}
+
+// Hit 是否命中任意屏蔽词或规则
+func (r *Result) Hit() bool {
+	return len(r.HitList) > 0
+}
+
+type Filter struct {
+	root     *trieNode
+	ruleList []*Rule
+}
+
+func NewFilter() *Filter {
+	return &Filter{
+		root:     newTrieNode(),
+		ruleList: make([]*Rule, 0),
+	}
+}
+
+func (f *Filter) AddWord(word string) {
+	node := f.root
+	count := 0
+	for _, c := range strings.ToLower(word) {
+		if isSkipRune(c) {
+			continue
+		}
+		next, exist := node.child[c]
+		if !exist {
+			next = newTrieNode()
+			node.child[c] = next
+		}
+		node = next
+		count++
+	}
+	if count > 0 {
+		node.end = true
+	}
+}
+
+func (f *Filter) AddRule(name string, pattern string, action int) error {
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	f.ruleList = append(f.ruleList, &Rule{Name: name, Regexp: reg, Action: action})
+	return nil
+}
+
+// Filter 过滤文本 命中的屏蔽词和屏蔽规则的匹配部分替换为*
+func (f *Filter) Filter(text string) *Result {
+	result := &Result{
+		Text:    text,
+		HitList: make([]string, 0),
+		Block:   false,
+	}
+	runeList := []rune(text)
+	maskList := make([]bool, len(runeList))
+	for i := 0; i < len(runeList); i++ {
+		if isSkipRune(runeList[i]) {
+			continue
+		}
+		end := f.matchWord(runeList, i)
+		if end == -1 {
+			continue
+		}
+		for j := i; j <= end; j++ {
+			if !isSkipRune(runeList[j]) {
+				maskList[j] = true
+			}
+		}
+		result.HitList = append(result.HitList, string(runeList[i:end+1]))
+		i = end
+	}
+	for _, rule := range f.ruleList {
+		indexList := rule.Regexp.FindAllStringIndex(text, -1)
+		if len(indexList) == 0 {
+			continue
+		}
+		result.HitList = append(result.HitList, rule.Name)
+		switch rule.Action {
+		case RuleActionBlock:
+			result.Block = true
+		case RuleActionFlag:
+			continue
+		}
+		for _, index := range indexList {
+			begin := len([]rune(text[:index[0]]))
+			end := begin + len([]rune(text[index[0]:index[1]]))
+			for j := begin; j < end; j++ {
+				maskList[j] = true
+			}
+		}
+	}
+	if !result.Hit() {
+		return result
+	}
+	for i := range runeList {
+		if maskList[i] {
+			runeList[i] = MaskRune
+		}
+	}
+	result.Text = string(runeList)
+	return result
+}
+
+// matchWord 从begin位置开始匹配最长的屏蔽词 返回屏蔽词结束的下标 未匹配返回-1
+func (f *Filter) matchWord(runeList []rune, begin int) int {
+	node := f.root
+	end := -1
+	for i := begin; i < len(runeList); i++ {
+		c := runeList[i]
+		if isSkipRune(c) {
+			continue
+		}
+		next, exist := node.child[unicode.ToLower(c)]
+		if !exist {
+			break
+		}
+		node = next
+		if node.end {
+			end = i
+		}
+	}
+	return end
+}
+
+// isSkipRune 匹配屏蔽词时跳过的字符 防止用空格和符号分隔绕过
+func isSkipRune(c rune) bool {
+	return unicode.IsSpace(c) || unicode.IsPunct(c) || unicode.IsSymbol(c)
+}
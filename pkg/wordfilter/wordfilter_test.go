@@ -0,0 +1,51 @@
+package wordfilter
+
+import (
+	"testing"
+)
+
+func TestFilterWord(t *testing.T) {
+	f := NewFilter()
+	f.AddWord("bad")
+	f.AddWord("坏蛋")
+	result := f.Filter("you are BAD guy")
+	if result.Text != "you are *** guy" || len(result.HitList) != 1 {
+		t.Fatalf("filter word fail, result: %v", result)
+	}
+	result = f.Filter("你是坏 蛋")
+	if result.Text != "你是* *" {
+		t.Fatalf("filter skip rune fail, result: %v", result)
+	}
+	result = f.Filter("good")
+	if result.Hit() || result.Text != "good" {
+		t.Fatalf("filter clean text fail, result: %v", result)
+	}
+}
+
+func TestFilterRule(t *testing.T) {
+	f := NewFilter()
+	err := f.AddRule("qq", `[0-9]{6,}`, RuleActionMask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.AddRule("url", `https?://`, RuleActionBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := f.Filter("加我12345678")
+	if result.Text != "加我********" || result.Block {
+		t.Fatalf("filter rule fail, result: %v", result)
+	}
+	result = f.Filter("see http://a.com")
+	if !result.Block || result.HitList[0] != "url" {
+		t.Fatalf("filter block rule fail, result: %v", result)
+	}
+	err = f.AddRule("ad", `代练`, RuleActionFlag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = f.Filter("找代练")
+	if !result.Hit() || result.Block || result.Text != "找代练" {
+		t.Fatalf("filter flag rule fail, result: %v", result)
+	}
+}
@@ -364,12 +364,21 @@ func gateLogin(account string, dispatchInfo *login.DispatchInfo, accountInfo *lo
 		PacketCaptureSession = session
 	}
 	logger.Info("robot gate login ok, account: %v", account)
+	err = sendPlayerLoginReq(session, accountInfo)
+	if err != nil {
+		logger.Error("gen clientVersionHashData error: %v", err)
+		return
+	}
+	client.Logic(account, session)
+}
+
+// sendPlayerLoginReq 网关登录成功后发送游戏服务器登录请求
+func sendPlayerLoginReq(session *net.Session, accountInfo *login.AccountInfo) error {
 	clientVersionHashData, err := hex.DecodeString(
 		endec.Sha1Str(config.GetConfig().Hk4eRobot.ClientVersion + session.ClientVersionRandomKey + "mhy2020"),
 	)
 	if err != nil {
-		logger.Error("gen clientVersionHashData error: %v", err)
-		return
+		return err
 	}
 	checksumClientVersion := strings.Split(config.GetConfig().Hk4eRobot.ClientVersion, "_")[0]
 	session.SendMsg(cmd.PlayerLoginReq, &proto.PlayerLoginReq{
@@ -386,5 +395,5 @@ func gateLogin(account string, dispatchInfo *login.DispatchInfo, accountInfo *lo
 		SecurityLibraryMd5:    "574a507ffee2eb6f997d11f71c8ae1fa",
 		Token:                 accountInfo.ComboToken,
 	})
-	client.Logic(account, session)
+	return nil
 }
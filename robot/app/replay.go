@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"hk4e/common/config"
+	hk4egatenet "hk4e/gate/net"
+	"hk4e/pkg/logger"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+	"hk4e/robot/login"
+	"hk4e/robot/net"
+
+	pb "google.golang.org/protobuf/proto"
+)
+
+// 抓包回放
+// 读取网关落盘的会话抓包文件 用机器人账号登录后按原始时间间隔重发客户端数据包
+// 场景令牌和实体id等由服务器分配的数据不会重写 需要回放账号的存档与抓包时一致才能得到确定性的结果
+
+const (
+	ReplayLoginTimeout = time.Second * 10 // 等待游戏服务器登录响应超时
+	ReplayTailTime     = time.Second * 3  // 回放结束后继续接收服务器消息的时间
+)
+
+// 回放时跳过的客户端数据包 登录流程由回放自身完成
+var replaySkipCmdIdMap = map[uint16]bool{
+	cmd.GetPlayerTokenReq: true,
+	cmd.PlayerLoginReq:    true,
+}
+
+// Replay 使用抓包文件驱动机器人会话回放 speed为回放倍速
+func Replay(ctx context.Context, configFile string, captureFile string, account string, speed float64) error {
+	config.InitConfig(configFile)
+
+	logger.InitLogger("replay")
+	defer func() {
+		logger.CloseLogger()
+	}()
+
+	packetList, err := hk4egatenet.ReadPacketCaptureFile(captureFile)
+	if err != nil {
+		logger.Error("read capture file error: %v", err)
+		return err
+	}
+	if account == "" {
+		account = config.GetConfig().Hk4eRobot.Account
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+
+	// 以抓包中的游戏服务器登录请求为时间起点 之前的都是网关登录流程
+	baseIndex := -1
+	for index, packet := range packetList {
+		if packet.Dir != hk4egatenet.PacketCaptureDirC2S {
+			continue
+		}
+		if packet.CmdId == cmd.PlayerLoginReq {
+			baseIndex = index
+			break
+		}
+		if baseIndex == -1 {
+			baseIndex = index
+		}
+	}
+	if baseIndex == -1 {
+		return errors.New("no client packet in capture file")
+	}
+	packetList = packetList[baseIndex:]
+	baseTime := packetList[0].Time
+
+	session, err := replayLogin(account)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	recvCmdCountMap := make(map[uint16]int)
+	expectCmdCountMap := make(map[uint16]int)
+	err = replayWaitLogin(ctx, session, recvCmdCountMap)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("replay begin, account: %v, packet num: %v, speed: %v", account, len(packetList), speed)
+	replayBeginTime := time.Now()
+	sendCount := 0
+	for _, packet := range packetList {
+		if packet.Dir == hk4egatenet.PacketCaptureDirS2C {
+			expectCmdCountMap[packet.CmdId]++
+			continue
+		}
+		if replaySkipCmdIdMap[packet.CmdId] {
+			continue
+		}
+		delay := time.Duration(float64(packet.Time-baseTime)/speed) * time.Millisecond
+		timer := time.NewTimer(time.Until(replayBeginTime.Add(delay)))
+		wait := true
+		for wait {
+			select {
+			case protoMsg := <-session.RecvChan:
+				recvCmdCountMap[protoMsg.CmdId]++
+			case <-timer.C:
+				wait = false
+			case <-session.DeadEvent:
+				timer.Stop()
+				logger.Error("replay session closed, send packet num: %v", sendCount)
+				return errors.New("session closed")
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		err = replaySendPacket(session, packet)
+		if err != nil {
+			logger.Error("replay packet error: %v, cmdId: %v, cmdName: %v", err, packet.CmdId, packet.CmdName)
+			continue
+		}
+		sendCount++
+	}
+
+	// 继续接收剩余的服务器响应
+	tailTimer := time.NewTimer(ReplayTailTime)
+	for wait := true; wait; {
+		select {
+		case protoMsg := <-session.RecvChan:
+			recvCmdCountMap[protoMsg.CmdId]++
+		case <-tailTimer.C:
+			wait = false
+		case <-session.DeadEvent:
+			tailTimer.Stop()
+			wait = false
+		}
+	}
+	logger.Info("replay finish, send packet num: %v, cost time: %v", sendCount, time.Since(replayBeginTime))
+	replayReport(session, expectCmdCountMap, recvCmdCountMap)
+	return nil
+}
+
+func replayLogin(account string) (*net.Session, error) {
+	dispatchInfo, err := login.GetDispatchInfo(config.GetConfig().Hk4eRobot.RegionListUrl,
+		config.GetConfig().Hk4eRobot.RegionListParam,
+		config.GetConfig().Hk4eRobot.CurRegionUrl,
+		config.GetConfig().Hk4eRobot.CurRegionParam,
+		config.GetConfig().Hk4eRobot.KeyId)
+	if err != nil {
+		logger.Error("get dispatch info error: %v", err)
+		return nil, err
+	}
+	accountInfo, err := login.AccountLogin(config.GetConfig().Hk4eRobot.LoginSdkUrl, account, config.GetConfig().Hk4eRobot.Password)
+	if err != nil {
+		logger.Error("account login error: %v", err)
+		return nil, err
+	}
+	session, err, _ := login.GateLogin(dispatchInfo, accountInfo, config.GetConfig().Hk4eRobot.KeyId, nil, 1)
+	if err != nil {
+		logger.Error("gate login error: %v", err)
+		return nil, err
+	}
+	err = sendPlayerLoginReq(session, accountInfo)
+	if err != nil {
+		logger.Error("send player login req error: %v", err)
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func replayWaitLogin(ctx context.Context, session *net.Session, recvCmdCountMap map[uint16]int) error {
+	timer := time.NewTimer(ReplayLoginTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case protoMsg := <-session.RecvChan:
+			recvCmdCountMap[protoMsg.CmdId]++
+			if protoMsg.CmdId != cmd.PlayerLoginRsp {
+				continue
+			}
+			rsp := protoMsg.PayloadMessage.(*proto.PlayerLoginRsp)
+			if rsp.Retcode != 0 {
+				logger.Error("replay login fail, retCode: %v", rsp.Retcode)
+				return errors.New("login fail")
+			}
+			return nil
+		case <-timer.C:
+			return errors.New("wait login rsp timeout")
+		case <-session.DeadEvent:
+			return errors.New("session closed")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func replaySendPacket(session *net.Session, packet *hk4egatenet.CapturePacket) error {
+	payloadMsg := session.ServerCmdProtoMap.GetProtoObjByCmdId(packet.CmdId)
+	if payloadMsg == nil {
+		return errors.New("unknown cmd id")
+	}
+	err := pb.Unmarshal(packet.PayloadData, payloadMsg)
+	if err != nil {
+		return err
+	}
+	headMsg := new(proto.PacketHead)
+	err = pb.Unmarshal(packet.HeadData, headMsg)
+	if err != nil {
+		return err
+	}
+	session.SendMsgFwd(packet.CmdId, headMsg.ClientSequenceId, payloadMsg)
+	return nil
+}
+
+// replayReport 对比抓包与回放收到的服务器消息数量
+func replayReport(session *net.Session, expectCmdCountMap map[uint16]int, recvCmdCountMap map[uint16]int) {
+	diffCount := 0
+	for cmdId, expectCount := range expectCmdCountMap {
+		recvCount := recvCmdCountMap[cmdId]
+		if recvCount == expectCount {
+			continue
+		}
+		diffCount++
+		logger.Warn("replay diff, cmdId: %v, cmdName: %v, expect: %v, recv: %v",
+			cmdId, session.ServerCmdProtoMap.GetCmdNameByCmdId(cmdId), expectCount, recvCount)
+	}
+	for cmdId, recvCount := range recvCmdCountMap {
+		_, exist := expectCmdCountMap[cmdId]
+		if exist {
+			continue
+		}
+		diffCount++
+		logger.Warn("replay diff, cmdId: %v, cmdName: %v, expect: %v, recv: %v",
+			cmdId, session.ServerCmdProtoMap.GetCmdNameByCmdId(cmdId), 0, recvCount)
+	}
+	logger.Info("replay report, diff cmd num: %v", diffCount)
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"hk4e/common/config"
+	"hk4e/pkg/logger"
+	"hk4e/robot/client"
+)
+
+// 场景脚本压测
+// 多个机器人并发执行同一个场景文件 执行结束后输出每个步骤的统计报告
+
+// RunScenario 使用num个机器人执行场景文件 有步骤失败时返回错误
+func RunScenario(ctx context.Context, configFile string, scenarioFile string, num int) error {
+	config.InitConfig(configFile)
+
+	logger.InitLogger("scenario")
+	defer func() {
+		logger.CloseLogger()
+	}()
+
+	scenario, err := client.LoadScenario(scenarioFile)
+	if err != nil {
+		logger.Error("load scenario file error: %v", err)
+		return err
+	}
+	if num <= 0 {
+		num = 1
+	}
+
+	logger.Info("scenario begin, name: %v, robot num: %v, step num: %v", scenario.Name, num, len(scenario.Step))
+	report := client.NewScenarioReport()
+	uidMap := new(client.ScenarioUidMap)
+	beginTime := time.Now()
+	wg := new(sync.WaitGroup)
+	wg.Add(num)
+	for i := 0; i < num; i++ {
+		account := config.GetConfig().Hk4eRobot.Account
+		if num > 1 {
+			account += strconv.Itoa(i)
+		}
+		go func(robotIndex int, account string) {
+			defer wg.Done()
+			loginBeginTime := time.Now()
+			session, err := replayLogin(account)
+			if err != nil {
+				report.Record(client.ScenarioStepLogin, time.Since(loginBeginTime), err)
+				return
+			}
+			runner := client.NewScenarioRunner(account, robotIndex, session, uidMap, report)
+			runner.Run(scenario, loginBeginTime)
+		}(i, account)
+	}
+
+	finishChan := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finishChan)
+	}()
+	select {
+	case <-finishChan:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	logger.Info("scenario finish, cost time: %v", time.Since(beginTime))
+	report.Print(scenario.Name)
+	failCount := report.FailCount()
+	if failCount > 0 {
+		return errors.New("scenario fail, fail step num: " + strconv.Itoa(failCount))
+	}
+	return nil
+}
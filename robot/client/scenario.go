@@ -0,0 +1,435 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hk4e/pkg/logger"
+	"hk4e/protocol/cmd"
+	"hk4e/protocol/proto"
+	"hk4e/robot/net"
+
+	"github.com/BurntSushi/toml"
+	"google.golang.org/protobuf/encoding/protojson"
+	pb "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 机器人场景脚本
+// 场景文件为toml格式 按顺序执行步骤 每个步骤发送请求并对响应做断言 统计每个步骤的成功率和耗时
+
+const (
+	ScenarioActionSend      = "send"       // 发送请求 等待响应 默认动作
+	ScenarioActionWaitScene = "wait_scene" // 等待进入场景完成
+	ScenarioActionSleep     = "sleep"      // 等待一段时间
+	ScenarioActionLogout    = "logout"     // 退出登录
+)
+
+const (
+	ScenarioStepLogin         = "login" // 登录步骤 由运行器自动执行
+	DefaultScenarioTimeout    = time.Second * 10
+	ScenarioPingInterval      = time.Second * 5
+	ScenarioVarWaitInterval   = time.Millisecond * 100
+	ScenarioEnterSceneDoneCmd = cmd.EnterSceneDoneRsp
+)
+
+type ScenarioStep struct {
+	Name      string         `toml:"name"`       // 步骤名 统计按步骤名聚合
+	Action    string         `toml:"action"`     // 动作 为空则为send
+	Cmd       string         `toml:"cmd"`        // 请求协议名
+	Req       string         `toml:"req"`        // 请求内容 protojson格式 支持${uid}和${uid_N}变量
+	Expect    string         `toml:"expect"`     // 期望收到的响应协议名 为空则不等待
+	Assert    map[string]any `toml:"assert"`     // 响应字段断言 字段路径以.分隔 重复字段可用下标
+	WaitScene bool           `toml:"wait_scene"` // 收到响应后是否继续等待进入场景完成 用于传送和进地牢
+	TimeoutMs int64          `toml:"timeout_ms"` // 超时时间 默认10秒
+	DelayMs   int64          `toml:"delay_ms"`   // 执行前等待时间
+	OnlyRobot []int          `toml:"only_robot"` // 只由指定编号的机器人执行 为空则全部执行
+}
+
+type Scenario struct {
+	Name string          `toml:"name"`
+	Loop int             `toml:"loop"` // 步骤循环执行次数 默认1次
+	Step []*ScenarioStep `toml:"step"`
+}
+
+func LoadScenario(fileName string) (*Scenario, error) {
+	scenario := new(Scenario)
+	_, err := toml.DecodeFile(fileName, scenario)
+	if err != nil {
+		return nil, err
+	}
+	if scenario.Loop <= 0 {
+		scenario.Loop = 1
+	}
+	cmdProtoMap := cmd.NewCmdProtoMap()
+	for index, step := range scenario.Step {
+		if step.Name == "" {
+			step.Name = strconv.Itoa(index) + "_" + step.Cmd
+		}
+		if step.Action == "" {
+			step.Action = ScenarioActionSend
+		}
+		switch step.Action {
+		case ScenarioActionSend:
+			if cmdProtoMap.GetCmdIdByCmdName(step.Cmd) == 0 {
+				return nil, fmt.Errorf("unknown cmd: %v, step: %v", step.Cmd, step.Name)
+			}
+			if step.Expect != "" && cmdProtoMap.GetCmdIdByCmdName(step.Expect) == 0 {
+				return nil, fmt.Errorf("unknown expect cmd: %v, step: %v", step.Expect, step.Name)
+			}
+		case ScenarioActionWaitScene, ScenarioActionSleep, ScenarioActionLogout:
+		default:
+			return nil, fmt.Errorf("unknown action: %v, step: %v", step.Action, step.Name)
+		}
+	}
+	return scenario, nil
+}
+
+// ScenarioUidMap 机器人编号到uid的映射 用于步骤间引用其它机器人
+type ScenarioUidMap struct {
+	uidMap sync.Map
+}
+
+func (s *ScenarioUidMap) Set(robotIndex int, uid uint32) {
+	s.uidMap.Store(robotIndex, uid)
+}
+
+func (s *ScenarioUidMap) Get(robotIndex int) (uint32, bool) {
+	value, exist := s.uidMap.Load(robotIndex)
+	if !exist {
+		return 0, false
+	}
+	return value.(uint32), true
+}
+
+var scenarioVarRegexp = regexp.MustCompile(`\$\{uid(_[0-9]+)?\}`)
+
+// ScenarioRunner 单个机器人的场景执行器
+type ScenarioRunner struct {
+	account     string
+	robotIndex  int
+	session     *net.Session
+	uidMap      *ScenarioUidMap
+	report      *ScenarioReport
+	pingSeq     uint32
+	lastPing    time.Time
+	sceneDone   bool
+	isLogout    bool
+	recvMsgList []*scenarioRecvMsg
+}
+
+type scenarioRecvMsg struct {
+	cmdId uint16
+	msg   pb.Message
+}
+
+func NewScenarioRunner(account string, robotIndex int, session *net.Session, uidMap *ScenarioUidMap, report *ScenarioReport) *ScenarioRunner {
+	r := &ScenarioRunner{
+		account:    account,
+		robotIndex: robotIndex,
+		session:    session,
+		uidMap:     uidMap,
+		report:     report,
+		lastPing:   time.Now(),
+	}
+	return r
+}
+
+// Run 执行场景 loginBeginTime为开始登录的时间 用于统计登录耗时
+func (r *ScenarioRunner) Run(scenario *Scenario, loginBeginTime time.Time) {
+	defer func() {
+		if !r.isLogout {
+			r.session.Close()
+		}
+	}()
+	_, err := r.waitCmd(cmd.PlayerLoginRsp, DefaultScenarioTimeout)
+	if err == nil {
+		_, err = r.waitCmd(ScenarioEnterSceneDoneCmd, DefaultScenarioTimeout)
+	}
+	r.report.Record(ScenarioStepLogin, time.Since(loginBeginTime), err)
+	if err != nil {
+		logger.Error("scenario robot login fail, account: %v, err: %v", r.account, err)
+		return
+	}
+	r.uidMap.Set(r.robotIndex, r.session.Uid)
+	for loop := 0; loop < scenario.Loop; loop++ {
+		for _, step := range scenario.Step {
+			if !r.isStepRobot(step) {
+				continue
+			}
+			if step.DelayMs > 0 {
+				r.idle(time.Duration(step.DelayMs) * time.Millisecond)
+			}
+			beginTime := time.Now()
+			err := r.runStep(step)
+			r.report.Record(step.Name, time.Since(beginTime), err)
+			if err != nil {
+				logger.Error("scenario step fail, account: %v, step: %v, err: %v", r.account, step.Name, err)
+			}
+			if r.isLogout || r.session.IsClose {
+				return
+			}
+		}
+	}
+}
+
+func (r *ScenarioRunner) isStepRobot(step *ScenarioStep) bool {
+	if len(step.OnlyRobot) == 0 {
+		return true
+	}
+	for _, robotIndex := range step.OnlyRobot {
+		if robotIndex == r.robotIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ScenarioRunner) runStep(step *ScenarioStep) error {
+	timeout := DefaultScenarioTimeout
+	if step.TimeoutMs > 0 {
+		timeout = time.Duration(step.TimeoutMs) * time.Millisecond
+	}
+	switch step.Action {
+	case ScenarioActionSleep:
+		return nil
+	case ScenarioActionWaitScene:
+		_, err := r.waitCmd(ScenarioEnterSceneDoneCmd, timeout)
+		return err
+	case ScenarioActionLogout:
+		r.session.SendMsg(cmd.PlayerForceExitReq, new(proto.PlayerForceExitReq))
+		r.isLogout = true
+		// 等待退出请求发出后再关闭连接
+		r.idle(time.Millisecond * 500)
+		r.session.Close()
+		return nil
+	}
+	cmdId := r.session.ServerCmdProtoMap.GetCmdIdByCmdName(step.Cmd)
+	req := r.session.ServerCmdProtoMap.GetProtoObjByCmdId(cmdId)
+	if req == nil {
+		return errors.New("unknown cmd")
+	}
+	if step.Req != "" {
+		reqJson, err := r.replaceVar(step.Req, timeout)
+		if err != nil {
+			return err
+		}
+		err = protojson.Unmarshal([]byte(reqJson), req)
+		if err != nil {
+			return err
+		}
+	}
+	if step.WaitScene {
+		r.sceneDone = false
+	}
+	// 发送请求之前收到的消息不属于本步骤
+	r.recvMsgList = r.recvMsgList[:0]
+	r.session.SendMsg(cmdId, req)
+	if step.Expect == "" {
+		return nil
+	}
+	expectCmdId := r.session.ServerCmdProtoMap.GetCmdIdByCmdName(step.Expect)
+	rsp, err := r.waitCmd(expectCmdId, timeout)
+	if err != nil {
+		return err
+	}
+	for path, value := range step.Assert {
+		err = scenarioAssert(rsp, path, value)
+		if err != nil {
+			return err
+		}
+	}
+	if step.WaitScene && !r.sceneDone {
+		_, err = r.waitCmd(ScenarioEnterSceneDoneCmd, timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceVar 替换请求中的uid变量 引用的机器人未登录时等待
+func (r *ScenarioRunner) replaceVar(text string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	var err error = nil
+	result := scenarioVarRegexp.ReplaceAllStringFunc(text, func(v string) string {
+		robotIndex := r.robotIndex
+		if strings.HasPrefix(v, "${uid_") {
+			robotIndex, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(v, "${uid_"), "}"))
+		}
+		for {
+			uid, exist := r.uidMap.Get(robotIndex)
+			if exist {
+				return strconv.Itoa(int(uid))
+			}
+			if time.Now().After(deadline) {
+				err = fmt.Errorf("wait robot uid timeout, robot index: %v", robotIndex)
+				return v
+			}
+			r.idle(ScenarioVarWaitInterval)
+		}
+	})
+	return result, err
+}
+
+// waitCmd 等待指定的服务器消息 期间自动处理场景进入流程和心跳
+func (r *ScenarioRunner) waitCmd(cmdId uint16, timeout time.Duration) (pb.Message, error) {
+	// 先检查已经收到的消息
+	for index, recvMsg := range r.recvMsgList {
+		if recvMsg.cmdId == cmdId {
+			r.recvMsgList = append(r.recvMsgList[:index], r.recvMsgList[index+1:]...)
+			return recvMsg.msg, nil
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case protoMsg := <-r.session.RecvChan:
+			r.handleMsg(protoMsg.CmdId, protoMsg.PayloadMessage)
+			if protoMsg.CmdId == cmdId {
+				return protoMsg.PayloadMessage, nil
+			}
+			r.addRecvMsg(protoMsg.CmdId, protoMsg.PayloadMessage)
+		case <-timer.C:
+			return nil, fmt.Errorf("wait %v timeout", r.session.ServerCmdProtoMap.GetCmdNameByCmdId(cmdId))
+		case <-r.session.DeadEvent:
+			r.session.DeadEvent <- true
+			return nil, errors.New("session closed")
+		}
+		r.ping()
+	}
+}
+
+// idle 空闲等待 期间继续处理服务器消息
+func (r *ScenarioRunner) idle(duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	for {
+		select {
+		case protoMsg := <-r.session.RecvChan:
+			r.handleMsg(protoMsg.CmdId, protoMsg.PayloadMessage)
+			r.addRecvMsg(protoMsg.CmdId, protoMsg.PayloadMessage)
+		case <-timer.C:
+			return
+		case <-r.session.DeadEvent:
+			r.session.DeadEvent <- true
+			return
+		}
+		r.ping()
+	}
+}
+
+// addRecvMsg 缓存最近收到的消息 供后续步骤匹配在等待前就已到达的响应
+func (r *ScenarioRunner) addRecvMsg(cmdId uint16, msg pb.Message) {
+	r.recvMsgList = append(r.recvMsgList, &scenarioRecvMsg{cmdId: cmdId, msg: msg})
+	if len(r.recvMsgList) > 100 {
+		r.recvMsgList = r.recvMsgList[1:]
+	}
+}
+
+func (r *ScenarioRunner) ping() {
+	if time.Since(r.lastPing) < ScenarioPingInterval {
+		return
+	}
+	r.lastPing = time.Now()
+	r.pingSeq++
+	r.session.SendMsg(cmd.PingReq, &proto.PingReq{
+		ClientTime: uint32(time.Now().Unix()),
+		Seq:        r.pingSeq,
+	})
+}
+
+// handleMsg 自动应答场景进入和多人世界申请
+func (r *ScenarioRunner) handleMsg(cmdId uint16, msg pb.Message) {
+	switch cmdId {
+	case cmd.PlayerEnterSceneNotify:
+		ntf := msg.(*proto.PlayerEnterSceneNotify)
+		r.sceneDone = false
+		r.session.SendMsg(cmd.EnterSceneReadyReq, &proto.EnterSceneReadyReq{EnterSceneToken: ntf.EnterSceneToken})
+	case cmd.EnterSceneReadyRsp:
+		rsp := msg.(*proto.EnterSceneReadyRsp)
+		r.session.SendMsg(cmd.SceneInitFinishReq, &proto.SceneInitFinishReq{EnterSceneToken: rsp.EnterSceneToken})
+	case cmd.SceneInitFinishRsp:
+		rsp := msg.(*proto.SceneInitFinishRsp)
+		r.session.SendMsg(cmd.EnterSceneDoneReq, &proto.EnterSceneDoneReq{EnterSceneToken: rsp.EnterSceneToken})
+	case cmd.EnterSceneDoneRsp:
+		rsp := msg.(*proto.EnterSceneDoneRsp)
+		r.sceneDone = true
+		r.session.SendMsg(cmd.PostEnterSceneReq, &proto.PostEnterSceneReq{EnterSceneToken: rsp.EnterSceneToken})
+	case cmd.PlayerApplyEnterMpNotify:
+		ntf := msg.(*proto.PlayerApplyEnterMpNotify)
+		r.session.SendMsg(cmd.PlayerApplyEnterMpResultReq, &proto.PlayerApplyEnterMpResultReq{ApplyUid: ntf.SrcPlayerInfo.Uid, IsAgreed: true})
+	case cmd.PlayerApplyEnterMpResultNotify:
+		ntf := msg.(*proto.PlayerApplyEnterMpResultNotify)
+		if ntf.IsAgreed {
+			r.session.SendMsg(cmd.JoinPlayerSceneReq, &proto.JoinPlayerSceneReq{TargetUid: ntf.TargetUid})
+		}
+	}
+}
+
+// scenarioAssert 断言消息字段的值 枚举字段可填数字或枚举名
+func scenarioAssert(msg pb.Message, path string, expect any) error {
+	value, fd, err := scenarioGetField(msg.ProtoReflect(), path)
+	if err != nil {
+		return err
+	}
+	expectStr := fmt.Sprint(expect)
+	actualStr := ""
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		enumNum := value.Enum()
+		actualStr = strconv.Itoa(int(enumNum))
+		enumValue := fd.Enum().Values().ByNumber(enumNum)
+		if enumValue != nil && string(enumValue.Name()) == expectStr {
+			return nil
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fmt.Errorf("assert field is message, path: %v", path)
+	default:
+		actualStr = fmt.Sprint(value.Interface())
+	}
+	if actualStr != expectStr {
+		return fmt.Errorf("assert fail, path: %v, expect: %v, actual: %v", path, expectStr, actualStr)
+	}
+	return nil
+}
+
+func scenarioGetField(msg protoreflect.Message, path string) (protoreflect.Value, protoreflect.FieldDescriptor, error) {
+	nameList := strings.Split(path, ".")
+	for i := 0; i < len(nameList); i++ {
+		fields := msg.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(nameList[i]))
+		if fd == nil {
+			fd = fields.ByJSONName(nameList[i])
+		}
+		if fd == nil {
+			return protoreflect.Value{}, nil, fmt.Errorf("field not exist, path: %v", path)
+		}
+		value := msg.Get(fd)
+		if fd.IsList() {
+			if i+1 >= len(nameList) {
+				return protoreflect.Value{}, nil, fmt.Errorf("list field need index, path: %v", path)
+			}
+			i++
+			index, err := strconv.Atoi(nameList[i])
+			if err != nil || index < 0 || index >= value.List().Len() {
+				return protoreflect.Value{}, nil, fmt.Errorf("list index invalid, path: %v", path)
+			}
+			value = value.List().Get(index)
+		}
+		if i == len(nameList)-1 {
+			return value, fd, nil
+		}
+		if fd.Kind() != protoreflect.MessageKind {
+			return protoreflect.Value{}, nil, fmt.Errorf("field not message, path: %v", path)
+		}
+		msg = value.Message()
+	}
+	return protoreflect.Value{}, nil, fmt.Errorf("empty path")
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"hk4e/pkg/logger"
+)
+
+// ScenarioReport 场景执行统计 按步骤名聚合所有机器人的结果
+type ScenarioReport struct {
+	lock        sync.Mutex
+	stepList    []string
+	stepStatMap map[string]*scenarioStepStat
+}
+
+type scenarioStepStat struct {
+	pass        int
+	fail        int
+	latencyList []time.Duration
+}
+
+func NewScenarioReport() *ScenarioReport {
+	r := &ScenarioReport{
+		stepList:    make([]string, 0),
+		stepStatMap: make(map[string]*scenarioStepStat),
+	}
+	return r
+}
+
+func (r *ScenarioReport) Record(stepName string, latency time.Duration, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	stat, exist := r.stepStatMap[stepName]
+	if !exist {
+		stat = &scenarioStepStat{latencyList: make([]time.Duration, 0)}
+		r.stepStatMap[stepName] = stat
+		r.stepList = append(r.stepList, stepName)
+	}
+	if err != nil {
+		stat.fail++
+		return
+	}
+	stat.pass++
+	stat.latencyList = append(stat.latencyList, latency)
+}
+
+// FailCount 失败的步骤总次数
+func (r *ScenarioReport) FailCount() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	failCount := 0
+	for _, stat := range r.stepStatMap {
+		failCount += stat.fail
+	}
+	return failCount
+}
+
+// Print 输出每个步骤的成功失败次数和耗时分位数 耗时只统计成功的步骤
+func (r *ScenarioReport) Print(scenarioName string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	logger.Info("scenario report, name: %v", scenarioName)
+	for _, stepName := range r.stepList {
+		stat := r.stepStatMap[stepName]
+		latencyList := make([]time.Duration, len(stat.latencyList))
+		copy(latencyList, stat.latencyList)
+		sort.Slice(latencyList, func(i, j int) bool {
+			return latencyList[i] < latencyList[j]
+		})
+		logger.Info("step: %v, total: %v, pass: %v, fail: %v, p50: %vms, p90: %vms, p99: %vms, max: %vms",
+			stepName, stat.pass+stat.fail, stat.pass, stat.fail,
+			scenarioPercentile(latencyList, 50), scenarioPercentile(latencyList, 90),
+			scenarioPercentile(latencyList, 99), scenarioPercentile(latencyList, 100))
+	}
+}
+
+// scenarioPercentile 最近秩法计算分位数 单位毫秒
+func scenarioPercentile(sortedList []time.Duration, percent int) float64 {
+	if len(sortedList) == 0 {
+		return 0
+	}
+	rank := (percent*len(sortedList) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sortedList[rank-1].Microseconds()) / 1000.0
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hk4e/protocol/proto"
+)
+
+func TestScenarioAssert(t *testing.T) {
+	ntf := &proto.DungeonChallengeFinishNotify{
+		ChallengeIndex: 1,
+		IsSuccess:      true,
+		FinishType:     proto.ChallengeFinishType_CHALLENGE_FINISH_TYPE_SUCC,
+	}
+	passCaseMap := map[string]any{
+		"challenge_index": 1,
+		"challengeIndex":  "1",
+		"is_success":      true,
+		"finish_type":     "CHALLENGE_FINISH_TYPE_SUCC",
+	}
+	for path, expect := range passCaseMap {
+		if err := scenarioAssert(ntf, path, expect); err != nil {
+			t.Errorf("assert should pass, path: %v, err: %v", path, err)
+		}
+	}
+	if err := scenarioAssert(ntf, "finish_type", int(proto.ChallengeFinishType_CHALLENGE_FINISH_TYPE_SUCC)); err != nil {
+		t.Errorf("enum assert by number should pass, err: %v", err)
+	}
+	failCaseMap := map[string]any{
+		"challenge_index": 2,
+		"is_success":      false,
+		"finish_type":     "CHALLENGE_FINISH_TYPE_FAIL",
+		"not_exist_field": 1,
+	}
+	for path, expect := range failCaseMap {
+		if err := scenarioAssert(ntf, path, expect); err == nil {
+			t.Errorf("assert should fail, path: %v", path)
+		}
+	}
+}
+
+func TestScenarioAssertNestedAndList(t *testing.T) {
+	mpNtf := &proto.PlayerApplyEnterMpNotify{
+		SrcPlayerInfo: &proto.OnlinePlayerInfo{Uid: 100000001, Nickname: "robot"},
+	}
+	if err := scenarioAssert(mpNtf, "src_player_info.uid", 100000001); err != nil {
+		t.Errorf("nested assert should pass, err: %v", err)
+	}
+	if err := scenarioAssert(mpNtf, "src_player_info", "robot"); err == nil {
+		t.Errorf("assert on message field should fail")
+	}
+	beginNtf := &proto.DungeonChallengeBeginNotify{ParamList: []uint32{30, 240001001, 5}}
+	if err := scenarioAssert(beginNtf, "param_list.2", 5); err != nil {
+		t.Errorf("list assert should pass, err: %v", err)
+	}
+	for _, path := range []string{"param_list", "param_list.3", "param_list.x"} {
+		if err := scenarioAssert(beginNtf, path, 5); err == nil {
+			t.Errorf("invalid list path should fail, path: %v", path)
+		}
+	}
+}
+
+func TestScenarioPercentile(t *testing.T) {
+	if scenarioPercentile(nil, 50) != 0 {
+		t.Fatalf("empty list percentile should be 0")
+	}
+	sortedList := make([]time.Duration, 0)
+	for i := 1; i <= 10; i++ {
+		sortedList = append(sortedList, time.Duration(i)*time.Millisecond)
+	}
+	caseMap := map[int]float64{
+		0:   1,
+		10:  1,
+		50:  5,
+		90:  9,
+		91:  10,
+		99:  10,
+		100: 10,
+	}
+	for percent, expect := range caseMap {
+		if actual := scenarioPercentile(sortedList, percent); actual != expect {
+			t.Errorf("percentile error, percent: %v, expect: %v, actual: %v", percent, expect, actual)
+		}
+	}
+	single := []time.Duration{1500 * time.Microsecond}
+	if actual := scenarioPercentile(single, 99); actual != 1.5 {
+		t.Errorf("single percentile error, actual: %v", actual)
+	}
+}
+
+func TestScenarioReportFailCount(t *testing.T) {
+	report := NewScenarioReport()
+	report.Record("login", time.Millisecond, nil)
+	report.Record("login", 0, errors.New("timeout"))
+	report.Record("enter_scene", 0, errors.New("assert fail"))
+	if report.FailCount() != 2 {
+		t.Fatalf("fail count error, count: %v", report.FailCount())
+	}
+	if len(report.stepList) != 2 || report.stepList[0] != "login" {
+		t.Fatalf("step order error, stepList: %v", report.stepList)
+	}
+	if len(report.stepStatMap["login"].latencyList) != 1 {
+		t.Fatalf("failed step latency should not be recorded")
+	}
+}